@@ -0,0 +1,341 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	DefaultTTL         = 300 // seconds
+	DefaultJitter      = 10  // percent
+	DefaultStaleTTL    = 0   // seconds
+	DefaultNegativeTTL = 0   // seconds
+	DefaultLoadTimeout = 30  // seconds
+
+	ErrNilConfig          = utils.Error("config is nil")
+	ErrNilBackend         = utils.Error("backend is nil")
+	ErrNilLoader          = utils.Error("loader is nil")
+	ErrMissingName        = utils.Error("missing cache name")
+	ErrInvalidTTL         = utils.Error("ttl must be >= 1")
+	ErrInvalidJitter      = utils.Error("jitter must be between 0 and 100")
+	ErrInvalidStaleTTL    = utils.Error("staleTtl must be >= 0")
+	ErrInvalidNegativeTTL = utils.Error("negativeTtl must be >= 0")
+	ErrInvalidLoadTimeout = utils.Error("loadTimeout must be >= 1")
+
+	// ErrNotFound returned by loaders when the value does not exist; with NegativeTTL, the absence is cached
+	ErrNotFound = utils.Error("not found")
+)
+
+// Config cache configuration; times are in seconds
+type Config struct {
+	TTL         int `json:"ttl"`         // TTL time in seconds values are fresh
+	Jitter      int `json:"jitter"`      // Jitter max random TTL increase, in percent, so entries don't expire together
+	StaleTTL    int `json:"staleTtl"`    // StaleTTL time in seconds expired values are served while reloaded; 0 to disable
+	NegativeTTL int `json:"negativeTtl"` // NegativeTTL time in seconds ErrNotFound results are cached; 0 to disable
+	LoadTimeout int `json:"loadTimeout"` // LoadTimeout max time in seconds of loader calls
+}
+
+// Backend stores cache entries; implementations must be safe for concurrent use
+type Backend interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// Loader loads a value on cache misses
+type Loader[T any] func(ctx context.Context) (T, error)
+
+// Cache typed cache over a Backend; values are stored as JSON
+// concurrent loads of the same key are executed once (single-flight), expired values can be served while they are
+// reloaded in the background (stale-while-revalidate), and missing values can be cached (negative caching)
+type Cache[T any] struct {
+	name     string
+	backend  Backend
+	config   *Config
+	group    singleflight.Group
+	requests *prometheus.CounterVec
+	loads    *prometheus.CounterVec
+}
+
+// entry stored envelope
+type entry struct {
+	Value    json.RawMessage `json:"v,omitempty"`
+	Fresh    int64           `json:"f"`           // Fresh unix time in nanoseconds the value is fresh until
+	Negative bool            `json:"n,omitempty"` // Negative value does not exist
+}
+
+func NewConfig() *Config {
+	return &Config{
+		TTL:         DefaultTTL,
+		Jitter:      DefaultJitter,
+		StaleTTL:    DefaultStaleTTL,
+		NegativeTTL: DefaultNegativeTTL,
+		LoadTimeout: DefaultLoadTimeout,
+	}
+}
+
+func (c *Config) Validate() error {
+	if c.TTL < 1 {
+		return ErrInvalidTTL
+	}
+	if c.Jitter < 0 || c.Jitter > 100 {
+		return ErrInvalidJitter
+	}
+	if c.StaleTTL < 0 {
+		return ErrInvalidStaleTTL
+	}
+	if c.NegativeTTL < 0 {
+		return ErrInvalidNegativeTTL
+	}
+	if c.LoadTimeout < 1 {
+		return ErrInvalidLoadTimeout
+	}
+	return nil
+}
+
+// New creates a new Cache; name prefixes the backend keys, and is exported as the "cache" metrics label
+//
+// Example usage:
+//
+//	cfg := cache.NewConfig()
+//	cfg.StaleTTL = 60
+//	cfg.NegativeTTL = 30
+//	users, err := cache.New[*User]("users", cache.NewMemoryBackend(), cfg)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	prometheus.MustRegister(users)
+//
+//	user, err := users.GetOrLoad(ctx, id, func(ctx context.Context) (*User, error) {
+//	  user := &User{}
+//	  if err := repo.FetchByKey("id", id, user); err != nil {
+//	    if db.EmptyResult(err) {
+//	      return nil, cache.ErrNotFound
+//	    }
+//	    return nil, err
+//	  }
+//	  return user, nil
+//	})
+func New[T any](name string, backend Backend, cfg *Config) (*Cache[T], error) {
+	if len(name) == 0 {
+		return nil, ErrMissingName
+	}
+	if backend == nil {
+		return nil, ErrNilBackend
+	}
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	labels := prometheus.Labels{"cache": name}
+	return &Cache[T]{
+		name:    name,
+		backend: backend,
+		config:  cfg,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   "blueprint",
+			Subsystem:   "cache",
+			Name:        "requests_total",
+			Help:        "Number of cache lookups, by result (hit, stale, negative, miss, error)",
+			ConstLabels: labels,
+		}, []string{"result"}),
+		loads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   "blueprint",
+			Subsystem:   "cache",
+			Name:        "loads_total",
+			Help:        "Number of loader executions, by status (ok, notfound, error)",
+			ConstLabels: labels,
+		}, []string{"status"}),
+	}, nil
+}
+
+// Get returns the fresh value of key; stale and negative entries are reported as missing
+func (c *Cache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	var result T
+	e, ok, err := c.lookup(ctx, key)
+	if err != nil || !ok || e.Negative || !e.fresh() {
+		return result, false, err
+	}
+	if err = json.Unmarshal(e.Value, &result); err != nil {
+		return result, false, err
+	}
+	return result, true, nil
+}
+
+// Set stores the value of key
+func (c *Cache[T]) Set(ctx context.Context, key string, value T) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.store(ctx, key, &entry{Value: data}, c.config.TTL)
+}
+
+// Delete removes key
+func (c *Cache[T]) Delete(ctx context.Context, key string) error {
+	return c.backend.Delete(ctx, c.key(key))
+}
+
+// GetOrLoad returns the value of key, calling loader on cache misses; concurrent misses of the same key call loader
+// once, and share its result, so the backing store is not overloaded when a popular key expires
+// loader is called with a context detached from ctx and limited to LoadTimeout seconds, so cancelling the caller
+// that started a load does not fail the callers sharing it; stale values are returned while loader is called in
+// the background; if loader returns ErrNotFound, the absence is cached for NegativeTTL seconds. Backend errors are logged, and handled as misses
+func (c *Cache[T]) GetOrLoad(ctx context.Context, key string, loader Loader[T]) (T, error) {
+	var result T
+	if loader == nil {
+		return result, ErrNilLoader
+	}
+	e, ok, err := c.lookup(ctx, key)
+	if err != nil {
+		log.Warn().Err(err).Str("cache", c.name).Str("key", key).Msg("cache lookup failed")
+	}
+	if ok {
+		value, decodeErr := c.decode(e)
+		switch {
+		case decodeErr != nil:
+			// invalid entries are reloaded
+		case e.fresh() && e.Negative:
+			c.requests.WithLabelValues("negative").Inc()
+			return result, ErrNotFound
+		case e.fresh():
+			c.requests.WithLabelValues("hit").Inc()
+			return value, nil
+		case !e.Negative:
+			c.requests.WithLabelValues("stale").Inc()
+			c.refresh(ctx, key, loader)
+			return value, nil
+		}
+	}
+	if err != nil {
+		c.requests.WithLabelValues("error").Inc()
+	} else {
+		c.requests.WithLabelValues("miss").Inc()
+	}
+	v, err, _ := c.group.Do(key, func() (any, error) {
+		// the load is shared by concurrent callers, and must not fail if the caller that started it is cancelled
+		loadCtx, cancel := c.loadContext(ctx)
+		defer cancel()
+		return c.load(loadCtx, key, loader)
+	})
+	if err != nil {
+		return result, err
+	}
+	value, _ := v.(T)
+	return value, nil
+}
+
+// Describe implements prometheus.Collector
+func (c *Cache[T]) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.loads.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Cache[T]) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.loads.Collect(ch)
+}
+
+// refresh reloads a stale key in the background; concurrent refreshes of the same key are executed once
+func (c *Cache[T]) refresh(ctx context.Context, key string, loader Loader[T]) {
+	ctx, cancel := c.loadContext(ctx)
+	ch := c.group.DoChan(key, func() (any, error) {
+		return c.load(ctx, key, loader)
+	})
+	go func() {
+		defer cancel()
+		if r := <-ch; r.Err != nil && !errors.Is(r.Err, ErrNotFound) {
+			log.Warn().Err(r.Err).Str("cache", c.name).Str("key", key).Msg("cache refresh failed")
+		}
+	}()
+}
+
+// loadContext returns a context detached from ctx, limited to LoadTimeout seconds
+func (c *Cache[T]) loadContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), time.Duration(c.config.LoadTimeout)*time.Second)
+}
+
+// load calls loader and stores its result
+func (c *Cache[T]) load(ctx context.Context, key string, loader Loader[T]) (T, error) {
+	value, err := loader(ctx)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.loads.WithLabelValues("notfound").Inc()
+			if c.config.NegativeTTL > 0 {
+				if storeErr := c.store(ctx, key, &entry{Negative: true}, c.config.NegativeTTL); storeErr != nil {
+					log.Warn().Err(storeErr).Str("cache", c.name).Str("key", key).Msg("cache store failed")
+				}
+			}
+		} else {
+			c.loads.WithLabelValues("error").Inc()
+		}
+		return value, err
+	}
+	c.loads.WithLabelValues("ok").Inc()
+	if err = c.Set(ctx, key, value); err != nil {
+		log.Warn().Err(err).Str("cache", c.name).Str("key", key).Msg("cache store failed")
+	}
+	return value, nil
+}
+
+// lookup reads the entry of key
+func (c *Cache[T]) lookup(ctx context.Context, key string) (*entry, bool, error) {
+	data, ok, err := c.backend.Get(ctx, c.key(key))
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	e := &entry{}
+	if err = json.Unmarshal(data, e); err != nil {
+		return nil, false, nil
+	}
+	return e, true, nil
+}
+
+// store writes an entry that is fresh for ttl seconds, plus jitter; positive entries are kept for StaleTTL seconds
+// after expiring
+func (c *Cache[T]) store(ctx context.Context, key string, e *entry, ttl int) error {
+	fresh := time.Duration(ttl) * time.Second
+	if c.config.Jitter > 0 {
+		fresh += time.Duration(rand.Int64N(int64(fresh) * int64(c.config.Jitter) / 100))
+	}
+	e.Fresh = time.Now().Add(fresh).UnixNano()
+	keep := fresh
+	if !e.Negative {
+		keep += time.Duration(c.config.StaleTTL) * time.Second
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return c.backend.Set(ctx, c.key(key), data, keep)
+}
+
+// decode decodes the value of a positive entry
+func (c *Cache[T]) decode(e *entry) (T, error) {
+	var result T
+	if e.Negative {
+		return result, nil
+	}
+	err := json.Unmarshal(e.Value, &result)
+	return result, err
+}
+
+// key returns the backend key
+func (c *Cache[T]) key(key string) string {
+	return c.name + ":" + key
+}
+
+// fresh returns true if the entry has not expired
+func (e *entry) fresh() bool {
+	return time.Now().UnixNano() < e.Fresh
+}
@@ -0,0 +1,259 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type user struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type failingBackend struct{}
+
+func (failingBackend) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("backend down")
+}
+
+func (failingBackend) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("backend down")
+}
+
+func (failingBackend) Delete(context.Context, string) error {
+	return errors.New("backend down")
+}
+
+// expire marks the entry of key as expired, keeping it in the backend
+func expire(c *Cache[*user], key string) {
+	m := c.backend.(*MemoryBackend)
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	e := m.entries[c.key(key)]
+	stored := &entry{}
+	_ = json.Unmarshal(e.value, stored)
+	stored.Fresh = time.Now().Add(-time.Second).UnixNano()
+	e.value, _ = json.Marshal(stored)
+	m.entries[c.key(key)] = e
+}
+
+func TestConfig(t *testing.T) {
+	cfg := NewConfig()
+	assert.Nil(t, cfg.Validate())
+	cfg.TTL = 0
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidTTL)
+	cfg = NewConfig()
+	cfg.Jitter = 101
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidJitter)
+	cfg = NewConfig()
+	cfg.StaleTTL = -1
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidStaleTTL)
+	cfg = NewConfig()
+	cfg.NegativeTTL = -1
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidNegativeTTL)
+	cfg = NewConfig()
+	cfg.LoadTimeout = 0
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidLoadTimeout)
+
+	_, err := New[*user]("", NewMemoryBackend(), NewConfig())
+	assert.ErrorIs(t, err, ErrMissingName)
+	_, err = New[*user]("users", nil, NewConfig())
+	assert.ErrorIs(t, err, ErrNilBackend)
+	_, err = New[*user]("users", NewMemoryBackend(), nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+}
+
+func TestCache(t *testing.T) {
+	backend := NewMemoryBackend()
+	c, err := New[*user]("users", backend, NewConfig())
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	_, ok, err := c.Get(ctx, "1")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, c.Set(ctx, "1", &user{Id: 1, Name: "John"}))
+	u, ok, err := c.Get(ctx, "1")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "John", u.Name)
+	assert.Equal(t, 1, backend.Len())
+
+	assert.Nil(t, c.Delete(ctx, "1"))
+	_, ok, _ = c.Get(ctx, "1")
+	assert.False(t, ok)
+
+	_, err = c.GetOrLoad(ctx, "1", nil)
+	assert.ErrorIs(t, err, ErrNilLoader)
+}
+
+func TestGetOrLoad(t *testing.T) {
+	cfg := NewConfig()
+	cfg.NegativeTTL = 60
+	c, err := New[*user]("users", NewMemoryBackend(), cfg)
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	// concurrent misses call the loader once
+	var loads atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (*user, error) {
+		loads.Add(1)
+		<-release
+		return &user{Id: 1, Name: "John"}, nil
+	}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, err := c.GetOrLoad(ctx, "1", loader)
+			assert.Nil(t, err)
+			assert.Equal(t, "John", u.Name)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), loads.Load())
+
+	// hit
+	u, err := c.GetOrLoad(ctx, "1", loader)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, u.Id)
+	assert.Equal(t, int32(1), loads.Load())
+
+	// negative caching
+	notFound := func(ctx context.Context) (*user, error) {
+		loads.Add(1)
+		return nil, ErrNotFound
+	}
+	_, err = c.GetOrLoad(ctx, "2", notFound)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = c.GetOrLoad(ctx, "2", notFound)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, int32(2), loads.Load())
+
+	// loader errors are not cached
+	failing := func(ctx context.Context) (*user, error) {
+		loads.Add(1)
+		return nil, errors.New("db down")
+	}
+	_, err = c.GetOrLoad(ctx, "3", failing)
+	assert.ErrorContains(t, err, "db down")
+	_, err = c.GetOrLoad(ctx, "3", failing)
+	assert.ErrorContains(t, err, "db down")
+	assert.Equal(t, int32(4), loads.Load())
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.requests.WithLabelValues("hit")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.requests.WithLabelValues("negative")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.loads.WithLabelValues("ok")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.loads.WithLabelValues("notfound")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(c.loads.WithLabelValues("error")))
+	assert.Equal(t, 3, testutil.CollectAndCount(c, "blueprint_cache_loads_total"))
+}
+
+func TestGetOrLoadCancelled(t *testing.T) {
+	c, err := New[*user]("users", NewMemoryBackend(), NewConfig())
+	assert.Nil(t, err)
+
+	// the caller that starts a shared load is cancelled; the load is not
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (*user, error) {
+		close(started)
+		<-release
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return &user{Id: 1, Name: "John"}, nil
+	}
+	done := make(chan error, 2)
+	go func() {
+		_, err := c.GetOrLoad(ctx, "1", loader)
+		done <- err
+	}()
+	<-started
+	go func() {
+		u, err := c.GetOrLoad(context.Background(), "1", loader)
+		if err == nil && u.Name != "John" {
+			err = errors.New("unexpected value")
+		}
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	close(release)
+	assert.Nil(t, <-done)
+	assert.Nil(t, <-done)
+
+	u, ok, err := c.Get(context.Background(), "1")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "John", u.Name)
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	cfg := NewConfig()
+	cfg.StaleTTL = 60
+	c, err := New[*user]("users", NewMemoryBackend(), cfg)
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	assert.Nil(t, c.Set(ctx, "1", &user{Id: 1, Name: "John"}))
+	expire(c, "1")
+	_, ok, _ := c.Get(ctx, "1")
+	assert.False(t, ok)
+
+	// the stale value is returned, and reloaded in the background
+	reloaded := make(chan struct{})
+	u, err := c.GetOrLoad(ctx, "1", func(ctx context.Context) (*user, error) {
+		defer close(reloaded)
+		return &user{Id: 1, Name: "Jane"}, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "John", u.Name)
+	<-reloaded
+	assert.Eventually(t, func() bool {
+		u, ok, _ := c.Get(ctx, "1")
+		return ok && u.Name == "Jane"
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.requests.WithLabelValues("stale")))
+}
+
+func TestBackendErrors(t *testing.T) {
+	c, err := New[*user]("users", failingBackend{}, NewConfig())
+	assert.Nil(t, err)
+	// backend errors are handled as misses
+	u, err := c.GetOrLoad(context.Background(), "1", func(ctx context.Context) (*user, error) {
+		return &user{Id: 1}, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, u.Id)
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.requests.WithLabelValues("error")))
+}
+
+func TestMemoryBackend(t *testing.T) {
+	m := NewMemoryBackend()
+	ctx := context.Background()
+	assert.Nil(t, m.Set(ctx, "a", []byte("1"), time.Millisecond))
+	assert.Nil(t, m.Set(ctx, "b", []byte("2"), time.Minute))
+	time.Sleep(5 * time.Millisecond)
+	_, ok, _ := m.Get(ctx, "a")
+	assert.False(t, ok)
+	v, ok, _ := m.Get(ctx, "b")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("2"), v)
+	assert.Equal(t, 1, m.Len())
+	assert.Nil(t, m.Delete(ctx, "b"))
+	assert.Equal(t, 0, m.Len())
+}
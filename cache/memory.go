@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval number of writes between removals of expired entries
+const sweepInterval = 1024
+
+// MemoryBackend in-process Backend; expired entries are removed on read, and periodically on write
+type MemoryBackend struct {
+	entries map[string]memoryEntry
+	writes  uint64
+	mx      sync.Mutex
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+// Get returns the value of key, if it exists and has not expired
+func (m *MemoryBackend) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(e.expires) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+// Set stores the value of key for ttl
+func (m *MemoryBackend) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	now := time.Now()
+	m.entries[key] = memoryEntry{value: value, expires: now.Add(ttl)}
+	m.writes++
+	if m.writes%sweepInterval == 0 {
+		for k, e := range m.entries {
+			if now.After(e.expires) {
+				delete(m.entries, k)
+			}
+		}
+	}
+	return nil
+}
+
+// Delete removes key
+func (m *MemoryBackend) Delete(_ context.Context, key string) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+// Len returns the number of stored entries, including expired entries not yet removed
+func (m *MemoryBackend) Len() int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return len(m.entries)
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"github.com/oddbit-project/blueprint/utils"
+	"strconv"
+	"strings"
+)
+
+const (
+	ErrInvalidConfig = utils.Error("invalid configuration")
+)
+
+// Validator configuration types that can check their values
+type Validator interface {
+	Validate() error
+}
+
+// ValidationError error of a configuration key
+type ValidationError struct {
+	Key string
+	Err error
+}
+
+// ValidationReport aggregates the errors of several configuration keys, so all misconfigurations are reported at
+// startup at once, instead of one per restart
+type ValidationReport struct {
+	Errors []*ValidationError
+}
+
+func (e *ValidationError) Error() string {
+	return e.Key + ": " + e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func NewValidationReport() *ValidationReport {
+	return &ValidationReport{
+		Errors: make([]*ValidationError, 0),
+	}
+}
+
+// Add adds the error of a configuration key to the report; errors joined with errors.Join() are added as separate
+// entries; nil errors are ignored
+func (r *ValidationReport) Add(key string, err error) {
+	if err == nil {
+		return
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			r.Add(key, e)
+		}
+		return
+	}
+	r.Errors = append(r.Errors, &ValidationError{Key: key, Err: err})
+}
+
+// Validate validates v and adds its errors to the report; returns false if v is invalid
+func (r *ValidationReport) Validate(key string, v Validator) bool {
+	count := len(r.Errors)
+	r.Add(key, v.Validate())
+	return len(r.Errors) == count
+}
+
+// Load reads key from cfg into dest, and validates dest if it implements Validator; errors are added to the report,
+// and false is returned
+//
+// Example usage:
+//
+//	report := config.NewValidationReport()
+//	httpConfig := httpserver.NewServerConfig()
+//	report.Load(cfg, "server", httpConfig)
+//	dbConfig := pgsql.NewClientConfig()
+//	report.Load(cfg, "db", dbConfig)
+//	if err := report.Err(); err != nil {
+//	  log.Fatal(err)
+//	}
+func (r *ValidationReport) Load(cfg ConfigInterface, key string, dest any) bool {
+	if err := cfg.GetKey(key, dest); err != nil {
+		r.Add(key, err)
+		return false
+	}
+	if v, ok := dest.(Validator); ok {
+		return r.Validate(key, v)
+	}
+	return true
+}
+
+// Err returns the report as error, or nil if there are no errors
+func (r *ValidationReport) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return r
+}
+
+// Error returns the report, one error per line
+func (r *ValidationReport) Error() string {
+	sb := strings.Builder{}
+	sb.WriteString(ErrInvalidConfig.Error())
+	sb.WriteString(" (")
+	sb.WriteString(strconv.Itoa(len(r.Errors)))
+	sb.WriteString(" errors):")
+	for _, e := range r.Errors {
+		sb.WriteString("\n  - ")
+		sb.WriteString(e.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap allows errors.Is() checks against ErrInvalidConfig and the errors of each key
+func (r *ValidationReport) Unwrap() []error {
+	result := make([]error, 0, len(r.Errors)+1)
+	result = append(result, ErrInvalidConfig)
+	for _, e := range r.Errors {
+		result = append(result, e)
+	}
+	return result
+}
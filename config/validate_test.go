@@ -0,0 +1,69 @@
+package config_test
+
+import (
+	"errors"
+	"github.com/oddbit-project/blueprint/config"
+	"github.com/oddbit-project/blueprint/config/provider"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const (
+	errMissingDSN = testError("empty DSN")
+	errBadCipher  = testError("non-supported cipher")
+	errBadVersion = testError("invalid TLS version")
+)
+
+type testError string
+
+func (e testError) Error() string { return string(e) }
+
+type testDbConfig struct {
+	DSN string `json:"dsn"`
+}
+
+func (c *testDbConfig) Validate() error {
+	if len(c.DSN) == 0 {
+		return errMissingDSN
+	}
+	return nil
+}
+
+type testServerConfig struct {
+	Port int `json:"port"`
+}
+
+func (c *testServerConfig) Validate() error {
+	return errors.Join(errBadCipher, errBadVersion)
+}
+
+func TestValidationReport(t *testing.T) {
+	cfg, err := provider.NewJsonProvider([]byte(`{"db": {"dsn": ""}, "server": {"port": 80}, "other": {}}`))
+	assert.Nil(t, err)
+
+	report := config.NewValidationReport()
+	assert.Nil(t, report.Err())
+	assert.False(t, report.Load(cfg, "db", &testDbConfig{}))
+	assert.False(t, report.Load(cfg, "server", &testServerConfig{}))
+	assert.False(t, report.Load(cfg, "missing", &testDbConfig{}))
+	assert.True(t, report.Load(cfg, "other", &struct{}{}))
+	assert.True(t, report.Validate("valid", &testDbConfig{DSN: "postgres://localhost"}))
+
+	err = report.Err()
+	assert.NotNil(t, err)
+	assert.Len(t, report.Errors, 4)
+	assert.ErrorIs(t, err, config.ErrInvalidConfig)
+	assert.ErrorIs(t, err, errMissingDSN)
+	assert.ErrorIs(t, err, errBadVersion)
+	assert.ErrorIs(t, err, config.ErrNoKey)
+
+	lines := strings.Split(err.Error(), "\n")
+	assert.Equal(t, []string{
+		"invalid configuration (4 errors):",
+		"  - db: empty DSN",
+		"  - server: non-supported cipher",
+		"  - server: invalid TLS version",
+		"  - missing: " + config.ErrNoKey.Error(),
+	}, lines)
+}
@@ -16,6 +16,7 @@ type Container struct {
 	Config    config.ConfigInterface
 	Context   context.Context
 	CancelCtx context.CancelFunc
+	report    *config.ValidationReport
 }
 
 // NewContainer create new container runtime with the specified config provider and a new application context
@@ -33,6 +34,36 @@ func (c *Container) GetContext() context.Context {
 	return c.Context
 }
 
+// LoadConfig reads the config key into dest, and validates dest if it implements config.Validator; errors are not
+// fatal, and are collected until ValidateConfig() or Run() is called, so all misconfigurations are reported at once
+//
+// Example usage:
+//
+//	httpConfig := httpserver.NewServerConfig()
+//	app.LoadConfig("server", httpConfig)
+//	dbConfig := pgsql.NewClientConfig()
+//	app.LoadConfig("db", dbConfig)
+//	app.AbortFatal(app.ValidateConfig())
+func (c *Container) LoadConfig(key string, dest any) bool {
+	if c.report == nil {
+		c.report = config.NewValidationReport()
+	}
+	return c.report.Load(c.Config, key, dest)
+}
+
+// ValidateConfig logs and returns the errors collected by LoadConfig() as a single *config.ValidationReport, or nil
+// if all configurations are valid; it should be called after all configurations are loaded, before providers are
+// created; Run() also calls it, and aborts if any configuration is invalid
+func (c *Container) ValidateConfig() error {
+	if c.report == nil {
+		return nil
+	}
+	for _, e := range c.report.Errors {
+		log.Error().Str("key", e.Key).Err(e.Err).Msg("invalid configuration")
+	}
+	return c.report.Err()
+}
+
 // Run runs application container
 // mainFn is a collection of non-blocking functions; they will be executed in order.
 // each one will receive the Container object as the parameter:
@@ -44,9 +75,16 @@ func (c *Container) GetContext() context.Context {
 //			return nil
 //	})
 //
+// configurations loaded with LoadConfig() are validated before mainFn is executed; if any configuration is
+// invalid, the errors are logged and the application is terminated
+//
 // the main loop will wait for an os signal on the 'monitor' channel; when signal is
 // received, the application is terminated in an orderly fashion by invoking Terminate()
 func (c *Container) Run(mainFn ...RuntimeFn) {
+	if err := c.ValidateConfig(); err != nil {
+		c.Terminate(err)
+	}
+
 	// capture os signals
 	monitor := make(chan os.Signal, 1)
 	signal.Notify(monitor, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
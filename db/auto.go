@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/utils"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	TagAuto     = "auto"    // TagAuto struct tag used to identify automatic fields
+	AutoCreated = "created" // AutoCreated field set on insert, if empty
+	AutoUpdated = "updated" // AutoUpdated field set on insert, if empty, and on update
+	AutoDeleted = "deleted" // AutoDeleted soft delete field; rows with non-null values are excluded from fetches
+
+	ErrNoSoftDelete = utils.Error("record has no soft delete field")
+)
+
+// autoField is a struct field tagged with TagAuto
+type autoField struct {
+	column string
+	index  []int
+}
+
+// autoFields automatic fields of a struct type
+type autoFields struct {
+	created *autoField
+	updated *autoField
+	deleted *autoField
+}
+
+var autoFieldCache sync.Map
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// getAutoFields returns the automatic fields of a struct type; t may be a pointer, slice or slice of pointers
+func getAutoFields(t reflect.Type) *autoFields {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	if v, ok := autoFieldCache.Load(t); ok {
+		return v.(*autoFields)
+	}
+	result := &autoFields{}
+	scanAutoFields(t, nil, result)
+	if result.created == nil && result.updated == nil && result.deleted == nil {
+		result = nil
+	}
+	autoFieldCache.Store(t, result)
+	return result
+}
+
+func scanAutoFields(t reflect.Type, parent []int, result *autoFields) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			scanAutoFields(f.Type, index, result)
+			continue
+		}
+		tag := f.Tag.Get(TagAuto)
+		if len(tag) == 0 || (f.Type != timeType && f.Type != reflect.PointerTo(timeType)) {
+			continue
+		}
+		col := strings.Split(f.Tag.Get("db"), ",")[0]
+		if len(col) == 0 {
+			col = strings.ToLower(f.Name)
+		}
+		field := &autoField{column: col, index: index}
+		switch tag {
+		case AutoCreated:
+			result.created = field
+		case AutoUpdated:
+			result.updated = field
+		case AutoDeleted:
+			result.deleted = field
+		}
+	}
+}
+
+// setTime sets a time.Time or *time.Time field; if onlyEmpty is true, fields with a value are kept
+func setTime(v reflect.Value, field *autoField, now time.Time, onlyEmpty bool) {
+	if field == nil {
+		return
+	}
+	fv := v.FieldByIndex(field.index)
+	if !fv.CanSet() {
+		return
+	}
+	if fv.Kind() == reflect.Pointer {
+		if onlyEmpty && !fv.IsNil() {
+			return
+		}
+		fv.Set(reflect.ValueOf(&now))
+		return
+	}
+	if onlyEmpty && !fv.Interface().(time.Time).IsZero() {
+		return
+	}
+	fv.Set(reflect.ValueOf(now))
+}
+
+// touchRows sets automatic timestamps of struct pointer rows; slices of rows are also supported
+// if insert is true, created and updated fields are set if empty; otherwise, updated fields are always set
+func touchRows(insert bool, rows ...any) {
+	now := time.Now()
+	for _, row := range rows {
+		v := reflect.ValueOf(row)
+		if v.Kind() == reflect.Slice {
+			for i := 0; i < v.Len(); i++ {
+				touchRows(insert, v.Index(i).Interface())
+			}
+			continue
+		}
+		if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		fields := getAutoFields(v.Type())
+		if fields == nil {
+			continue
+		}
+		v = v.Elem()
+		if insert {
+			setTime(v, fields.created, now, true)
+			setTime(v, fields.updated, now, true)
+		} else {
+			setTime(v, fields.updated, now, false)
+		}
+	}
+}
+
+// softDeleteColumn returns the soft delete column of a struct, struct pointer or slice target
+func softDeleteColumn(target any) (string, bool) {
+	if target == nil {
+		return "", false
+	}
+	fields := getAutoFields(reflect.TypeOf(target))
+	if fields == nil || fields.deleted == nil {
+		return "", false
+	}
+	return fields.deleted.column, true
+}
+
+// excludeDeleted adds a "deleted IS NULL" condition to qry if target has a soft delete field
+func excludeDeleted(qry *goqu.SelectDataset, tableName string, target any) *goqu.SelectDataset {
+	if col, ok := softDeleteColumn(target); ok {
+		return qry.Where(deletedColumn(qry, tableName, col).IsNull())
+	}
+	return qry
+}
+
+// deletedColumn returns the soft delete column qualified by the FROM source of qry: the table name if qry selects
+// from the table, or its alias if the table is aliased; if qry selects from other sources, such as subqueries, the
+// column is not qualified
+func deletedColumn(qry *goqu.SelectDataset, tableName string, col string) exp.IdentifierExpression {
+	from := qry.GetClauses().From()
+	if from == nil || len(from.Columns()) != 1 {
+		return goqu.C(col)
+	}
+	switch source := from.Columns()[0].(type) {
+	case exp.IdentifierExpression:
+		if identifierName(source) == tableName {
+			return goqu.I(tableName + "." + col)
+		}
+	case exp.AliasedExpression:
+		if table, ok := source.Aliased().(exp.IdentifierExpression); ok && identifierName(table) == tableName {
+			return goqu.T(identifierName(source.GetAs())).Col(col)
+		}
+	}
+	return goqu.C(col)
+}
+
+// identifierName returns the dot-separated name of an identifier, e.g. "schema.table"
+func identifierName(i exp.IdentifierExpression) string {
+	parts := make([]string, 0, 3)
+	if schema := i.GetSchema(); len(schema) > 0 {
+		parts = append(parts, schema)
+	}
+	if table := i.GetTable(); len(table) > 0 {
+		parts = append(parts, table)
+	}
+	if col, ok := i.GetCol().(string); ok && len(col) > 0 {
+		parts = append(parts, col)
+	}
+	return strings.Join(parts, ".")
+}
+
+// SoftDelete marks rows matching fieldValues as deleted, setting the soft delete field of model to the current time
+// model is a struct (or pointer) of the table record type, and must have a field tagged with `auto:"deleted"`
+func SoftDelete(ctx context.Context, conn sqlx.ExecerContext, qry *goqu.UpdateDataset, model any, fieldValues map[string]any) error {
+	return setDeleted(ctx, conn, qry, model, fieldValues, time.Now())
+}
+
+// Restore clears the soft delete field of rows matching fieldValues
+func Restore(ctx context.Context, conn sqlx.ExecerContext, qry *goqu.UpdateDataset, model any, fieldValues map[string]any) error {
+	return setDeleted(ctx, conn, qry, model, fieldValues, nil)
+}
+
+func setDeleted(ctx context.Context, conn sqlx.ExecerContext, qry *goqu.UpdateDataset, model any, fieldValues map[string]any, value any) error {
+	if fieldValues == nil {
+		return ErrInvalidParameters
+	}
+	col, ok := softDeleteColumn(model)
+	if !ok {
+		return ErrNoSoftDelete
+	}
+	for field, v := range fieldValues {
+		qry = qry.Where(goqu.C(field).Eq(v))
+	}
+	return Update(ctx, conn, qry.Set(goqu.Record{col: value}))
+}
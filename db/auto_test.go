@@ -0,0 +1,40 @@
+package db
+
+import (
+	"github.com/doug-martin/goqu/v9"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type softDeleteRecord struct {
+	Id        int        `db:"id_user"`
+	DeletedAt *time.Time `db:"deleted_at" auto:"deleted"`
+}
+
+func TestExcludeDeleted(t *testing.T) {
+	dialect := goqu.Dialect("postgres")
+	cases := []struct {
+		table    string
+		qry      *goqu.SelectDataset
+		expected string
+	}{
+		{"users", dialect.From("users"), `SELECT * FROM "users" WHERE ("users"."deleted_at" IS NULL)`},
+		{"users", dialect.From(goqu.T("users")), `SELECT * FROM "users" WHERE ("users"."deleted_at" IS NULL)`},
+		{"app.users", dialect.From("app.users"), `SELECT * FROM "app"."users" WHERE ("app"."users"."deleted_at" IS NULL)`},
+		{"users", dialect.From(goqu.T("users").As("u")).Join(goqu.T("groups").As("g"), goqu.On(goqu.I("u.id_group").Eq(goqu.I("g.id_group")))),
+			`SELECT * FROM "users" AS "u" INNER JOIN "groups" AS "g" ON ("u"."id_group" = "g"."id_group") WHERE ("u"."deleted_at" IS NULL)`},
+		{"users", dialect.From(dialect.From("users").Where(goqu.C("active").IsTrue()).As("t")),
+			`SELECT * FROM (SELECT * FROM "users" WHERE ("active" IS TRUE)) AS "t" WHERE ("deleted_at" IS NULL)`},
+	}
+	for _, c := range cases {
+		qry, _, err := excludeDeleted(c.qry, c.table, &softDeleteRecord{}).ToSQL()
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, qry)
+	}
+
+	// targets without a soft delete field are not scoped
+	qry, _, err := excludeDeleted(dialect.From("users"), "users", &struct{}{}).ToSQL()
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM "users"`, qry)
+}
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/utils"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const (
+	DefaultBatchSize = 1000
+
+	dialectMysql = "mysql" // goqu dialect name; mysql renders conflicts as ON DUPLICATE KEY UPDATE
+
+	ErrInvalidBatchSize    = utils.Error("batchSize must be >= 1")
+	ErrMissingConflict     = utils.Error("missing conflict fields")
+	ErrMismatchedRowFields = utils.Error("rows have different fields")
+)
+
+// RowRecord converts a struct, struct pointer or map row into a goqu record
+// struct fields are mapped using db tags, and fields tagged with goqu:"skipinsert" (forInsert) or
+// goqu:"skipupdate" (forUpdate) are ignored
+func RowRecord(row any, forInsert bool, forUpdate bool) (exp.Record, error) {
+	switch v := row.(type) {
+	case nil:
+		return nil, ErrInvalidParameters
+	case exp.Record:
+		return v, nil
+	case map[string]any:
+		return v, nil
+	default:
+		// goqu only maps struct values
+		rv := reflect.ValueOf(row)
+		for rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return nil, ErrInvalidParameters
+			}
+			rv = rv.Elem()
+		}
+		return exp.NewRecordFromStruct(rv.Interface(), forInsert, forUpdate)
+	}
+}
+
+// RowValues extracts the insert columns and per-row values of a set of rows, in column order
+// all rows must have the same columns; as with Insert(), empty automatic created/updated fields of struct pointer
+// rows are set to the current time
+func RowValues(rows []any) ([]string, [][]any, error) {
+	if len(rows) == 0 {
+		return nil, nil, ErrInvalidParameters
+	}
+	touchRows(true, rows...)
+	var columns []string
+	values := make([][]any, len(rows))
+	for i, row := range rows {
+		record, err := RowRecord(row, true, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if columns == nil {
+			columns = record.Cols()
+		} else if len(record) != len(columns) {
+			return nil, nil, ErrMismatchedRowFields
+		}
+		items := make([]any, len(columns))
+		for j, col := range columns {
+			v, ok := record[col]
+			if !ok {
+				return nil, nil, ErrMismatchedRowFields
+			}
+			items[j] = v
+		}
+		values[i] = items
+	}
+	return columns, values, nil
+}
+
+// InsertBatch inserts rows using multi-row INSERT statements of at most batchSize rows each
+// when not executed within a transaction, a failing batch does not roll back the previous ones
+func InsertBatch(ctx context.Context, conn sqlx.ExecerContext, qry *goqu.InsertDataset, batchSize int, rows ...any) error {
+	if batchSize < 1 {
+		return ErrInvalidBatchSize
+	}
+	for start := 0; start < len(rows); start += batchSize {
+		end := min(start+batchSize, len(rows))
+		if err := Insert(ctx, conn, qry, rows[start:end]...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Upsert inserts rows, updating updateFields of existing rows that conflict on conflictFields
+// if updateFields is empty, all updatable fields of the first row except the conflict fields are updated
+//
+// Example:
+//
+//	// INSERT INTO "users" (...) VALUES (...) ON CONFLICT (email) DO UPDATE SET "name"="excluded"."name"
+//	// mysql: INSERT INTO `users` (...) VALUES (...) ON DUPLICATE KEY UPDATE `name`=VALUES(`name`)
+//	err := Upsert(ctx, conn, dialect.Insert("users"), []string{"email"}, []string{"name"}, user)
+func Upsert(ctx context.Context, conn sqlx.ExecerContext, qry *goqu.InsertDataset, conflictFields []string, updateFields []string, rows ...any) error {
+	if len(rows) == 0 {
+		return ErrInvalidParameters
+	}
+	if len(conflictFields) == 0 {
+		return ErrMissingConflict
+	}
+	if len(updateFields) == 0 {
+		record, err := RowRecord(rows[0], false, true)
+		if err != nil {
+			return err
+		}
+		for _, col := range record.Cols() {
+			if !slices.Contains(conflictFields, col) {
+				updateFields = append(updateFields, col)
+			}
+		}
+	}
+
+	var conflict exp.ConflictExpression
+	target := strings.Join(conflictFields, ",")
+	mysql := qry.Dialect().Dialect() == dialectMysql
+	switch {
+	case len(updateFields) > 0:
+		update := goqu.Record{}
+		for _, field := range updateFields {
+			if mysql {
+				update[field] = goqu.L("VALUES(?)", goqu.I(field))
+			} else {
+				update[field] = goqu.I("excluded." + field)
+			}
+		}
+		conflict = goqu.DoUpdate(target, update)
+	case mysql:
+		// mysql has no DO NOTHING; a self-assignment keeps the existing row
+		conflict = goqu.DoUpdate(target, goqu.Record{conflictFields[0]: goqu.I(conflictFields[0])})
+	default:
+		conflict = goqu.DoNothing()
+	}
+	return Insert(ctx, conn, qry.OnConflict(conflict), rows...)
+}
+
+// UpsertBatch executes Upsert in batches of at most batchSize rows
+// when not executed within a transaction, a failing batch does not roll back the previous ones
+func UpsertBatch(ctx context.Context, conn sqlx.ExecerContext, qry *goqu.InsertDataset, batchSize int, conflictFields []string, updateFields []string, rows ...any) error {
+	if batchSize < 1 {
+		return ErrInvalidBatchSize
+	}
+	for start := 0; start < len(rows); start += batchSize {
+		end := min(start+batchSize, len(rows))
+		if err := Upsert(ctx, conn, qry, conflictFields, updateFields, rows[start:end]...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,8 +1,9 @@
 package db
 
 import (
-	_ "database/sql"
+	"database/sql"
 	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/types/connhooks"
 	"github.com/oddbit-project/blueprint/utils"
 )
 
@@ -21,11 +22,16 @@ type ConnectionOptions interface {
 	Apply(db *sqlx.DB) error
 }
 
+// SqlClient database client; connection hooks are called on Connect() and Disconnect()
+// the connection pool re-establishes broken connections transparently, so no reconnect events are emitted
 type SqlClient struct {
 	Conn        *sqlx.DB
 	Dsn         string
 	DriverName  string
 	connOptions ConnectionOptions
+	hooks       []QueryHook
+	txRetry     TxRetryFn
+	connhooks.Hooks
 }
 
 func NewSqlClient(dsn string, driverName string, connOptions ConnectionOptions) *SqlClient {
@@ -37,8 +43,28 @@ func NewSqlClient(dsn string, driverName string, connOptions ConnectionOptions)
 	}
 }
 
+// AddQueryHook registers hooks called after each statement; hooks must be added before Connect()
+//
+// Example usage:
+//
+//	client, err := pgsql.NewClient(cfg)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	client.AddQueryHook(db.NewQueryLogger(&db.QueryLoggerConfig{SlowQueryThreshold: 500}))
+//	err = client.Connect()
+func (c *SqlClient) AddQueryHook(hooks ...QueryHook) {
+	c.hooks = append(c.hooks, hooks...)
+}
+
+// SetTxRetryFn sets the function used by WithTransaction to detect retryable transaction errors; providers set it
+// to recognize their serialization failures, deadlocks and lock timeouts
+func (c *SqlClient) SetTxRetryFn(fn TxRetryFn) {
+	c.txRetry = fn
+}
+
 func (c *SqlClient) Connect() error {
-	conn, err := sqlx.Open(c.DriverName, c.Dsn)
+	conn, err := c.open()
 	if err != nil {
 		return err
 	}
@@ -52,6 +78,7 @@ func (c *SqlClient) Connect() error {
 		return err
 	}
 	c.Conn = conn
+	c.Emit(c.DriverName, connhooks.Connect, nil)
 	return nil
 }
 
@@ -74,4 +101,20 @@ func (c *SqlClient) Disconnect() {
 	}
 	_ = c.Conn.Close()
 	c.Conn = nil
+	c.Emit(c.DriverName, connhooks.Disconnect, nil)
+}
+
+// open creates the connection pool; if query hooks are registered, connections are wrapped to call them
+func (c *SqlClient) open() (*sqlx.DB, error) {
+	if len(c.hooks) == 0 {
+		return sqlx.Open(c.DriverName, c.Dsn)
+	}
+	// sql.Open does not connect; it is used to obtain the registered driver
+	db, err := sql.Open(c.DriverName, c.Dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := db.Driver()
+	_ = db.Close()
+	return sqlx.NewDb(sql.OpenDB(&hookConnector{driver: drv, dsn: c.Dsn, hooks: c.hooks}), c.DriverName), nil
 }
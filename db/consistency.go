@@ -0,0 +1,19 @@
+package db
+
+import "context"
+
+// consistencyKey context.Context key for the consistency token
+type consistencyKey struct{}
+
+// WithConsistencyToken returns a context carrying a consistency token; the token identifies a write position on the
+// primary server (e.g. a PostgreSQL LSN), and replica routers only route reads of the context to replicas that
+// already replayed that position
+func WithConsistencyToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, token)
+}
+
+// ConsistencyToken returns the consistency token of a context, if any
+func ConsistencyToken(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(consistencyKey{}).(string)
+	return token, ok && len(token) > 0
+}
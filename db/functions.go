@@ -123,6 +123,7 @@ func Insert(ctx context.Context, conn sqlx.ExecerContext, qry *goqu.InsertDatase
 	if len(rows) == 0 {
 		return ErrInvalidParameters
 	}
+	touchRows(true, rows...)
 	sqlQry, args, err := qry.Rows(rows...).Prepared(true).ToSQL()
 	if err != nil {
 		return err
@@ -135,6 +136,7 @@ func InsertReturning(ctx context.Context, conn sqlx.QueryerContext, qry *goqu.In
 	if record == nil || returnFields == nil || len(target) == 0 {
 		return ErrInvalidParameters
 	}
+	touchRows(true, record)
 	sqlQry, values, err := qry.Rows(record).Prepared(true).Returning(returnFields...).ToSQL()
 	if err != nil {
 		return err
@@ -154,24 +156,38 @@ func Update(ctx context.Context, conn sqlx.ExecerContext, qry *goqu.UpdateDatase
 	return err
 }
 
+// UpdateRecord updates record using a WHERE condition with AND
+// if record is a struct with a `lock:"version"` field, the update uses optimistic locking, and fails with
+// ErrStaleRecord if the row was modified concurrently
 func UpdateRecord(ctx context.Context, conn sqlx.ExecerContext, qry *goqu.UpdateDataset, record any, whereFieldsValues map[string]any) error {
 	if record == nil {
 		return ErrInvalidParameters
 	}
-	qry = qry.Set(record)
+	touchRows(false, record)
 	if whereFieldsValues != nil {
 		for field, value := range whereFieldsValues {
 			qry = qry.Where(goqu.C(field).Eq(value))
 		}
 	}
-	return Update(ctx, conn, qry)
+	if column, version, ok := lockVersion(record); ok {
+		return updateLocked(ctx, conn, qry, record, column, version)
+	}
+	return Update(ctx, conn, qry.Set(record))
 }
 
+// UpdateByKey updates record using WHERE keyField=value
+// if record is a struct with a `lock:"version"` field, the update uses optimistic locking, and fails with
+// ErrStaleRecord if the row was modified concurrently
 func UpdateByKey(ctx context.Context, conn sqlx.ExecerContext, qry *goqu.UpdateDataset, record any, keyField string, value any) error {
 	if record == nil {
 		return ErrInvalidParameters
 	}
-	return Update(ctx, conn, qry.Set(record).Where(goqu.C(keyField).Eq(value)))
+	touchRows(false, record)
+	qry = qry.Where(goqu.C(keyField).Eq(value))
+	if column, version, ok := lockVersion(record); ok {
+		return updateLocked(ctx, conn, qry, record, column, version)
+	}
+	return Update(ctx, conn, qry.Set(record))
 }
 
 func Count(ctx context.Context, conn sqlx.QueryerContext, qry *goqu.SelectDataset) (int64, error) {
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+)
+
+// QueryEvent is a statement executed by a client
+type QueryEvent struct {
+	Query    string
+	Args     []any
+	Duration time.Duration
+	Err      error
+}
+
+// QueryHook is called after each statement executed by a client, including statements in transactions
+// hooks are called synchronously, and should not block
+type QueryHook interface {
+	AfterQuery(ctx context.Context, event *QueryEvent)
+}
+
+// QueryHookFunc adapts a function to QueryHook
+type QueryHookFunc func(ctx context.Context, event *QueryEvent)
+
+func (f QueryHookFunc) AfterQuery(ctx context.Context, event *QueryEvent) {
+	f(ctx, event)
+}
+
+// hookConnector driver.Connector that wraps connections to call hooks
+type hookConnector struct {
+	driver driver.Driver
+	dsn    string
+	hooks  []QueryHook
+}
+
+func (c *hookConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	var conn driver.Conn
+	var err error
+	if dc, ok := c.driver.(driver.DriverContext); ok {
+		var connector driver.Connector
+		if connector, err = dc.OpenConnector(c.dsn); err != nil {
+			return nil, err
+		}
+		conn, err = connector.Connect(ctx)
+	} else {
+		conn, err = c.driver.Open(c.dsn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hookConn{Conn: conn, hooks: c.hooks}, nil
+}
+
+func (c *hookConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// hookConn driver.Conn wrapper; optional interfaces are forwarded to the wrapped connection
+type hookConn struct {
+	driver.Conn
+	hooks []QueryHook
+}
+
+// Unwrap returns the wrapped driver connection, e.g. to access driver-specific APIs via sql.Conn.Raw()
+func (c *hookConn) Unwrap() driver.Conn {
+	return c.Conn
+}
+
+// UnwrapConn returns the underlying driver connection of conn, removing any query hook wrappers
+func UnwrapConn(conn any) any {
+	for {
+		w, ok := conn.(interface{ Unwrap() driver.Conn })
+		if !ok {
+			return conn
+		}
+		conn = w.Unwrap()
+	}
+}
+
+func (c *hookConn) after(ctx context.Context, query string, args []driver.NamedValue, start time.Time, err error) {
+	if err == driver.ErrSkip {
+		return
+	}
+	event := &QueryEvent{
+		Query:    query,
+		Args:     namedValues(args),
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	for _, h := range c.hooks {
+		h.AfterQuery(ctx, event)
+	}
+}
+
+func (c *hookConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	c.after(ctx, query, args, start, err)
+	return result, err
+}
+
+func (c *hookConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	c.after(ctx, query, args, start, err)
+	return rows, err
+}
+
+func (c *hookConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hookStmt{Stmt: stmt, conn: c, query: query}, nil
+}
+
+func (c *hookConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *hookConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() //nolint:staticcheck
+}
+
+func (c *hookConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *hookConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *hookConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *hookConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// hookStmt driver.Stmt wrapper for prepared statements
+type hookStmt struct {
+	driver.Stmt
+	conn  *hookConn
+	query string
+}
+
+func (s *hookStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = e.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(values(args)) //nolint:staticcheck
+	}
+	s.conn.after(ctx, s.query, args, start, err)
+	return result, err
+}
+
+func (s *hookStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(values(args)) //nolint:staticcheck
+	}
+	s.conn.after(ctx, s.query, args, start, err)
+	return rows, err
+}
+
+func (s *hookStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+func namedValues(args []driver.NamedValue) []any {
+	result := make([]any, len(args))
+	for i, a := range args {
+		result[i] = a.Value
+	}
+	return result
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	result := make([]driver.Value, len(args))
+	for i, a := range args {
+		result[i] = a.Value
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/utils"
+	"reflect"
+	"strings"
+)
+
+const (
+	TagLock     = "lock"    // TagLock struct tag used to identify the optimistic lock field
+	LockVersion = "version" // LockVersion tag value for version-based optimistic locking
+
+	ErrStaleRecord = utils.Error("record was modified concurrently")
+)
+
+// lockVersion returns the column name and value of the optimistic lock field of a struct record, tagged with
+// `lock:"version"`; the field must be an integer
+func lockVersion(record any) (string, reflect.Value, bool) {
+	v := reflect.ValueOf(record)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", reflect.Value{}, false
+	}
+	return findLockField(v)
+}
+
+// findLockField searches the struct fields, including embedded structs, for the lock field
+func findLockField(v reflect.Value) (string, reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if col, fv, ok := findLockField(v.Field(i)); ok {
+				return col, fv, ok
+			}
+			continue
+		}
+		if f.Tag.Get(TagLock) != LockVersion {
+			continue
+		}
+		switch f.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		default:
+			return "", reflect.Value{}, false
+		}
+		col := strings.Split(f.Tag.Get("db"), ",")[0]
+		if len(col) == 0 {
+			col = strings.ToLower(f.Name)
+		}
+		return col, v.Field(i), true
+	}
+	return "", reflect.Value{}, false
+}
+
+// updateLocked executes an update of a record with a version field; the version is incremented, and the update
+// only succeeds if the stored version matches the record version
+// on success, the record version field is updated if the record is addressable
+func updateLocked(ctx context.Context, conn sqlx.ExecerContext, qry *goqu.UpdateDataset, record any, column string, version reflect.Value) error {
+	values, err := exp.NewRecordFromStruct(record, false, true)
+	if err != nil {
+		return err
+	}
+	current := version.Int()
+	values[column] = current + 1
+
+	sqlQry, args, err := qry.Set(values).Where(goqu.C(column).Eq(current)).Prepared(true).ToSQL()
+	if err != nil {
+		return err
+	}
+	result, err := conn.ExecContext(ctx, sqlQry, args...)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrStaleRecord
+	}
+	if version.CanSet() {
+		version.SetInt(current + 1)
+	}
+	return nil
+}
+
+// RetryStale executes fn up to attempts times, while it fails with ErrStaleRecord
+// fn is expected to re-read the record and re-apply the changes; fn is always executed at least once, even if
+// attempts is less than 1
+//
+// Example:
+//
+//	err := db.RetryStale(3, func() error {
+//	  if err := repo.FetchByKey("id_account", id, account); err != nil {
+//	    return err
+//	  }
+//	  account.Balance += amount
+//	  return repo.UpdateByKey(account, "id_account", id)
+//	})
+func RetryStale(attempts int, fn func() error) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = fn(); !errors.Is(err, ErrStaleRecord) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRetryStale(t *testing.T) {
+	calls := 0
+	err := RetryStale(3, func() error {
+		calls++
+		return ErrStaleRecord
+	})
+	assert.ErrorIs(t, err, ErrStaleRecord)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = RetryStale(3, func() error {
+		calls++
+		if calls < 2 {
+			return ErrStaleRecord
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+
+	other := errors.New("other")
+	calls = 0
+	err = RetryStale(3, func() error {
+		calls++
+		return other
+	})
+	assert.ErrorIs(t, err, other)
+	assert.Equal(t, 1, calls)
+
+	// fn is executed at least once
+	for _, attempts := range []int{0, -1} {
+		calls = 0
+		err = RetryStale(attempts, func() error {
+			calls++
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, calls)
+	}
+}
@@ -0,0 +1,138 @@
+package masking
+
+import (
+	"context"
+	"fmt"
+	"github.com/oddbit-project/blueprint/provider/pgsql/pgsqltest"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+)
+
+func TestTransformers(t *testing.T) {
+	// nil values are kept
+	for _, fn := range []Transformer{Hash("s"), Email("s", "example.com"), FirstName("s"), LastName("s"), FullName("s"), Phone("s"), Text("s"), Redact("x")} {
+		assert.Nil(t, fn(nil))
+	}
+	assert.Nil(t, Null()("value"))
+	assert.Equal(t, "x", Redact("x")("value"))
+
+	// deterministic
+	assert.Equal(t, Hash("s")("value"), Hash("s")([]byte("value")))
+	assert.NotEqual(t, Hash("s")("value"), Hash("t")("value"))
+	assert.Len(t, Hash("s")("value"), 64)
+
+	email := Email("s", "example.com")("John@Acme.com")
+	assert.Regexp(t, regexp.MustCompile(`^user_[0-9a-f]{12}@example\.com$`), email)
+	assert.Equal(t, email, Email("s", "example.com")("john@acme.com"))
+
+	assert.Contains(t, firstNames, FirstName("s")("John"))
+	assert.Contains(t, lastNames, LastName("s")("Doe"))
+	assert.Regexp(t, regexp.MustCompile(`^\w+ \w+$`), FullName("s")("John Doe"))
+
+	phone := Phone("s")("+351 912-345-678").(string)
+	assert.Regexp(t, regexp.MustCompile(`^\+\d{3} \d{3}-\d{3}-\d{3}$`), phone)
+	assert.NotEqual(t, "+351 912-345-678", phone)
+
+	assert.Len(t, Text("s")("some free text"), 14)
+	assert.Equal(t, "", Text("s")(""))
+}
+
+func TestTable(t *testing.T) {
+	tbl := NewTable("users", "id", map[string]Transformer{"email": Redact("hidden")})
+	assert.Nil(t, tbl.Validate())
+	row := tbl.Mask(map[string]any{"id": 1, "email": "john@acme.com"})
+	assert.Equal(t, map[string]any{"id": 1, "email": "hidden"}, row)
+
+	assert.ErrorIs(t, NewTable("", "id", nil).Validate(), ErrMissingTable)
+	assert.ErrorIs(t, NewTable("users", "", nil).Validate(), ErrMissingKeyField)
+	tbl.BatchSize = 0
+	assert.ErrorIs(t, tbl.Validate(), ErrInvalidBatchSize)
+
+	_, err := NewPipeline(nil, nil)
+	assert.ErrorIs(t, err, ErrNilClient)
+}
+
+type maskedUser struct {
+	Id    int     `db:"id_user"`
+	Name  string  `db:"name"`
+	Email string  `db:"email"`
+	Notes *string `db:"notes"`
+	Score int     `db:"score"`
+}
+
+func TestPipelineCopy(t *testing.T) {
+	src := pgsqltest.Client(t)
+	defer src.Disconnect()
+	// destination uses a separate schema, with the same table name
+	dst := pgsqltest.ClientDSN(t, pgsqltest.DSN()+"?search_path=masking_dst_test")
+	defer dst.Disconnect()
+
+	statements := []string{
+		"DROP TABLE IF EXISTS masking_users_test",
+		"DROP SCHEMA IF EXISTS masking_dst_test CASCADE",
+		"CREATE SCHEMA masking_dst_test",
+		`CREATE TABLE masking_users_test(
+			id_user INT PRIMARY KEY,
+			name TEXT NOT NULL,
+			email TEXT NOT NULL,
+			notes TEXT,
+			score INT NOT NULL)`,
+		`CREATE TABLE masking_dst_test.masking_users_test(
+			id_user INT PRIMARY KEY,
+			name TEXT NOT NULL,
+			email TEXT NOT NULL,
+			notes TEXT,
+			score INT NOT NULL)`,
+		`INSERT INTO masking_users_test
+			SELECT i, 'User ' || i, 'user' || i || '@acme.com', 'private notes ' || i, i * 10 FROM generate_series(1, 25) i`,
+	}
+	for _, stmt := range statements {
+		_, err := src.Db().Exec(stmt)
+		assert.Nil(t, err)
+	}
+	defer src.Db().Exec("DROP SCHEMA IF EXISTS masking_dst_test CASCADE")
+	defer src.Db().Exec("DROP TABLE IF EXISTS masking_users_test")
+
+	p, err := NewPipeline(src, dst)
+	assert.Nil(t, err)
+	tbl := NewTable("masking_users_test", "id_user", map[string]Transformer{
+		"name":  Redact("***"),
+		"email": Email("salt", "example.com"),
+		"notes": Null(),
+	})
+	tbl.BatchSize = 10
+
+	count, err := p.Copy(context.Background(), tbl)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(25), count)
+
+	rows := make([]maskedUser, 0)
+	assert.Nil(t, dst.Db().Select(&rows, "SELECT * FROM masking_users_test ORDER BY id_user"))
+	assert.Len(t, rows, 25)
+	for i, row := range rows {
+		assert.Equal(t, i+1, row.Id)
+		assert.Equal(t, "***", row.Name)
+		assert.Equal(t, Email("salt", "example.com")(fmt.Sprintf("user%d@acme.com", i+1)), row.Email)
+		assert.Regexp(t, regexp.MustCompile(`@example\.com$`), row.Email)
+		assert.Nil(t, row.Notes)
+		// columns without rules are copied as-is
+		assert.Equal(t, (i+1)*10, row.Score)
+	}
+
+	// source rows are not modified
+	user := maskedUser{}
+	assert.Nil(t, src.Db().Get(&user, "SELECT * FROM masking_users_test WHERE id_user=1"))
+	assert.Equal(t, "User 1", user.Name)
+	assert.Equal(t, "user1@acme.com", user.Email)
+
+	// Run copies all tables; rows already copied fail on the destination primary key
+	assert.Nil(t, p.Add(tbl))
+	assert.NotNil(t, p.Run(context.Background()))
+	_, err = dst.Db().Exec("DELETE FROM masking_users_test")
+	assert.Nil(t, err)
+	assert.Nil(t, p.Run(context.Background()))
+	total := 0
+	assert.Nil(t, dst.Db().Get(&total, "SELECT COUNT(*) FROM masking_users_test"))
+	assert.Equal(t, 25, total)
+}
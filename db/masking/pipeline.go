@@ -0,0 +1,181 @@
+package masking
+
+import (
+	"context"
+	"fmt"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultBatchSize = 1000
+
+	ErrNilClient        = utils.Error("client is nil")
+	ErrNilTable         = utils.Error("table is nil")
+	ErrMissingTable     = utils.Error("missing table name")
+	ErrMissingKeyField  = utils.Error("missing table key field")
+	ErrInvalidBatchSize = utils.Error("table batchSize must be >= 1")
+)
+
+// Table declares how a table is copied; columns listed in Rules are masked with the associated Transformer,
+// other columns are copied as-is
+type Table struct {
+	Name      string
+	KeyField  string
+	BatchSize int
+	Rules     map[string]Transformer
+}
+
+// Pipeline copies tables between two databases, applying masking rules
+type Pipeline struct {
+	src    *db.SqlClient
+	dst    *db.SqlClient
+	tables []*Table
+}
+
+func NewTable(name string, keyField string, rules map[string]Transformer) *Table {
+	if rules == nil {
+		rules = make(map[string]Transformer)
+	}
+	return &Table{
+		Name:      name,
+		KeyField:  keyField,
+		BatchSize: DefaultBatchSize,
+		Rules:     rules,
+	}
+}
+
+func (t *Table) Validate() error {
+	if len(t.Name) == 0 {
+		return ErrMissingTable
+	}
+	if len(t.KeyField) == 0 {
+		return ErrMissingKeyField
+	}
+	if t.BatchSize < 1 {
+		return ErrInvalidBatchSize
+	}
+	return nil
+}
+
+// Mask applies the table rules to a row, in place
+func (t *Table) Mask(row map[string]any) map[string]any {
+	for field, fn := range t.Rules {
+		if v, ok := row[field]; ok {
+			row[field] = fn(v)
+		}
+	}
+	return row
+}
+
+// NewPipeline creates a masking pipeline from src to dst
+// destination tables must exist and have compatible columns
+//
+// Example usage:
+//
+//	p, err := masking.NewPipeline(prodClient, stagingClient)
+//	p.Add(masking.NewTable("users", "id_user", map[string]masking.Transformer{
+//	  "email": masking.Email(salt, "example.com"),
+//	  "name":  masking.FullName(salt),
+//	  "phone": masking.Phone(salt),
+//	}))
+//	err = p.Run(ctx)
+func NewPipeline(src *db.SqlClient, dst *db.SqlClient) (*Pipeline, error) {
+	if src == nil || dst == nil {
+		return nil, ErrNilClient
+	}
+	return &Pipeline{
+		src:    src,
+		dst:    dst,
+		tables: make([]*Table, 0),
+	}, nil
+}
+
+// Add validates and registers a table
+func (p *Pipeline) Add(t *Table) error {
+	if t == nil {
+		return ErrNilTable
+	}
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	p.tables = append(p.tables, t)
+	return nil
+}
+
+// Run copies all registered tables, in registration order
+func (p *Pipeline) Run(ctx context.Context) error {
+	for _, t := range p.tables {
+		if _, err := p.Copy(ctx, t); err != nil {
+			return fmt.Errorf("masking of '%s' failed: %w", t.Name, err)
+		}
+	}
+	return nil
+}
+
+// Copy copies a single table in batches ordered by KeyField, and returns the number of copied rows
+func (p *Pipeline) Copy(ctx context.Context, t *Table) (int64, error) {
+	src := db.NewRepository(ctx, p.src, t.Name)
+	dst := db.NewRepository(ctx, p.dst, t.Name)
+	logger := log.With().Str("table", t.Name).Logger()
+
+	var total int64
+	var last any
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		qry := src.SqlSelect().Order(goqu.C(t.KeyField).Asc()).Limit(uint(t.BatchSize))
+		if last != nil {
+			qry = qry.Where(goqu.C(t.KeyField).Gt(last))
+		}
+		rows, err := fetchRows(ctx, src, qry)
+		if err != nil {
+			return total, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		last = rows[len(rows)-1][t.KeyField]
+
+		records := make([]any, len(rows))
+		for i, row := range rows {
+			records[i] = goqu.Record(t.Mask(row))
+		}
+		if err = dst.Insert(records...); err != nil {
+			return total, err
+		}
+		total += int64(len(rows))
+		logger.Debug().Int64("rows", total).Msg("masking batch copied")
+		if len(rows) < t.BatchSize {
+			break
+		}
+	}
+	logger.Info().Int64("rows", total).Msg("masked table copied")
+	return total, nil
+}
+
+// fetchRows reads the query result as maps
+func fetchRows(ctx context.Context, repo db.Repository, qry *goqu.SelectDataset) ([]map[string]any, error) {
+	sqlQry, args, err := qry.Prepared(true).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := repo.Db().QueryxContext(ctx, sqlQry, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]map[string]any, 0)
+	for rows.Next() {
+		row := make(map[string]any)
+		if err = rows.MapScan(row); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,158 @@
+package masking
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Transformer masks a single field value
+// transformers receive nil values and []byte text columns, and must handle them
+type Transformer func(value any) any
+
+var (
+	firstNames = []string{
+		"Alex", "Ana", "Bruno", "Carla", "Daniel", "Elena", "Filipe", "Gloria", "Hugo", "Ines",
+		"John", "Julia", "Leo", "Maria", "Nuno", "Olivia", "Paulo", "Rita", "Sam", "Teresa",
+	}
+	lastNames = []string{
+		"Almeida", "Brown", "Costa", "Davis", "Evans", "Ferreira", "Garcia", "Harris", "Lopes", "Martin",
+		"Miller", "Nunes", "Pereira", "Ribeiro", "Santos", "Silva", "Smith", "Sousa", "Taylor", "Wilson",
+	}
+)
+
+// asString converts a column value to string; ok is false for nil values
+func asString(value any) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// digest returns the salted sha256 of the value
+func digest(salt string, value string) []byte {
+	h := sha256.Sum256([]byte(salt + value))
+	return h[:]
+}
+
+// Null replaces the value with nil
+func Null() Transformer {
+	return func(value any) any {
+		return nil
+	}
+}
+
+// Redact replaces non-nil values with a fixed replacement
+func Redact(replacement any) Transformer {
+	return func(value any) any {
+		if value == nil {
+			return nil
+		}
+		return replacement
+	}
+}
+
+// Hash replaces the value with the hex-encoded salted sha256 of its contents
+// the result is deterministic, so masked columns can still be joined
+func Hash(salt string) Transformer {
+	return func(value any) any {
+		s, ok := asString(value)
+		if !ok {
+			return nil
+		}
+		return hex.EncodeToString(digest(salt, s))
+	}
+}
+
+// Email replaces the value with a deterministic address in the given domain
+//
+// Example:
+//
+//	Email("salt", "example.com")("john@acme.com") // "user_4f0c1a2b3d4e@example.com"
+func Email(salt string, domain string) Transformer {
+	return func(value any) any {
+		s, ok := asString(value)
+		if !ok {
+			return nil
+		}
+		return "user_" + hex.EncodeToString(digest(salt, strings.ToLower(s))[:6]) + "@" + domain
+	}
+}
+
+// FirstName replaces the value with a deterministic fake first name
+func FirstName(salt string) Transformer {
+	return pick(salt, firstNames)
+}
+
+// LastName replaces the value with a deterministic fake last name
+func LastName(salt string) Transformer {
+	return pick(salt, lastNames)
+}
+
+// FullName replaces the value with a deterministic fake "first last" name
+func FullName(salt string) Transformer {
+	return func(value any) any {
+		s, ok := asString(value)
+		if !ok {
+			return nil
+		}
+		d := digest(salt, s)
+		return firstNames[binary.BigEndian.Uint32(d[0:4])%uint32(len(firstNames))] + " " +
+			lastNames[binary.BigEndian.Uint32(d[4:8])%uint32(len(lastNames))]
+	}
+}
+
+// Phone replaces every digit with a deterministic digit, keeping length and formatting
+func Phone(salt string) Transformer {
+	return func(value any) any {
+		s, ok := asString(value)
+		if !ok {
+			return nil
+		}
+		d := digest(salt, s)
+		i := 0
+		return strings.Map(func(r rune) rune {
+			if r < '0' || r > '9' {
+				return r
+			}
+			r = rune('0' + d[i%len(d)]%10)
+			i++
+			return r
+		}, s)
+	}
+}
+
+// Text replaces free text with a hash-based placeholder of similar length
+func Text(salt string) Transformer {
+	return func(value any) any {
+		s, ok := asString(value)
+		if !ok {
+			return nil
+		}
+		if len(s) == 0 {
+			return s
+		}
+		h := hex.EncodeToString(digest(salt, s))
+		return strings.Repeat(h, len(s)/len(h)+1)[:len(s)]
+	}
+}
+
+// pick returns a transformer that deterministically selects an item from list
+func pick(salt string, list []string) Transformer {
+	return func(value any) any {
+		s, ok := asString(value)
+		if !ok {
+			return nil
+		}
+		d := digest(salt, s)
+		return list[binary.BigEndian.Uint32(d[0:4])%uint32(len(list))]
+	}
+}
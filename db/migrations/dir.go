@@ -0,0 +1,170 @@
+package migrations
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"text/template"
+)
+
+const (
+	DefaultDirPattern = "*" + MigrationFileExtension
+)
+
+// DirSource is a Source that loads migrations from a directory at runtime
+// file names matching Pattern are ordered by name; if Data is set, migrations are rendered as text/template
+// templates, e.g. "CREATE TABLE {{ .Schema }}.users(...)"
+type DirSource struct {
+	Path    string
+	Pattern string
+	Data    any
+	hashes  map[string]string
+	mx      sync.Mutex
+}
+
+// NewDirSource creates a new DirSource for path, using DefaultDirPattern
+//
+// Example usage:
+//
+//	src, err := migrations.NewDirSource("/etc/myapp/migrations")
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	src.Data = map[string]string{"Schema": os.Getenv("DB_SCHEMA")}
+//	err = mgr.Run(ctx, src, migrations.DefaultProgressFn)
+//
+//	// later, check if files were modified since the last List()
+//	if changed, _ := src.Changed(); len(changed) > 0 {
+//	  log.Warn().Strs("files", changed).Msg("migration folder changed")
+//	}
+func NewDirSource(path string) (*DirSource, error) {
+	var err error
+	if path, err = filepath.Abs(path); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrPathNotFound
+		}
+		return nil, ErrInvalidPath
+	}
+	if !info.IsDir() {
+		return nil, ErrInvalidPath
+	}
+	return &DirSource{
+		Path:    path,
+		Pattern: DefaultDirPattern,
+		hashes:  make(map[string]string),
+	}, nil
+}
+
+// List migration files matching Pattern, sorted by name; the file checksums are kept for Changed()
+func (d *DirSource) List() ([]string, error) {
+	names, hashes, err := d.scan()
+	if err != nil {
+		return nil, err
+	}
+	d.mx.Lock()
+	d.hashes = hashes
+	d.mx.Unlock()
+	return names, nil
+}
+
+// Read a migration file, rendering it as template if Data is set
+func (d *DirSource) Read(name string) (*MigrationRecord, error) {
+	// names are always relative to Path
+	if filepath.Base(name) != name {
+		return nil, ErrInvalidFile
+	}
+	fullPath := filepath.Join(d.Path, name)
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrPathNotFound
+		}
+		return nil, ErrReadMigration
+	}
+	if info.IsDir() {
+		return nil, ErrInvalidFile
+	}
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, ErrReadMigration
+	}
+	if d.Data != nil {
+		if content, err = d.render(name, content); err != nil {
+			return nil, err
+		}
+	}
+	return LoadMigration(name, content)
+}
+
+// Changed returns the names of files added, removed or modified since the last List() call, sorted by name
+func (d *DirSource) Changed() ([]string, error) {
+	_, hashes, err := d.scan()
+	if err != nil {
+		return nil, err
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	result := make([]string, 0)
+	for name, hash := range hashes {
+		if prev, ok := d.hashes[name]; !ok || prev != hash {
+			result = append(result, name)
+		}
+	}
+	for name := range d.hashes {
+		if _, ok := hashes[name]; !ok {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// scan returns the sorted file names matching Pattern and their checksums
+func (d *DirSource) scan() ([]string, map[string]string, error) {
+	pattern := d.Pattern
+	if len(pattern) == 0 {
+		pattern = DefaultDirPattern
+	}
+	matches, err := filepath.Glob(filepath.Join(d.Path, pattern))
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, 0, len(matches))
+	hashes := make(map[string]string, len(matches))
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(m)
+		if err != nil {
+			return nil, nil, ErrReadMigration
+		}
+		h := sha256.Sum256(content)
+		name := filepath.Base(m)
+		names = append(names, name)
+		hashes[name] = hex.EncodeToString(h[:])
+	}
+	sort.Strings(names)
+	return names, hashes, nil
+}
+
+func (d *DirSource) render(name string, content []byte) ([]byte, error) {
+	tpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err = tpl.Execute(buf, d.Data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
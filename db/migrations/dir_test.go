@@ -0,0 +1,73 @@
+package migrations
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirSource(t *testing.T) {
+	_, err := NewDirSource("/non/existing/path")
+	assert.ErrorIs(t, err, ErrPathNotFound)
+
+	dir := t.TempDir()
+	write := func(name string, content string) {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("002_data.sql", "INSERT INTO {{ .Schema }}.users(name) VALUES('admin');")
+	write("001_users.sql", "CREATE TABLE {{ .Schema }}.users(name TEXT);")
+	write("readme.txt", "not a migration")
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "003_dir.sql"), 0755))
+
+	_, err = NewDirSource(filepath.Join(dir, "readme.txt"))
+	assert.ErrorIs(t, err, ErrInvalidPath)
+
+	src, err := NewDirSource(dir)
+	assert.Nil(t, err)
+	files, err := src.List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"001_users.sql", "002_data.sql"}, files)
+
+	// no templating without data
+	record, err := src.Read("001_users.sql")
+	assert.Nil(t, err)
+	assert.Equal(t, "CREATE TABLE {{ .Schema }}.users(name TEXT);", record.Contents)
+
+	src.Data = map[string]string{"Schema": "tenant1"}
+	record, err = src.Read("001_users.sql")
+	assert.Nil(t, err)
+	assert.Equal(t, "CREATE TABLE tenant1.users(name TEXT);", record.Contents)
+
+	src.Data = map[string]string{}
+	_, err = src.Read("001_users.sql")
+	assert.NotNil(t, err)
+
+	_, err = src.Read("../001_users.sql")
+	assert.ErrorIs(t, err, ErrInvalidFile)
+	_, err = src.Read("004_missing.sql")
+	assert.ErrorIs(t, err, ErrPathNotFound)
+
+	// change detection
+	changed, err := src.Changed()
+	assert.Nil(t, err)
+	assert.Len(t, changed, 0)
+	write("002_data.sql", "SELECT 1;")
+	write("000_init.sql", "SELECT 0;")
+	assert.Nil(t, os.Remove(filepath.Join(dir, "001_users.sql")))
+	changed, err = src.Changed()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"000_init.sql", "001_users.sql", "002_data.sql"}, changed)
+
+	_, err = src.List()
+	assert.Nil(t, err)
+	changed, err = src.Changed()
+	assert.Nil(t, err)
+	assert.Len(t, changed, 0)
+
+	// custom pattern
+	src.Pattern = "00[02]_*.sql"
+	files, err = src.List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"000_init.sql", "002_data.sql"}, files)
+}
@@ -0,0 +1,130 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/utils"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	MarkerFunc = "-- migrate:func" // MarkerFunc prefix of the registered contents of a function migration
+
+	ErrMissingMigrationName = utils.Error("missing migration name")
+	ErrMissingFuncVersion   = utils.Error("missing function migration version")
+	ErrNilMigrationFunc     = utils.Error("migration function is nil")
+	ErrDuplicateMigration   = utils.Error("migration already exists in source")
+)
+
+// MigrationFunc is a migration step implemented in Go; it runs inside the migration transaction
+type MigrationFunc func(ctx context.Context, tx *sqlx.Tx) error
+
+// FuncMigration is a migration implemented as a Go function, e.g. a data backfill
+// the registered contents of the migration are its name and version; changing Version is detected as drift
+type FuncMigration struct {
+	Name    string
+	Version string
+	Up      MigrationFunc
+}
+
+// FuncSource is a Source that merges function migrations with the migrations of another source
+// all migrations are ordered by name
+type FuncSource struct {
+	src   Source
+	funcs map[string]FuncMigration
+}
+
+// NewFuncSource creates a new FuncSource; src is optional
+//
+// Example usage:
+//
+//	sqlSrc, _ := migrations.NewDiskSource("migrations")
+//	src := migrations.NewFuncSource(sqlSrc)
+//	src.Add(migrations.FuncMigration{
+//	  Name:    "003_backfill_user_slug.go",
+//	  Version: "1",
+//	  Up: func(ctx context.Context, tx *sqlx.Tx) error {
+//	    // read rows, compute values and update them
+//	    return nil
+//	  },
+//	})
+//	err := mgr.Run(ctx, src, migrations.DefaultProgressFn)
+func NewFuncSource(src Source) *FuncSource {
+	return &FuncSource{
+		src:   src,
+		funcs: make(map[string]FuncMigration),
+	}
+}
+
+// Add registers a function migration
+func (s *FuncSource) Add(m FuncMigration) error {
+	if len(m.Name) == 0 {
+		return ErrMissingMigrationName
+	}
+	if len(m.Version) == 0 {
+		return ErrMissingFuncVersion
+	}
+	if m.Up == nil {
+		return ErrNilMigrationFunc
+	}
+	if _, ok := s.funcs[m.Name]; ok {
+		return ErrDuplicateMigration
+	}
+	if s.src != nil {
+		files, err := s.src.List()
+		if err != nil {
+			return err
+		}
+		if slices.Contains(files, m.Name) {
+			return ErrDuplicateMigration
+		}
+	}
+	s.funcs[m.Name] = m
+	return nil
+}
+
+// List migrations of both the base source and function migrations, sorted by name
+func (s *FuncSource) List() ([]string, error) {
+	files := make([]string, 0, len(s.funcs))
+	if s.src != nil {
+		list, err := s.src.List()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, list...)
+	}
+	for name := range s.funcs {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Read a migration; function migrations have the Func field set
+func (s *FuncSource) Read(name string) (*MigrationRecord, error) {
+	if m, ok := s.funcs[name]; ok {
+		record, err := LoadMigration(name, []byte(FuncContents(m.Name, m.Version)))
+		if err != nil {
+			return nil, err
+		}
+		record.Func = m.Up
+		return record, nil
+	}
+	if s.src == nil {
+		return nil, ErrFileNotFound
+	}
+	return s.src.Read(name)
+}
+
+// FuncContents returns the registered contents of a function migration
+func FuncContents(name string, version string) string {
+	return fmt.Sprintf("%s %s %s", MarkerFunc, name, version)
+}
+
+// IsFunc returns true if the record contents identify a function migration
+func (m *MigrationRecord) IsFunc() bool {
+	return m.Func != nil || strings.HasPrefix(m.Contents, MarkerFunc)
+}
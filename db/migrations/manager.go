@@ -13,6 +13,8 @@ const (
 	MsgRunMigration
 	MsgFinishedMigration
 	MsgError
+	MsgRollbackMigration
+	MsgFinishedRollback
 
 	ErrMigrationNameHashMismatch = utils.Error("Migration name or hash exists but they mismatch. Migration file was edited or renamed?")
 	ErrMigrationExists           = utils.Error("Migration already executed")
@@ -22,10 +24,11 @@ const (
 type ProgressFn func(msgType int, migrationName string, e error)
 
 type MigrationRecord struct {
-	Created  time.Time `db:"created"`
-	Name     string    `db:"name"`
-	SHA2     string    `db:"sha2"`
-	Contents string    `db:"contents"`
+	Created  time.Time     `db:"created"`
+	Name     string        `db:"name"`
+	SHA2     string        `db:"sha2"`
+	Contents string        `db:"contents"`
+	Func     MigrationFunc `db:"-"` // Func function of function migrations; not persisted
 }
 
 type Source interface {
@@ -39,6 +42,9 @@ type Manager interface {
 	RunMigration(ctx context.Context, m *MigrationRecord) error
 	RegisterMigration(ctx context.Context, m *MigrationRecord) error
 	Run(ctx context.Context, src Source, consoleFn ProgressFn) error
+	Plan(ctx context.Context, src Source) ([]*MigrationRecord, error)
+	Rollback(ctx context.Context, steps int, consoleFn ProgressFn) error
+	Repair(ctx context.Context, src Source) (*VerifyResult, error)
 }
 
 func DefaultProgressFn(msgType int, migrationName string, e error) {
@@ -50,6 +56,12 @@ func DefaultProgressFn(msgType int, migrationName string, e error) {
 	case MsgFinishedMigration:
 		msg = console.Regular(fmt.Sprintf("Migration '%s' finished successfully", migrationName))
 		break
+	case MsgRollbackMigration:
+		msg = console.Regular(fmt.Sprintf("Rolling back migration '%s'...", migrationName))
+		break
+	case MsgFinishedRollback:
+		msg = console.Regular(fmt.Sprintf("Migration '%s' rolled back successfully", migrationName))
+		break
 	case MsgSkipMigration:
 		msg = console.Info(fmt.Sprintf("Migration '%s' already run, skipping", migrationName))
 		break
@@ -0,0 +1,104 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"github.com/oddbit-project/blueprint/utils"
+	"io"
+	"strings"
+)
+
+const (
+	MarkerUp   = "-- migrate:up"   // MarkerUp marks the start of the forward section of a migration
+	MarkerDown = "-- migrate:down" // MarkerDown marks the start of the rollback section of a migration
+
+	ErrNoDownMigration = utils.Error("Migration has no down section")
+	ErrInvalidSteps    = utils.Error("Rollback steps must be >= 1")
+)
+
+// SplitMigration splits migration contents into the up and down sections
+// sections are delimited by MarkerUp and MarkerDown lines; contents without markers are considered up-only
+//
+// Example migration:
+//
+//	-- migrate:up
+//	CREATE TABLE users(id_user SERIAL PRIMARY KEY, name TEXT);
+//
+//	-- migrate:down
+//	DROP TABLE users;
+func SplitMigration(contents string) (up string, down string) {
+	var upLines, downLines []string
+	current := &upLines
+	for _, line := range strings.Split(contents, "\n") {
+		switch strings.TrimSpace(line) {
+		case MarkerUp:
+			current = &upLines
+			continue
+		case MarkerDown:
+			current = &downLines
+			continue
+		}
+		*current = append(*current, line)
+	}
+	return strings.TrimSpace(strings.Join(upLines, "\n")), strings.TrimSpace(strings.Join(downLines, "\n"))
+}
+
+// Up returns the forward section of the migration
+func (m *MigrationRecord) Up() string {
+	up, _ := SplitMigration(m.Contents)
+	return up
+}
+
+// Down returns the rollback section of the migration
+func (m *MigrationRecord) Down() string {
+	_, down := SplitMigration(m.Contents)
+	return down
+}
+
+// DryRun writes the SQL of the pending migrations of src to w, without executing it
+func DryRun(ctx context.Context, m Manager, src Source, w io.Writer) error {
+	pending, err := m.Plan(ctx, src)
+	if err != nil {
+		return err
+	}
+	for _, r := range pending {
+		if _, err = fmt.Fprintf(w, "-- migration: %s\n%s\n\n", r.Name, r.Up()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DryRunRollback writes the SQL that Rollback() would execute for the last steps migrations to w, without executing it
+func DryRunRollback(ctx context.Context, m Manager, steps int, w io.Writer) error {
+	records, err := RollbackPlan(ctx, m, steps)
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		down := r.Down()
+		if len(down) == 0 {
+			return fmt.Errorf("%w: %s", ErrNoDownMigration, r.Name)
+		}
+		if _, err = fmt.Fprintf(w, "-- rollback: %s\n%s\n\n", r.Name, down); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RollbackPlan returns the last steps applied migrations, most recent first
+func RollbackPlan(ctx context.Context, m Manager, steps int) ([]*MigrationRecord, error) {
+	if steps < 1 {
+		return nil, ErrInvalidSteps
+	}
+	applied, err := m.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*MigrationRecord, 0, steps)
+	for i := len(applied) - 1; i >= 0 && len(result) < steps; i-- {
+		result = append(result, applied[i])
+	}
+	return result, nil
+}
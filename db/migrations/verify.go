@@ -0,0 +1,79 @@
+package migrations
+
+import (
+	"context"
+	"slices"
+)
+
+// VerifyResult reports the differences between applied migrations and a migration source
+// all fields contain migration names; the struct can be serialized to JSON for CI checks
+type VerifyResult struct {
+	Drifted    []string `json:"drifted"`    // Drifted applied migrations whose source contents changed
+	Missing    []string `json:"missing"`    // Missing applied migrations not found in the source
+	OutOfOrder []string `json:"outOfOrder"` // OutOfOrder pending migrations sorting before the last applied migration
+	Pending    []string `json:"pending"`    // Pending migrations not applied yet, including OutOfOrder ones
+}
+
+// OK returns true if no drifted, missing or out-of-order migrations were found
+func (r *VerifyResult) OK() bool {
+	return len(r.Drifted) == 0 && len(r.Missing) == 0 && len(r.OutOfOrder) == 0
+}
+
+// Verify compares the migrations applied by m with src
+//
+// Example:
+//
+//	result, err := migrations.Verify(ctx, mgr, src)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	if !result.OK() {
+//	  json.NewEncoder(os.Stdout).Encode(result)
+//	  os.Exit(1)
+//	}
+func Verify(ctx context.Context, m Manager, src Source) (*VerifyResult, error) {
+	files, err := src.List()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VerifyResult{
+		Drifted:    make([]string, 0),
+		Missing:    make([]string, 0),
+		OutOfOrder: make([]string, 0),
+		Pending:    make([]string, 0),
+	}
+	appliedNames := make([]string, 0, len(applied))
+	last := ""
+	for _, r := range applied {
+		appliedNames = append(appliedNames, r.Name)
+		if r.Name > last {
+			last = r.Name
+		}
+		if !slices.Contains(files, r.Name) {
+			result.Missing = append(result.Missing, r.Name)
+			continue
+		}
+		record, err := src.Read(r.Name)
+		if err != nil {
+			return nil, err
+		}
+		if record.SHA2 != r.SHA2 {
+			result.Drifted = append(result.Drifted, r.Name)
+		}
+	}
+	for _, f := range files {
+		if slices.Contains(appliedNames, f) {
+			continue
+		}
+		result.Pending = append(result.Pending, f)
+		if f < last {
+			result.OutOfOrder = append(result.OutOfOrder, f)
+		}
+	}
+	return result, nil
+}
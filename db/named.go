@@ -0,0 +1,248 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/utils"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	ErrMissingParam = utils.Error("missing query parameter")
+	ErrEmptyInList  = utils.Error("empty list parameter")
+	ErrInvalidTag   = utils.Error("invalid query tag; allowed characters are letters, digits and _.:=-")
+)
+
+// NamedQuery is a raw SQL query with named parameters, for queries not easily expressed with the query builder
+// parameters are written as :name, and are replaced with the placeholders of the connection driver; casts
+// (e.g. "::int"), quoted strings and comments are left untouched. Backslash escapes in quoted strings are only
+// recognized for mysql; PostgreSQL E'...' strings and dollar-quoted strings are not parsed, and must not contain
+// text resembling a parameter
+// slice parameters are expanded into a placeholder list, for use with IN clauses; slices implementing
+// driver.Valuer and []byte are passed as a single value
+type NamedQuery struct {
+	query  string
+	params map[string]any
+	tag    string
+}
+
+// NewNamedQuery creates a new NamedQuery
+//
+// Example usage:
+//
+//	qry := db.NewNamedQuery("SELECT * FROM users WHERE id_tenant = :tenant AND status IN (:status)").
+//	  Bind("tenant", 12).
+//	  Bind("status", []string{"active", "pending"}).
+//	  Tag("users.listActive")
+//	users := make([]*User, 0)
+//	err := qry.Select(ctx, client.Db(), &users)
+//	// executes /* users.listActive */ SELECT * FROM users WHERE id_tenant = $1 AND status IN ($2, $3)
+func NewNamedQuery(query string) *NamedQuery {
+	return &NamedQuery{
+		query:  query,
+		params: make(map[string]any),
+	}
+}
+
+// Bind sets the value of a parameter
+func (q *NamedQuery) Bind(name string, value any) *NamedQuery {
+	q.params[name] = value
+	return q
+}
+
+// BindMap sets the value of multiple parameters
+func (q *NamedQuery) BindMap(params map[string]any) *NamedQuery {
+	for k, v := range params {
+		q.params[k] = v
+	}
+	return q
+}
+
+// Tag sets a tag prepended to the query as a comment, e.g. to attribute queries in pg_stat_statements or
+// system.query_log; tags may only contain letters, digits and _.:=- otherwise Build() fails with ErrInvalidTag
+func (q *NamedQuery) Tag(tag string) *NamedQuery {
+	q.tag = tag
+	return q
+}
+
+// Build returns the query with placeholders for driverName, and the positional arguments
+func (q *NamedQuery) Build(driverName string) (string, []any, error) {
+	if !validTag(q.tag) {
+		return "", nil, ErrInvalidTag
+	}
+	bindType := sqlx.BindType(driverName)
+	backslash := driverName == "mysql"
+	b := &strings.Builder{}
+	if len(q.tag) > 0 {
+		b.WriteString("/* ")
+		b.WriteString(q.tag)
+		b.WriteString(" */ ")
+	}
+	args := make([]any, 0, len(q.params))
+	src := q.query
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// quoted string or identifier
+			end := closingQuote(src, i, backslash)
+			b.WriteString(src[i:end])
+			i = end - 1
+		case c == '-' && i+1 < len(src) && src[i+1] == '-':
+			// line comment
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src)
+			} else {
+				end += i + 1
+			}
+			b.WriteString(src[i:end])
+			i = end - 1
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			// block comment
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				end = len(src)
+			} else {
+				end += i + 4
+			}
+			b.WriteString(src[i:end])
+			i = end - 1
+		case c == ':' && i+1 < len(src) && src[i+1] == ':':
+			// cast
+			b.WriteString("::")
+			i++
+		case c == ':' && i+1 < len(src) && isParamChar(src[i+1]):
+			end := i + 1
+			for end < len(src) && isParamChar(src[end]) {
+				end++
+			}
+			name := src[i+1 : end]
+			value, ok := q.params[name]
+			if !ok {
+				return "", nil, fmt.Errorf("%w: %s", ErrMissingParam, name)
+			}
+			values, expand := listValues(value)
+			if !expand {
+				values = []any{value}
+			} else if len(values) == 0 {
+				return "", nil, fmt.Errorf("%w: %s", ErrEmptyInList, name)
+			}
+			for j, v := range values {
+				if j > 0 {
+					b.WriteString(", ")
+				}
+				args = append(args, v)
+				writePlaceholder(b, bindType, len(args))
+			}
+			i = end - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), args, nil
+}
+
+// Select executes the query and scans the rows into target, a slice
+func (q *NamedQuery) Select(ctx context.Context, conn sqlx.ExtContext, target any) error {
+	qry, args, err := q.Build(conn.DriverName())
+	if err != nil {
+		return err
+	}
+	return sqlx.SelectContext(ctx, conn, target, qry, args...)
+}
+
+// Get executes the query and scans a single row into target
+func (q *NamedQuery) Get(ctx context.Context, conn sqlx.ExtContext, target any) error {
+	qry, args, err := q.Build(conn.DriverName())
+	if err != nil {
+		return err
+	}
+	return sqlx.GetContext(ctx, conn, target, qry, args...)
+}
+
+// Exec executes the query
+func (q *NamedQuery) Exec(ctx context.Context, conn sqlx.ExtContext) (sql.Result, error) {
+	qry, args, err := q.Build(conn.DriverName())
+	if err != nil {
+		return nil, err
+	}
+	return conn.ExecContext(ctx, qry, args...)
+}
+
+// closingQuote returns the position after the quote closing the string starting at start, or len(src)
+// doubled quotes are escapes; if backslash is true, backslash escapes are also recognized
+func closingQuote(src string, start int, backslash bool) int {
+	quote := src[start]
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			if backslash {
+				i++
+			}
+		case quote:
+			if i+1 < len(src) && src[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(src)
+}
+
+// validTag returns true if tag only contains characters safe to place inside a comment
+func validTag(tag string) bool {
+	for i := 0; i < len(tag); i++ {
+		c := tag[i]
+		if !isParamChar(c) && c != '.' && c != ':' && c != '=' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func isParamChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// listValues returns the elements of value and true if value is a slice or array to be expanded
+func listValues(value any) ([]any, bool) {
+	if value == nil {
+		return nil, false
+	}
+	if _, ok := value.(driver.Valuer); ok {
+		return nil, false
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		// []byte
+		return nil, false
+	}
+	result := make([]any, v.Len())
+	for i := range result {
+		result[i] = v.Index(i).Interface()
+	}
+	return result, true
+}
+
+func writePlaceholder(b *strings.Builder, bindType int, n int) {
+	switch bindType {
+	case sqlx.DOLLAR:
+		b.WriteString("$" + strconv.Itoa(n))
+	case sqlx.NAMED:
+		b.WriteString(":arg" + strconv.Itoa(n))
+	case sqlx.AT:
+		b.WriteString("@p" + strconv.Itoa(n))
+	default:
+		b.WriteString("?")
+	}
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNamedQuery(t *testing.T) {
+	qry := NewNamedQuery("SELECT id::text, ':skip' FROM users WHERE id_tenant = :tenant AND status IN (:status) AND data = :data AND id_tenant <> :tenant").
+		Bind("tenant", 12).
+		Bind("status", []string{"active", "pending"}).
+		Bind("data", []byte("raw"))
+
+	sql, args, err := qry.Build("pgx")
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT id::text, ':skip' FROM users WHERE id_tenant = $1 AND status IN ($2, $3) AND data = $4 AND id_tenant <> $5", sql)
+	assert.Equal(t, []any{12, "active", "pending", []byte("raw"), 12}, args)
+
+	// clickhouse and mysql use positional placeholders
+	sql, _, err = qry.Build("clickhouse")
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT id::text, ':skip' FROM users WHERE id_tenant = ? AND status IN (?, ?) AND data = ? AND id_tenant <> ?", sql)
+
+	// tags
+	sql, _, err = NewNamedQuery("SELECT 1").Tag("reports.daily:id=12").Build("pgx")
+	assert.Nil(t, err)
+	assert.Equal(t, "/* reports.daily:id=12 */ SELECT 1", sql)
+	_, _, err = NewNamedQuery("SELECT 1").Tag("reports.daily */ DROP TABLE x").Build("pgx")
+	assert.ErrorIs(t, err, ErrInvalidTag)
+	_, _, err = NewNamedQuery("SELECT 1").Tag("**// ; DROP TABLE users; --").Build("pgx")
+	assert.ErrorIs(t, err, ErrInvalidTag)
+
+	// comments and escaped quotes
+	sql, args, err = NewNamedQuery("SELECT 'it''s :a', `:b` -- :c\nFROM t /* :d */ WHERE id = :id").Bind("id", 1).Build("pgx")
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT 'it''s :a', `:b` -- :c\nFROM t /* :d */ WHERE id = $1", sql)
+	assert.Equal(t, []any{1}, args)
+	sql, _, err = NewNamedQuery(`SELECT 'it\'s :a' WHERE id = :id`).Bind("id", 1).Build("mysql")
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT 'it\'s :a' WHERE id = ?`, sql)
+
+	// errors
+	_, _, err = NewNamedQuery("SELECT * FROM users WHERE id = :id").Build("pgx")
+	assert.ErrorIs(t, err, ErrMissingParam)
+	_, _, err = NewNamedQuery("SELECT * FROM users WHERE id IN (:ids)").Bind("ids", []int{}).Build("pgx")
+	assert.ErrorIs(t, err, ErrEmptyInList)
+
+	// BindMap
+	sql, args, err = NewNamedQuery("UPDATE users SET name=:name WHERE id=:id").
+		BindMap(map[string]any{"name": "john", "id": 1}).Build("mysql")
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE users SET name=? WHERE id=?", sql)
+	assert.Equal(t, []any{"john", 1}, args)
+}
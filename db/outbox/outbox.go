@@ -0,0 +1,245 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/provider/broker"
+	"github.com/oddbit-project/blueprint/provider/pgsql"
+	"github.com/oddbit-project/blueprint/utils"
+	"regexp"
+	"strings"
+)
+
+const (
+	DefaultTable        = "outbox"
+	DefaultBatchSize    = 100
+	DefaultPollInterval = 1000 // milliseconds
+	DefaultMaxAttempts  = 10
+
+	HeaderDedupKey = "outbox-dedup-key" // HeaderDedupKey message header with the deduplication key of published messages
+
+	ErrNilConfig           = utils.Error("config is nil")
+	ErrNilClient           = utils.Error("client is nil")
+	ErrNilOutbox           = utils.Error("outbox is nil")
+	ErrNilPublisher        = utils.Error("publisher is nil")
+	ErrNilMessage          = utils.Error("message is nil")
+	ErrInvalidTable        = utils.Error("invalid outbox table name")
+	ErrInvalidChannel      = utils.Error("invalid outbox notification channel")
+	ErrInvalidBatchSize    = utils.Error("batchSize must be >= 1")
+	ErrInvalidPollInterval = utils.Error("pollInterval must be >= 1")
+	ErrInvalidMaxAttempts  = utils.Error("maxAttempts must be >= 1")
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}(\.[A-Za-z_][A-Za-z0-9_]{0,62})?$`)
+
+// Config outbox configuration
+type Config struct {
+	Table        string `json:"table"`        // Table outbox table name, optionally schema-qualified
+	Channel      string `json:"channel"`      // Channel optional LISTEN/NOTIFY channel used to wake the relay on new messages
+	BatchSize    int    `json:"batchSize"`    // BatchSize max messages published per relay iteration
+	PollInterval int    `json:"pollInterval"` // PollInterval interval in milliseconds between relay polls
+	// DedupHeader publish the dedup key in the HeaderDedupKey header; disable for brokers without headers, such as mqtt
+	DedupHeader bool `json:"dedupHeader"`
+	// MaxAttempts max publish attempts of a message; messages failing MaxAttempts times are marked as failed
+	// (failed_at is set), and are no longer published
+	MaxAttempts int `json:"maxAttempts"`
+}
+
+// Message is a message to be published by the relay
+type Message struct {
+	Topic   string
+	Key     []byte
+	Payload []byte
+	Headers map[string]string
+	// DedupKey optional unique key; appending a message with an existing DedupKey is a no-op, and the key is
+	// published in the HeaderDedupKey header so consumers can discard redeliveries
+	DedupKey string
+}
+
+// Outbox stores messages in the same transaction as domain writes; messages are published by a Relay after the
+// transaction commits
+type Outbox struct {
+	config  *Config
+	dialect goqu.DialectWrapper
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Table:        DefaultTable,
+		Channel:      "",
+		BatchSize:    DefaultBatchSize,
+		PollInterval: DefaultPollInterval,
+		DedupHeader:  true,
+		MaxAttempts:  DefaultMaxAttempts,
+	}
+}
+
+func (c *Config) Validate() error {
+	if !identifier.MatchString(c.Table) {
+		return ErrInvalidTable
+	}
+	if len(c.Channel) > 0 && !identifier.MatchString(c.Channel) {
+		return ErrInvalidChannel
+	}
+	if c.BatchSize < 1 {
+		return ErrInvalidBatchSize
+	}
+	if c.PollInterval < 1 {
+		return ErrInvalidPollInterval
+	}
+	if c.MaxAttempts < 1 {
+		return ErrInvalidMaxAttempts
+	}
+	return nil
+}
+
+// NewOutbox creates a new Outbox
+//
+// Example usage:
+//
+//	ob, err := outbox.NewOutbox(outbox.NewConfig())
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//
+//	// within a transaction
+//	if err = tx.Repository("orders").Insert(order); err != nil {
+//	  return err
+//	}
+//	if err = ob.Append(ctx, tx.Db(), &outbox.Message{Topic: "orders", Payload: payload, DedupKey: order.Id}); err != nil {
+//	  return err
+//	}
+//	return tx.Commit()
+func NewOutbox(cfg *Config) (*Outbox, error) {
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Outbox{
+		config:  cfg,
+		dialect: goqu.Dialect("pgx"),
+	}, nil
+}
+
+// Schema returns the DDL statements to create the outbox table; failed_at is added to tables created by previous
+// versions
+func (o *Outbox) Schema() string {
+	// index names cannot be schema-qualified; the index is created in the table schema
+	index := o.config.Table[strings.LastIndex(o.config.Table, ".")+1:] + "_pending_idx"
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id BIGSERIAL PRIMARY KEY,
+	topic TEXT NOT NULL,
+	key BYTEA,
+	payload BYTEA,
+	headers JSONB,
+	dedup_key TEXT UNIQUE,
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	published_at TIMESTAMPTZ,
+	failed_at TIMESTAMPTZ
+);
+ALTER TABLE %s ADD COLUMN IF NOT EXISTS failed_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS %s ON %s (id) WHERE published_at IS NULL;`, o.config.Table, o.config.Table, index, o.config.Table)
+}
+
+// Append stores messages using conn, usually the transaction of the domain writes (see db.Tx.Db()); messages
+// with a DedupKey already stored are ignored
+// if a notification channel is configured, a notification is sent, and delivered when the transaction commits
+func (o *Outbox) Append(ctx context.Context, conn sqlx.ExecerContext, msgs ...*Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	for _, msg := range msgs {
+		if msg == nil {
+			return ErrNilMessage
+		}
+		if len(msg.Topic) == 0 {
+			return broker.ErrMissingTopic
+		}
+		record := goqu.Record{
+			"topic":     msg.Topic,
+			"key":       msg.Key,
+			"payload":   msg.Payload,
+			"headers":   nil,
+			"dedup_key": nil,
+		}
+		if len(msg.Headers) > 0 {
+			headers, err := json.Marshal(msg.Headers)
+			if err != nil {
+				return err
+			}
+			record["headers"] = string(headers)
+		}
+		if len(msg.DedupKey) > 0 {
+			record["dedup_key"] = msg.DedupKey
+		}
+		qry, args, err := o.dialect.Insert(o.config.Table).
+			Rows(record).
+			OnConflict(goqu.DoNothing()).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+		if _, err = conn.ExecContext(ctx, qry, args...); err != nil {
+			return err
+		}
+	}
+	if len(o.config.Channel) > 0 {
+		return pgsql.Notify(ctx, conn, o.config.Channel, o.config.Table)
+	}
+	return nil
+}
+
+// record is a stored outbox message
+type record struct {
+	Id       int64          `db:"id"`
+	Topic    string         `db:"topic"`
+	Key      []byte         `db:"key"`
+	Payload  []byte         `db:"payload"`
+	Headers  sql.NullString `db:"headers"`
+	DedupKey sql.NullString `db:"dedup_key"`
+	Attempts int            `db:"attempts"`
+}
+
+// message converts a stored message to a broker message; the dedup key defaults to <table>:<id>
+// messages without headers have nil Headers
+func (o *Outbox) message(r *record) (*broker.Message, error) {
+	headers := make(map[string]string)
+	if r.Headers.Valid {
+		if err := json.Unmarshal([]byte(r.Headers.String), &headers); err != nil {
+			return nil, err
+		}
+	}
+	if o.config.DedupHeader {
+		if r.DedupKey.Valid {
+			headers[HeaderDedupKey] = r.DedupKey.String
+		} else {
+			headers[HeaderDedupKey] = fmt.Sprintf("%s:%d", o.config.Table, r.Id)
+		}
+	}
+	if len(headers) == 0 {
+		headers = nil
+	}
+	return &broker.Message{
+		Topic:   r.Topic,
+		Key:     r.Key,
+		Payload: r.Payload,
+		Headers: headers,
+	}, nil
+}
+
+// DedupKey returns the deduplication key of a message published by a Relay, or an empty string
+func DedupKey(msg *broker.Message) string {
+	if msg == nil || msg.Headers == nil {
+		return ""
+	}
+	return msg.Headers[HeaderDedupKey]
+}
@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/provider/broker"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type execRecorder struct {
+	queries []string
+	args    [][]any
+}
+
+func (e *execRecorder) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	e.queries = append(e.queries, query)
+	e.args = append(e.args, args)
+	return nil, nil
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.Nil(t, NewConfig().Validate())
+
+	cases := []struct {
+		fn  func(c *Config)
+		err error
+	}{
+		{func(c *Config) { c.Table = "" }, ErrInvalidTable},
+		{func(c *Config) { c.Table = "outbox; DROP TABLE users" }, ErrInvalidTable},
+		{func(c *Config) { c.Channel = "outbox-events" }, ErrInvalidChannel},
+		{func(c *Config) { c.BatchSize = 0 }, ErrInvalidBatchSize},
+		{func(c *Config) { c.PollInterval = 0 }, ErrInvalidPollInterval},
+		{func(c *Config) { c.MaxAttempts = 0 }, ErrInvalidMaxAttempts},
+	}
+	for _, c := range cases {
+		cfg := NewConfig()
+		c.fn(cfg)
+		assert.ErrorIs(t, cfg.Validate(), c.err)
+	}
+
+	cfg := NewConfig()
+	cfg.Table = "events.outbox"
+	cfg.Channel = "outbox_events"
+	assert.Nil(t, cfg.Validate())
+}
+
+func TestOutboxAppend(t *testing.T) {
+	_, err := NewOutbox(nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+
+	cfg := NewConfig()
+	cfg.Table = "events.outbox"
+	cfg.Channel = "outbox_events"
+	ob, err := NewOutbox(cfg)
+	assert.Nil(t, err)
+	assert.Contains(t, ob.Schema(), "CREATE TABLE IF NOT EXISTS events.outbox")
+	assert.Contains(t, ob.Schema(), "CREATE INDEX IF NOT EXISTS outbox_pending_idx ON events.outbox")
+	assert.Contains(t, ob.Schema(), "ALTER TABLE events.outbox ADD COLUMN IF NOT EXISTS failed_at")
+
+	conn := &execRecorder{}
+	assert.Nil(t, ob.Append(context.Background(), conn))
+	assert.Len(t, conn.queries, 0)
+	assert.ErrorIs(t, ob.Append(context.Background(), conn, nil), ErrNilMessage)
+	assert.ErrorIs(t, ob.Append(context.Background(), conn, &Message{}), broker.ErrMissingTopic)
+
+	err = ob.Append(context.Background(), conn,
+		&Message{Topic: "orders", Payload: []byte("{}"), DedupKey: "order-1"},
+		&Message{Topic: "orders", Payload: []byte("{}"), Headers: map[string]string{"type": "created"}},
+	)
+	assert.Nil(t, err)
+	// two inserts and a notification
+	assert.Len(t, conn.queries, 3)
+	assert.Contains(t, conn.queries[0], `INSERT INTO "events"."outbox"`)
+	assert.Contains(t, conn.queries[0], "ON CONFLICT DO NOTHING")
+	assert.Contains(t, conn.args[0], "order-1")
+	assert.Contains(t, conn.args[1], `{"type":"created"}`)
+	assert.Contains(t, conn.queries[2], "pg_notify")
+}
+
+func TestOutboxMessage(t *testing.T) {
+	ob, err := NewOutbox(NewConfig())
+	assert.Nil(t, err)
+
+	msg, err := ob.message(&record{
+		Id:      12,
+		Topic:   "orders",
+		Key:     []byte("key"),
+		Payload: []byte("payload"),
+		Headers: sql.NullString{String: `{"type":"created"}`, Valid: true},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "orders", msg.Topic)
+	assert.Equal(t, []byte("key"), msg.Key)
+	assert.Equal(t, "created", msg.Headers["type"])
+	assert.Equal(t, "outbox:12", DedupKey(msg))
+
+	msg, err = ob.message(&record{Id: 13, Topic: "orders", DedupKey: sql.NullString{String: "order-1", Valid: true}})
+	assert.Nil(t, err)
+	assert.Equal(t, "order-1", DedupKey(msg))
+	assert.Equal(t, "", DedupKey(&broker.Message{}))
+
+	ob.config.DedupHeader = false
+	msg, err = ob.message(&record{Id: 14, Topic: "orders"})
+	assert.Nil(t, err)
+	assert.Nil(t, msg.Headers)
+}
+
+func TestNewRelay(t *testing.T) {
+	ob, err := NewOutbox(NewConfig())
+	assert.Nil(t, err)
+	client := db.NewSqlClient("", "pgx", nil)
+	publisher, err := broker.NewMemory(10)
+	assert.Nil(t, err)
+
+	_, err = NewRelay(nil, ob, publisher)
+	assert.ErrorIs(t, err, ErrNilClient)
+	_, err = NewRelay(client, nil, publisher)
+	assert.ErrorIs(t, err, ErrNilOutbox)
+	_, err = NewRelay(client, ob, nil)
+	assert.ErrorIs(t, err, ErrNilPublisher)
+	_, err = NewRelay(client, ob, publisher)
+	assert.Nil(t, err)
+}
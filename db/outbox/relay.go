@@ -0,0 +1,261 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/provider/broker"
+	"github.com/oddbit-project/blueprint/provider/pgsql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// Relay publishes stored outbox messages, in insertion order, with at-least-once guarantees: messages are marked
+// as published only after the publisher acknowledges them, so a failure after publishing causes a redelivery;
+// consumers should discard duplicates using DedupKey()
+// several relays may run concurrently on the same table; pending messages are locked with FOR UPDATE SKIP LOCKED,
+// so each batch is published by a single relay, but ordering across batches is not guaranteed
+type Relay struct {
+	client    *db.SqlClient
+	outbox    *Outbox
+	publisher broker.Publisher
+	messages  *prometheus.CounterVec
+	runs      *prometheus.CounterVec
+}
+
+// NewRelay creates a new Relay
+//
+// Example usage:
+//
+//	relay, err := outbox.NewRelay(client, ob, kafka.NewBrokerPublisher(producer))
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	prometheus.MustRegister(relay)
+//	go relay.Run(ctx)
+func NewRelay(client *db.SqlClient, outbox *Outbox, publisher broker.Publisher) (*Relay, error) {
+	if client == nil {
+		return nil, ErrNilClient
+	}
+	if outbox == nil {
+		return nil, ErrNilOutbox
+	}
+	if publisher == nil {
+		return nil, ErrNilPublisher
+	}
+	return &Relay{
+		client:    client,
+		outbox:    outbox,
+		publisher: publisher,
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "blueprint",
+			Subsystem: "outbox",
+			Name:      "messages_total",
+			Help:      "Number of outbox messages processed by the relay",
+		}, []string{"table", "status"}),
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "blueprint",
+			Subsystem: "outbox",
+			Name:      "runs_total",
+			Help:      "Number of outbox relay iterations",
+		}, []string{"table", "status"}),
+	}, nil
+}
+
+// Run publishes pending messages every PollInterval milliseconds until ctx is cancelled; if a notification
+// channel is configured, the relay is also woken up when messages are appended
+// errors are logged and failed messages are retried on the next iteration, up to MaxAttempts times
+// Note: this function is blocking; it returns nil when ctx is cancelled
+func (r *Relay) Run(ctx context.Context) error {
+	wake := make(chan struct{}, 1)
+	if len(r.outbox.config.Channel) > 0 {
+		listener := pgsql.NewListener(r.client)
+		go func() {
+			err := listener.Listen(ctx, r.outbox.config.Channel, func(ctx context.Context, n *pgsql.Notification) error {
+				select {
+				case wake <- struct{}{}:
+				default:
+				}
+				return nil
+			})
+			// the relay keeps polling without notifications
+			if err != nil && ctx.Err() == nil {
+				log.Error().Err(err).Str("table", r.outbox.config.Table).Msg("outbox relay notification listener failed")
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(time.Duration(r.outbox.config.PollInterval) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		// full batches are followed immediately by the next batch
+		for {
+			count, err := r.RunOnce(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Error().Err(err).Str("table", r.outbox.config.Table).Msg("outbox relay failed")
+				}
+				break
+			}
+			if count < r.outbox.config.BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// RunOnce publishes a single batch of pending messages, and returns the number of published messages
+// if a message fails to publish, the messages published before it are marked as published, and the publish error
+// is returned
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	table := r.outbox.config.Table
+	count, err := r.publishBatch(ctx)
+	if count > 0 {
+		r.messages.WithLabelValues(table, "published").Add(float64(count))
+	}
+	if err != nil {
+		r.runs.WithLabelValues(table, "error").Inc()
+		return count, err
+	}
+	r.runs.WithLabelValues(table, "success").Inc()
+	return count, nil
+}
+
+// publishBatch locks, publishes and marks a batch of pending messages within a single transaction
+// the batch is published with a single Publish() call; if it fails, messages are published one by one, in order,
+// until the first failure, so a failing message does not cause the whole batch to be retried; the failing message
+// has its attempts incremented, and is marked as failed after MaxAttempts attempts. Messages that cannot be decoded
+// are marked as failed immediately
+func (r *Relay) publishBatch(ctx context.Context) (int, error) {
+	table := r.outbox.config.Table
+	dialect := r.outbox.dialect
+
+	tx, err := r.client.Begin(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	qry, args, err := dialect.From(table).
+		Select("id", "topic", "key", "payload", "headers", "dedup_key", "attempts").
+		Where(goqu.C("published_at").IsNull(), goqu.C("failed_at").IsNull()).
+		Order(goqu.C("id").Asc()).
+		Limit(uint(r.outbox.config.BatchSize)).
+		ForUpdate(exp.SkipLocked).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return 0, err
+	}
+	records := make([]*record, 0, r.outbox.config.BatchSize)
+	if err = tx.Db().SelectContext(ctx, &records, qry, args...); err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	// undecodable messages will never be published
+	valid := make([]*record, 0, len(records))
+	msgs := make([]*broker.Message, 0, len(records))
+	for _, rec := range records {
+		msg, err := r.outbox.message(rec)
+		if err != nil {
+			r.messages.WithLabelValues(table, "dead").Inc()
+			log.Error().Err(err).Str("table", table).Int64("id", rec.Id).Msg("invalid outbox message, marked as failed")
+			if err = r.markFailed(ctx, tx, rec, err, true); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		valid = append(valid, rec)
+		msgs = append(msgs, msg)
+	}
+
+	published := valid
+	var pubErr error
+	if len(msgs) > 0 {
+		if pubErr = r.publisher.Publish(ctx, msgs...); pubErr != nil {
+			// find the first failing message
+			published = nil
+			for i, msg := range msgs {
+				if pubErr = r.publisher.Publish(ctx, msg); pubErr != nil {
+					rec := valid[i]
+					dead := rec.Attempts+1 >= r.outbox.config.MaxAttempts
+					r.messages.WithLabelValues(table, "failed").Inc()
+					if dead {
+						r.messages.WithLabelValues(table, "dead").Inc()
+						log.Error().Err(pubErr).Str("table", table).Int64("id", rec.Id).Msg("outbox message reached max attempts, marked as failed")
+					}
+					if err = r.markFailed(ctx, tx, rec, pubErr, dead); err != nil {
+						return 0, errors.Join(pubErr, err)
+					}
+					break
+				}
+				published = valid[:i+1]
+			}
+		}
+	}
+
+	if len(published) > 0 {
+		ids := make([]int64, len(published))
+		for i, rec := range published {
+			ids[i] = rec.Id
+		}
+		qry, args, err = dialect.Update(table).
+			Set(goqu.Record{"attempts": goqu.L("attempts + 1"), "last_error": nil, "published_at": goqu.L("NOW()")}).
+			Where(goqu.C("id").In(ids)).
+			Prepared(true).
+			ToSQL()
+		if err != nil {
+			return 0, errors.Join(pubErr, err)
+		}
+		if _, err = tx.Db().ExecContext(ctx, qry, args...); err != nil {
+			return 0, errors.Join(pubErr, err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, errors.Join(pubErr, err)
+	}
+	return len(published), pubErr
+}
+
+// markFailed increments the attempts of a message and stores the error; if dead is true, the message is marked as
+// failed, and is no longer published
+func (r *Relay) markFailed(ctx context.Context, tx *db.Tx, rec *record, cause error, dead bool) error {
+	fields := goqu.Record{"attempts": goqu.L("attempts + 1"), "last_error": cause.Error()}
+	if dead {
+		fields["failed_at"] = goqu.L("NOW()")
+	}
+	qry, args, err := r.outbox.dialect.Update(r.outbox.config.Table).
+		Set(fields).
+		Where(goqu.C("id").Eq(rec.Id)).
+		Prepared(true).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Db().ExecContext(ctx, qry, args...)
+	return err
+}
+
+// Describe implements prometheus.Collector
+func (r *Relay) Describe(ch chan<- *prometheus.Desc) {
+	r.messages.Describe(ch)
+	r.runs.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (r *Relay) Collect(ch chan<- prometheus.Metric) {
+	r.messages.Collect(ch)
+	r.runs.Collect(ch)
+}
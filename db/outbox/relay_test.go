@@ -0,0 +1,98 @@
+package outbox
+
+import (
+	"context"
+	"github.com/oddbit-project/blueprint/provider/broker"
+	"github.com/oddbit-project/blueprint/provider/pgsql/pgsqltest"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const errPublish = utils.Error("publish failed")
+
+// topicPublisher fails to publish messages of a given topic
+type topicPublisher struct {
+	failTopic string
+	published []*broker.Message
+}
+
+func (p *topicPublisher) Publish(ctx context.Context, msgs ...*broker.Message) error {
+	for _, m := range msgs {
+		if m.Topic == p.failTopic {
+			return errPublish
+		}
+	}
+	p.published = append(p.published, msgs...)
+	return nil
+}
+
+func (p *topicPublisher) Close() error {
+	return nil
+}
+
+func TestRelayFailures(t *testing.T) {
+	client := pgsqltest.Client(t)
+	defer client.Disconnect()
+	ctx := context.Background()
+
+	cfg := NewConfig()
+	cfg.Table = "outbox_relay_test"
+	cfg.MaxAttempts = 2
+	ob, err := NewOutbox(cfg)
+	assert.Nil(t, err)
+	_, err = client.Db().Exec("DROP TABLE IF EXISTS outbox_relay_test")
+	assert.Nil(t, err)
+	_, err = client.Db().Exec(ob.Schema())
+	assert.Nil(t, err)
+	defer client.Db().Exec("DROP TABLE IF EXISTS outbox_relay_test")
+
+	assert.Nil(t, ob.Append(ctx, client.Db(),
+		&Message{Topic: "orders", Payload: []byte("1")},
+		&Message{Topic: "poison", Payload: []byte("2")},
+		&Message{Topic: "orders", Payload: []byte("3")},
+		&Message{Topic: "orders", Payload: []byte("4")},
+	))
+	// undecodable headers
+	_, err = client.Db().Exec("UPDATE outbox_relay_test SET headers='\"invalid\"' WHERE payload='4'")
+	assert.Nil(t, err)
+
+	publisher := &topicPublisher{failTopic: "poison"}
+	relay, err := NewRelay(client, ob, publisher)
+	assert.Nil(t, err)
+
+	// messages before the failing one are published; the invalid message is marked as failed
+	count, err := relay.RunOnce(ctx)
+	assert.ErrorIs(t, err, errPublish)
+	assert.Equal(t, 1, count)
+	assert.Len(t, publisher.published, 1)
+
+	// the failing message is marked as failed after MaxAttempts attempts, and the next ones are published
+	count, err = relay.RunOnce(ctx)
+	assert.ErrorIs(t, err, errPublish)
+	assert.Equal(t, 0, count)
+	count, err = relay.RunOnce(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	assert.Len(t, publisher.published, 2)
+	assert.Equal(t, []byte("3"), publisher.published[1].Payload)
+
+	type row struct {
+		Payload  []byte `db:"payload"`
+		Attempts int    `db:"attempts"`
+		Failed   bool   `db:"failed"`
+	}
+	rows := make([]*row, 0)
+	assert.Nil(t, client.Db().Select(&rows, "SELECT payload, attempts, failed_at IS NOT NULL AS failed FROM outbox_relay_test WHERE published_at IS NULL ORDER BY id"))
+	assert.Len(t, rows, 2)
+	assert.Equal(t, []byte("2"), rows[0].Payload)
+	assert.Equal(t, 2, rows[0].Attempts)
+	assert.True(t, rows[0].Failed)
+	assert.Equal(t, []byte("4"), rows[1].Payload)
+	assert.True(t, rows[1].Failed)
+
+	// nothing left to publish
+	count, err = relay.RunOnce(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+}
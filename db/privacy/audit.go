@@ -0,0 +1,33 @@
+package privacy
+
+import (
+	"context"
+	"github.com/oddbit-project/blueprint/db"
+)
+
+// RepositoryAuditor stores audit records in a database table
+//
+// The table must have the columns defined in AuditRecord, e.g. for PostgreSQL:
+//
+//	CREATE TABLE privacy_audit(
+//	  created TIMESTAMP WITH TIME ZONE,
+//	  operation TEXT,
+//	  subject_id TEXT,
+//	  entity TEXT,
+//	  rows BIGINT,
+//	  error TEXT
+//	);
+type RepositoryAuditor struct {
+	repo db.Repository
+}
+
+func NewRepositoryAuditor(repo db.Repository) *RepositoryAuditor {
+	return &RepositoryAuditor{
+		repo: repo,
+	}
+}
+
+// Audit inserts the audit record
+func (a *RepositoryAuditor) Audit(ctx context.Context, record *AuditRecord) error {
+	return a.repo.Insert(record)
+}
@@ -0,0 +1,274 @@
+package privacy
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/rs/zerolog/log"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	ErasureDelete    = "delete"
+	ErasureAnonymize = "anonymize"
+
+	OperationExport = "export"
+	OperationErase  = "erase"
+
+	ErrNilDescriptor       = utils.Error("descriptor is nil")
+	ErrMissingEntity       = utils.Error("missing descriptor entity name")
+	ErrMissingRepository   = utils.Error("missing descriptor repository")
+	ErrMissingSubjectField = utils.Error("missing descriptor subject field")
+	ErrInvalidErasure      = utils.Error("invalid descriptor erasure strategy")
+	ErrMissingAnonymize    = utils.Error("anonymize erasure requires replacement values")
+	ErrDuplicateEntity     = utils.Error("entity already registered")
+)
+
+// Descriptor describes the personal data of a data subject stored in a repository
+type Descriptor struct {
+	Entity       string         // Entity name used in exports and audit records
+	Repository   db.Repository  // Repository table holding the data
+	SubjectField string         // SubjectField column identifying the data subject
+	Fields       []string       // Fields columns to export; if empty, all columns are exported
+	Erasure      string         // Erasure strategy, ErasureDelete or ErasureAnonymize
+	Anonymize    map[string]any // Anonymize column replacement values used by ErasureAnonymize
+}
+
+// AuditRecord registers an export or erasure operation
+type AuditRecord struct {
+	Created   time.Time `db:"created"`
+	Operation string    `db:"operation"`
+	SubjectId string    `db:"subject_id"`
+	Entity    string    `db:"entity"`
+	Rows      int64     `db:"rows"`
+	Error     string    `db:"error"`
+}
+
+// Auditor persists audit records
+type Auditor interface {
+	Audit(ctx context.Context, record *AuditRecord) error
+}
+
+// Manager orchestrates export and erasure of data subject records across registered repositories
+type Manager struct {
+	descriptors []*Descriptor
+	auditor     Auditor
+	mx          sync.RWMutex
+}
+
+func (d *Descriptor) Validate() error {
+	if len(d.Entity) == 0 {
+		return ErrMissingEntity
+	}
+	if d.Repository == nil {
+		return ErrMissingRepository
+	}
+	if len(d.SubjectField) == 0 {
+		return ErrMissingSubjectField
+	}
+	switch d.Erasure {
+	case ErasureDelete:
+	case ErasureAnonymize:
+		if len(d.Anonymize) == 0 {
+			return ErrMissingAnonymize
+		}
+	default:
+		return ErrInvalidErasure
+	}
+	return nil
+}
+
+// NewManager creates a new privacy manager; auditor is optional
+//
+// Example usage:
+//
+//	mgr := privacy.NewManager(privacy.NewRepositoryAuditor(auditRepo))
+//	mgr.Register(&privacy.Descriptor{
+//	  Entity:       "users",
+//	  Repository:   userRepo,
+//	  SubjectField: "id_user",
+//	  Erasure:      privacy.ErasureAnonymize,
+//	  Anonymize:    map[string]any{"name": "anonymous", "email": nil},
+//	})
+//	mgr.Register(&privacy.Descriptor{
+//	  Entity:       "orders",
+//	  Repository:   orderRepo,
+//	  SubjectField: "id_user",
+//	  Erasure:      privacy.ErasureDelete,
+//	})
+//	err := mgr.ExportJSON(ctx, userId, w)
+func NewManager(auditor Auditor) *Manager {
+	return &Manager{
+		descriptors: make([]*Descriptor, 0),
+		auditor:     auditor,
+	}
+}
+
+// Register adds a descriptor
+func (m *Manager) Register(d *Descriptor) error {
+	if d == nil {
+		return ErrNilDescriptor
+	}
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	for _, existing := range m.descriptors {
+		if existing.Entity == d.Entity {
+			return ErrDuplicateEntity
+		}
+	}
+	m.descriptors = append(m.descriptors, d)
+	return nil
+}
+
+// Descriptors returns the registered descriptors
+func (m *Manager) Descriptors() []*Descriptor {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	result := make([]*Descriptor, len(m.descriptors))
+	copy(result, m.descriptors)
+	return result
+}
+
+// Export fetches all records of the data subject, indexed by entity name
+func (m *Manager) Export(ctx context.Context, subjectId any) (map[string][]map[string]any, error) {
+	result := make(map[string][]map[string]any)
+	for _, d := range m.Descriptors() {
+		rows, err := m.fetch(ctx, d, subjectId)
+		m.audit(ctx, OperationExport, subjectId, d.Entity, int64(len(rows)), err)
+		if err != nil {
+			return nil, fmt.Errorf("export of '%s' failed: %w", d.Entity, err)
+		}
+		result[d.Entity] = rows
+	}
+	return result, nil
+}
+
+// ExportJSON writes all records of the data subject as a JSON document
+func (m *Manager) ExportJSON(ctx context.Context, subjectId any, w io.Writer) error {
+	data, err := m.Export(ctx, subjectId)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(data)
+}
+
+// ExportArchive writes all records of the data subject as a zip archive, with one JSON file per entity
+func (m *Manager) ExportArchive(ctx context.Context, subjectId any, w io.Writer) error {
+	data, err := m.Export(ctx, subjectId)
+	if err != nil {
+		return err
+	}
+	archive := zip.NewWriter(w)
+	for _, d := range m.Descriptors() {
+		f, err := archive.Create(d.Entity + ".json")
+		if err != nil {
+			return err
+		}
+		if err = json.NewEncoder(f).Encode(data[d.Entity]); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// Erase removes or anonymizes all records of the data subject, according to each descriptor erasure strategy
+// it returns the number of affected rows per entity; a failing entity does not prevent erasure of the others
+func (m *Manager) Erase(ctx context.Context, subjectId any) (map[string]int64, error) {
+	result := make(map[string]int64)
+	var errs []error
+	for _, d := range m.Descriptors() {
+		count, err := m.erase(ctx, d, subjectId)
+		m.audit(ctx, OperationErase, subjectId, d.Entity, count, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("erasure of '%s' failed: %w", d.Entity, err))
+			continue
+		}
+		result[d.Entity] = count
+	}
+	return result, errors.Join(errs...)
+}
+
+// fetch reads the subject records of a descriptor as maps
+func (m *Manager) fetch(ctx context.Context, d *Descriptor, subjectId any) ([]map[string]any, error) {
+	qry := d.Repository.SqlSelect().Where(goqu.C(d.SubjectField).Eq(subjectId))
+	if len(d.Fields) > 0 {
+		cols := make([]any, len(d.Fields))
+		for i, f := range d.Fields {
+			cols[i] = goqu.C(f)
+		}
+		qry = qry.Select(cols...)
+	}
+	sqlQry, args, err := qry.Prepared(true).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := d.Repository.Db().QueryxContext(ctx, sqlQry, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]map[string]any, 0)
+	for rows.Next() {
+		record := make(map[string]any)
+		if err = rows.MapScan(record); err != nil {
+			return nil, err
+		}
+		// some drivers return text columns as []byte
+		for k, v := range record {
+			if b, ok := v.([]byte); ok {
+				record[k] = string(b)
+			}
+		}
+		result = append(result, record)
+	}
+	return result, rows.Err()
+}
+
+// erase applies the erasure strategy of a descriptor
+func (m *Manager) erase(ctx context.Context, d *Descriptor, subjectId any) (int64, error) {
+	where := db.FV{d.SubjectField: subjectId}
+	count, err := d.Repository.CountWhere(where)
+	if err != nil || count == 0 {
+		return 0, err
+	}
+	if d.Erasure == ErasureAnonymize {
+		err = d.Repository.UpdateRecord(goqu.Record(d.Anonymize), where)
+	} else {
+		err = d.Repository.DeleteWhere(where)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// audit registers an operation using the configured Auditor
+func (m *Manager) audit(ctx context.Context, operation string, subjectId any, entity string, rows int64, opErr error) {
+	if m.auditor == nil {
+		return
+	}
+	record := &AuditRecord{
+		Created:   time.Now(),
+		Operation: operation,
+		SubjectId: fmt.Sprint(subjectId),
+		Entity:    entity,
+		Rows:      rows,
+	}
+	if opErr != nil {
+		record.Error = opErr.Error()
+	}
+	if err := m.auditor.Audit(ctx, record); err != nil {
+		log.Error().Err(err).Str("operation", operation).Str("entity", entity).Msg("failed to write privacy audit record")
+	}
+}
@@ -0,0 +1,224 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/provider/pgsql/pgsqltest"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+)
+
+// stubRepository satisfies db.Repository without a database connection
+type stubRepository struct {
+	db.Repository
+}
+
+func newRepository() db.Repository {
+	return stubRepository{}
+}
+
+func TestDescriptorValidate(t *testing.T) {
+	repo := newRepository()
+	cases := []struct {
+		d   *Descriptor
+		err error
+	}{
+		{&Descriptor{Repository: repo, SubjectField: "id", Erasure: ErasureDelete}, ErrMissingEntity},
+		{&Descriptor{Entity: "users", SubjectField: "id", Erasure: ErasureDelete}, ErrMissingRepository},
+		{&Descriptor{Entity: "users", Repository: repo, Erasure: ErasureDelete}, ErrMissingSubjectField},
+		{&Descriptor{Entity: "users", Repository: repo, SubjectField: "id"}, ErrInvalidErasure},
+		{&Descriptor{Entity: "users", Repository: repo, SubjectField: "id", Erasure: ErasureAnonymize}, ErrMissingAnonymize},
+		{&Descriptor{Entity: "users", Repository: repo, SubjectField: "id", Erasure: ErasureDelete}, nil},
+	}
+	for _, c := range cases {
+		assert.ErrorIs(t, c.d.Validate(), c.err)
+	}
+}
+
+func TestManagerRegister(t *testing.T) {
+	mgr := NewManager(nil)
+	assert.ErrorIs(t, mgr.Register(nil), ErrNilDescriptor)
+
+	d := &Descriptor{Entity: "users", Repository: newRepository(), SubjectField: "id", Erasure: ErasureDelete}
+	assert.Nil(t, mgr.Register(d))
+	assert.ErrorIs(t, mgr.Register(d), ErrDuplicateEntity)
+	assert.Len(t, mgr.Descriptors(), 1)
+}
+
+func setupTables(t *testing.T, client *db.SqlClient) func() {
+	drop := func() {
+		client.Db().Exec("DROP TABLE IF EXISTS privacy_users_test, privacy_orders_test, privacy_audit_test")
+	}
+	drop()
+	statements := []string{
+		`CREATE TABLE privacy_users_test(
+			id_user INT PRIMARY KEY,
+			name TEXT,
+			email TEXT)`,
+		`CREATE TABLE privacy_orders_test(
+			id_order SERIAL PRIMARY KEY,
+			id_user INT NOT NULL,
+			amount INT NOT NULL)`,
+		`CREATE TABLE privacy_audit_test(
+			created TIMESTAMP WITH TIME ZONE,
+			operation TEXT,
+			subject_id TEXT,
+			entity TEXT,
+			rows BIGINT,
+			error TEXT)`,
+		`INSERT INTO privacy_users_test VALUES (1, 'John', 'john@example.com'), (2, 'Jane', 'jane@example.com')`,
+		`INSERT INTO privacy_orders_test(id_user, amount) VALUES (1, 10), (1, 20), (2, 30)`,
+	}
+	for _, stmt := range statements {
+		_, err := client.Db().Exec(stmt)
+		assert.Nil(t, err)
+	}
+	return drop
+}
+
+func newDbManager(client *db.SqlClient) (*Manager, error) {
+	ctx := context.Background()
+	mgr := NewManager(NewRepositoryAuditor(db.NewRepository(ctx, client, "privacy_audit_test")))
+	if err := mgr.Register(&Descriptor{
+		Entity:       "users",
+		Repository:   db.NewRepository(ctx, client, "privacy_users_test"),
+		SubjectField: "id_user",
+		Fields:       []string{"name", "email"},
+		Erasure:      ErasureAnonymize,
+		Anonymize:    map[string]any{"name": "anonymous", "email": nil},
+	}); err != nil {
+		return nil, err
+	}
+	err := mgr.Register(&Descriptor{
+		Entity:       "orders",
+		Repository:   db.NewRepository(ctx, client, "privacy_orders_test"),
+		SubjectField: "id_user",
+		Erasure:      ErasureDelete,
+	})
+	return mgr, err
+}
+
+func TestManagerExport(t *testing.T) {
+	client := pgsqltest.Client(t)
+	defer client.Disconnect()
+	defer setupTables(t, client)()
+	mgr, err := newDbManager(client)
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	data, err := mgr.Export(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]any{{"name": "John", "email": "john@example.com"}}, data["users"])
+	assert.Len(t, data["orders"], 2)
+	for _, row := range data["orders"] {
+		assert.EqualValues(t, 1, row["id_user"])
+		assert.Contains(t, row, "amount")
+	}
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, mgr.ExportJSON(ctx, 1, buf))
+	exported := make(map[string][]map[string]any)
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &exported))
+	assert.Equal(t, "john@example.com", exported["users"][0]["email"])
+	assert.Len(t, exported["orders"], 2)
+
+	buf.Reset()
+	assert.Nil(t, mgr.ExportArchive(ctx, 1, buf))
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Nil(t, err)
+	assert.Len(t, archive.File, 2)
+	assert.Equal(t, "users.json", archive.File[0].Name)
+	f, err := archive.File[1].Open()
+	assert.Nil(t, err)
+	content, err := io.ReadAll(f)
+	assert.Nil(t, err)
+	orders := make([]map[string]any, 0)
+	assert.Nil(t, json.Unmarshal(content, &orders))
+	assert.Len(t, orders, 2)
+
+	// unknown subjects export empty entities
+	data, err = mgr.Export(ctx, 99)
+	assert.Nil(t, err)
+	assert.Len(t, data["users"], 0)
+	assert.Len(t, data["orders"], 0)
+}
+
+func TestManagerErase(t *testing.T) {
+	client := pgsqltest.Client(t)
+	defer client.Disconnect()
+	defer setupTables(t, client)()
+	mgr, err := newDbManager(client)
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	result, err := mgr.Erase(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int64{"users": 1, "orders": 2}, result)
+
+	// anonymized user row is kept, orders are deleted
+	user := struct {
+		Name  string  `db:"name"`
+		Email *string `db:"email"`
+	}{}
+	assert.Nil(t, client.Db().Get(&user, "SELECT name, email FROM privacy_users_test WHERE id_user=1"))
+	assert.Equal(t, "anonymous", user.Name)
+	assert.Nil(t, user.Email)
+	count := 0
+	assert.Nil(t, client.Db().Get(&count, "SELECT COUNT(*) FROM privacy_orders_test WHERE id_user=1"))
+	assert.Equal(t, 0, count)
+
+	// other subjects are not affected
+	assert.Nil(t, client.Db().Get(&user, "SELECT name, email FROM privacy_users_test WHERE id_user=2"))
+	assert.Equal(t, "Jane", user.Name)
+	assert.Nil(t, client.Db().Get(&count, "SELECT COUNT(*) FROM privacy_orders_test WHERE id_user=2"))
+	assert.Equal(t, 1, count)
+
+	// erasure is idempotent
+	result, err = mgr.Erase(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), result["orders"])
+
+	records := make([]*AuditRecord, 0)
+	assert.Nil(t, client.Db().Select(&records, "SELECT * FROM privacy_audit_test WHERE operation=$1 ORDER BY created", OperationErase))
+	assert.Len(t, records, 4)
+	assert.Equal(t, "1", records[0].SubjectId)
+	assert.Equal(t, "users", records[0].Entity)
+	assert.Equal(t, int64(1), records[0].Rows)
+	assert.Equal(t, "orders", records[1].Entity)
+	assert.Equal(t, int64(2), records[1].Rows)
+	assert.Empty(t, records[1].Error)
+}
+
+func TestManagerEraseFailure(t *testing.T) {
+	client := pgsqltest.Client(t)
+	defer client.Disconnect()
+	defer setupTables(t, client)()
+	ctx := context.Background()
+
+	mgr := NewManager(NewRepositoryAuditor(db.NewRepository(ctx, client, "privacy_audit_test")))
+	assert.Nil(t, mgr.Register(&Descriptor{
+		Entity:       "missing",
+		Repository:   db.NewRepository(ctx, client, "privacy_missing_test"),
+		SubjectField: "id_user",
+		Erasure:      ErasureDelete,
+	}))
+	assert.Nil(t, mgr.Register(&Descriptor{
+		Entity:       "orders",
+		Repository:   db.NewRepository(ctx, client, "privacy_orders_test"),
+		SubjectField: "id_user",
+		Erasure:      ErasureDelete,
+	}))
+
+	// a failing entity does not prevent erasure of the others
+	result, err := mgr.Erase(ctx, 1)
+	assert.NotNil(t, err)
+	assert.Equal(t, map[string]int64{"orders": 2}, result)
+
+	record := &AuditRecord{}
+	assert.Nil(t, client.Db().Get(record, "SELECT * FROM privacy_audit_test WHERE entity='missing'"))
+	assert.NotEmpty(t, record.Error)
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"github.com/oddbit-project/blueprint/log/zerolog/logctx"
+	"github.com/oddbit-project/blueprint/utils"
+	"time"
+)
+
+const (
+	DefaultSlowQueryThreshold = 1000 // milliseconds
+
+	RedactedArg = "[redacted]"
+
+	ErrInvalidSlowQueryThreshold = utils.Error("slowQueryThreshold must be >= 0")
+)
+
+// QueryLoggerConfig query logging configuration
+type QueryLoggerConfig struct {
+	// LogQueries logs all statements at debug level; if false, only slow and failed statements are logged
+	LogQueries bool `json:"logQueries"`
+	// LogArgs logs statement arguments; if false, arguments are redacted
+	LogArgs bool `json:"logArgs"`
+	// SlowQueryThreshold duration in milliseconds above which statements are logged as warnings; 0 disables
+	SlowQueryThreshold int `json:"slowQueryThreshold"`
+}
+
+// QueryLogger is a QueryHook that logs statements with the request logger
+type QueryLogger struct {
+	config *QueryLoggerConfig
+	slow   time.Duration
+}
+
+func NewQueryLoggerConfig() *QueryLoggerConfig {
+	return &QueryLoggerConfig{
+		LogQueries:         false,
+		LogArgs:            false,
+		SlowQueryThreshold: DefaultSlowQueryThreshold,
+	}
+}
+
+func (c *QueryLoggerConfig) Validate() error {
+	if c.SlowQueryThreshold < 0 {
+		return ErrInvalidSlowQueryThreshold
+	}
+	return nil
+}
+
+// NewQueryLogger creates a new QueryLogger; if cfg is nil, the default configuration is used
+// failed statements are logged as errors, statements slower than SlowQueryThreshold as warnings, and all other
+// statements at debug level if LogQueries is true
+func NewQueryLogger(cfg *QueryLoggerConfig) *QueryLogger {
+	if cfg == nil {
+		cfg = NewQueryLoggerConfig()
+	}
+	return &QueryLogger{
+		config: cfg,
+		slow:   time.Duration(cfg.SlowQueryThreshold) * time.Millisecond,
+	}
+}
+
+// AfterQuery implements QueryHook
+func (l *QueryLogger) AfterQuery(ctx context.Context, event *QueryEvent) {
+	logger := logctx.FromContext(ctx)
+	slow := l.slow > 0 && event.Duration > l.slow
+	switch {
+	case event.Err != nil:
+		logger.Error().Err(event.Err).Str("query", event.Query).Interface("args", l.args(event.Args)).
+			Dur("duration", event.Duration).Msg("query failed")
+	case slow:
+		logger.Warn().Str("query", event.Query).Interface("args", l.args(event.Args)).
+			Dur("duration", event.Duration).Msg("slow query")
+	case l.config.LogQueries:
+		logger.Debug().Str("query", event.Query).Interface("args", l.args(event.Args)).
+			Dur("duration", event.Duration).Msg("query")
+	}
+}
+
+// args returns the arguments to log
+func (l *QueryLogger) args(args []any) []any {
+	if l.config.LogArgs {
+		return args
+	}
+	result := make([]any, len(args))
+	for i := range args {
+		result[i] = RedactedArg
+	}
+	return result
+}
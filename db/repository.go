@@ -24,11 +24,13 @@ type Reader interface {
 	FetchWhere(fieldValues map[string]any, target any) error
 	FetchByKey(keyField string, value any, target any) error
 	Exists(fieldName string, fieldValue any, skip ...any) (bool, error)
+	ExistsScoped(model any, fieldName string, fieldValue any, skip ...any) (bool, error)
 }
 
 type Counter interface {
 	Count() (int64, error)
 	CountWhere(fieldValues map[string]any) (int64, error)
+	CountScoped(model any, fieldValues map[string]any) (int64, error)
 }
 
 type Executor interface {
@@ -41,6 +43,12 @@ type Writer interface {
 	Insert(records ...any) error
 	InsertReturning(record any, returnFields []interface{}, target ...any) error
 }
+type BatchWriter interface {
+	InsertMany(records []any, batchSize int) error
+	Upsert(record any, conflictFields []string, updateFields ...string) error
+	UpsertMany(records []any, batchSize int, conflictFields []string, updateFields ...string) error
+}
+
 type Updater interface {
 	Update(qry *goqu.UpdateDataset) error
 	UpdateRecord(record any, whereFieldsValues map[string]any) error
@@ -53,6 +61,11 @@ type Deleter interface {
 	DeleteByKey(keyField string, value any) error
 }
 
+type SoftDeleter interface {
+	SoftDelete(model any, fieldValues map[string]any) error
+	Restore(model any, fieldValues map[string]any) error
+}
+
 type Identifier interface {
 	Db() *sqlx.DB
 	Name() string
@@ -68,10 +81,13 @@ type Repository interface {
 	Reader
 	Executor
 	Writer
+	BatchWriter
 	Deleter
 	Updater
 	Counter
+	SoftDeleter
 	NewTransaction(opts *sql.TxOptions) (Transaction, error)
+	WithDeleted() Repository
 }
 
 type Transaction interface {
@@ -79,31 +95,39 @@ type Transaction interface {
 	Reader
 	Executor
 	Writer
+	BatchWriter
 	Deleter
 	Updater
 	Counter
+	SoftDeleter
 
 	Db() *sqlx.Tx
 	Name() string
 
 	Commit() error
 	Rollback() error
+	Savepoint(name string) error
+	RollbackTo(name string) error
+	ReleaseSavepoint(name string) error
+	WithDeleted() Transaction
 }
 
 type FV map[string]any // alias for fieldValues maps
 
 type repository struct {
-	conn      *sqlx.DB
-	ctx       context.Context
-	tableName string
-	dialect   goqu.DialectWrapper
+	conn        *sqlx.DB
+	ctx         context.Context
+	tableName   string
+	dialect     goqu.DialectWrapper
+	withDeleted bool
 }
 
 type tx struct {
-	conn      *sqlx.Tx
-	ctx       context.Context
-	tableName string
-	dialect   goqu.DialectWrapper
+	conn        *sqlx.Tx
+	ctx         context.Context
+	tableName   string
+	dialect     goqu.DialectWrapper
+	withDeleted bool
 }
 
 func NewRepository(ctx context.Context, conn *SqlClient, tableName string) Repository {
@@ -121,13 +145,50 @@ func (r *repository) NewTransaction(opts *sql.TxOptions) (Transaction, error) {
 		return nil, err
 	}
 	return &tx{
-		conn:      t,
-		ctx:       r.ctx,
-		tableName: r.tableName,
-		dialect:   r.dialect,
+		conn:        t,
+		ctx:         r.ctx,
+		tableName:   r.tableName,
+		dialect:     r.dialect,
+		withDeleted: r.withDeleted,
 	}, nil
 }
 
+// WithDeleted returns a copy of the repository that does not exclude soft-deleted rows from fetch operations
+func (r *repository) WithDeleted() Repository {
+	result := *r
+	result.withDeleted = true
+	return &result
+}
+
+// SoftDelete marks rows matching fieldValues as deleted; model is a struct of the table record type,
+// with a field tagged with `auto:"deleted"`
+//
+// Example:
+//
+//	type User struct {
+//	  Id        int        `db:"id_user" goqu:"skipinsert"`
+//	  CreatedAt time.Time  `db:"created_at" auto:"created"`
+//	  UpdatedAt time.Time  `db:"updated_at" auto:"updated"`
+//	  DeletedAt *time.Time `db:"deleted_at" auto:"deleted"`
+//	}
+//	err := repo.SoftDelete(&User{}, db.FV{"id_user": 1})
+func (r *repository) SoftDelete(model any, fieldValues map[string]any) error {
+	return SoftDelete(r.ctx, r.conn, r.SqlUpdate(), model, fieldValues)
+}
+
+// Restore clears the soft delete field of rows matching fieldValues
+func (r *repository) Restore(model any, fieldValues map[string]any) error {
+	return Restore(r.ctx, r.conn, r.SqlUpdate(), model, fieldValues)
+}
+
+// scope excludes soft-deleted rows from qry, if target has a soft delete field
+func (r *repository) scope(qry *goqu.SelectDataset, target any) *goqu.SelectDataset {
+	if r.withDeleted || qry == nil {
+		return qry
+	}
+	return excludeDeleted(qry, r.tableName, target)
+}
+
 func (r *repository) Db() *sqlx.DB {
 	return r.conn
 }
@@ -167,7 +228,7 @@ func (r *repository) SqlDelete() *goqu.DeleteDataset {
 //	row:= &MyRecord{}
 //	err := repo.FetchOne(repo.SqlSelect(), row)
 func (r *repository) FetchOne(qry *goqu.SelectDataset, target any) error {
-	return FetchOne(r.ctx, r.conn, qry, target)
+	return FetchOne(r.ctx, r.conn, r.scope(qry, target), target)
 }
 
 // Fetch records; target must be a slice
@@ -176,7 +237,7 @@ func (r *repository) FetchOne(qry *goqu.SelectDataset, target any) error {
 //	rows:= make([]*MyRecord,0)
 //	err := repo.Fetch(repo.SqlSelect(), rows)
 func (r *repository) Fetch(qry *goqu.SelectDataset, target any) error {
-	return Fetch(r.ctx, r.conn, qry, target)
+	return Fetch(r.ctx, r.conn, r.scope(qry, target), target)
 }
 
 // FetchRecord fetch a single record with WHERE clause; all clauses are AND
@@ -185,17 +246,17 @@ func (r *repository) Fetch(qry *goqu.SelectDataset, target any) error {
 //	row:= &MyRecord{}
 //	err:= repo.FetchRecord(map[string]any{"name":"foo","email":"foo@bar"}, row)
 func (r *repository) FetchRecord(fieldValues map[string]any, target any) error {
-	return FetchRecord(r.ctx, r.conn, r.SqlSelect(), fieldValues, target)
+	return FetchRecord(r.ctx, r.conn, r.scope(r.SqlSelect(), target), fieldValues, target)
 }
 
 // FetchByKey fetch a single record with WHERE keyField=value
 func (r *repository) FetchByKey(keyField string, value any, target any) error {
-	return FetchByKey(r.ctx, r.conn, r.SqlSelect(), keyField, value, target)
+	return FetchByKey(r.ctx, r.conn, r.scope(r.SqlSelect(), target), keyField, value, target)
 }
 
 // FetchWhere fetch multiple records with WHERE clause
 func (r *repository) FetchWhere(fieldValues map[string]any, target any) error {
-	return FetchWhere(r.ctx, r.conn, r.SqlSelect(), fieldValues, target)
+	return FetchWhere(r.ctx, r.conn, r.scope(r.SqlSelect(), target), fieldValues, target)
 }
 
 // Exec execute a query
@@ -216,10 +277,18 @@ func (r *repository) RawExec(sql string, args ...any) error {
 //	exists, err := repo.Exists("label", "record 4")
 //	// check if a record with label == "record 4" and id_sample_table<>4 exists
 //	exists, err := repo.Exists("label", "record 4", "id_sample_table", 4)
+//
+// Note: soft-deleted rows are not excluded; use ExistsScoped() for tables with soft delete
 func (r *repository) Exists(fieldName string, fieldValue any, skip ...any) (bool, error) {
 	return Exists(r.ctx, r.conn, r.SqlSelect(), fieldName, fieldValue, skip...)
 }
 
+// ExistsScoped works like Exists, but excludes soft-deleted rows if model has a soft delete field, as in Fetch
+// operations; model is a struct of the table record type
+func (r *repository) ExistsScoped(model any, fieldName string, fieldValue any, skip ...any) (bool, error) {
+	return Exists(r.ctx, r.conn, r.scope(r.SqlSelect(), model), fieldName, fieldValue, skip...)
+}
+
 func (r *repository) Delete(qry *goqu.DeleteDataset) error {
 	return Del(r.ctx, r.conn, qry)
 }
@@ -240,18 +309,52 @@ func (r *repository) Insert(rows ...any) error {
 	return Insert(r.ctx, r.conn, r.SqlInsert(), rows...)
 }
 
-// Count returns the total number of rows in the database table
+// InsertMany inserts records in batches of batchSize rows; if batchSize is 0, DefaultBatchSize is used
+// batches are executed as separate statements; use a Transaction for atomicity
+func (r *repository) InsertMany(records []any, batchSize int) error {
+	if batchSize == 0 {
+		batchSize = DefaultBatchSize
+	}
+	return InsertBatch(r.ctx, r.conn, r.SqlInsert(), batchSize, records...)
+}
+
+// Upsert inserts a record, or updates updateFields if it conflicts with an existing row on conflictFields
+// if no updateFields are specified, all record fields except conflictFields are updated
+//
+// Example:
+//
+//	err := repo.Upsert(user, []string{"email"}, "name", "updated_at")
+func (r *repository) Upsert(record any, conflictFields []string, updateFields ...string) error {
+	return Upsert(r.ctx, r.conn, r.SqlInsert(), conflictFields, updateFields, record)
+}
+
+// UpsertMany executes Upsert for records in batches of batchSize rows; if batchSize is 0, DefaultBatchSize is used
+// batches are executed as separate statements; use a Transaction for atomicity
+func (r *repository) UpsertMany(records []any, batchSize int, conflictFields []string, updateFields ...string) error {
+	if batchSize == 0 {
+		batchSize = DefaultBatchSize
+	}
+	return UpsertBatch(r.ctx, r.conn, r.SqlInsert(), batchSize, conflictFields, updateFields, records...)
+}
+
+// Count returns the total number of rows in the database table, including soft-deleted rows
 func (r *repository) Count() (int64, error) {
 	return Count(r.ctx, r.conn, r.SqlSelect().Select(goqu.L("COUNT(*)")))
 }
 
-// CountWhere returns the number of rows matching the fieldValues map
+// CountWhere returns the number of rows matching the fieldValues map, including soft-deleted rows
 func (r *repository) CountWhere(fieldValues map[string]any) (int64, error) {
-	qry := r.SqlSelect().Select(goqu.L("COUNT(*)"))
-	for field, value := range fieldValues {
-		qry = qry.Where(goqu.C(field).Eq(value))
-	}
-	return Count(r.ctx, r.conn, qry)
+	return Count(r.ctx, r.conn, countQuery(r.SqlSelect(), fieldValues))
+}
+
+// CountScoped returns the number of rows matching the fieldValues map, or all rows if fieldValues is nil;
+// soft-deleted rows are excluded if model has a soft delete field, as in Fetch operations
+//
+// Example:
+//
+//	count, err := repo.CountScoped(&User{}, db.FV{"status": "active"})
+func (r *repository) CountScoped(model any, fieldValues map[string]any) (int64, error) {
+	return Count(r.ctx, r.conn, countQuery(r.scope(r.SqlSelect(), model), fieldValues))
 }
 
 // InsertReturning inserts a record, and returns the specified return fields into target
@@ -288,6 +391,39 @@ func (t *tx) Rollback() error {
 	return t.conn.Rollback()
 }
 
+func (t *tx) WithDeleted() Transaction {
+	result := *t
+	result.withDeleted = true
+	return &result
+}
+
+func (t *tx) SoftDelete(model any, fieldValues map[string]any) error {
+	return SoftDelete(t.ctx, t.conn, t.SqlUpdate(), model, fieldValues)
+}
+
+func (t *tx) Restore(model any, fieldValues map[string]any) error {
+	return Restore(t.ctx, t.conn, t.SqlUpdate(), model, fieldValues)
+}
+
+func (t *tx) scope(qry *goqu.SelectDataset, target any) *goqu.SelectDataset {
+	if t.withDeleted || qry == nil {
+		return qry
+	}
+	return excludeDeleted(qry, t.tableName, target)
+}
+
+func (t *tx) Savepoint(name string) error {
+	return Savepoint(t.ctx, t.conn, name)
+}
+
+func (t *tx) RollbackTo(name string) error {
+	return RollbackTo(t.ctx, t.conn, name)
+}
+
+func (t *tx) ReleaseSavepoint(name string) error {
+	return ReleaseSavepoint(t.ctx, t.conn, name)
+}
+
 func (t *tx) Db() *sqlx.Tx {
 	return t.conn
 }
@@ -318,26 +454,26 @@ func (t *tx) SqlDelete() *goqu.DeleteDataset {
 
 // FetchOne fetch a record; target must be a struct
 func (t *tx) FetchOne(qry *goqu.SelectDataset, target any) error {
-	return FetchOne(t.ctx, t.conn, qry, target)
+	return FetchOne(t.ctx, t.conn, t.scope(qry, target), target)
 }
 
 func (t *tx) Fetch(qry *goqu.SelectDataset, target any) error {
-	return Fetch(t.ctx, t.conn, qry, target)
+	return Fetch(t.ctx, t.conn, t.scope(qry, target), target)
 }
 
 // FetchRecord fetch a single record with WHERE clause
 func (t *tx) FetchRecord(fieldValues map[string]any, target any) error {
-	return FetchRecord(t.ctx, t.conn, t.SqlSelect(), fieldValues, target)
+	return FetchRecord(t.ctx, t.conn, t.scope(t.SqlSelect(), target), fieldValues, target)
 }
 
 // FetchByKey fetch a single record with WHERE keyField=value
 func (t *tx) FetchByKey(keyField string, value any, target any) error {
-	return FetchByKey(t.ctx, t.conn, t.SqlSelect(), keyField, value, target)
+	return FetchByKey(t.ctx, t.conn, t.scope(t.SqlSelect(), target), keyField, value, target)
 }
 
 // FetchWhere fetch multiple records with WHERE clause
 func (t *tx) FetchWhere(fieldValues map[string]any, target any) error {
-	return FetchWhere(t.ctx, t.conn, t.SqlSelect(), fieldValues, target)
+	return FetchWhere(t.ctx, t.conn, t.scope(t.SqlSelect(), target), fieldValues, target)
 }
 
 // Exists returns true if one or more records exist WHERE fieldName=fieldValue
@@ -346,6 +482,10 @@ func (t *tx) Exists(fieldName string, fieldValue any, skip ...any) (bool, error)
 	return Exists(t.ctx, t.conn, t.SqlSelect(), fieldName, fieldValue, skip...)
 }
 
+func (t *tx) ExistsScoped(model any, fieldName string, fieldValue any, skip ...any) (bool, error) {
+	return Exists(t.ctx, t.conn, t.scope(t.SqlSelect(), model), fieldName, fieldValue, skip...)
+}
+
 func (t *tx) Exec(qry *goqu.SelectDataset) error {
 	return Exec(t.ctx, t.conn, qry)
 }
@@ -374,6 +514,24 @@ func (t *tx) Insert(rows ...any) error {
 	return Insert(t.ctx, t.conn, t.SqlInsert(), rows...)
 }
 
+func (t *tx) InsertMany(records []any, batchSize int) error {
+	if batchSize == 0 {
+		batchSize = DefaultBatchSize
+	}
+	return InsertBatch(t.ctx, t.conn, t.SqlInsert(), batchSize, records...)
+}
+
+func (t *tx) Upsert(record any, conflictFields []string, updateFields ...string) error {
+	return Upsert(t.ctx, t.conn, t.SqlInsert(), conflictFields, updateFields, record)
+}
+
+func (t *tx) UpsertMany(records []any, batchSize int, conflictFields []string, updateFields ...string) error {
+	if batchSize == 0 {
+		batchSize = DefaultBatchSize
+	}
+	return UpsertBatch(t.ctx, t.conn, t.SqlInsert(), batchSize, conflictFields, updateFields, records...)
+}
+
 // InsertReturning inserts a record, and returns the specified return fields into target
 func (t *tx) InsertReturning(record any, returnFields []interface{}, target ...any) error {
 	return InsertReturning(t.ctx, t.conn, t.SqlInsert(), record, returnFields, target...)
@@ -400,13 +558,23 @@ func (t *tx) Count() (int64, error) {
 	return Count(t.ctx, t.conn, t.SqlSelect().Select(goqu.L("COUNT(*)")))
 }
 
-// CountWhere returns the number of rows matching the fieldValues map
+// CountWhere returns the number of rows matching the fieldValues map, including soft-deleted rows
 func (t *tx) CountWhere(fieldValues map[string]any) (int64, error) {
-	qry := t.SqlSelect().Select(goqu.L("COUNT(*)"))
+	return Count(t.ctx, t.conn, countQuery(t.SqlSelect(), fieldValues))
+}
+
+// CountScoped returns the number of rows matching the fieldValues map, excluding soft-deleted rows
+func (t *tx) CountScoped(model any, fieldValues map[string]any) (int64, error) {
+	return Count(t.ctx, t.conn, countQuery(t.scope(t.SqlSelect(), model), fieldValues))
+}
+
+// countQuery returns a COUNT(*) query of the rows of qry matching fieldValues
+func countQuery(qry *goqu.SelectDataset, fieldValues map[string]any) *goqu.SelectDataset {
+	qry = qry.Select(goqu.L("COUNT(*)"))
 	for field, value := range fieldValues {
 		qry = qry.Where(goqu.C(field).Eq(value))
 	}
-	return Count(t.ctx, t.conn, qry)
+	return qry
 }
 
 // EmptyResult returns true if error is empty result
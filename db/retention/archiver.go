@@ -0,0 +1,39 @@
+package retention
+
+import (
+	"context"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/oddbit-project/blueprint/db"
+)
+
+// RepositoryArchiver archives rows by inserting them into another table, possibly in a different
+// database (e.g. a clickhouse table); the destination must have compatible column names
+type RepositoryArchiver struct {
+	repo db.Repository
+}
+
+// NewRepositoryArchiver creates an Archiver that copies rows into the repository table
+//
+// Example usage:
+//
+//	archive := db.NewRepository(ctx, chClient, "access_log_archive")
+//	policy := retention.NewPolicy("access_log", "id_access_log", "created_at", 30)
+//	policy.Action = retention.ActionArchive
+//	policy.Archiver = retention.NewRepositoryArchiver(archive)
+func NewRepositoryArchiver(repo db.Repository) *RepositoryArchiver {
+	return &RepositoryArchiver{
+		repo: repo,
+	}
+}
+
+// Archive inserts all rows into the destination table
+func (a *RepositoryArchiver) Archive(ctx context.Context, table string, rows []map[string]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	records := make([]any, len(rows))
+	for i, row := range rows {
+		records[i] = goqu.Record(row)
+	}
+	return a.repo.Insert(records...)
+}
@@ -0,0 +1,336 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+const (
+	ActionDelete  = "delete"
+	ActionArchive = "archive"
+
+	DefaultBatchSize = 1000
+
+	ErrNilClient        = utils.Error("client is nil")
+	ErrNilPolicy        = utils.Error("policy is nil")
+	ErrMissingTable     = utils.Error("missing policy table name")
+	ErrMissingKeyField  = utils.Error("missing policy key field")
+	ErrMissingTimeField = utils.Error("missing policy time field")
+	ErrInvalidMaxAge    = utils.Error("policy maxAgeDays must be >= 1")
+	ErrInvalidBatchSize = utils.Error("policy batchSize must be >= 1")
+	ErrInvalidAction    = utils.Error("invalid policy action")
+	ErrMissingArchiver  = utils.Error("archive policy requires an Archiver")
+	ErrInvalidInterval  = utils.Error("interval must be greater than zero")
+	ErrAlreadyRunning   = utils.Error("retention run already in progress")
+)
+
+// Archiver receives rows before they are removed by an archive policy
+// if Archive returns an error, the rows are not deleted
+type Archiver interface {
+	Archive(ctx context.Context, table string, rows []map[string]any) error
+}
+
+// Policy declares the retention rules of a table
+// rows with TimeField older than MaxAgeDays are deleted (or archived and then deleted) in batches of BatchSize,
+// using KeyField to identify each row
+type Policy struct {
+	Table      string   `json:"table"`
+	KeyField   string   `json:"keyField"`
+	TimeField  string   `json:"timeField"`
+	MaxAgeDays int      `json:"maxAgeDays"`
+	BatchSize  int      `json:"batchSize"`
+	Action     string   `json:"action"`
+	Archiver   Archiver `json:"-"`
+}
+
+// Manager executes retention policies against a database client
+type Manager struct {
+	client   *db.SqlClient
+	dialect  goqu.DialectWrapper
+	policies []*Policy
+	rows     *prometheus.CounterVec
+	runs     *prometheus.CounterVec
+	running  sync.Mutex
+	mx       sync.RWMutex
+}
+
+func NewPolicy(table string, keyField string, timeField string, maxAgeDays int) *Policy {
+	return &Policy{
+		Table:      table,
+		KeyField:   keyField,
+		TimeField:  timeField,
+		MaxAgeDays: maxAgeDays,
+		BatchSize:  DefaultBatchSize,
+		Action:     ActionDelete,
+		Archiver:   nil,
+	}
+}
+
+func (p *Policy) Validate() error {
+	if len(p.Table) == 0 {
+		return ErrMissingTable
+	}
+	if len(p.KeyField) == 0 {
+		return ErrMissingKeyField
+	}
+	if len(p.TimeField) == 0 {
+		return ErrMissingTimeField
+	}
+	if p.MaxAgeDays < 1 {
+		return ErrInvalidMaxAge
+	}
+	if p.BatchSize < 1 {
+		return ErrInvalidBatchSize
+	}
+	switch p.Action {
+	case ActionDelete:
+	case ActionArchive:
+		if p.Archiver == nil {
+			return ErrMissingArchiver
+		}
+	default:
+		return ErrInvalidAction
+	}
+	return nil
+}
+
+// Cutoff returns the timestamp before which rows are expired
+func (p *Policy) Cutoff(now time.Time) time.Time {
+	return now.Add(-time.Duration(p.MaxAgeDays) * 24 * time.Hour)
+}
+
+// NewManager creates a new retention Manager
+// Manager implements prometheus.Collector, and can be registered directly to expose processed row counts
+//
+// Example usage:
+//
+//	mgr, err := retention.NewManager(client)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	if err = mgr.Add(retention.NewPolicy("access_log", "id_access_log", "created_at", 90)); err != nil {
+//	  log.Fatal(err)
+//	}
+//	prometheus.MustRegister(mgr)
+//	mgr.Start(ctx, time.Hour)
+func NewManager(client *db.SqlClient) (*Manager, error) {
+	if client == nil {
+		return nil, ErrNilClient
+	}
+	return &Manager{
+		client:   client,
+		dialect:  goqu.Dialect(client.DriverName),
+		policies: make([]*Policy, 0),
+		rows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "blueprint",
+			Subsystem: "retention",
+			Name:      "rows_total",
+			Help:      "Number of rows processed by retention policies",
+		}, []string{"table", "action"}),
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "blueprint",
+			Subsystem: "retention",
+			Name:      "runs_total",
+			Help:      "Number of retention policy executions",
+		}, []string{"table", "status"}),
+	}, nil
+}
+
+// Add validates and registers a policy
+func (m *Manager) Add(p *Policy) error {
+	if p == nil {
+		return ErrNilPolicy
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.policies = append(m.policies, p)
+	return nil
+}
+
+// Policies returns the registered policies
+func (m *Manager) Policies() []*Policy {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	result := make([]*Policy, len(m.policies))
+	copy(result, m.policies)
+	return result
+}
+
+// Run executes all registered policies; a failing policy does not prevent the execution of the others
+// returns ErrAlreadyRunning if a previous run is still in progress
+func (m *Manager) Run(ctx context.Context) error {
+	if !m.running.TryLock() {
+		return ErrAlreadyRunning
+	}
+	defer m.running.Unlock()
+
+	var result []error
+	for _, p := range m.Policies() {
+		if _, err := m.RunPolicy(ctx, p); err != nil {
+			result = append(result, fmt.Errorf("retention policy for '%s': %w", p.Table, err))
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return errors.Join(result...)
+}
+
+// Start runs all policies periodically in a separate goroutine, until ctx is cancelled
+func (m *Manager) Start(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return ErrInvalidInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.Run(ctx); err != nil && !errors.Is(err, ErrAlreadyRunning) {
+					log.Error().Err(err).Msg("retention run failed")
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// RunPolicy executes a single policy, and returns the number of deleted rows
+func (m *Manager) RunPolicy(ctx context.Context, p *Policy) (int64, error) {
+	var total int64
+	cutoff := p.Cutoff(time.Now())
+	logger := log.With().Str("table", p.Table).Str("action", p.Action).Time("cutoff", cutoff).Logger()
+	logger.Info().Msg("retention policy started")
+
+	for {
+		if err := ctx.Err(); err != nil {
+			m.runs.WithLabelValues(p.Table, "cancelled").Inc()
+			return total, err
+		}
+		selected, count, err := m.runBatch(ctx, p, cutoff)
+		total += count
+		if err != nil {
+			m.runs.WithLabelValues(p.Table, "error").Inc()
+			logger.Error().Err(err).Int64("rows", total).Msg("retention policy failed")
+			return total, err
+		}
+		if count > 0 {
+			m.rows.WithLabelValues(p.Table, p.Action).Add(float64(count))
+			logger.Debug().Int64("rows", total).Msg("retention batch processed")
+		}
+		if selected < p.BatchSize {
+			break
+		}
+	}
+	m.runs.WithLabelValues(p.Table, "success").Inc()
+	logger.Info().Int64("rows", total).Msg("retention policy finished")
+	return total, nil
+}
+
+// runBatch processes a single batch of expired rows, and returns the number of selected and deleted rows; the rows
+// of delete policies are selected and deleted in a single transaction
+func (m *Manager) runBatch(ctx context.Context, p *Policy, cutoff time.Time) (int, int64, error) {
+	if p.Action == ActionArchive {
+		// archiving may be slow, or target another database; the transaction is not kept open while it runs
+		return m.processBatch(ctx, m.client.Db(), p, cutoff)
+	}
+	var selected int
+	var deleted int64
+	err := db.WithTransaction(ctx, m.client, nil, func(tx *db.Tx) error {
+		var err error
+		selected, deleted, err = m.processBatch(ctx, tx.Db(), p, cutoff)
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return selected, deleted, nil
+}
+
+// processBatch selects a batch of expired rows, archives them if required, and deletes them
+func (m *Manager) processBatch(ctx context.Context, conn sqlx.ExtContext, p *Policy, cutoff time.Time) (int, int64, error) {
+	expired := goqu.C(p.TimeField).Lt(cutoff)
+	qry := m.dialect.From(p.Table).
+		Where(expired).
+		Order(goqu.C(p.TimeField).Asc()).
+		Limit(uint(p.BatchSize))
+	if p.Action == ActionDelete {
+		qry = qry.Select(goqu.C(p.KeyField))
+	}
+	sqlQry, args, err := qry.Prepared(true).ToSQL()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rows, err := conn.QueryxContext(ctx, sqlQry, args...)
+	if err != nil {
+		return 0, 0, err
+	}
+	records := make([]map[string]any, 0, p.BatchSize)
+	keys := make([]any, 0, p.BatchSize)
+	for rows.Next() {
+		record := make(map[string]any)
+		if err = rows.MapScan(record); err != nil {
+			_ = rows.Close()
+			return 0, 0, err
+		}
+		records = append(records, record)
+		keys = append(keys, record[p.KeyField])
+	}
+	_ = rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, 0, err
+	}
+	if len(keys) == 0 {
+		return 0, 0, nil
+	}
+
+	if p.Action == ActionArchive {
+		if err = p.Archiver.Archive(ctx, p.Table, records); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	// rows updated since the select are no longer expired, and are kept
+	sqlQry, args, err = m.dialect.Delete(p.Table).Where(goqu.C(p.KeyField).In(keys), expired).Prepared(true).ToSQL()
+	if err != nil {
+		return 0, 0, err
+	}
+	result, err := conn.ExecContext(ctx, sqlQry, args...)
+	if err != nil {
+		return 0, 0, err
+	}
+	// not all drivers report affected rows; the selected row count is used instead
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		deleted = int64(len(keys))
+	}
+	return len(keys), deleted, nil
+}
+
+// Describe implements prometheus.Collector
+func (m *Manager) Describe(ch chan<- *prometheus.Desc) {
+	m.rows.Describe(ch)
+	m.runs.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (m *Manager) Collect(ch chan<- prometheus.Metric) {
+	m.rows.Collect(ch)
+	m.runs.Collect(ch)
+}
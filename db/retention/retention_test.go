@@ -0,0 +1,234 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/provider/pgsql/pgsqltest"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const errArchive = utils.Error("archive failed")
+
+type nullArchiver struct{}
+
+func (n nullArchiver) Archive(ctx context.Context, table string, rows []map[string]any) error {
+	return nil
+}
+
+// batchArchiver records the archived batches, and fails if err is set
+type batchArchiver struct {
+	batches [][]map[string]any
+	err     error
+}
+
+func (a *batchArchiver) Archive(ctx context.Context, table string, rows []map[string]any) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.batches = append(a.batches, rows)
+	return nil
+}
+
+// createLogTable creates table with the given number of expired and recent rows
+func createLogTable(t *testing.T, client *db.SqlClient, table string, expired int, recent int) {
+	_, err := client.Db().Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", table))
+	assert.Nil(t, err)
+	_, err = client.Db().Exec(fmt.Sprintf(`CREATE TABLE %s (
+		id_log SERIAL PRIMARY KEY,
+		message TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL)`, table))
+	assert.Nil(t, err)
+	_, err = client.Db().Exec(fmt.Sprintf(`INSERT INTO %s (message, created_at)
+		SELECT 'expired ' || i, now() - interval '60 days' + i * interval '1 second' FROM generate_series(1, $1) i`, table), expired)
+	assert.Nil(t, err)
+	_, err = client.Db().Exec(fmt.Sprintf(`INSERT INTO %s (message, created_at)
+		SELECT 'recent ' || i, now() - interval '1 day' FROM generate_series(1, $1) i`, table), recent)
+	assert.Nil(t, err)
+}
+
+func countRows(t *testing.T, client *db.SqlClient, table string, like string) int {
+	count := 0
+	assert.Nil(t, client.Db().Get(&count, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE message LIKE $1", table), like))
+	return count
+}
+
+func TestPolicyValidate(t *testing.T) {
+	p := NewPolicy("table", "id", "created_at", 30)
+	assert.Nil(t, p.Validate())
+
+	cases := []struct {
+		fn  func(p *Policy)
+		err error
+	}{
+		{func(p *Policy) { p.Table = "" }, ErrMissingTable},
+		{func(p *Policy) { p.KeyField = "" }, ErrMissingKeyField},
+		{func(p *Policy) { p.TimeField = "" }, ErrMissingTimeField},
+		{func(p *Policy) { p.MaxAgeDays = 0 }, ErrInvalidMaxAge},
+		{func(p *Policy) { p.BatchSize = 0 }, ErrInvalidBatchSize},
+		{func(p *Policy) { p.Action = "truncate" }, ErrInvalidAction},
+		{func(p *Policy) { p.Action = ActionArchive }, ErrMissingArchiver},
+	}
+	for _, c := range cases {
+		p := NewPolicy("table", "id", "created_at", 30)
+		c.fn(p)
+		assert.ErrorIs(t, p.Validate(), c.err)
+	}
+
+	p.Action = ActionArchive
+	p.Archiver = nullArchiver{}
+	assert.Nil(t, p.Validate())
+}
+
+func TestPolicyCutoff(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	p := NewPolicy("table", "id", "created_at", 30)
+	assert.Equal(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), p.Cutoff(now))
+}
+
+func TestManager(t *testing.T) {
+	_, err := NewManager(nil)
+	assert.ErrorIs(t, err, ErrNilClient)
+
+	mgr, err := NewManager(db.NewSqlClient("", "pgx", nil))
+	assert.Nil(t, err)
+	assert.ErrorIs(t, mgr.Add(nil), ErrNilPolicy)
+	assert.ErrorIs(t, mgr.Add(NewPolicy("", "id", "created_at", 1)), ErrMissingTable)
+	assert.Nil(t, mgr.Add(NewPolicy("table", "id", "created_at", 1)))
+	assert.Len(t, mgr.Policies(), 1)
+	assert.ErrorIs(t, mgr.Start(context.Background(), 0), ErrInvalidInterval)
+}
+
+func TestRunPolicyDelete(t *testing.T) {
+	client := pgsqltest.Client(t)
+	defer client.Disconnect()
+	createLogTable(t, client, "retention_delete_test", 25, 5)
+	defer client.Db().Exec("DROP TABLE IF EXISTS retention_delete_test")
+
+	mgr, err := NewManager(client)
+	assert.Nil(t, err)
+	p := NewPolicy("retention_delete_test", "id_log", "created_at", 30)
+	p.BatchSize = 10
+
+	count, err := mgr.RunPolicy(context.Background(), p)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(25), count)
+	assert.Equal(t, 0, countRows(t, client, "retention_delete_test", "expired%"))
+	assert.Equal(t, 5, countRows(t, client, "retention_delete_test", "recent%"))
+
+	// nothing left to process
+	count, err = mgr.RunPolicy(context.Background(), p)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, 5, countRows(t, client, "retention_delete_test", "recent%"))
+}
+
+func TestRunPolicyArchive(t *testing.T) {
+	client := pgsqltest.Client(t)
+	defer client.Disconnect()
+	createLogTable(t, client, "retention_archive_test", 25, 5)
+	defer client.Db().Exec("DROP TABLE IF EXISTS retention_archive_test")
+
+	mgr, err := NewManager(client)
+	assert.Nil(t, err)
+	archiver := &batchArchiver{}
+	p := NewPolicy("retention_archive_test", "id_log", "created_at", 30)
+	p.BatchSize = 10
+	p.Action = ActionArchive
+	p.Archiver = archiver
+
+	count, err := mgr.RunPolicy(context.Background(), p)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(25), count)
+
+	// batches are limited to BatchSize, and processed oldest first
+	assert.Len(t, archiver.batches, 3)
+	assert.Len(t, archiver.batches[0], 10)
+	assert.Len(t, archiver.batches[1], 10)
+	assert.Len(t, archiver.batches[2], 5)
+	assert.Equal(t, "expired 1", archiver.batches[0][0]["message"])
+	assert.Contains(t, archiver.batches[0][0], "created_at")
+	for _, batch := range archiver.batches {
+		for _, row := range batch {
+			assert.Contains(t, row["message"], "expired")
+		}
+	}
+	assert.Equal(t, 0, countRows(t, client, "retention_archive_test", "expired%"))
+	assert.Equal(t, 5, countRows(t, client, "retention_archive_test", "recent%"))
+}
+
+func TestRunPolicyArchiveFailure(t *testing.T) {
+	client := pgsqltest.Client(t)
+	defer client.Disconnect()
+	createLogTable(t, client, "retention_failure_test", 5, 0)
+	defer client.Db().Exec("DROP TABLE IF EXISTS retention_failure_test")
+
+	mgr, err := NewManager(client)
+	assert.Nil(t, err)
+	p := NewPolicy("retention_failure_test", "id_log", "created_at", 30)
+	p.Action = ActionArchive
+	p.Archiver = &batchArchiver{err: errArchive}
+
+	// rows are not deleted if archiving fails
+	count, err := mgr.RunPolicy(context.Background(), p)
+	assert.ErrorIs(t, err, errArchive)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, 5, countRows(t, client, "retention_failure_test", "expired%"))
+
+	assert.Nil(t, mgr.Add(p))
+	assert.ErrorIs(t, mgr.Run(context.Background()), errArchive)
+}
+
+func TestRepositoryArchiver(t *testing.T) {
+	client := pgsqltest.Client(t)
+	defer client.Disconnect()
+	createLogTable(t, client, "retention_source_test", 3, 2)
+	defer client.Db().Exec("DROP TABLE IF EXISTS retention_source_test")
+	createLogTable(t, client, "retention_destination_test", 0, 0)
+	defer client.Db().Exec("DROP TABLE IF EXISTS retention_destination_test")
+
+	mgr, err := NewManager(client)
+	assert.Nil(t, err)
+	p := NewPolicy("retention_source_test", "id_log", "created_at", 30)
+	p.Action = ActionArchive
+	p.Archiver = NewRepositoryArchiver(db.NewRepository(context.Background(), client, "retention_destination_test"))
+
+	count, err := mgr.RunPolicy(context.Background(), p)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+	assert.Equal(t, 0, countRows(t, client, "retention_source_test", "expired%"))
+	assert.Equal(t, 3, countRows(t, client, "retention_destination_test", "expired%"))
+	assert.Equal(t, 0, countRows(t, client, "retention_destination_test", "recent%"))
+}
+
+// refreshArchiver updates the time field of the first archived row, as a concurrent write would
+type refreshArchiver struct {
+	client *db.SqlClient
+}
+
+func (a *refreshArchiver) Archive(ctx context.Context, table string, rows []map[string]any) error {
+	_, err := a.client.Db().ExecContext(ctx, fmt.Sprintf("UPDATE %s SET created_at = now() WHERE id_log = $1", table), rows[0]["id_log"])
+	return err
+}
+
+func TestRunPolicyRefreshedRows(t *testing.T) {
+	client := pgsqltest.Client(t)
+	defer client.Disconnect()
+	createLogTable(t, client, "retention_refresh_test", 5, 0)
+	defer client.Db().Exec("DROP TABLE IF EXISTS retention_refresh_test")
+
+	mgr, err := NewManager(client)
+	assert.Nil(t, err)
+	p := NewPolicy("retention_refresh_test", "id_log", "created_at", 30)
+	p.Action = ActionArchive
+	p.Archiver = &refreshArchiver{client: client}
+
+	// rows no longer expired when deleted are kept
+	count, err := mgr.RunPolicy(context.Background(), p)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), count)
+	assert.Equal(t, 1, countRows(t, client, "retention_refresh_test", "expired 1"))
+}
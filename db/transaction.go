@@ -0,0 +1,191 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/utils"
+	"regexp"
+	"time"
+)
+
+const (
+	DefaultTxRetries = 3                     // DefaultTxRetries maximum retries of WithTransaction on serialization failures
+	DefaultTxBackoff = 50 * time.Millisecond // DefaultTxBackoff base delay between WithTransaction retries
+
+	ErrInvalidSavepoint = utils.Error("invalid savepoint name")
+	ErrNilTxFunc        = utils.Error("transaction function is nil")
+)
+
+var savepointName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+// TxRetryFn returns true if err is a transient transaction error, such as a serialization failure or deadlock,
+// and the transaction can be safely retried
+type TxRetryFn func(err error) bool
+
+// Tx is a database transaction not bound to a specific table; Repository() returns table-scoped
+// Transaction instances sharing the same underlying transaction
+type Tx struct {
+	conn    *sqlx.Tx
+	ctx     context.Context
+	dialect goqu.DialectWrapper
+}
+
+// Begin starts a new transaction
+//
+// Example usage:
+//
+//	tx, err := client.Begin(ctx, nil)
+//	if err != nil {
+//	  return err
+//	}
+//	defer tx.Rollback()
+//	if err = tx.Repository("orders").Insert(order); err != nil {
+//	  return err
+//	}
+//	if err = tx.Repository("order_items").Insert(items...); err != nil {
+//	  return err
+//	}
+//	return tx.Commit()
+func (c *SqlClient) Begin(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	t, err := c.Db().BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{
+		conn:    t,
+		ctx:     ctx,
+		dialect: goqu.Dialect(c.DriverName),
+	}, nil
+}
+
+// Repository returns a Transaction for the given table, within the current transaction
+func (t *Tx) Repository(tableName string) Transaction {
+	return &tx{
+		conn:      t.conn,
+		ctx:       t.ctx,
+		tableName: tableName,
+		dialect:   t.dialect,
+	}
+}
+
+func (t *Tx) Db() *sqlx.Tx {
+	return t.conn
+}
+
+func (t *Tx) Commit() error {
+	return t.conn.Commit()
+}
+
+// Rollback aborts the transaction; calling Rollback after Commit is a no-op that returns sql.ErrTxDone
+func (t *Tx) Rollback() error {
+	return t.conn.Rollback()
+}
+
+// Savepoint creates a savepoint with the given name
+func (t *Tx) Savepoint(name string) error {
+	return Savepoint(t.ctx, t.conn, name)
+}
+
+// RollbackTo rolls back to a previously created savepoint
+func (t *Tx) RollbackTo(name string) error {
+	return RollbackTo(t.ctx, t.conn, name)
+}
+
+// ReleaseSavepoint releases a previously created savepoint
+func (t *Tx) ReleaseSavepoint(name string) error {
+	return ReleaseSavepoint(t.ctx, t.conn, name)
+}
+
+// Savepoint executes SAVEPOINT name
+func Savepoint(ctx context.Context, conn sqlx.ExecerContext, name string) error {
+	return savepointExec(ctx, conn, "SAVEPOINT ", name)
+}
+
+// RollbackTo executes ROLLBACK TO SAVEPOINT name
+func RollbackTo(ctx context.Context, conn sqlx.ExecerContext, name string) error {
+	return savepointExec(ctx, conn, "ROLLBACK TO SAVEPOINT ", name)
+}
+
+// ReleaseSavepoint executes RELEASE SAVEPOINT name
+func ReleaseSavepoint(ctx context.Context, conn sqlx.ExecerContext, name string) error {
+	return savepointExec(ctx, conn, "RELEASE SAVEPOINT ", name)
+}
+
+func savepointExec(ctx context.Context, conn sqlx.ExecerContext, stmt string, name string) error {
+	if !savepointName.MatchString(name) {
+		return ErrInvalidSavepoint
+	}
+	return RawExec(ctx, conn, stmt+name)
+}
+
+// IsSerializationFailure returns true if err is a PostgreSQL serialization failure or deadlock,
+// and the transaction can be safely retried; it is used by WithTransaction for clients without a TxRetryFn
+func IsSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// serialization_failure, deadlock_detected
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	return false
+}
+
+// WithTransaction executes fn within a transaction; the transaction is committed if fn returns nil, and rolled back
+// otherwise. If fn or the commit fail with a retryable error, as detected by the client TxRetryFn (see
+// SqlClient.SetTxRetryFn()), the whole transaction is retried up to DefaultTxRetries times; fn must be safe to
+// execute multiple times
+//
+// Example usage:
+//
+//	err := db.WithTransaction(ctx, client, &sql.TxOptions{Isolation: sql.LevelSerializable}, func(tx *db.Tx) error {
+//	  accounts := tx.Repository("accounts")
+//	  if err := accounts.UpdateRecord(db.FV{"balance": goqu.L("balance - ?", amount)}, db.FV{"id_account": from}); err != nil {
+//	    return err
+//	  }
+//	  return accounts.UpdateRecord(db.FV{"balance": goqu.L("balance + ?", amount)}, db.FV{"id_account": to})
+//	})
+func WithTransaction(ctx context.Context, client *SqlClient, opts *sql.TxOptions, fn func(tx *Tx) error) error {
+	if fn == nil {
+		return ErrNilTxFunc
+	}
+	retry := client.txRetry
+	if retry == nil {
+		retry = IsSerializationFailure
+	}
+	var err error
+	for attempt := 0; attempt <= DefaultTxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * DefaultTxBackoff):
+			}
+		}
+		if err = runTransaction(ctx, client, opts, fn); err == nil || !retry(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// runTransaction executes a single transaction attempt
+func runTransaction(ctx context.Context, client *SqlClient, opts *sql.TxOptions, fn func(tx *Tx) error) error {
+	t, err := client.Begin(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = t.Rollback()
+			panic(p)
+		}
+	}()
+	if err = fn(t); err != nil {
+		_ = t.Rollback()
+		return err
+	}
+	return t.Commit()
+}
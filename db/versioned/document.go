@@ -0,0 +1,233 @@
+package versioned
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/oddbit-project/blueprint/utils"
+	"sync"
+)
+
+const (
+	// VersionField document field holding the schema version; documents without it are version 1
+	VersionField = "_version"
+
+	ErrMissingName      = utils.Error("missing document name")
+	ErrInvalidVersion   = utils.Error("invalid document version")
+	ErrDuplicateUpgrade = utils.Error("upgrade already registered")
+	ErrMissingUpgrade   = utils.Error("missing document upgrade")
+	ErrNewerVersion     = utils.Error("document version is newer than the current version")
+	ErrNotObject        = utils.Error("document is not a JSON object")
+)
+
+// UpgradeFunc upgrades a document to the next version, in place; the version field is managed by Document
+type UpgradeFunc func(doc map[string]any) error
+
+// Document versioned schema of a JSON document type, such as a jsonb column or a stored payload; documents are
+// stored with the schema version in VersionField, and are upgraded to the current version when read
+type Document struct {
+	name     string
+	version  int
+	upgrades map[int]UpgradeFunc
+	mx       sync.RWMutex
+}
+
+// NewDocument creates a new Document with the current schema version (>= 1)
+//
+// Example usage:
+//
+//	// v1: {"name": "John Doe"}
+//	// v2: {"firstName": "John", "lastName": "Doe"}
+//	profile, err := versioned.NewDocument("profile", 2)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	err = profile.AddUpgrade(1, func(doc map[string]any) error {
+//	  name, _ := doc["name"].(string)
+//	  doc["firstName"], doc["lastName"], _ = strings.Cut(name, " ")
+//	  delete(doc, "name")
+//	  return nil
+//	})
+//
+//	data, err := profile.Marshal(&Profile{FirstName: "John", LastName: "Doe"})
+//	...
+//	p := &Profile{}
+//	upgraded, err := profile.Unmarshal(data, p)
+func NewDocument(name string, version int) (*Document, error) {
+	if len(name) == 0 {
+		return nil, ErrMissingName
+	}
+	if version < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidVersion, version)
+	}
+	return &Document{
+		name:     name,
+		version:  version,
+		upgrades: make(map[int]UpgradeFunc),
+	}, nil
+}
+
+// Name returns the document name
+func (d *Document) Name() string {
+	return d.name
+}
+
+// Version returns the current schema version
+func (d *Document) Version() int {
+	return d.version
+}
+
+// AddUpgrade registers the upgrade from version from to version from+1
+func (d *Document) AddUpgrade(from int, fn UpgradeFunc) error {
+	if from < 1 || from >= d.version {
+		return fmt.Errorf("%w: %s upgrade from %d", ErrInvalidVersion, d.name, from)
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if _, ok := d.upgrades[from]; ok {
+		return fmt.Errorf("%w: %s upgrade from %d", ErrDuplicateUpgrade, d.name, from)
+	}
+	d.upgrades[from] = fn
+	return nil
+}
+
+// Validate checks that upgrades are registered for all previous versions; should be called at startup, after
+// registering upgrades
+func (d *Document) Validate() error {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	for v := 1; v < d.version; v++ {
+		if _, ok := d.upgrades[v]; !ok {
+			return fmt.Errorf("%w: %s upgrade from %d", ErrMissingUpgrade, d.name, v)
+		}
+	}
+	return nil
+}
+
+// Marshal encodes v with the current schema version; v must encode to a JSON object
+func (d *Document) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	doc[VersionField] = d.version
+	return json.Marshal(doc)
+}
+
+// Unmarshal decodes a stored document into dest, upgrading it to the current version first; upgraded is true if
+// the document was stored with a previous version, and should be written back
+func (d *Document) Unmarshal(data []byte, dest any) (upgraded bool, err error) {
+	doc, upgraded, err := d.upgrade(data)
+	if err != nil {
+		return false, err
+	}
+	delete(doc, VersionField)
+	if data, err = json.Marshal(doc); err != nil {
+		return false, err
+	}
+	return upgraded, json.Unmarshal(data, dest)
+}
+
+// Migrate upgrades a stored document to the current version; upgraded is false if the document was already current,
+// and data is returned unchanged
+func (d *Document) Migrate(data []byte) (result []byte, upgraded bool, err error) {
+	doc, upgraded, err := d.upgrade(data)
+	if err != nil || !upgraded {
+		return data, false, err
+	}
+	doc[VersionField] = d.version
+	if result, err = json.Marshal(doc); err != nil {
+		return data, false, err
+	}
+	return result, true, nil
+}
+
+// DocumentVersion returns the schema version of a stored document
+func (d *Document) DocumentVersion(data []byte) (int, error) {
+	doc, err := decode(data)
+	if err != nil {
+		return 0, err
+	}
+	return docVersion(doc)
+}
+
+// PgsqlOutdated returns a PostgreSQL condition matching rows where the json/jsonb column holds a document with a
+// previous version, to find rows to migrate
+//
+// Example usage:
+//
+//	rows := make([]*User, 0)
+//	qry := repo.SqlSelect().Where(profile.PgsqlOutdated("profile")).Limit(100)
+//	err := repo.Fetch(qry, &rows)
+func (d *Document) PgsqlOutdated(column string) exp.Expression {
+	return goqu.L("COALESCE((?->>?)::int, 1) < ?", goqu.I(column), VersionField, d.version)
+}
+
+// upgrade decodes data and applies the upgrades up to the current version
+func (d *Document) upgrade(data []byte) (map[string]any, bool, error) {
+	doc, err := decode(data)
+	if err != nil {
+		return nil, false, err
+	}
+	version, err := docVersion(doc)
+	if err != nil {
+		return nil, false, err
+	}
+	if version > d.version {
+		return nil, false, fmt.Errorf("%w: %s version %d, current is %d", ErrNewerVersion, d.name, version, d.version)
+	}
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	for v := version; v < d.version; v++ {
+		fn, ok := d.upgrades[v]
+		if !ok {
+			return nil, false, fmt.Errorf("%w: %s upgrade from %d", ErrMissingUpgrade, d.name, v)
+		}
+		if err = fn(doc); err != nil {
+			return nil, false, fmt.Errorf("%s upgrade from %d: %w", d.name, v, err)
+		}
+	}
+	return doc, version < d.version, nil
+}
+
+// decode decodes a JSON object, keeping numbers as json.Number
+func decode(data []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, ErrNotObject
+		}
+		return nil, err
+	}
+	if doc == nil {
+		return nil, ErrNotObject
+	}
+	return doc, nil
+}
+
+// docVersion returns the version of a decoded document
+func docVersion(doc map[string]any) (int, error) {
+	value, ok := doc[VersionField]
+	if !ok {
+		return 1, nil
+	}
+	n, ok := value.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidVersion, value)
+	}
+	v, err := n.Int64()
+	if err != nil || v < 1 {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidVersion, value)
+	}
+	return int(v), nil
+}
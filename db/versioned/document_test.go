@@ -0,0 +1,113 @@
+package versioned
+
+import (
+	"errors"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type profile struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Age       int64  `json:"age"`
+}
+
+func newProfile(t *testing.T) *Document {
+	doc, err := NewDocument("profile", 3)
+	assert.Nil(t, err)
+	// v1 -> v2: split name
+	assert.Nil(t, doc.AddUpgrade(1, func(d map[string]any) error {
+		name, ok := d["name"].(string)
+		if !ok {
+			return errors.New("missing name")
+		}
+		d["firstName"], d["lastName"], _ = strings.Cut(name, " ")
+		delete(d, "name")
+		return nil
+	}))
+	// v2 -> v3: rename years to age
+	assert.Nil(t, doc.AddUpgrade(2, func(d map[string]any) error {
+		d["age"] = d["years"]
+		delete(d, "years")
+		return nil
+	}))
+	return doc
+}
+
+func TestNewDocument(t *testing.T) {
+	_, err := NewDocument("", 1)
+	assert.ErrorIs(t, err, ErrMissingName)
+	_, err = NewDocument("profile", 0)
+	assert.ErrorIs(t, err, ErrInvalidVersion)
+
+	doc, err := NewDocument("profile", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "profile", doc.Name())
+	assert.Equal(t, 3, doc.Version())
+	noop := func(map[string]any) error { return nil }
+	assert.ErrorIs(t, doc.AddUpgrade(3, noop), ErrInvalidVersion)
+	assert.ErrorIs(t, doc.AddUpgrade(0, noop), ErrInvalidVersion)
+	assert.Nil(t, doc.AddUpgrade(1, noop))
+	assert.ErrorIs(t, doc.AddUpgrade(1, noop), ErrDuplicateUpgrade)
+	assert.ErrorIs(t, doc.Validate(), ErrMissingUpgrade)
+	assert.Nil(t, doc.AddUpgrade(2, noop))
+	assert.Nil(t, doc.Validate())
+}
+
+func TestDocument(t *testing.T) {
+	doc := newProfile(t)
+
+	data, err := doc.Marshal(&profile{FirstName: "John", LastName: "Doe", Age: 42})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"firstName":"John","lastName":"Doe","age":42,"_version":3}`, string(data))
+	v, err := doc.DocumentVersion(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, v)
+
+	p := &profile{}
+	upgraded, err := doc.Unmarshal(data, p)
+	assert.Nil(t, err)
+	assert.False(t, upgraded)
+	assert.Equal(t, "Doe", p.LastName)
+
+	// legacy document without version
+	legacy := []byte(`{"name":"Jane Roe","years":9007199254740993}`)
+	v, err = doc.DocumentVersion(legacy)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v)
+	p = &profile{}
+	upgraded, err = doc.Unmarshal(legacy, p)
+	assert.Nil(t, err)
+	assert.True(t, upgraded)
+	assert.Equal(t, profile{FirstName: "Jane", LastName: "Roe", Age: 9007199254740993}, *p)
+
+	migrated, upgraded, err := doc.Migrate([]byte(`{"firstName":"Jane","lastName":"Roe","years":30,"_version":2}`))
+	assert.Nil(t, err)
+	assert.True(t, upgraded)
+	assert.JSONEq(t, `{"firstName":"Jane","lastName":"Roe","age":30,"_version":3}`, string(migrated))
+	same, upgraded, err := doc.Migrate(migrated)
+	assert.Nil(t, err)
+	assert.False(t, upgraded)
+	assert.Equal(t, migrated, same)
+
+	_, err = doc.Unmarshal([]byte(`{"_version":4}`), p)
+	assert.ErrorIs(t, err, ErrNewerVersion)
+	_, err = doc.Unmarshal([]byte(`{"_version":"2"}`), p)
+	assert.ErrorIs(t, err, ErrInvalidVersion)
+	_, err = doc.Unmarshal([]byte(`[1, 2]`), p)
+	assert.ErrorIs(t, err, ErrNotObject)
+	_, err = doc.Marshal("text")
+	assert.ErrorIs(t, err, ErrNotObject)
+	_, err = doc.Unmarshal([]byte(`{"_version":1}`), p)
+	assert.ErrorContains(t, err, "profile upgrade from 1: missing name")
+}
+
+func TestPgsqlOutdated(t *testing.T) {
+	doc := newProfile(t)
+	sql, args, err := goqu.From("users").Where(doc.PgsqlOutdated("profile")).Prepared(true).ToSQL()
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM "users" WHERE COALESCE(("profile"->>?)::int, 1) < ?`, sql)
+	assert.Equal(t, []any{VersionField, int64(3)}, args)
+}
@@ -0,0 +1,74 @@
+package degradation
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/provider/httpserver"
+	"net/http"
+)
+
+// overrideRequest admin request body; a null Enabled removes the manual override
+type overrideRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// Require returns a middleware that serves fallback when the capability is disabled
+// if fallback is nil, a 503 response is generated
+//
+// Example usage:
+//
+//	router.GET("/search", degradation.Require(mgr, "search", searchFallback), searchHandler)
+func Require(m *Manager, name string, fallback gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if m.Enabled(name) {
+			ctx.Next()
+			return
+		}
+		if fallback != nil {
+			fallback(ctx)
+			ctx.Abort()
+			return
+		}
+		if httpserver.IsJSONRequest(ctx) {
+			httpserver.JSONError(ctx, http.StatusServiceUnavailable, httpserver.JSONErrorDetail{Message: http.StatusText(http.StatusServiceUnavailable)})
+			return
+		}
+		ctx.AbortWithStatus(http.StatusServiceUnavailable)
+	}
+}
+
+// RegisterAdmin registers the capability admin endpoints on router:
+//
+//	GET /          list capability state
+//	PUT /:name     set a manual override, with body {"enabled": true|false|null}
+//
+// the endpoints should be protected by an authentication middleware
+//
+// Example usage:
+//
+//	admin := router.Group("/admin/capabilities", authMiddleware)
+//	degradation.RegisterAdmin(admin, mgr)
+func RegisterAdmin(router gin.IRouter, m *Manager) {
+	router.GET("", func(ctx *gin.Context) {
+		httpserver.JSONSuccess(ctx, http.StatusOK, m.Status())
+	})
+	router.PUT("/:name", func(ctx *gin.Context) {
+		req := &overrideRequest{}
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			httpserver.JSONError(ctx, http.StatusBadRequest, httpserver.JSONErrorDetail{Message: err.Error()})
+			return
+		}
+		var err error
+		name := ctx.Param("name")
+		if req.Enabled == nil {
+			err = m.Reset(name)
+		} else {
+			err = m.Set(name, *req.Enabled)
+		}
+		if errors.Is(err, ErrUnknownCapability) {
+			httpserver.JSONError(ctx, http.StatusNotFound, httpserver.JSONErrorDetail{Message: err.Error()})
+			return
+		}
+		httpserver.JSONSuccess(ctx, http.StatusOK, m.Status())
+	})
+}
@@ -0,0 +1,225 @@
+package degradation
+
+import (
+	"context"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/rs/zerolog/log"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	ErrMissingName         = utils.Error("missing capability name")
+	ErrDuplicateCapability = utils.Error("capability already registered")
+	ErrUnknownCapability   = utils.Error("unknown capability")
+	ErrInvalidInterval     = utils.Error("interval must be greater than zero")
+	ErrNilHandler          = utils.Error("change handler is nil")
+)
+
+// Probe reports if the backing provider of a capability is available, e.g. if its circuit breaker is closed
+type Probe func(ctx context.Context) bool
+
+// ChangeHandler is called when the effective state of a capability changes
+type ChangeHandler func(name string, enabled bool)
+
+// Status is the current state of a capability
+type Status struct {
+	Name      string    `json:"name"`
+	Enabled   bool      `json:"enabled"`   // Enabled effective state
+	Available bool      `json:"available"` // Available result of the last probe
+	Override  *bool     `json:"override"`  // Override manual state, if set
+	Changed   time.Time `json:"changed"`
+}
+
+type capability struct {
+	probe     Probe
+	available bool
+	override  *bool
+	changed   time.Time
+}
+
+// Manager tracks named capabilities that can be disabled automatically, when their probe fails, or manually
+type Manager struct {
+	capabilities map[string]*capability
+	handlers     []ChangeHandler
+	mx           sync.RWMutex
+}
+
+func (c *capability) enabled() bool {
+	if c.override != nil {
+		return *c.override
+	}
+	return c.available
+}
+
+// NewManager creates a new degradation Manager
+//
+// Example usage:
+//
+//	mgr := degradation.NewManager()
+//	mgr.Register("search", func(ctx context.Context) bool {
+//	  return searchClient.Ping(ctx) == nil
+//	})
+//	mgr.Start(ctx, 10*time.Second)
+//
+//	// in a handler
+//	if !mgr.Enabled("search") {
+//	  // serve fallback
+//	}
+func NewManager() *Manager {
+	return &Manager{
+		capabilities: make(map[string]*capability),
+		handlers:     make([]ChangeHandler, 0),
+	}
+}
+
+// Register adds a capability; probe is optional, and capabilities without probe can only be disabled manually
+// capabilities are initially enabled
+func (m *Manager) Register(name string, probe Probe) error {
+	if len(name) == 0 {
+		return ErrMissingName
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if _, ok := m.capabilities[name]; ok {
+		return ErrDuplicateCapability
+	}
+	m.capabilities[name] = &capability{
+		probe:     probe,
+		available: true,
+		changed:   time.Now(),
+	}
+	return nil
+}
+
+// OnChange registers a handler called when a capability is enabled or disabled
+func (m *Manager) OnChange(fn ChangeHandler) error {
+	if fn == nil {
+		return ErrNilHandler
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.handlers = append(m.handlers, fn)
+	return nil
+}
+
+// Enabled returns true if the capability is enabled; unknown capabilities are reported as enabled
+func (m *Manager) Enabled(name string) bool {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	if c, ok := m.capabilities[name]; ok {
+		return c.enabled()
+	}
+	return true
+}
+
+// Set manually enables or disables a capability, overriding its probe
+func (m *Manager) Set(name string, enabled bool) error {
+	return m.setOverride(name, &enabled)
+}
+
+// Reset removes the manual override of a capability, restoring the probe state
+func (m *Manager) Reset(name string) error {
+	return m.setOverride(name, nil)
+}
+
+// Status returns the state of all capabilities, sorted by name
+func (m *Manager) Status() []Status {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	result := make([]Status, 0, len(m.capabilities))
+	for name, c := range m.capabilities {
+		result = append(result, Status{
+			Name:      name,
+			Enabled:   c.enabled(),
+			Available: c.available,
+			Override:  c.override,
+			Changed:   c.changed,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Check executes all probes and updates capability state
+func (m *Manager) Check(ctx context.Context) {
+	m.mx.RLock()
+	probes := make(map[string]Probe)
+	for name, c := range m.capabilities {
+		if c.probe != nil {
+			probes[name] = c.probe
+		}
+	}
+	m.mx.RUnlock()
+
+	for name, probe := range probes {
+		available := probe(ctx)
+		m.update(name, func(c *capability) {
+			c.available = available
+		})
+	}
+}
+
+// Start executes Check periodically in a separate goroutine, until ctx is cancelled
+func (m *Manager) Start(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return ErrInvalidInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.Check(ctx)
+			}
+		}
+	}()
+	return nil
+}
+
+func (m *Manager) setOverride(name string, enabled *bool) error {
+	if !m.update(name, func(c *capability) {
+		c.override = enabled
+	}) {
+		return ErrUnknownCapability
+	}
+	return nil
+}
+
+// update applies fn to a capability and notifies handlers if its effective state changed
+// returns false if the capability does not exist
+func (m *Manager) update(name string, fn func(c *capability)) bool {
+	m.mx.Lock()
+	c, ok := m.capabilities[name]
+	if !ok {
+		m.mx.Unlock()
+		return false
+	}
+	before := c.enabled()
+	fn(c)
+	after := c.enabled()
+	if before == after {
+		m.mx.Unlock()
+		return true
+	}
+	c.changed = time.Now()
+	handlers := make([]ChangeHandler, len(m.handlers))
+	copy(handlers, m.handlers)
+	m.mx.Unlock()
+
+	if after {
+		log.Info().Str("capability", name).Msg("capability enabled")
+	} else {
+		log.Warn().Str("capability", name).Msg("capability disabled")
+	}
+	for _, h := range handlers {
+		h(name, after)
+	}
+	return true
+}
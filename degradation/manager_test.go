@@ -0,0 +1,84 @@
+package degradation
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestManager(t *testing.T) {
+	mgr := NewManager()
+	available := true
+	assert.ErrorIs(t, mgr.Register("", nil), ErrMissingName)
+	assert.Nil(t, mgr.Register("search", func(ctx context.Context) bool { return available }))
+	assert.ErrorIs(t, mgr.Register("search", nil), ErrDuplicateCapability)
+	assert.Nil(t, mgr.Register("recommendations", nil))
+
+	changes := make([]bool, 0)
+	assert.ErrorIs(t, mgr.OnChange(nil), ErrNilHandler)
+	assert.Nil(t, mgr.OnChange(func(name string, enabled bool) {
+		changes = append(changes, enabled)
+	}))
+
+	assert.True(t, mgr.Enabled("search"))
+	assert.True(t, mgr.Enabled("unknown"))
+
+	// probe failure disables
+	available = false
+	mgr.Check(context.Background())
+	assert.False(t, mgr.Enabled("search"))
+
+	// manual override
+	assert.Nil(t, mgr.Set("search", true))
+	assert.True(t, mgr.Enabled("search"))
+	assert.Nil(t, mgr.Reset("search"))
+	assert.False(t, mgr.Enabled("search"))
+	assert.ErrorIs(t, mgr.Set("unknown", true), ErrUnknownCapability)
+	assert.Equal(t, []bool{false, true, false}, changes)
+
+	status := mgr.Status()
+	assert.Len(t, status, 2)
+	assert.Equal(t, "recommendations", status[0].Name)
+	assert.Equal(t, "search", status[1].Name)
+	assert.False(t, status[1].Available)
+
+	assert.ErrorIs(t, mgr.Start(context.Background(), 0), ErrInvalidInterval)
+}
+
+func TestHttp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mgr := NewManager()
+	assert.Nil(t, mgr.Register("search", nil))
+
+	router := gin.New()
+	router.GET("/search", Require(mgr, "search", nil), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "results")
+	})
+	router.GET("/fallback", Require(mgr, "search", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "fallback")
+	}), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "results")
+	})
+	RegisterAdmin(router.Group("/admin/capabilities"), mgr)
+
+	request := func(method string, path string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, "results", request(http.MethodGet, "/search", "").Body.String())
+	assert.Equal(t, http.StatusOK, request(http.MethodPut, "/admin/capabilities/search", `{"enabled":false}`).Code)
+	assert.Equal(t, http.StatusServiceUnavailable, request(http.MethodGet, "/search", "").Code)
+	assert.Equal(t, "fallback", request(http.MethodGet, "/fallback", "").Body.String())
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPut, "/admin/capabilities/unknown", `{"enabled":false}`).Code)
+	assert.Equal(t, http.StatusOK, request(http.MethodPut, "/admin/capabilities/search", `{"enabled":null}`).Code)
+	assert.True(t, mgr.Enabled("search"))
+	assert.Contains(t, request(http.MethodGet, "/admin/capabilities", "").Body.String(), `"name":"search"`)
+}
@@ -0,0 +1,302 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oddbit-project/blueprint/threadpool"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/rs/zerolog/log"
+	"reflect"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	DefaultWorkers   = 4
+	DefaultQueueSize = 1000
+
+	ErrNilConfig        = utils.Error("config is nil")
+	ErrNilBus           = utils.Error("bus is nil")
+	ErrNilHandler       = utils.Error("event handler is nil")
+	ErrMissingTopic     = utils.Error("missing topic name")
+	ErrInvalidWorkers   = utils.Error("workers must be >= 1")
+	ErrInvalidQueueSize = utils.Error("queueSize must be >= 1")
+	ErrTopicType        = utils.Error("topic already registered with a different event type")
+	ErrBusClosed        = utils.Error("bus is closed")
+	ErrHandlerPanic     = utils.Error("event handler panic")
+)
+
+// Config bus configuration
+type Config struct {
+	Workers   int `json:"workers"`   // Workers number of goroutines executing asynchronous handlers
+	QueueSize int `json:"queueSize"` // QueueSize max queued asynchronous handler executions
+}
+
+// Topic typed event topic; handlers and publishers of a topic share the event type T
+type Topic[T any] struct {
+	name string
+}
+
+// Handler handles the events of a topic
+type Handler[T any] func(ctx context.Context, event T) error
+
+// Bus in-process event bus; handlers of a topic are called synchronously by Publish(), or asynchronously by
+// PublishAsync(); handler errors and panics are isolated, and do not prevent other handlers from running
+type Bus struct {
+	pool     *threadpool.ThreadPool
+	topics   map[string]*topic
+	nextId   uint64
+	closed   atomic.Bool    // closed is only set with mx held, so inflight.Add() always happens before Drain() waits
+	inflight sync.WaitGroup // inflight PublishAsync() calls queueing handlers
+	mx       sync.RWMutex
+}
+
+type topic struct {
+	eventType reflect.Type
+	handlers  []*subscription
+}
+
+type subscription struct {
+	id      uint64
+	handler func(ctx context.Context, event any) error
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Workers:   DefaultWorkers,
+		QueueSize: DefaultQueueSize,
+	}
+}
+
+func (c *Config) Validate() error {
+	if c.Workers < 1 {
+		return ErrInvalidWorkers
+	}
+	if c.QueueSize < 1 {
+		return ErrInvalidQueueSize
+	}
+	return nil
+}
+
+// NewTopic creates a topic with the event type T
+func NewTopic[T any](name string) Topic[T] {
+	return Topic[T]{name: name}
+}
+
+// Name returns the topic name
+func (t Topic[T]) Name() string {
+	return t.name
+}
+
+// New creates a new Bus; asynchronous handlers are executed by a threadpool.ThreadPool
+//
+// Example usage:
+//
+//	var UserCreated = bus.NewTopic[*User]("user.created")
+//
+//	eventBus, err := bus.New(bus.NewConfig())
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	blueprint.RegisterDrain(eventBus.Drain)
+//
+//	// mail module
+//	bus.Subscribe(eventBus, UserCreated, func(ctx context.Context, u *User) error {
+//	  return mailer.SendWelcome(ctx, u.Email)
+//	})
+//
+//	// users module
+//	err = bus.PublishAsync(ctx, eventBus, UserCreated, user)
+func New(cfg *Config) (*Bus, error) {
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	pool, err := threadpool.NewThreadPool(cfg.Workers, cfg.QueueSize)
+	if err != nil {
+		return nil, err
+	}
+	if err = pool.Start(context.Background()); err != nil {
+		return nil, err
+	}
+	return &Bus{
+		pool:   pool,
+		topics: make(map[string]*topic),
+	}, nil
+}
+
+// Subscribe registers a handler of topic t; returns a function that removes the handler
+func Subscribe[T any](b *Bus, t Topic[T], handler Handler[T]) (func(), error) {
+	if b == nil {
+		return nil, ErrNilBus
+	}
+	if handler == nil {
+		return nil, ErrNilHandler
+	}
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	tp, err := b.topic(t.name, reflect.TypeFor[T]())
+	if err != nil {
+		return nil, err
+	}
+	b.nextId++
+	id := b.nextId
+	tp.handlers = append(tp.handlers, &subscription{
+		id: id,
+		handler: func(ctx context.Context, event any) error {
+			return handler(ctx, event.(T))
+		},
+	})
+	return func() {
+		b.unsubscribe(t.name, id)
+	}, nil
+}
+
+// Publish calls the handlers of topic t in the current goroutine, in subscription order; all handlers are called,
+// and their errors are returned joined (see errors.Join()); handler panics are returned as ErrHandlerPanic errors
+func Publish[T any](ctx context.Context, b *Bus, t Topic[T], event T) error {
+	if b == nil {
+		return ErrNilBus
+	}
+	b.mx.RLock()
+	handlers, err := b.handlers(t.name, reflect.TypeFor[T]())
+	b.mx.RUnlock()
+	if err != nil {
+		return err
+	}
+	errs := make([]error, 0)
+	for _, h := range handlers {
+		if err := call(ctx, h, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PublishAsync queues the execution of each handler of topic t, and returns; handlers are called with a context
+// detached from ctx, and their errors are logged; ctx only limits the wait for queue space when the queue is full
+func PublishAsync[T any](ctx context.Context, b *Bus, t Topic[T], event T) error {
+	if b == nil {
+		return ErrNilBus
+	}
+	// the lock is released before queueing, as queueing blocks while the queue is full; handlers publishing
+	// events would otherwise deadlock with a pending Subscribe() or Drain()
+	b.mx.RLock()
+	handlers, err := b.handlers(t.name, reflect.TypeFor[T]())
+	if err == nil {
+		b.inflight.Add(1)
+	}
+	b.mx.RUnlock()
+	if err != nil {
+		return err
+	}
+	defer b.inflight.Done()
+	for _, h := range handlers {
+		h := h
+		err = b.pool.DispatchContext(ctx, threadpool.JobFunc(func(ctx context.Context) {
+			if err := call(ctx, h, event); err != nil {
+				log.Error().Err(err).Str("topic", t.name).Msg("event handler failed")
+			}
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats returns the counters of the asynchronous handler pool; implements metrics.ThreadPoolStatsProvider
+func (b *Bus) Stats() threadpool.Stats {
+	return b.pool.Stats()
+}
+
+// Drain closes the bus, and waits for queued asynchronous handlers to finish; if ctx expires first, the context of
+// running handlers is cancelled, and ctx.Err() is returned; Publish() and PublishAsync() fail with ErrBusClosed after
+// Drain() is called
+func (b *Bus) Drain(ctx context.Context) error {
+	b.mx.Lock()
+	if !b.closed.CompareAndSwap(false, true) {
+		b.mx.Unlock()
+		return nil
+	}
+	b.mx.Unlock()
+
+	// wait for PublishAsync() calls in progress to finish queueing
+	done := make(chan struct{})
+	go func() {
+		b.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	return b.pool.Drain(ctx)
+}
+
+// topic returns the topic with name, creating it if needed; must be called with the lock held
+func (b *Bus) topic(name string, eventType reflect.Type) (*topic, error) {
+	if len(name) == 0 {
+		return nil, ErrMissingTopic
+	}
+	tp, ok := b.topics[name]
+	if !ok {
+		tp = &topic{eventType: eventType}
+		b.topics[name] = tp
+	}
+	if tp.eventType != eventType {
+		return nil, fmt.Errorf("%w: %s is %s, not %s", ErrTopicType, name, tp.eventType, eventType)
+	}
+	return tp, nil
+}
+
+// handlers returns a copy of the handlers of a topic; must be called with the lock held
+func (b *Bus) handlers(name string, eventType reflect.Type) ([]*subscription, error) {
+	if b.closed.Load() {
+		return nil, ErrBusClosed
+	}
+	if len(name) == 0 {
+		return nil, ErrMissingTopic
+	}
+	tp, ok := b.topics[name]
+	if !ok {
+		return nil, nil
+	}
+	if tp.eventType != eventType {
+		return nil, fmt.Errorf("%w: %s is %s, not %s", ErrTopicType, name, tp.eventType, eventType)
+	}
+	result := make([]*subscription, len(tp.handlers))
+	copy(result, tp.handlers)
+	return result, nil
+}
+
+// unsubscribe removes a handler
+func (b *Bus) unsubscribe(name string, id uint64) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	tp, ok := b.topics[name]
+	if !ok {
+		return
+	}
+	for i, s := range tp.handlers {
+		if s.id == id {
+			tp.handlers = append(tp.handlers[:i:i], tp.handlers[i+1:]...)
+			return
+		}
+	}
+}
+
+// call calls a handler, converting panics into errors
+func call(ctx context.Context, s *subscription, event any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Str("stack", string(debug.Stack())).Msgf("event handler panic: %v", r)
+			err = fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+		}
+	}()
+	return s.handler(ctx, event)
+}
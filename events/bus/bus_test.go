@@ -0,0 +1,171 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type userCreated struct {
+	Id    int
+	Email string
+}
+
+var topicUserCreated = NewTopic[*userCreated]("user.created")
+
+func newBus(t *testing.T) *Bus {
+	b, err := New(NewConfig())
+	assert.Nil(t, err)
+	return b
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+	cfg := NewConfig()
+	cfg.Workers = 0
+	_, err = New(cfg)
+	assert.ErrorIs(t, err, ErrInvalidWorkers)
+	cfg = NewConfig()
+	cfg.QueueSize = 0
+	_, err = New(cfg)
+	assert.ErrorIs(t, err, ErrInvalidQueueSize)
+
+	assert.Equal(t, "user.created", topicUserCreated.Name())
+	_, err = Subscribe(nil, topicUserCreated, func(ctx context.Context, u *userCreated) error { return nil })
+	assert.ErrorIs(t, err, ErrNilBus)
+	assert.ErrorIs(t, Publish(context.Background(), nil, topicUserCreated, &userCreated{}), ErrNilBus)
+}
+
+func TestPublish(t *testing.T) {
+	b := newBus(t)
+	defer b.Drain(context.Background())
+
+	_, err := Subscribe[*userCreated](b, topicUserCreated, nil)
+	assert.ErrorIs(t, err, ErrNilHandler)
+	_, err = Subscribe(b, NewTopic[int](""), func(ctx context.Context, v int) error { return nil })
+	assert.ErrorIs(t, err, ErrMissingTopic)
+
+	// no subscribers
+	assert.Nil(t, Publish(context.Background(), b, topicUserCreated, &userCreated{Id: 1}))
+
+	calls := make([]string, 0)
+	_, err = Subscribe(b, topicUserCreated, func(ctx context.Context, u *userCreated) error {
+		calls = append(calls, "mail:"+u.Email)
+		return nil
+	})
+	assert.Nil(t, err)
+	_, err = Subscribe(b, topicUserCreated, func(ctx context.Context, u *userCreated) error {
+		calls = append(calls, "failed")
+		return errors.New("cache unavailable")
+	})
+	assert.Nil(t, err)
+	_, err = Subscribe(b, topicUserCreated, func(ctx context.Context, u *userCreated) error {
+		panic("boom")
+	})
+	assert.Nil(t, err)
+	unsubscribe, err := Subscribe(b, topicUserCreated, func(ctx context.Context, u *userCreated) error {
+		calls = append(calls, "audit")
+		return nil
+	})
+	assert.Nil(t, err)
+
+	// same name, different type
+	_, err = Subscribe(b, NewTopic[userCreated]("user.created"), func(ctx context.Context, u userCreated) error { return nil })
+	assert.ErrorIs(t, err, ErrTopicType)
+	assert.ErrorIs(t, Publish(context.Background(), b, NewTopic[string]("user.created"), "x"), ErrTopicType)
+
+	// all handlers are called, and errors are joined
+	err = Publish(context.Background(), b, topicUserCreated, &userCreated{Id: 1, Email: "john@example.com"})
+	assert.ErrorContains(t, err, "cache unavailable")
+	assert.ErrorIs(t, err, ErrHandlerPanic)
+	assert.Equal(t, []string{"mail:john@example.com", "failed", "audit"}, calls)
+
+	unsubscribe()
+	calls = calls[:0]
+	_ = Publish(context.Background(), b, topicUserCreated, &userCreated{Id: 2, Email: "jane@example.com"})
+	assert.Equal(t, []string{"mail:jane@example.com", "failed"}, calls)
+}
+
+func TestPublishAsync(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Workers = 2
+	b, err := New(cfg)
+	assert.Nil(t, err)
+
+	var count atomic.Int32
+	topic := NewTopic[int]("counter")
+	_, err = Subscribe(b, topic, func(ctx context.Context, v int) error {
+		time.Sleep(5 * time.Millisecond)
+		count.Add(int32(v))
+		return nil
+	})
+	assert.Nil(t, err)
+	_, err = Subscribe(b, topic, func(ctx context.Context, v int) error {
+		panic("boom")
+	})
+	assert.Nil(t, err)
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, PublishAsync(context.Background(), b, topic, 1))
+	}
+	// drain waits for queued handlers
+	assert.Nil(t, b.Drain(context.Background()))
+	assert.Equal(t, int32(10), count.Load())
+	// handler panics are recovered by the bus
+	assert.Equal(t, uint64(20), b.Stats().DurationCount)
+	assert.Equal(t, uint64(0), b.Stats().Panics)
+
+	assert.ErrorIs(t, PublishAsync(context.Background(), b, topic, 1), ErrBusClosed)
+	assert.ErrorIs(t, Publish(context.Background(), b, topic, 1), ErrBusClosed)
+	assert.Nil(t, b.Drain(context.Background()))
+}
+
+func TestPublishAsyncFromHandler(t *testing.T) {
+	b := newBus(t)
+	ctx := context.Background()
+	nested := NewTopic[int]("nested")
+	var count atomic.Int64
+	_, err := Subscribe(b, nested, func(ctx context.Context, depth int) error {
+		count.Add(1)
+		if depth > 0 {
+			return PublishAsync(ctx, b, nested, depth-1)
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+
+	// subscribe and unsubscribe while handlers publish events
+	stop := make(chan struct{})
+	subscriber := make(chan struct{})
+	go func() {
+		defer close(subscriber)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			unsubscribe, err := Subscribe(b, NewTopic[int]("other"), func(ctx context.Context, event int) error { return nil })
+			assert.Nil(t, err)
+			unsubscribe()
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		assert.Nil(t, PublishAsync(ctx, b, nested, 5))
+	}
+	assert.Eventually(t, func() bool {
+		return count.Load() == 20*6
+	}, 5*time.Second, 10*time.Millisecond)
+	close(stop)
+	<-subscriber
+
+	drainCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	assert.Nil(t, b.Drain(drainCtx))
+	assert.ErrorIs(t, PublishAsync(ctx, b, nested, 1), ErrBusClosed)
+}
@@ -0,0 +1,111 @@
+package events
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type orderItem struct {
+	Sku      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+type orderCreated struct {
+	OrderId  string      `json:"orderId"`
+	Total    float64     `json:"total"`
+	Created  time.Time   `json:"created"`
+	Items    []orderItem `json:"items"`
+	Comments *string     `json:"comments,omitempty"`
+	internal string
+}
+
+type recorder struct {
+	data [][]byte
+}
+
+func (r *recorder) Write(value []byte, key ...[]byte) error {
+	r.data = append(r.data, value)
+	return nil
+}
+
+func newRegistry(t *testing.T) *Registry {
+	r := NewRegistry()
+	assert.ErrorIs(t, r.Register(Event{Topic: "orders", Version: "1.0", Schema: orderCreated{}}), ErrMissingName)
+	assert.ErrorIs(t, r.Register(Event{Name: "order.created", Version: "1.0", Schema: orderCreated{}}), ErrMissingTopic)
+	assert.ErrorIs(t, r.Register(Event{Name: "order.created", Topic: "orders", Version: "1.0"}), ErrMissingSchema)
+	assert.Nil(t, r.Register(Event{Name: "order.created", Topic: "orders", Version: "1.2.0", Schema: &orderCreated{}}))
+	assert.ErrorIs(t, r.Register(Event{Name: "order.created", Topic: "orders", Version: "1.0", Schema: orderCreated{}}), ErrDuplicateEvent)
+	return r
+}
+
+func TestSchema(t *testing.T) {
+	r := newRegistry(t)
+	schema, err := r.Schema("order.created")
+	assert.Nil(t, err)
+	assert.Equal(t, SchemaDraft, schema.Schema)
+	assert.Equal(t, "order.created", schema.Title)
+	assert.Equal(t, []string{"orderId", "total", "created", "items"}, schema.Required)
+	assert.Len(t, schema.Properties, 5)
+	assert.Equal(t, "date-time", schema.Properties["created"].Format)
+	assert.Equal(t, TypeList{TypeArray, TypeNull}, schema.Properties["items"].Type)
+	assert.Equal(t, TypeList{TypeInteger}, schema.Properties["items"].Items.Properties["quantity"].Type)
+
+	docs, err := r.Docs()
+	assert.Nil(t, err)
+	decoded := make(map[string]*Schema)
+	assert.Nil(t, json.Unmarshal(docs, &decoded))
+	assert.Equal(t, schema, decoded["order.created"])
+	assert.Contains(t, string(docs), `"type": "object"`)
+
+	_, err = NewSchema("string")
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+func TestPublish(t *testing.T) {
+	r := newRegistry(t)
+	p := &recorder{}
+
+	assert.Nil(t, r.Publish(p, "order.created", &orderCreated{OrderId: "abc", Total: 10}))
+	assert.Len(t, p.data, 1)
+	assert.ErrorIs(t, r.Publish(p, "order.deleted", &orderCreated{}), ErrUnknownEvent)
+	assert.ErrorIs(t, r.Publish(nil, "order.created", &orderCreated{}), ErrNilPublisher)
+
+	// generic payloads are validated against the schema
+	payload := map[string]any{
+		"orderId": "abc",
+		"total":   10,
+		"created": time.Now(),
+		"items":   []map[string]any{{"sku": "x", "quantity": 1}},
+	}
+	assert.Nil(t, r.Validate("order.created", payload))
+
+	delete(payload, "total")
+	assert.ErrorIs(t, r.Validate("order.created", payload), ErrInvalidPayload)
+
+	payload["total"] = "10"
+	assert.ErrorIs(t, r.Validate("order.created", payload), ErrInvalidPayload)
+
+	payload["total"] = 10.5
+	payload["items"] = []map[string]any{{"sku": "x", "quantity": 1.5}}
+	err := r.Publish(p, "order.created", payload)
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+	assert.Contains(t, err.Error(), "$.items[0].quantity")
+	assert.Len(t, p.data, 1)
+}
+
+func TestCheck(t *testing.T) {
+	r := newRegistry(t)
+	assert.ErrorIs(t, r.Check(), ErrMissingSubscriptions)
+	assert.Nil(t, r.Check(Subscription{Name: "order.created", Version: "1.0"}))
+	assert.Nil(t, r.Check(Subscription{Name: "order.created", Version: "1.2.5"}))
+	assert.ErrorIs(t, r.Check(Subscription{Name: "order.created", Version: "1.3"}), ErrIncompatibleVersion)
+	assert.ErrorIs(t, r.Check(Subscription{Name: "order.created", Version: "2.0"}), ErrIncompatibleVersion)
+
+	err := r.Check(
+		Subscription{Name: "order.created", Version: "1.1"},
+		Subscription{Name: "order.deleted", Version: "1.0"},
+	)
+	assert.ErrorIs(t, err, ErrUnknownEvent)
+}
@@ -0,0 +1,244 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/oddbit-project/blueprint/utils/version"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+const (
+	ErrMissingName          = utils.Error("missing event name")
+	ErrMissingTopic         = utils.Error("missing event topic")
+	ErrMissingSchema        = utils.Error("missing event schema")
+	ErrDuplicateEvent       = utils.Error("event already registered")
+	ErrUnknownEvent         = utils.Error("unknown event")
+	ErrIncompatibleVersion  = utils.Error("incompatible event version")
+	ErrInvalidPayload       = utils.Error("payload does not match event schema")
+	ErrNilPublisher         = utils.Error("publisher is nil")
+	ErrMissingSubscriptions = utils.Error("no subscriptions to check")
+)
+
+// Event describes a published event type
+type Event struct {
+	Name    string `json:"name"`    // Name unique event name, e.g. "order.created"
+	Topic   string `json:"topic"`   // Topic where the event is published
+	Version string `json:"version"` // Version semantic version of the event contract
+	Schema  any    `json:"-"`       // Schema struct (or struct pointer) describing the payload
+	schema  *Schema
+	version version.Version
+}
+
+// Subscription is an event version expected by a consumer
+type Subscription struct {
+	Name    string
+	Version string
+}
+
+// Publisher writes encoded events; e.g. *kafka.KafkaProducer
+type Publisher interface {
+	Write(value []byte, key ...[]byte) error
+}
+
+// Registry holds the event contracts of a service
+type Registry struct {
+	events map[string]*Event
+	mx     sync.RWMutex
+}
+
+// NewRegistry creates a new event registry
+//
+// Example usage:
+//
+//	type OrderCreated struct {
+//	  OrderId  string  `json:"orderId"`
+//	  Total    float64 `json:"total"`
+//	  Comments string  `json:"comments,omitempty"`
+//	}
+//
+//	registry := events.NewRegistry()
+//	registry.Register(events.Event{
+//	  Name:    "order.created",
+//	  Topic:   "orders",
+//	  Version: "1.2.0",
+//	  Schema:  OrderCreated{},
+//	})
+//
+//	// publisher
+//	err := registry.Publish(producer, "order.created", &OrderCreated{OrderId: "abc", Total: 10})
+//
+//	// consumer startup
+//	if err := registry.Check(events.Subscription{Name: "order.created", Version: "1.1"}); err != nil {
+//	  log.Fatal().Err(err).Msg("incompatible event contracts")
+//	}
+func NewRegistry() *Registry {
+	return &Registry{
+		events: make(map[string]*Event),
+	}
+}
+
+// Register adds an event contract to the registry
+func (r *Registry) Register(e Event) error {
+	if len(e.Name) == 0 {
+		return ErrMissingName
+	}
+	if len(e.Topic) == 0 {
+		return ErrMissingTopic
+	}
+	if e.Schema == nil {
+		return ErrMissingSchema
+	}
+	v, err := version.Parse(e.Version)
+	if err != nil {
+		return err
+	}
+	schema, err := NewSchema(e.Schema)
+	if err != nil {
+		return err
+	}
+	schema.Title = e.Name
+	schema.Version = e.Version
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if _, ok := r.events[e.Name]; ok {
+		return ErrDuplicateEvent
+	}
+	e.version = v
+	e.schema = schema
+	r.events[e.Name] = &e
+	return nil
+}
+
+// Get returns a registered event
+func (r *Registry) Get(name string) (Event, bool) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	if e, ok := r.events[name]; ok {
+		return *e, true
+	}
+	return Event{}, false
+}
+
+// Events returns all registered events, sorted by name
+func (r *Registry) Events() []Event {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	result := make([]Event, 0, len(r.events))
+	for _, e := range r.events {
+		result = append(result, *e)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Schema returns the JSON Schema of a registered event
+func (r *Registry) Schema(name string) (*Schema, error) {
+	e, err := r.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return e.schema, nil
+}
+
+// Docs returns the JSON Schema documents of all registered events, indexed by event name
+func (r *Registry) Docs() ([]byte, error) {
+	r.mx.RLock()
+	docs := make(map[string]*Schema, len(r.events))
+	for name, e := range r.events {
+		docs[name] = e.schema
+	}
+	r.mx.RUnlock()
+	return json.MarshalIndent(docs, "", "  ")
+}
+
+// Validate checks if payload conforms to the schema of the event
+// payload can be a value of the schema type, or any other value that encodes to a compatible JSON document
+func (r *Registry) Validate(name string, payload any) error {
+	_, err := r.Encode(name, payload)
+	return err
+}
+
+// Encode validates payload and returns its JSON encoding
+func (r *Registry) Encode(name string, payload any) ([]byte, error) {
+	e, err := r.get(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	// values of the schema type are valid by definition
+	if t := reflect.TypeOf(payload); t != nil && indirectType(t) == indirectType(reflect.TypeOf(e.Schema)) {
+		return data, nil
+	}
+	var doc any
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if err = e.schema.Validate(doc); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPayload, name, err)
+	}
+	return data, nil
+}
+
+// Publish validates and encodes payload, and writes it using p
+func (r *Registry) Publish(p Publisher, name string, payload any, key ...[]byte) error {
+	if p == nil {
+		return ErrNilPublisher
+	}
+	data, err := r.Encode(name, payload)
+	if err != nil {
+		return err
+	}
+	return p.Write(data, key...)
+}
+
+// Check verifies that subscribed event versions are compatible with the registered contracts
+// a subscription is compatible if the major versions match and the subscribed minor version is not newer than the
+// registered one; all incompatibilities are reported
+func (r *Registry) Check(subscriptions ...Subscription) error {
+	if len(subscriptions) == 0 {
+		return ErrMissingSubscriptions
+	}
+	errs := make([]error, 0)
+	for _, s := range subscriptions {
+		e, err := r.get(s.Name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s", err, s.Name))
+			continue
+		}
+		v, err := version.Parse(s.Version)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s", err, s.Name))
+			continue
+		}
+		if v.Major != e.version.Major || v.Minor > e.version.Minor {
+			errs = append(errs, fmt.Errorf("%w: %s subscribed %s, published %s", ErrIncompatibleVersion, s.Name, s.Version, e.Version))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Registry) get(name string) (*Event, error) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	if e, ok := r.events[name]; ok {
+		return e, nil
+	}
+	return nil, ErrUnknownEvent
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,257 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/oddbit-project/blueprint/utils"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeNull    = "null"
+
+	ErrUnsupportedType = utils.Error("unsupported schema type")
+)
+
+// TypeList is a list of JSON Schema types; it is encoded as a string if it has a single element
+type TypeList []string
+
+// Schema is a JSON Schema document generated from a Go type
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Version              string             `json:"version,omitempty"`
+	Type                 TypeList           `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+func (t TypeList) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *TypeList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = TypeList{s}
+		return nil
+	}
+	var l []string
+	if err := json.Unmarshal(data, &l); err != nil {
+		return err
+	}
+	*t = l
+	return nil
+}
+
+// NewSchema generates a JSON Schema from a struct value or pointer
+// struct fields follow encoding/json naming rules; fields without omitempty are required, and pointer fields are
+// nullable
+func NewSchema(v any) (*Schema, error) {
+	if v == nil {
+		return nil, ErrMissingSchema
+	}
+	t := indirectType(reflect.TypeOf(v))
+	if t.Kind() != reflect.Struct {
+		return nil, ErrUnsupportedType
+	}
+	schema, err := typeSchema(t)
+	if err != nil {
+		return nil, err
+	}
+	schema.Schema = SchemaDraft
+	return schema, nil
+}
+
+func typeSchema(t reflect.Type) (*Schema, error) {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+	schema := &Schema{}
+	switch {
+	case t == timeType:
+		schema.Type = TypeList{TypeString}
+		schema.Format = "date-time"
+	case t == rawMessageType || t.Kind() == reflect.Interface:
+		// any value
+		return schema, nil
+	case t.Kind() == reflect.Struct:
+		schema.Type = TypeList{TypeObject}
+		schema.Properties = make(map[string]*Schema)
+		schema.Required = make([]string, 0)
+		if err := structProperties(t, schema); err != nil {
+			return nil, err
+		}
+	case t.Kind() == reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+		}
+		items, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		schema.Type = TypeList{TypeObject}
+		schema.AdditionalProperties = items
+		nullable = true
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		schema.Type = TypeList{TypeString}
+		schema.Format = "byte"
+		nullable = true
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		items, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		schema.Type = TypeList{TypeArray}
+		schema.Items = items
+		nullable = nullable || t.Kind() == reflect.Slice
+	case t.Kind() == reflect.String:
+		schema.Type = TypeList{TypeString}
+	case t.Kind() == reflect.Bool:
+		schema.Type = TypeList{TypeBoolean}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		schema.Type = TypeList{TypeInteger}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		schema.Type = TypeList{TypeNumber}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+	}
+	if nullable {
+		schema.Type = append(schema.Type, TypeNull)
+	}
+	return schema, nil
+}
+
+func structProperties(t reflect.Type, schema *Schema) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		if tag[0] == "-" && len(tag) == 1 {
+			continue
+		}
+		// embedded structs without name are flattened
+		if f.Anonymous && len(tag[0]) == 0 && indirectType(f.Type).Kind() == reflect.Struct {
+			if err := structProperties(indirectType(f.Type), schema); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name := tag[0]
+		if len(name) == 0 {
+			name = f.Name
+		}
+		prop, err := typeSchema(f.Type)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		schema.Properties[name] = prop
+		if !slices.Contains(tag[1:], "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return nil
+}
+
+// Validate checks if a decoded JSON document (as returned by json.Unmarshal into an any) conforms to the schema
+func (s *Schema) Validate(doc any) error {
+	return s.validate("$", doc)
+}
+
+func (s *Schema) validate(path string, doc any) error {
+	if len(s.Type) == 0 {
+		return nil
+	}
+	docType := jsonType(doc)
+	if !s.accepts(docType) {
+		return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(s.Type, " or "), docType)
+	}
+	switch v := doc.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %s", path, name)
+			}
+		}
+		for name, value := range v {
+			prop, ok := s.Properties[name]
+			if !ok {
+				prop = s.AdditionalProperties
+			}
+			if prop == nil {
+				continue
+			}
+			if err := prop.validate(path+"."+name, value); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if s.Items == nil {
+			return nil
+		}
+		for i, value := range v {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) accepts(docType string) bool {
+	if slices.Contains(s.Type, docType) {
+		return true
+	}
+	// integers are also numbers
+	return docType == TypeInteger && slices.Contains(s.Type, TypeNumber)
+}
+
+func jsonType(doc any) string {
+	switch v := doc.(type) {
+	case nil:
+		return TypeNull
+	case map[string]any:
+		return TypeObject
+	case []any:
+		return TypeArray
+	case string:
+		return TypeString
+	case bool:
+		return TypeBoolean
+	case float64:
+		if v == float64(int64(v)) {
+			return TypeInteger
+		}
+		return TypeNumber
+	}
+	return fmt.Sprintf("%T", doc)
+}
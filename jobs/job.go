@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/oddbit-project/blueprint/utils"
+	"time"
+)
+
+const (
+	StatusPending = "pending" // StatusPending job is waiting to run, or waiting for a retry
+	StatusRunning = "running" // StatusRunning job was claimed by a worker
+	StatusDone    = "done"    // StatusDone job completed successfully
+	StatusDead    = "dead"    // StatusDead job failed after all attempts
+
+	DefaultQueue       = "default"
+	DefaultMaxAttempts = 5
+
+	ErrNilJob             = utils.Error("job is nil")
+	ErrMissingQueue       = utils.Error("missing job queue")
+	ErrMissingType        = utils.Error("missing job type")
+	ErrInvalidMaxAttempts = utils.Error("maxAttempts must be >= 1")
+	ErrUnknownJobType     = utils.Error("no handler registered for job type")
+	ErrJobPanic           = utils.Error("job handler panic")
+)
+
+// Job is a persistent unit of work
+type Job struct {
+	Id          int64           `db:"id"`
+	Queue       string          `db:"queue"`
+	Type        string          `db:"type"`         // Type selects the handler of the job
+	Payload     json.RawMessage `db:"payload"`      // Payload JSON encoded job arguments
+	UniqueKey   *string         `db:"unique_key"`   // UniqueKey optional; enqueuing a job with an existing key is a no-op
+	Status      string          `db:"status"`       // Status job status; set by the store
+	Attempts    int             `db:"attempts"`     // Attempts number of executions, including the current one
+	MaxAttempts int             `db:"max_attempts"` // MaxAttempts max executions before the job is marked as dead
+	RunAt       time.Time       `db:"run_at"`       // RunAt the job is not executed before RunAt
+	LastError   *string         `db:"last_error"`
+	CreatedAt   time.Time       `db:"created_at"`
+}
+
+// Handler executes a job; if an error is returned, the job is retried until MaxAttempts is reached
+type Handler func(ctx context.Context, job *Job) error
+
+// Middleware wraps a Handler
+type Middleware func(next Handler) Handler
+
+// NewJob creates a job of type jobType in the default queue, with the JSON encoding of payload as arguments
+//
+// Example usage:
+//
+//	job, err := jobs.NewJob("email.welcome", &WelcomeEmail{UserId: user.Id})
+//	if err != nil {
+//	  return err
+//	}
+//	job.RunAt = time.Now().Add(time.Hour)
+//	err = store.Enqueue(ctx, job)
+func NewJob(jobType string, payload any) (*Job, error) {
+	var data json.RawMessage
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+	return &Job{
+		Queue:       DefaultQueue,
+		Type:        jobType,
+		Payload:     data,
+		Status:      StatusPending,
+		MaxAttempts: DefaultMaxAttempts,
+		RunAt:       time.Now(),
+	}, nil
+}
+
+// Validate checks the job fields set by the caller
+func (j *Job) Validate() error {
+	if len(j.Queue) == 0 {
+		return ErrMissingQueue
+	}
+	if len(j.Type) == 0 {
+		return ErrMissingType
+	}
+	if j.MaxAttempts < 1 {
+		return ErrInvalidMaxAttempts
+	}
+	return nil
+}
+
+// Decode unmarshals the job payload into v
+func (j *Job) Decode(v any) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// WithUniqueKey sets the unique key of the job, and returns the job
+func (j *Job) WithUniqueKey(key string) *Job {
+	j.UniqueKey = &key
+	return j
+}
@@ -0,0 +1,276 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"github.com/oddbit-project/blueprint"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	base := time.Date(2024, 3, 15, 10, 7, 30, 0, time.UTC) // Friday
+
+	cases := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, 3, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 3, 15, 10, 15, 0, 0, time.UTC)},
+		{"0 6 * * *", time.Date(2024, 3, 16, 6, 0, 0, 0, time.UTC)},
+		{"30 8-18/2 * * mon-fri", time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * sat,sun", time.Date(2024, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * mon", time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 3, 15, 11, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		s, err := ParseSchedule(c.spec)
+		assert.Nil(t, err, c.spec)
+		assert.Equal(t, c.next, s.Next(base), c.spec)
+	}
+
+	s, err := ParseSchedule("0 0 30 2 *")
+	assert.Nil(t, err)
+	assert.True(t, s.Next(base).IsZero())
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		_, err = ParseSchedule(spec)
+		assert.ErrorIs(t, err, ErrInvalidSchedule, spec)
+	}
+}
+
+func TestJob(t *testing.T) {
+	job, err := NewJob("email", map[string]string{"to": "user@example.com"})
+	assert.Nil(t, err)
+	assert.Nil(t, job.Validate())
+	payload := make(map[string]string)
+	assert.Nil(t, job.Decode(&payload))
+	assert.Equal(t, "user@example.com", payload["to"])
+
+	job.MaxAttempts = 0
+	assert.ErrorIs(t, job.Validate(), ErrInvalidMaxAttempts)
+	job.Type = ""
+	assert.ErrorIs(t, job.Validate(), ErrMissingType)
+	job.Queue = ""
+	assert.ErrorIs(t, job.Validate(), ErrMissingQueue)
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	first, _ := NewJob("a", nil)
+	first.WithUniqueKey("key")
+	duplicate, _ := NewJob("a", nil)
+	duplicate.WithUniqueKey("key")
+	delayed, _ := NewJob("b", nil)
+	delayed.RunAt = time.Now().Add(time.Hour)
+	assert.Nil(t, store.Enqueue(ctx, first, duplicate, delayed))
+
+	claimed, err := store.Claim(ctx, DefaultQueue, 10, time.Minute)
+	assert.Nil(t, err)
+	assert.Len(t, claimed, 1)
+	assert.Equal(t, first.Id, claimed[0].Id)
+	assert.Equal(t, 1, claimed[0].Attempts)
+
+	// claimed jobs are not claimed again until the lease expires
+	claimed2, err := store.Claim(ctx, DefaultQueue, 10, time.Minute)
+	assert.Nil(t, err)
+	assert.Len(t, claimed2, 0)
+
+	assert.Nil(t, store.Retry(ctx, claimed[0], time.Now(), errors.New("failed")))
+	assert.ErrorIs(t, store.Complete(ctx, claimed[0]), ErrJobNotClaimed)
+
+	// expired lease
+	claimed, err = store.Claim(ctx, DefaultQueue, 10, 0)
+	assert.Nil(t, err)
+	assert.Len(t, claimed, 1)
+	reclaimed, err := store.Claim(ctx, DefaultQueue, 10, time.Minute)
+	assert.Nil(t, err)
+	assert.Len(t, reclaimed, 1)
+	assert.Equal(t, 3, reclaimed[0].Attempts)
+	assert.ErrorIs(t, store.Complete(ctx, claimed[0]), ErrJobNotClaimed)
+	job, ok := store.Get(first.Id)
+	assert.True(t, ok)
+	assert.Equal(t, StatusRunning, job.Status)
+	assert.Equal(t, "failed", *job.LastError)
+	assert.Nil(t, store.Complete(ctx, reclaimed[0]))
+
+	// finished jobs are removed, and their unique key can be enqueued again
+	_, ok = store.Get(first.Id)
+	assert.False(t, ok)
+	assert.Nil(t, store.Enqueue(ctx, duplicate))
+	claimed, err = store.Claim(ctx, DefaultQueue, 10, time.Minute)
+	assert.Nil(t, err)
+	assert.Len(t, claimed, 1)
+	assert.Equal(t, duplicate.Id, claimed[0].Id)
+	assert.Nil(t, store.Kill(ctx, claimed[0], errors.New("failed")))
+	_, ok = store.Get(duplicate.Id)
+	assert.False(t, ok)
+}
+
+func TestWorker(t *testing.T) {
+	store := NewMemoryStore()
+	cfg := NewWorkerConfig()
+	cfg.PollInterval = 5
+	cfg.RetryDelay = 0
+	cfg.MaxRetryDelay = 0
+
+	_, err := NewWorker(nil, cfg)
+	assert.ErrorIs(t, err, ErrNilStore)
+	_, err = NewWorker(store, nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+
+	worker, err := NewWorker(store, cfg)
+	assert.Nil(t, err)
+	assert.ErrorIs(t, worker.Handle("", func(ctx context.Context, job *Job) error { return nil }), ErrMissingType)
+	assert.ErrorIs(t, worker.Handle("ok", nil), ErrNilHandler)
+
+	// last execution result of each job
+	results := sync.Map{}
+	var executions atomic.Int32
+	worker.Use(func(next Handler) Handler {
+		return func(ctx context.Context, job *Job) error {
+			err := next(ctx, job)
+			results.Store(job.Id, err)
+			return err
+		}
+	}, Recovery(), func(next Handler) Handler {
+		return func(ctx context.Context, job *Job) error {
+			executions.Add(1)
+			return next(ctx, job)
+		}
+	})
+	assert.Nil(t, worker.Handle("ok", func(ctx context.Context, job *Job) error { return nil }))
+	assert.Nil(t, worker.Handle("flaky", func(ctx context.Context, job *Job) error {
+		if job.Attempts < 2 {
+			return errors.New("flaky")
+		}
+		return nil
+	}))
+	assert.Nil(t, worker.Handle("panic", func(ctx context.Context, job *Job) error { panic("boom") }))
+
+	ok, _ := NewJob("ok", nil)
+	flaky, _ := NewJob("flaky", nil)
+	panics, _ := NewJob("panic", nil)
+	panics.MaxAttempts = 2
+	unknown, _ := NewJob("unknown", nil)
+	assert.Nil(t, store.Enqueue(context.Background(), ok, flaky, panics, unknown))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- worker.Run(ctx)
+	}()
+
+	// finished jobs are removed from the store
+	finished := func(jobs ...*Job) bool {
+		for _, job := range jobs {
+			if _, exists := store.Get(job.Id); exists {
+				return false
+			}
+		}
+		return true
+	}
+	assert.Eventually(t, func() bool {
+		return finished(ok, flaky, panics, unknown)
+	}, 2*time.Second, 5*time.Millisecond)
+	result := func(id int64) error {
+		err, _ := results.Load(id)
+		if err == nil {
+			return nil
+		}
+		return err.(error)
+	}
+	assert.Nil(t, result(ok.Id))
+	assert.Nil(t, result(flaky.Id))
+	assert.ErrorIs(t, result(panics.Id), ErrJobPanic)
+	assert.ErrorIs(t, result(unknown.Id), ErrUnknownJobType)
+
+	// executions are tracked by the worker pool
+	assert.Eventually(t, func() bool {
+		return worker.Stats().DurationCount == 6
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, DefaultConcurrency, worker.Stats().Workers)
+
+	cancel()
+	assert.Nil(t, <-done)
+	assert.Nil(t, worker.Drain(context.Background()))
+	// ok once, flaky twice, panic twice, unknown once
+	assert.Equal(t, int32(6), executions.Load())
+}
+
+func TestWorkerBackoff(t *testing.T) {
+	cfg := NewWorkerConfig()
+	cfg.RetryDelay = 100
+	cfg.MaxRetryDelay = 1000
+	worker, err := NewWorker(NewMemoryStore(), cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, 100*time.Millisecond, worker.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, worker.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, worker.backoff(4))
+	assert.Equal(t, time.Second, worker.backoff(10))
+
+	cfg.MaxRetryDelay = 10
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidMaxRetryDelay)
+}
+
+func TestScheduler(t *testing.T) {
+	store := NewMemoryStore()
+	scheduler, err := NewScheduler(store)
+	assert.Nil(t, err)
+	assert.ErrorIs(t, scheduler.Run(context.Background()), ErrNoScheduledEntries)
+
+	job, _ := NewJob("report", nil)
+	assert.ErrorIs(t, scheduler.Add("", "@daily", job), ErrMissingEntryName)
+	assert.ErrorIs(t, scheduler.Add("report", "@daily", nil), ErrNilJob)
+	assert.ErrorIs(t, scheduler.Add("report", "daily", job), ErrInvalidSchedule)
+	assert.Nil(t, scheduler.Add("report", "@daily", job))
+	assert.ErrorIs(t, scheduler.Add("report", "@hourly", job), ErrDuplicateEntry)
+
+	// activations are enqueued once, even if enqueued by several schedulers
+	at := time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)
+	assert.Nil(t, scheduler.enqueue(context.Background(), scheduler.entries[0], at))
+	assert.Nil(t, scheduler.enqueue(context.Background(), scheduler.entries[0], at))
+	claimed, err := store.Claim(context.Background(), DefaultQueue, 10, time.Minute)
+	assert.Nil(t, err)
+	assert.Len(t, claimed, 1)
+	assert.Equal(t, "report:1710547200", *claimed[0].UniqueKey)
+	assert.Equal(t, at, claimed[0].RunAt)
+
+	// runtime function for the application container
+	fn := scheduler.RuntimeFn()
+	assert.ErrorIs(t, fn(nil), ErrInvalidContainer)
+	app := blueprint.NewContainer(nil)
+	assert.Nil(t, fn(app))
+	assert.ErrorIs(t, scheduler.Run(context.Background()), ErrSchedulerRunning)
+	app.CancelCtx()
+	// the scheduler stops with the container context
+	assert.Eventually(t, func() bool {
+		scheduler.mx.Lock()
+		defer scheduler.mx.Unlock()
+		return !scheduler.running
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestPgsqlStore(t *testing.T) {
+	_, err := NewPgsqlStore(nil, DefaultTable)
+	assert.ErrorIs(t, err, ErrNilClient)
+	client := db.NewSqlClient("", "pgx", nil)
+	_, err = NewPgsqlStore(client, "jobs; DROP TABLE users")
+	assert.ErrorIs(t, err, ErrInvalidTable)
+
+	store, err := NewPgsqlStore(client, "app.jobs")
+	assert.Nil(t, err)
+	assert.Contains(t, store.Schema(), "CREATE TABLE IF NOT EXISTS app.jobs")
+	assert.Contains(t, store.Schema(), "CREATE INDEX IF NOT EXISTS jobs_due_idx ON app.jobs")
+	assert.Contains(t, store.Schema(), "CREATE UNIQUE INDEX IF NOT EXISTS jobs_unique_key_idx ON app.jobs (unique_key) WHERE status IN ('pending', 'running')")
+}
@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+	"runtime/debug"
+	"time"
+)
+
+// Recovery returns a middleware that converts handler panics into ErrJobPanic errors, so the job is retried
+func Recovery() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, job *Job) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().
+						Int64("jobId", job.Id).
+						Str("type", job.Type).
+						Str("stack", string(debug.Stack())).
+						Msgf("job handler panic: %v", r)
+					err = fmt.Errorf("%w: %v", ErrJobPanic, r)
+				}
+			}()
+			return next(ctx, job)
+		}
+	}
+}
+
+// Logging returns a middleware that logs the outcome and duration of each job execution
+func Logging() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, job *Job) error {
+			start := time.Now()
+			err := next(ctx, job)
+			event := log.Info()
+			if err != nil {
+				event = log.Warn().Err(err)
+			}
+			event.
+				Int64("jobId", job.Id).
+				Str("queue", job.Queue).
+				Str("type", job.Type).
+				Int("attempt", job.Attempts).
+				Dur("duration", time.Since(start)).
+				Msg("job executed")
+			return err
+		}
+	}
+}
+
+// Metrics job execution metrics; implements prometheus.Collector
+type Metrics struct {
+	executions *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+}
+
+// NewMetrics creates a new Metrics
+//
+// Example usage:
+//
+//	m := jobs.NewMetrics()
+//	prometheus.MustRegister(m)
+//	worker.Use(m.Middleware())
+func NewMetrics() *Metrics {
+	return &Metrics{
+		executions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "blueprint",
+			Subsystem: "jobs",
+			Name:      "executions_total",
+			Help:      "Number of job executions",
+		}, []string{"queue", "type", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "blueprint",
+			Subsystem: "jobs",
+			Name:      "duration_seconds",
+			Help:      "Job execution duration in seconds",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"queue", "type"}),
+	}
+}
+
+// Middleware returns a middleware that records the outcome and duration of each job execution
+func (m *Metrics) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, job *Job) error {
+			start := time.Now()
+			err := next(ctx, job)
+			status := "success"
+			if err != nil {
+				status = "error"
+			}
+			m.executions.WithLabelValues(job.Queue, job.Type, status).Inc()
+			m.duration.WithLabelValues(job.Queue, job.Type).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
+
+// Describe implements prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.executions.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.executions.Collect(ch)
+	m.duration.Collect(ch)
+}
@@ -0,0 +1,198 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/utils"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTable = "jobs"
+
+	ErrNilClient    = utils.Error("client is nil")
+	ErrInvalidTable = utils.Error("invalid jobs table name")
+)
+
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}(\.[A-Za-z_][A-Za-z0-9_]{0,62})?$`)
+
+// PgsqlStore is a PostgreSQL Store; jobs are claimed with FOR UPDATE SKIP LOCKED, so several workers and
+// applications can share the same table
+type PgsqlStore struct {
+	client *db.SqlClient
+	table  string
+}
+
+// pgsqlJob is a stored job; the payload is read as text, as jsonb scanning is driver-dependent
+type pgsqlJob struct {
+	Job
+	Payload sql.NullString `db:"payload"`
+}
+
+const jobColumns = "id, queue, type, payload::text AS payload, unique_key, status, attempts, max_attempts, run_at, last_error, created_at"
+
+// NewPgsqlStore creates a new PgsqlStore using table
+//
+// Example usage:
+//
+//	store, err := jobs.NewPgsqlStore(client, jobs.DefaultTable)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	// create the table, usually in a migration
+//	if _, err = client.Db().Exec(store.Schema()); err != nil {
+//	  log.Fatal(err)
+//	}
+func NewPgsqlStore(client *db.SqlClient, table string) (*PgsqlStore, error) {
+	if client == nil {
+		return nil, ErrNilClient
+	}
+	if !tableName.MatchString(table) {
+		return nil, ErrInvalidTable
+	}
+	return &PgsqlStore{
+		client: client,
+		table:  table,
+	}, nil
+}
+
+// Schema returns the DDL statements to create the jobs table
+// unique keys are only enforced for pending and running jobs, so a key can be enqueued again once its job finishes
+func (s *PgsqlStore) Schema() string {
+	// index names cannot be schema-qualified; the indexes are created in the table schema
+	prefix := s.table[strings.LastIndex(s.table, ".")+1:]
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id BIGSERIAL PRIMARY KEY,
+	queue TEXT NOT NULL,
+	type TEXT NOT NULL,
+	payload JSONB,
+	unique_key TEXT,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	max_attempts INT NOT NULL,
+	run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	locked_until TIMESTAMPTZ,
+	last_error TEXT,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	finished_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS %s_due_idx ON %s (queue, run_at) WHERE status IN ('pending', 'running');
+CREATE UNIQUE INDEX IF NOT EXISTS %s_unique_key_idx ON %s (unique_key) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS %s_finished_idx ON %s (finished_at) WHERE finished_at IS NOT NULL;`,
+		s.table, prefix, s.table, prefix, s.table, prefix, s.table)
+}
+
+// Enqueue stores jobs; the job ids are updated, except for jobs ignored because of a pending or running job with
+// the same UniqueKey
+func (s *PgsqlStore) Enqueue(ctx context.Context, jobs ...*Job) error {
+	return s.EnqueueTx(ctx, s.client.Db(), jobs...)
+}
+
+// EnqueueTx stores jobs using conn, usually the transaction of the domain writes (see db.Tx.Db()), so jobs are
+// only executed if the transaction commits
+func (s *PgsqlStore) EnqueueTx(ctx context.Context, conn sqlx.QueryerContext, jobs ...*Job) error {
+	for _, job := range jobs {
+		if job == nil {
+			return ErrNilJob
+		}
+		if err := job.Validate(); err != nil {
+			return err
+		}
+	}
+	qry := fmt.Sprintf(`INSERT INTO %s (queue, type, payload, unique_key, max_attempts, run_at)
+		VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING RETURNING id`, s.table)
+	for _, job := range jobs {
+		var payload any
+		if len(job.Payload) > 0 {
+			payload = string(job.Payload)
+		}
+		runAt := job.RunAt
+		if runAt.IsZero() {
+			runAt = time.Now()
+		}
+		err := conn.QueryRowxContext(ctx, qry, job.Queue, job.Type, payload, job.UniqueKey, job.MaxAttempts, runAt).Scan(&job.Id)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *PgsqlStore) Claim(ctx context.Context, queue string, limit int, lease time.Duration) ([]*Job, error) {
+	qry := fmt.Sprintf(`UPDATE %s SET status = $1, attempts = attempts + 1, locked_until = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM %s WHERE queue = $3 AND run_at <= NOW()
+			AND (status = $4 OR (status = $1 AND locked_until < NOW()))
+			ORDER BY run_at, id LIMIT $5 FOR UPDATE SKIP LOCKED
+		) RETURNING %s`, s.table, s.table, jobColumns)
+	records := make([]*pgsqlJob, 0, limit)
+	err := s.client.Db().SelectContext(ctx, &records, qry, StatusRunning, lease.Seconds(), queue, StatusPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Job, len(records))
+	for i, r := range records {
+		job := r.Job
+		if r.Payload.Valid {
+			job.Payload = json.RawMessage(r.Payload.String)
+		}
+		result[i] = &job
+	}
+	return result, nil
+}
+
+func (s *PgsqlStore) Complete(ctx context.Context, job *Job) error {
+	qry := fmt.Sprintf(`UPDATE %s SET status = $1, locked_until = NULL, finished_at = NOW()
+		WHERE id = $2 AND status = $3 AND attempts = $4`, s.table)
+	return s.update(ctx, qry, StatusDone, job.Id, StatusRunning, job.Attempts)
+}
+
+func (s *PgsqlStore) Retry(ctx context.Context, job *Job, runAt time.Time, err error) error {
+	qry := fmt.Sprintf(`UPDATE %s SET status = $1, locked_until = NULL, run_at = $5, last_error = $6
+		WHERE id = $2 AND status = $3 AND attempts = $4`, s.table)
+	return s.update(ctx, qry, StatusPending, job.Id, StatusRunning, job.Attempts, runAt, err.Error())
+}
+
+func (s *PgsqlStore) Kill(ctx context.Context, job *Job, err error) error {
+	qry := fmt.Sprintf(`UPDATE %s SET status = $1, locked_until = NULL, last_error = $5, finished_at = NOW()
+		WHERE id = $2 AND status = $3 AND attempts = $4`, s.table)
+	return s.update(ctx, qry, StatusDead, job.Id, StatusRunning, job.Attempts, err.Error())
+}
+
+// Purge deletes done and dead jobs finished before before, and returns the number of deleted jobs
+//
+// Example usage:
+//
+//	// remove jobs finished more than a week ago
+//	count, err := store.Purge(ctx, time.Now().AddDate(0, 0, -7))
+func (s *PgsqlStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	qry := fmt.Sprintf(`DELETE FROM %s WHERE status IN ($1, $2) AND finished_at < $3`, s.table)
+	result, err := s.client.Db().ExecContext(ctx, qry, StatusDone, StatusDead, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// update executes a claimed job update
+func (s *PgsqlStore) update(ctx context.Context, qry string, args ...any) error {
+	result, err := s.client.Db().ExecContext(ctx, qry, args...)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrJobNotClaimed
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package jobs
+
+import (
+	"fmt"
+	"github.com/oddbit-project/blueprint/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ErrInvalidSchedule = utils.Error("invalid schedule")
+
+	// maxScheduleYears max years searched for the next activation of a schedule
+	maxScheduleYears = 5
+)
+
+var scheduleDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	anyDom bool
+	anyDow bool
+	spec   string
+}
+
+// ParseSchedule parses a standard 5-field cron expression (minute, hour, day of month, month, day of week), or one
+// of the descriptors @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly
+// fields support *, lists (1,15), ranges (1-5), steps (*/10, 0-30/5), and month (jan-dec) and day (sun-sat) names;
+// day of week 7 is Sunday; if both day of month and day of week are restricted, either one matches
+//
+// Example usage:
+//
+//	schedule, err := jobs.ParseSchedule("*/15 8-18 * * mon-fri")
+//	if err != nil {
+//	  return err
+//	}
+//	next := schedule.Next(time.Now())
+func ParseSchedule(spec string) (*Schedule, error) {
+	expr := strings.TrimSpace(spec)
+	if descriptor, ok := scheduleDescriptors[strings.ToLower(expr)]; ok {
+		expr = descriptor
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields in '%s'", ErrInvalidSchedule, spec)
+	}
+	s := &Schedule{spec: spec}
+	var err error
+	if s.minute, err = parseScheduleField(fields[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseScheduleField(fields[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseScheduleField(fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseScheduleField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseScheduleField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, err
+	}
+	// 7 is an alias of Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.anyDom = strings.HasPrefix(fields[2], "*")
+	s.anyDow = strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+// String returns the schedule expression
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first activation time after t, in the location of t; seconds are ignored
+// returns the zero time if there is no activation within the next 5 years (e.g. February 30th)
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + maxScheduleYears
+	for t.Year() <= limit {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches checks the day of month and day of week fields
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.anyDom || s.anyDow {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// parseScheduleField parses a cron field into a bitset
+func parseScheduleField(field string, minValue int, maxValue int, names map[string]int) (uint64, error) {
+	var result uint64
+	for _, item := range strings.Split(field, ",") {
+		rng, stepValue, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepValue); err != nil || step < 1 {
+				return 0, fmt.Errorf("%w: invalid step in '%s'", ErrInvalidSchedule, item)
+			}
+		}
+		start, end := minValue, maxValue
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if start, err = parseScheduleValue(from, minValue, maxValue, names); err != nil {
+				return 0, err
+			}
+			switch {
+			case isRange:
+				if end, err = parseScheduleValue(to, minValue, maxValue, names); err != nil {
+					return 0, err
+				}
+			case !hasStep:
+				end = start
+			}
+			if start > end {
+				return 0, fmt.Errorf("%w: invalid range '%s'", ErrInvalidSchedule, rng)
+			}
+		}
+		for v := start; v <= end; v += step {
+			result |= 1 << uint(v)
+		}
+	}
+	return result, nil
+}
+
+// parseScheduleValue parses a numeric or named field value
+func parseScheduleValue(value string, minValue int, maxValue int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < minValue || v > maxValue {
+		return 0, fmt.Errorf("%w: invalid value '%s'", ErrInvalidSchedule, value)
+	}
+	return v, nil
+}
@@ -0,0 +1,208 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"github.com/oddbit-project/blueprint"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+const (
+	ErrMissingEntryName   = utils.Error("missing schedule entry name")
+	ErrDuplicateEntry     = utils.Error("duplicate schedule entry name")
+	ErrSchedulerRunning   = utils.Error("scheduler is already running")
+	ErrNoScheduledEntries = utils.Error("scheduler has no entries")
+	ErrInvalidContainer   = utils.Error("application is not a *blueprint.Container")
+)
+
+// scheduleEntry is a recurring job
+type scheduleEntry struct {
+	name     string
+	schedule *Schedule
+	job      Job
+	next     time.Time
+}
+
+// Scheduler enqueues recurring jobs according to cron schedules
+// each activation is enqueued with the unique key <name>:<unix time>, so schedulers running in several
+// application instances with a shared Store enqueue each activation once, while the job is pending or running
+type Scheduler struct {
+	store   Store
+	entries []*scheduleEntry
+	running bool
+	mx      sync.Mutex
+}
+
+// NewScheduler creates a new Scheduler
+//
+// Example usage:
+//
+//	scheduler, err := jobs.NewScheduler(store)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	job, _ := jobs.NewJob("reports.daily", nil)
+//	if err = scheduler.Add("daily-report", "0 6 * * *", job); err != nil {
+//	  log.Fatal(err)
+//	}
+//	app.Run(scheduler.RuntimeFn())
+func NewScheduler(store Store) (*Scheduler, error) {
+	if store == nil {
+		return nil, ErrNilStore
+	}
+	return &Scheduler{
+		store:   store,
+		entries: make([]*scheduleEntry, 0),
+	}, nil
+}
+
+// Add registers a recurring job; on each activation, a copy of job is enqueued with RunAt set to the activation
+// time; entries must be added before Run() is called
+func (s *Scheduler) Add(name string, spec string, job *Job) error {
+	if len(name) == 0 {
+		return ErrMissingEntryName
+	}
+	if job == nil {
+		return ErrNilJob
+	}
+	if err := job.Validate(); err != nil {
+		return err
+	}
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.running {
+		return ErrSchedulerRunning
+	}
+	for _, e := range s.entries {
+		if e.name == name {
+			return ErrDuplicateEntry
+		}
+	}
+	s.entries = append(s.entries, &scheduleEntry{
+		name:     name,
+		schedule: schedule,
+		job:      *job,
+	})
+	return nil
+}
+
+// Run enqueues jobs on each activation of their schedule until ctx is cancelled; enqueue errors are logged, and
+// the activation is skipped
+// Note: this function is blocking; it returns nil when ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) error {
+	entries, err := s.start()
+	if err != nil {
+		return err
+	}
+	s.run(ctx, entries)
+	return nil
+}
+
+// RuntimeFn returns a blueprint.RuntimeFn that runs the scheduler in the background with the container context;
+// the scheduler is stopped in the blueprint.StageStopIntake shutdown stage
+//
+// Example usage:
+//
+//	app := blueprint.NewContainer(cfg)
+//	app.Run(scheduler.RuntimeFn())
+func (s *Scheduler) RuntimeFn() blueprint.RuntimeFn {
+	return func(app interface{}) error {
+		container, ok := app.(*blueprint.Container)
+		if !ok {
+			return ErrInvalidContainer
+		}
+		entries, err := s.start()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithCancel(container.GetContext())
+		if err = blueprint.RegisterStageDestructor(blueprint.StageStopIntake, func() error {
+			cancel()
+			return nil
+		}); err != nil {
+			cancel()
+			s.stop()
+			return err
+		}
+		go s.run(ctx, entries)
+		return nil
+	}
+}
+
+// start marks the scheduler as running, and returns its entries
+func (s *Scheduler) start() ([]*scheduleEntry, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.running {
+		return nil, ErrSchedulerRunning
+	}
+	if len(s.entries) == 0 {
+		return nil, ErrNoScheduledEntries
+	}
+	s.running = true
+	return s.entries, nil
+}
+
+// stop marks the scheduler as stopped
+func (s *Scheduler) stop() {
+	s.mx.Lock()
+	s.running = false
+	s.mx.Unlock()
+}
+
+// run enqueues jobs on each activation of entries until ctx is cancelled
+func (s *Scheduler) run(ctx context.Context, entries []*scheduleEntry) {
+	defer s.stop()
+
+	now := time.Now()
+	for _, e := range entries {
+		e.next = e.schedule.Next(now)
+	}
+	for {
+		var wake time.Time
+		for _, e := range entries {
+			if !e.next.IsZero() && (wake.IsZero() || e.next.Before(wake)) {
+				wake = e.next
+			}
+		}
+		if wake.IsZero() {
+			// no further activations
+			<-ctx.Done()
+			return
+		}
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		now = time.Now()
+		for _, e := range entries {
+			if e.next.IsZero() || e.next.After(now) {
+				continue
+			}
+			if err := s.enqueue(ctx, e, e.next); err != nil {
+				log.Error().Err(err).Str("entry", e.name).Time("activation", e.next).Msg("failed to enqueue scheduled job")
+			}
+			e.next = e.schedule.Next(now)
+		}
+	}
+}
+
+// enqueue enqueues an activation of a schedule entry
+func (s *Scheduler) enqueue(ctx context.Context, e *scheduleEntry, at time.Time) error {
+	job := e.job
+	job.Id = 0
+	job.RunAt = at
+	job.WithUniqueKey(fmt.Sprintf("%s:%d", e.name, at.Unix()))
+	return s.store.Enqueue(ctx, &job)
+}
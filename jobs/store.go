@@ -0,0 +1,167 @@
+package jobs
+
+import (
+	"context"
+	"github.com/oddbit-project/blueprint/utils"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	ErrJobNotClaimed = utils.Error("job is not claimed by this worker")
+)
+
+// Store persists jobs
+// Complete, Retry and Kill only update the job if it is still claimed by the caller (same status and attempt);
+// otherwise ErrJobNotClaimed is returned, e.g. if the lease expired and the job was claimed by another worker
+type Store interface {
+	// Enqueue stores jobs; jobs with the UniqueKey of a pending or running job are ignored
+	Enqueue(ctx context.Context, jobs ...*Job) error
+	// Claim marks up to limit due jobs of queue as running, for the duration of lease; running jobs with an
+	// expired lease are claimed again
+	Claim(ctx context.Context, queue string, limit int, lease time.Duration) ([]*Job, error)
+	// Complete marks a claimed job as done
+	Complete(ctx context.Context, job *Job) error
+	// Retry schedules a claimed job for another attempt at runAt
+	Retry(ctx context.Context, job *Job, runAt time.Time, err error) error
+	// Kill marks a claimed job as dead
+	Kill(ctx context.Context, job *Job, err error) error
+}
+
+// MemoryStore is a non-persistent Store, for tests and single-process applications
+// finished jobs are removed when completed or killed, releasing their unique key
+type MemoryStore struct {
+	jobs   map[int64]*Job
+	unique map[string]int64
+	leases map[int64]time.Time
+	nextId int64
+	mx     sync.Mutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		jobs:   make(map[int64]*Job),
+		unique: make(map[string]int64),
+		leases: make(map[int64]time.Time),
+	}
+}
+
+// Enqueue stores copies of jobs; the job ids are updated
+func (s *MemoryStore) Enqueue(ctx context.Context, jobs ...*Job) error {
+	for _, job := range jobs {
+		if job == nil {
+			return ErrNilJob
+		}
+		if err := job.Validate(); err != nil {
+			return err
+		}
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	for _, job := range jobs {
+		if job.UniqueKey != nil {
+			if _, exists := s.unique[*job.UniqueKey]; exists {
+				continue
+			}
+		}
+		s.nextId++
+		job.Id = s.nextId
+		record := *job
+		record.Status = StatusPending
+		record.Attempts = 0
+		record.CreatedAt = time.Now()
+		s.jobs[record.Id] = &record
+		if job.UniqueKey != nil {
+			s.unique[*job.UniqueKey] = record.Id
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, queue string, limit int, lease time.Duration) ([]*Job, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	now := time.Now()
+	due := make([]*Job, 0)
+	for id, job := range s.jobs {
+		if job.Queue != queue || job.RunAt.After(now) {
+			continue
+		}
+		if job.Status == StatusPending || (job.Status == StatusRunning && s.leases[id].Before(now)) {
+			due = append(due, job)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].RunAt.Equal(due[j].RunAt) {
+			return due[i].Id < due[j].Id
+		}
+		return due[i].RunAt.Before(due[j].RunAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	result := make([]*Job, len(due))
+	for i, job := range due {
+		job.Status = StatusRunning
+		job.Attempts++
+		s.leases[job.Id] = now.Add(lease)
+		claimed := *job
+		result[i] = &claimed
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, job *Job) error {
+	return s.update(job, func(record *Job) {
+		s.remove(record)
+	})
+}
+
+func (s *MemoryStore) Retry(ctx context.Context, job *Job, runAt time.Time, err error) error {
+	return s.update(job, func(record *Job) {
+		msg := err.Error()
+		record.Status = StatusPending
+		record.RunAt = runAt
+		record.LastError = &msg
+	})
+}
+
+func (s *MemoryStore) Kill(ctx context.Context, job *Job, err error) error {
+	return s.update(job, func(record *Job) {
+		s.remove(record)
+	})
+}
+
+// Get returns a copy of a pending or running job
+func (s *MemoryStore) Get(id int64) (*Job, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	result := *job
+	return &result, true
+}
+
+// remove removes a finished job and its unique key
+func (s *MemoryStore) remove(record *Job) {
+	delete(s.jobs, record.Id)
+	if record.UniqueKey != nil {
+		delete(s.unique, *record.UniqueKey)
+	}
+}
+
+// update applies fn to a claimed job
+func (s *MemoryStore) update(job *Job, fn func(record *Job)) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	record, ok := s.jobs[job.Id]
+	if !ok || record.Status != StatusRunning || record.Attempts != job.Attempts {
+		return ErrJobNotClaimed
+	}
+	fn(record)
+	delete(s.leases, job.Id)
+	return nil
+}
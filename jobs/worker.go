@@ -0,0 +1,304 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oddbit-project/blueprint/threadpool"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultConcurrency   = 10
+	DefaultPollInterval  = 1000   // milliseconds
+	DefaultLeaseTime     = 300    // seconds
+	DefaultRetryDelay    = 1000   // milliseconds
+	DefaultMaxRetryDelay = 600000 // milliseconds
+
+	statusTimeout = 30 * time.Second
+
+	ErrNilConfig            = utils.Error("config is nil")
+	ErrNilStore             = utils.Error("store is nil")
+	ErrNilHandler           = utils.Error("job handler is nil")
+	ErrInvalidConcurrency   = utils.Error("concurrency must be >= 1")
+	ErrInvalidPollInterval  = utils.Error("pollInterval must be >= 1")
+	ErrInvalidLeaseTime     = utils.Error("leaseTime must be >= 1")
+	ErrInvalidRetryDelay    = utils.Error("retryDelay must be >= 0")
+	ErrInvalidMaxRetryDelay = utils.Error("maxRetryDelay must be >= retryDelay")
+	ErrWorkerRunning        = utils.Error("worker is already running")
+)
+
+// WorkerConfig worker configuration
+type WorkerConfig struct {
+	Queue         string `json:"queue"`         // Queue name of the processed queue
+	Concurrency   int    `json:"concurrency"`   // Concurrency max jobs executed simultaneously
+	PollInterval  int    `json:"pollInterval"`  // PollInterval interval in milliseconds between polls when idle
+	LeaseTime     int    `json:"leaseTime"`     // LeaseTime max job execution time in seconds; expired jobs are claimed again
+	RetryDelay    int    `json:"retryDelay"`    // RetryDelay delay in milliseconds before the first retry; doubles on each retry
+	MaxRetryDelay int    `json:"maxRetryDelay"` // MaxRetryDelay max delay in milliseconds between retries
+}
+
+// Worker executes the jobs of a queue with a bounded number of concurrent handlers, using a threadpool.ThreadPool
+// failed jobs are retried with exponential backoff until MaxAttempts is reached, and then marked as dead
+type Worker struct {
+	store      Store
+	config     *WorkerConfig
+	pool       *threadpool.ThreadPool
+	handlers   map[string]Handler
+	middleware []Middleware
+	running    bool
+	stopFn     context.CancelFunc
+	done       chan struct{}
+	mx         sync.RWMutex
+}
+
+func NewWorkerConfig() *WorkerConfig {
+	return &WorkerConfig{
+		Queue:         DefaultQueue,
+		Concurrency:   DefaultConcurrency,
+		PollInterval:  DefaultPollInterval,
+		LeaseTime:     DefaultLeaseTime,
+		RetryDelay:    DefaultRetryDelay,
+		MaxRetryDelay: DefaultMaxRetryDelay,
+	}
+}
+
+func (c *WorkerConfig) Validate() error {
+	if len(c.Queue) == 0 {
+		return ErrMissingQueue
+	}
+	if c.Concurrency < 1 {
+		return ErrInvalidConcurrency
+	}
+	if c.PollInterval < 1 {
+		return ErrInvalidPollInterval
+	}
+	if c.LeaseTime < 1 {
+		return ErrInvalidLeaseTime
+	}
+	if c.RetryDelay < 0 {
+		return ErrInvalidRetryDelay
+	}
+	if c.MaxRetryDelay < c.RetryDelay {
+		return ErrInvalidMaxRetryDelay
+	}
+	return nil
+}
+
+// NewWorker creates a new Worker
+//
+// Example usage:
+//
+//	worker, err := jobs.NewWorker(store, jobs.NewWorkerConfig())
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	worker.Use(jobs.Recovery(), jobs.Logging())
+//	worker.Handle("email.welcome", func(ctx context.Context, job *jobs.Job) error {
+//	  msg := &WelcomeEmail{}
+//	  if err := job.Decode(msg); err != nil {
+//	    return err
+//	  }
+//	  return mailer.Send(ctx, msg)
+//	})
+//	go worker.Run(app.Context)
+//	blueprint.RegisterDrain(worker.Drain)
+func NewWorker(store Store, cfg *WorkerConfig) (*Worker, error) {
+	if store == nil {
+		return nil, ErrNilStore
+	}
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	// claims are bounded by Concurrency, so dispatching never blocks
+	pool, err := threadpool.NewThreadPool(cfg.Concurrency, cfg.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+	// the job context expires with the lease
+	pool.SetJobTimeout(time.Duration(cfg.LeaseTime) * time.Second)
+	return &Worker{
+		store:      store,
+		config:     cfg,
+		pool:       pool,
+		handlers:   make(map[string]Handler),
+		middleware: make([]Middleware, 0),
+	}, nil
+}
+
+// Handle registers the handler of a job type
+func (w *Worker) Handle(jobType string, handler Handler) error {
+	if len(jobType) == 0 {
+		return ErrMissingType
+	}
+	if handler == nil {
+		return ErrNilHandler
+	}
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	w.handlers[jobType] = handler
+	return nil
+}
+
+// Use adds middlewares; the first middleware is the outermost
+func (w *Worker) Use(mw ...Middleware) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	w.middleware = append(w.middleware, mw...)
+}
+
+// Run claims and executes jobs until ctx is cancelled or Stop() is called; store errors are logged and retried on
+// the next poll; jobs in execution keep running with their own context, use Drain() to wait for them
+// Note: this function is blocking; it returns nil when ctx is cancelled
+func (w *Worker) Run(ctx context.Context) error {
+	w.mx.Lock()
+	if w.running {
+		w.mx.Unlock()
+		return ErrWorkerRunning
+	}
+	// jobs in execution are not cancelled with ctx
+	if err := w.pool.Start(context.Background()); err != nil && !errors.Is(err, threadpool.ErrPoolAlreadyStarted) {
+		w.mx.Unlock()
+		return err
+	}
+	w.running = true
+	ctx, w.stopFn = context.WithCancel(ctx)
+	done := make(chan struct{})
+	w.done = done
+	w.mx.Unlock()
+	defer func() {
+		w.mx.Lock()
+		w.stopFn()
+		w.running = false
+		w.mx.Unlock()
+		close(done)
+	}()
+
+	pollInterval := time.Duration(w.config.PollInterval) * time.Millisecond
+	lease := time.Duration(w.config.LeaseTime) * time.Second
+	slots := make(chan struct{}, w.config.Concurrency)
+	for {
+		free := cap(slots) - len(slots)
+		claimed := 0
+		if free > 0 {
+			jobs, err := w.store.Claim(ctx, w.config.Queue, free, lease)
+			if err != nil && ctx.Err() == nil {
+				log.Error().Err(err).Str("queue", w.config.Queue).Msg("failed to claim jobs")
+			}
+			claimed = len(jobs)
+			for _, job := range jobs {
+				slots <- struct{}{}
+				w.pool.Dispatch(threadpool.JobFunc(func(ctx context.Context) {
+					defer func() { <-slots }()
+					w.execute(ctx, job)
+				}))
+			}
+		}
+		// when all slots were filled, poll again as soon as a slot is released
+		wait := pollInterval
+		if claimed > 0 && claimed == free {
+			wait = 0
+			select {
+			case slots <- struct{}{}:
+				<-slots
+			case <-ctx.Done():
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Stop stops claiming jobs; Run() returns
+func (w *Worker) Stop() {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	if w.running {
+		w.stopFn()
+	}
+}
+
+// Stats returns the counters of the worker pool; implements metrics.ThreadPoolStatsProvider
+//
+// Example usage:
+//
+//	prometheus.MustRegister(metrics.NewThreadPoolCollector("jobs", worker))
+func (w *Worker) Stats() threadpool.Stats {
+	return w.pool.Stats()
+}
+
+// Drain stops claiming jobs, and waits for jobs in execution to finish; if ctx expires first, the context of jobs
+// in execution is cancelled, and ctx.Err() is returned
+func (w *Worker) Drain(ctx context.Context) error {
+	w.Stop()
+	w.mx.RLock()
+	stopped := w.done
+	w.mx.RUnlock()
+	if stopped != nil {
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := w.pool.Drain(ctx); err != nil && !errors.Is(err, threadpool.ErrPoolNotStarted) {
+		return err
+	}
+	return nil
+}
+
+// execute runs a claimed job and updates its status; the job context expires with the lease
+func (w *Worker) execute(ctx context.Context, job *Job) {
+	err := w.handler(job.Type)(ctx, job)
+
+	// status updates use a fresh context, so timed out jobs are still retried
+	updateCtx, updateCancel := context.WithTimeout(context.Background(), statusTimeout)
+	defer updateCancel()
+	switch {
+	case err == nil:
+		err = w.store.Complete(updateCtx, job)
+	case job.Attempts >= job.MaxAttempts || errors.Is(err, ErrUnknownJobType):
+		err = w.store.Kill(updateCtx, job, err)
+	default:
+		err = w.store.Retry(updateCtx, job, time.Now().Add(w.backoff(job.Attempts)), err)
+	}
+	if err != nil {
+		log.Error().Err(err).Int64("jobId", job.Id).Str("type", job.Type).Msg("failed to update job status")
+	}
+}
+
+// handler returns the handler of jobType, wrapped by the middlewares
+func (w *Worker) handler(jobType string) Handler {
+	w.mx.RLock()
+	defer w.mx.RUnlock()
+	h, ok := w.handlers[jobType]
+	if !ok {
+		h = func(ctx context.Context, job *Job) error {
+			return fmt.Errorf("%w: %s", ErrUnknownJobType, job.Type)
+		}
+	}
+	for i := len(w.middleware) - 1; i >= 0; i-- {
+		h = w.middleware[i](h)
+	}
+	return h
+}
+
+// backoff returns the delay before the next attempt
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := time.Duration(w.config.RetryDelay) * time.Millisecond
+	maxDelay := time.Duration(w.config.MaxRetryDelay) * time.Millisecond
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
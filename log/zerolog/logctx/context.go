@@ -0,0 +1,54 @@
+package logctx
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	FieldRequestId = "requestId"
+	FieldSessionId = "sessionId"
+	FieldSubject   = "subject"
+	FieldTopic     = "topic"
+	FieldPartition = "partition"
+	FieldOffset    = "offset"
+)
+
+// FromContext returns the logger stored in the context
+// if no logger exists, the global logger is returned
+//
+// Example usage:
+//
+//	func handler(ctx context.Context, msg kafka.Message) error {
+//	  logctx.FromContext(ctx).Info().Msg("processing message") // includes topic, partition and offset
+//	  return nil
+//	}
+func FromContext(ctx context.Context) *zerolog.Logger {
+	if ctx != nil {
+		if l := zerolog.Ctx(ctx); l != nil && l.GetLevel() != zerolog.Disabled {
+			return l
+		}
+	}
+	return &log.Logger
+}
+
+// WithLogger stores a logger in the context
+func WithLogger(ctx context.Context, logger zerolog.Logger) context.Context {
+	return logger.WithContext(ctx)
+}
+
+// WithFields returns a new context with a child logger containing the specified fields
+// fields are added to the logger already present in the context, if any
+func WithFields(ctx context.Context, fields map[string]any) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	logger := FromContext(ctx).With().Fields(fields).Logger()
+	return logger.WithContext(ctx)
+}
+
+// WithField returns a new context with a child logger containing the specified field
+func WithField(ctx context.Context, key string, value any) context.Context {
+	return WithFields(ctx, map[string]any{key: value})
+}
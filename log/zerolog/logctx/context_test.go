@@ -0,0 +1,34 @@
+package logctx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWithFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := WithLogger(context.Background(), zerolog.New(buf))
+	ctx = WithField(ctx, FieldRequestId, "abc")
+	ctx = WithFields(ctx, map[string]any{FieldTopic: "events", FieldOffset: 12})
+
+	FromContext(ctx).Info().Msg("test")
+
+	record := map[string]any{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "abc", record[FieldRequestId])
+	assert.Equal(t, "events", record[FieldTopic])
+	assert.Equal(t, float64(12), record[FieldOffset])
+	assert.Equal(t, "test", record["message"])
+}
+
+func TestFromContextFallback(t *testing.T) {
+	assert.NotNil(t, FromContext(context.Background()))
+	assert.NotNil(t, FromContext(nil))
+	// empty field list returns same context
+	ctx := context.Background()
+	assert.Equal(t, ctx, WithFields(ctx, nil))
+}
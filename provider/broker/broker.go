@@ -0,0 +1,41 @@
+package broker
+
+import (
+	"context"
+	"github.com/oddbit-project/blueprint/utils"
+)
+
+const (
+	ErrTopicMismatch         = utils.Error("topic does not match the configured topic")
+	ErrHeadersNotSupported   = utils.Error("message headers are not supported by the broker")
+	ErrMissingTopic          = utils.Error("missing topic")
+	ErrNilHandler            = utils.Error("message handler is nil")
+	ErrClosed                = utils.Error("broker is closed")
+	ErrInvalidMemoryCapacity = utils.Error("capacity must be >= 1")
+)
+
+// Message is a broker-independent message
+type Message struct {
+	Topic   string
+	Key     []byte // Key partitioning key; ignored by brokers without partitions
+	Payload []byte
+	Headers map[string]string
+}
+
+// Handler processes a message; a message is acknowledged when the handler returns nil
+// the behaviour on error depends on the broker, see the adapter documentation
+type Handler func(ctx context.Context, msg *Message) error
+
+// Publisher publishes messages
+type Publisher interface {
+	Publish(ctx context.Context, msgs ...*Message) error
+	Close() error
+}
+
+// Subscriber receives messages
+type Subscriber interface {
+	// Subscribe calls handler for each message of topic until ctx is cancelled or an error occurs
+	// Note: this function is blocking; it returns nil when ctx is cancelled
+	Subscribe(ctx context.Context, topic string, handler Handler) error
+	Close() error
+}
@@ -0,0 +1,140 @@
+package broker
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	DefaultMemoryCapacity = 1000
+)
+
+// Memory is an in-process Publisher and Subscriber, for unit tests and single-process applications
+// published messages are delivered to all subscribers of the topic, and kept for inspection with Messages();
+// if a handler returns an error, Subscribe() returns the error
+type Memory struct {
+	capacity  int
+	subs      map[string][]*memorySubscription
+	published map[string][]*Message
+	closed    bool
+	mx        sync.Mutex
+}
+
+// memorySubscription subscriber queue; done is closed when the subscription ends, so publishers do not block on
+// queues that are no longer consumed
+type memorySubscription struct {
+	ch   chan *Message
+	done chan struct{}
+}
+
+// NewMemory creates a new Memory broker; capacity is the max pending messages per subscriber
+//
+// Example usage:
+//
+//	bus, err := broker.NewMemory(broker.DefaultMemoryCapacity)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	svc := NewOrderService(bus) // the service depends on broker.Publisher
+//	err = svc.PlaceOrder(ctx, order)
+//	assert.Len(t, bus.Messages("orders"), 1)
+func NewMemory(capacity int) (*Memory, error) {
+	if capacity < 1 {
+		return nil, ErrInvalidMemoryCapacity
+	}
+	return &Memory{
+		capacity:  capacity,
+		subs:      make(map[string][]*memorySubscription),
+		published: make(map[string][]*Message),
+	}, nil
+}
+
+// Publish delivers messages to the subscribers of their topic; blocks if a subscriber queue is full, until the
+// message is consumed, the subscription ends or ctx is cancelled
+func (m *Memory) Publish(ctx context.Context, msgs ...*Message) error {
+	for _, msg := range msgs {
+		if len(msg.Topic) == 0 {
+			return ErrMissingTopic
+		}
+		m.mx.Lock()
+		if m.closed {
+			m.mx.Unlock()
+			return ErrClosed
+		}
+		m.published[msg.Topic] = append(m.published[msg.Topic], msg)
+		subs := append([]*memorySubscription{}, m.subs[msg.Topic]...)
+		m.mx.Unlock()
+
+		for _, sub := range subs {
+			select {
+			case sub.ch <- msg:
+			case <-sub.done:
+				// the subscriber returned, e.g. after a handler error
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// Subscribe calls handler for each message published to topic after the subscription
+func (m *Memory) Subscribe(ctx context.Context, topic string, handler Handler) error {
+	if len(topic) == 0 {
+		return ErrMissingTopic
+	}
+	if handler == nil {
+		return ErrNilHandler
+	}
+	sub := &memorySubscription{
+		ch:   make(chan *Message, m.capacity),
+		done: make(chan struct{}),
+	}
+	m.mx.Lock()
+	if m.closed {
+		m.mx.Unlock()
+		return ErrClosed
+	}
+	m.subs[topic] = append(m.subs[topic], sub)
+	m.mx.Unlock()
+	defer m.unsubscribe(topic, sub)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg := <-sub.ch:
+			if err := handler(ctx, msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Messages returns the messages published to topic
+func (m *Memory) Messages(topic string) []*Message {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return append([]*Message{}, m.published[topic]...)
+}
+
+// Close rejects further publishing and subscriptions; active subscriptions end when their context is cancelled
+func (m *Memory) Close() error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.closed = true
+	return nil
+}
+
+func (m *Memory) unsubscribe(topic string, sub *memorySubscription) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	close(sub.done)
+	subs := m.subs[topic]
+	for i, s := range subs {
+		if s == sub {
+			m.subs[topic] = append(subs[:i], subs[i+1:]...)
+			return
+		}
+	}
+}
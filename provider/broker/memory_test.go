@@ -0,0 +1,96 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	_, err := NewMemory(0)
+	assert.ErrorIs(t, err, ErrInvalidMemoryCapacity)
+
+	bus, err := NewMemory(DefaultMemoryCapacity)
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.ErrorIs(t, bus.Publish(ctx, &Message{Payload: []byte("x")}), ErrMissingTopic)
+	assert.ErrorIs(t, bus.Subscribe(ctx, "orders", nil), ErrNilHandler)
+
+	received := make(chan *Message, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- bus.Subscribe(ctx, "orders", func(ctx context.Context, msg *Message) error {
+			if string(msg.Payload) == "fail" {
+				return errors.New("handler failed")
+			}
+			received <- msg
+			return nil
+		})
+	}()
+	// wait for the subscription
+	assert.Eventually(t, func() bool {
+		bus.mx.Lock()
+		defer bus.mx.Unlock()
+		return len(bus.subs["orders"]) == 1
+	}, time.Second, time.Millisecond)
+
+	msg := &Message{Topic: "orders", Key: []byte("1"), Payload: []byte("created"), Headers: map[string]string{"type": "order.created"}}
+	assert.Nil(t, bus.Publish(ctx, msg, &Message{Topic: "users", Payload: []byte("ignored")}))
+	assert.Equal(t, msg, <-received)
+	assert.Len(t, bus.Messages("orders"), 1)
+	assert.Len(t, bus.Messages("users"), 1)
+
+	// handler errors end the subscription
+	assert.Nil(t, bus.Publish(ctx, &Message{Topic: "orders", Payload: []byte("fail")}))
+	assert.EqualError(t, <-done, "handler failed")
+
+	// cancelled subscriptions return nil
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	cancel2()
+	assert.Nil(t, bus.Subscribe(ctx2, "orders", func(ctx context.Context, msg *Message) error { return nil }))
+
+	assert.Nil(t, bus.Close())
+	assert.ErrorIs(t, bus.Publish(ctx, msg), ErrClosed)
+}
+
+func TestMemoryPublishAfterHandlerError(t *testing.T) {
+	bus, err := NewMemory(1)
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- bus.Subscribe(ctx, "orders", func(ctx context.Context, msg *Message) error {
+			<-release
+			return errors.New("handler failed")
+		})
+	}()
+	assert.Eventually(t, func() bool {
+		bus.mx.Lock()
+		defer bus.mx.Unlock()
+		return len(bus.subs["orders"]) == 1
+	}, time.Second, time.Millisecond)
+
+	// the first message is being handled, and the second fills the subscriber queue
+	assert.Nil(t, bus.Publish(ctx, &Message{Topic: "orders", Payload: []byte("1")}, &Message{Topic: "orders", Payload: []byte("2")}))
+	published := make(chan error, 1)
+	go func() {
+		published <- bus.Publish(ctx, &Message{Topic: "orders", Payload: []byte("3")})
+	}()
+
+	// publishing without a deadline does not block once the subscription ends
+	close(release)
+	assert.EqualError(t, <-done, "handler failed")
+	select {
+	case err = <-published:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("publish blocked after the subscription ended")
+	}
+	assert.Len(t, bus.Messages("orders"), 3)
+}
@@ -0,0 +1,369 @@
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/rs/zerolog/log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBatchWriterSize   = 10000
+	DefaultFlushInterval     = 1000 // milliseconds
+	DefaultBatchMaxRetries   = 3
+	DefaultBatchRetryDelay   = 500 // milliseconds
+	DefaultBatchFlushTimeout = 30  // seconds
+
+	// batchQueueSize max full batches waiting to be inserted; Write blocks when the queue is full
+	batchQueueSize = 16
+
+	ErrInvalidBatchWriterSize = utils.Error("batchSize must be >= 1")
+	ErrInvalidFlushInterval   = utils.Error("flushInterval must be >= 1")
+	ErrInvalidMaxRetries      = utils.Error("maxRetries must be >= 0")
+	ErrInvalidRetryDelay      = utils.Error("retryDelay must be >= 0")
+	ErrInvalidFlushTimeout    = utils.Error("flushTimeout must be >= 1")
+	ErrBatchWriterClosed      = utils.Error("batch writer is closed")
+	ErrMissingTable           = utils.Error("missing table name")
+	ErrClientNotConnected     = utils.Error("client is not connected")
+	ErrInvalidIdentifier      = utils.Error("invalid table or column name")
+)
+
+// identifierRegex valid table and column names; tables may be qualified with the database name
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// BatchWriterConfig batch writer configuration
+type BatchWriterConfig struct {
+	BatchSize     int `json:"batchSize"`     // BatchSize max buffered rows per table before flushing
+	FlushInterval int `json:"flushInterval"` // FlushInterval interval in milliseconds between periodic flushes
+	MaxRetries    int `json:"maxRetries"`    // MaxRetries retries of a failed batch, before calling the dead-letter fn
+	RetryDelay    int `json:"retryDelay"`    // RetryDelay delay in milliseconds before the first retry; doubles on each retry
+	FlushTimeout  int `json:"flushTimeout"`  // FlushTimeout timeout in seconds for each batch insert
+	// AsyncInsert enables server-side async inserts (async_insert=1)
+	AsyncInsert bool `json:"asyncInsert"`
+	// WaitForAsyncInsert waits for async inserts to be written before acknowledging (wait_for_async_insert=1)
+	WaitForAsyncInsert bool `json:"waitForAsyncInsert"`
+}
+
+// DeadLetterFn is called with the rows of a batch that failed after all retries
+type DeadLetterFn func(table string, rows []any, err error)
+
+// BatchWriter buffers rows per table, and inserts them in batches when the buffer reaches BatchSize rows, or
+// every FlushInterval milliseconds; batches are inserted by a background goroutine, so retries do not block
+// writers; the client must be connected before rows are flushed
+type BatchWriter struct {
+	client     *db.SqlClient
+	config     *BatchWriterConfig
+	deadLetter DeadLetterFn
+	onFlush    func(table string, rows int)
+	buffers    map[string][]any
+	queue      chan batch
+	senders    sync.WaitGroup
+	closed     bool
+	stopFn     context.CancelFunc
+	done       chan struct{}
+	mx         sync.Mutex
+}
+
+// batch full table batch waiting to be inserted
+type batch struct {
+	table string
+	rows  []any
+}
+
+func NewBatchWriterConfig() *BatchWriterConfig {
+	return &BatchWriterConfig{
+		BatchSize:          DefaultBatchWriterSize,
+		FlushInterval:      DefaultFlushInterval,
+		MaxRetries:         DefaultBatchMaxRetries,
+		RetryDelay:         DefaultBatchRetryDelay,
+		FlushTimeout:       DefaultBatchFlushTimeout,
+		AsyncInsert:        false,
+		WaitForAsyncInsert: true,
+	}
+}
+
+func (c *BatchWriterConfig) Validate() error {
+	if c.BatchSize < 1 {
+		return ErrInvalidBatchWriterSize
+	}
+	if c.FlushInterval < 1 {
+		return ErrInvalidFlushInterval
+	}
+	if c.MaxRetries < 0 {
+		return ErrInvalidMaxRetries
+	}
+	if c.RetryDelay < 0 {
+		return ErrInvalidRetryDelay
+	}
+	if c.FlushTimeout < 1 {
+		return ErrInvalidFlushTimeout
+	}
+	return nil
+}
+
+// NewBatchWriter creates a new BatchWriter and starts the periodic flush; if deadLetter is nil, failed batches
+// are logged and discarded
+//
+// Example usage:
+//
+//	writer, err := clickhouse.NewBatchWriter(client, clickhouse.NewBatchWriterConfig(), func(table string, rows []any, err error) {
+//	  log.Error().Err(err).Str("table", table).Int("rows", len(rows)).Msg("dropped telemetry batch")
+//	})
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	blueprint.RegisterDrain(writer.Close)
+//
+//	err = writer.Write("events", &Event{Timestamp: time.Now(), Name: "login"})
+func NewBatchWriter(client *db.SqlClient, cfg *BatchWriterConfig, deadLetter DeadLetterFn) (*BatchWriter, error) {
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, stopFn := context.WithCancel(context.Background())
+	w := &BatchWriter{
+		client:     client,
+		config:     cfg,
+		deadLetter: deadLetter,
+		buffers:    make(map[string][]any),
+		queue:      make(chan batch, batchQueueSize),
+		stopFn:     stopFn,
+		done:       make(chan struct{}),
+	}
+	go w.run(ctx)
+	return w, nil
+}
+
+// Write buffers rows for insertion in table; rows are structs, struct pointers or maps, mapped as in db.RowValues()
+// if the table buffer reaches BatchSize rows, the batch is queued for insertion by the background goroutine; Write
+// only blocks if the insertion queue is full, i.e. if inserts are slower than writes
+// table must be a valid identifier, optionally qualified with the database name
+func (w *BatchWriter) Write(table string, rows ...any) error {
+	if len(table) == 0 {
+		return ErrMissingTable
+	}
+	if _, err := quoteTable(table); err != nil {
+		return err
+	}
+	w.mx.Lock()
+	if w.closed {
+		w.mx.Unlock()
+		return ErrBatchWriterClosed
+	}
+	buf := append(w.buffers[table], rows...)
+	var full []any
+	if len(buf) >= w.config.BatchSize {
+		full = buf
+		buf = nil
+		w.senders.Add(1)
+	}
+	w.buffers[table] = buf
+	w.mx.Unlock()
+
+	if full != nil {
+		defer w.senders.Done()
+		w.queue <- batch{table: table, rows: full}
+	}
+	return nil
+}
+
+// Buffered returns the number of buffered rows for table
+func (w *BatchWriter) Buffered(table string) int {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	return len(w.buffers[table])
+}
+
+// Flush inserts all buffered rows
+func (w *BatchWriter) Flush() error {
+	w.mx.Lock()
+	buffers := w.buffers
+	w.buffers = make(map[string][]any)
+	w.mx.Unlock()
+
+	errs := make([]error, 0)
+	for table, rows := range buffers {
+		if len(rows) == 0 {
+			continue
+		}
+		if err := w.flush(table, rows); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops the periodic flush and inserts all queued and buffered rows; further writes return
+// ErrBatchWriterClosed; returns ctx.Err() if ctx expires before the final flush completes
+func (w *BatchWriter) Close(ctx context.Context) error {
+	w.mx.Lock()
+	if w.closed {
+		w.mx.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mx.Unlock()
+
+	result := make(chan error, 1)
+	go func() {
+		// pending writes are received by the background goroutine
+		w.senders.Wait()
+		w.stopFn()
+		<-w.done
+
+		var errs []error
+		for len(w.queue) > 0 {
+			b := <-w.queue
+			if err := w.flush(b.table, b.rows); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		errs = append(errs, w.Flush())
+		result <- errors.Join(errs...)
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *BatchWriter) run(ctx context.Context) {
+	defer close(w.done)
+	ticker := time.NewTicker(time.Duration(w.config.FlushInterval) * time.Millisecond)
+	defer ticker.Stop()
+	// errors are reported to the dead-letter fn
+	for {
+		// queued batches left when stopped are inserted by Close
+		if ctx.Err() != nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case b := <-w.queue:
+			_ = w.flush(b.table, b.rows)
+		case <-ticker.C:
+			_ = w.Flush()
+		}
+	}
+}
+
+// flush inserts a batch, retrying with exponential backoff; if all attempts fail, the dead-letter fn is called
+func (w *BatchWriter) flush(table string, rows []any) error {
+	delay := time.Duration(w.config.RetryDelay) * time.Millisecond
+	var err error
+	for attempt := 0; attempt <= w.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		if err = w.insert(table, rows); err == nil {
+			if w.onFlush != nil {
+				w.onFlush(table, len(rows))
+			}
+			return nil
+		}
+		if errors.Is(err, ErrInvalidIdentifier) {
+			// not retryable
+			break
+		}
+		log.Warn().Err(err).Str("table", table).Int("rows", len(rows)).Int("attempt", attempt+1).Msg("batch insert failed")
+	}
+	if w.deadLetter != nil {
+		w.deadLetter(table, rows, err)
+	} else {
+		log.Error().Err(err).Str("table", table).Int("rows", len(rows)).Msg("batch discarded")
+	}
+	return err
+}
+
+// insert inserts rows using the driver batch API: all rows are sent in a single block when the transaction commits
+func (w *BatchWriter) insert(table string, rows []any) error {
+	columns, values, err := db.RowValues(rows)
+	if err != nil {
+		return err
+	}
+	qTable, err := quoteTable(table)
+	if err != nil {
+		return err
+	}
+	qColumns := make([]string, len(columns))
+	for i, c := range columns {
+		if qColumns[i], err = quoteIdentifier(c); err != nil {
+			return err
+		}
+	}
+	if !w.client.IsConnected() {
+		return ErrClientNotConnected
+	}
+	ctx, cancel := context.WithTimeout(w.context(), time.Duration(w.config.FlushTimeout)*time.Second)
+	defer cancel()
+
+	tx, err := w.client.Db().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	qry := fmt.Sprintf("INSERT INTO %s (%s)", qTable, strings.Join(qColumns, ", "))
+	stmt, err := tx.PrepareContext(ctx, qry)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, v := range values {
+		if _, err = stmt.ExecContext(ctx, v...); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// context returns the insert context with the async insert settings
+func (w *BatchWriter) context() context.Context {
+	if !w.config.AsyncInsert {
+		return context.Background()
+	}
+	wait := 0
+	if w.config.WaitForAsyncInsert {
+		wait = 1
+	}
+	return ch.Context(context.Background(), ch.WithSettings(ch.Settings{
+		"async_insert":          1,
+		"wait_for_async_insert": wait,
+	}))
+}
+
+// quoteIdentifier validates and quotes a column name
+func quoteIdentifier(name string) (string, error) {
+	if !identifierRegex.MatchString(name) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+	}
+	return "`" + name + "`", nil
+}
+
+// quoteTable validates and quotes a table name, optionally qualified with the database name
+func quoteTable(name string) (string, error) {
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+	}
+	for i, p := range parts {
+		if !identifierRegex.MatchString(p) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+		}
+		parts[i] = "`" + p + "`"
+	}
+	return strings.Join(parts, "."), nil
+}
@@ -0,0 +1,172 @@
+package clickhouse
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatchWriterConfigValidate(t *testing.T) {
+	cfg := NewBatchWriterConfig()
+	assert.Nil(t, cfg.Validate())
+
+	cfg.BatchSize = 0
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidBatchWriterSize)
+	cfg.BatchSize = 10
+	cfg.FlushInterval = 0
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidFlushInterval)
+	cfg.FlushInterval = 10
+	cfg.MaxRetries = -1
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidMaxRetries)
+	cfg.MaxRetries = 0
+	cfg.RetryDelay = -1
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidRetryDelay)
+	cfg.RetryDelay = 0
+	cfg.FlushTimeout = 0
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidFlushTimeout)
+
+	_, err := NewBatchWriter(nil, nil, nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+}
+
+func TestBatchWriterDeadLetter(t *testing.T) {
+	// client is never connected, so all batches fail
+	client, err := NewClient(&ClientConfig{DSN: "clickhouse://localhost:9000/default"})
+	assert.Nil(t, err)
+
+	type event struct {
+		Name string `db:"name"`
+	}
+	failed := make(map[string]int)
+	mx := sync.Mutex{}
+	cfg := NewBatchWriterConfig()
+	cfg.BatchSize = 3
+	cfg.FlushInterval = 60000
+	cfg.MaxRetries = 1
+	cfg.RetryDelay = 1
+	w, err := NewBatchWriter(client, cfg, func(table string, rows []any, err error) {
+		assert.ErrorIs(t, err, ErrClientNotConnected)
+		mx.Lock()
+		failed[table] += len(rows)
+		mx.Unlock()
+	})
+	assert.Nil(t, err)
+
+	assert.ErrorIs(t, w.Write("", &event{}), ErrMissingTable)
+
+	// below batch size, rows are buffered
+	assert.Nil(t, w.Write("events", &event{Name: "a"}, &event{Name: "b"}))
+	assert.Nil(t, w.Write("metrics", &event{Name: "c"}))
+	assert.Equal(t, 2, w.Buffered("events"))
+
+	// batch size reached; flushed in the background
+	assert.Nil(t, w.Write("events", &event{Name: "d"}))
+	assert.Equal(t, 0, w.Buffered("events"))
+	assert.Eventually(t, func() bool {
+		mx.Lock()
+		defer mx.Unlock()
+		return failed["events"] == 3
+	}, 5*time.Second, 5*time.Millisecond)
+
+	// remaining rows are flushed on close
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.ErrorIs(t, w.Close(ctx), ErrClientNotConnected)
+	mx.Lock()
+	assert.Equal(t, 1, failed["metrics"])
+	mx.Unlock()
+	assert.ErrorIs(t, w.Write("events", &event{}), ErrBatchWriterClosed)
+	assert.Nil(t, w.Close(ctx))
+}
+
+func TestBatchWriterInterval(t *testing.T) {
+	client, err := NewClient(&ClientConfig{DSN: "clickhouse://localhost:9000/default"})
+	assert.Nil(t, err)
+
+	flushed := make(chan int, 1)
+	cfg := NewBatchWriterConfig()
+	cfg.FlushInterval = 10
+	cfg.MaxRetries = 0
+	w, err := NewBatchWriter(client, cfg, func(table string, rows []any, err error) {
+		flushed <- len(rows)
+	})
+	assert.Nil(t, err)
+	defer w.Close(context.Background())
+
+	assert.Nil(t, w.Write("events", map[string]any{"name": "a"}, map[string]any{"name": "b"}))
+	select {
+	case n := <-flushed:
+		assert.Equal(t, 2, n)
+	case <-time.After(5 * time.Second):
+		t.Fatal("rows were not flushed")
+	}
+}
+
+func TestBatchWriterAsync(t *testing.T) {
+	client, err := NewClient(&ClientConfig{DSN: "clickhouse://localhost:9000/default"})
+	assert.Nil(t, err)
+
+	failed := make(chan int, 10)
+	cfg := NewBatchWriterConfig()
+	cfg.BatchSize = 1
+	cfg.FlushInterval = 60000
+	cfg.MaxRetries = 2
+	cfg.RetryDelay = 100
+	w, err := NewBatchWriter(client, cfg, func(table string, rows []any, err error) {
+		failed <- len(rows)
+	})
+	assert.Nil(t, err)
+
+	// retries do not block writers
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, w.Write("events", map[string]any{"name": "a"}))
+	}
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// queued batches are inserted on close
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.ErrorIs(t, w.Close(ctx), ErrClientNotConnected)
+	assert.Len(t, failed, 3)
+}
+
+func TestBatchWriterIdentifiers(t *testing.T) {
+	client, err := NewClient(&ClientConfig{DSN: "clickhouse://localhost:9000/default"})
+	assert.Nil(t, err)
+	cfg := NewBatchWriterConfig()
+	cfg.MaxRetries = 0
+	w, err := NewBatchWriter(client, cfg, nil)
+	assert.Nil(t, err)
+	defer w.Close(context.Background())
+
+	for _, table := range []string{"events; DROP TABLE users", "a.b.c", "db.", "`events`", "events "} {
+		assert.ErrorIs(t, w.Write(table, map[string]any{"name": "a"}), ErrInvalidIdentifier)
+	}
+	assert.Nil(t, w.Write("logs.events", map[string]any{"name": "a"}))
+
+	q, err := quoteTable("logs.events")
+	assert.Nil(t, err)
+	assert.Equal(t, "`logs`.`events`", q)
+	q, err = quoteIdentifier("created_at")
+	assert.Nil(t, err)
+	assert.Equal(t, "`created_at`", q)
+	_, err = quoteIdentifier("name) VALUES (1); --")
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+
+	// invalid columns are not retried
+	cfg = NewBatchWriterConfig()
+	cfg.MaxRetries = 3
+	cfg.RetryDelay = 1000
+	retrying, err := NewBatchWriter(client, cfg, nil)
+	assert.Nil(t, err)
+	defer retrying.Close(context.Background())
+	start := time.Now()
+	assert.ErrorIs(t, retrying.flush("events", []any{map[string]any{"bad column": "a"}}), ErrInvalidIdentifier)
+	assert.Less(t, time.Since(start), time.Second)
+
+	_, err = NewEventWriter(client, "events;", NewEventWriterConfig())
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+}
@@ -14,12 +14,17 @@ const (
 
 type ClientConfig struct {
 	DSN string `json:"dsn"`
+	// QueryLog enables statement logging, see db.QueryLogger; if nil, statements are not logged
+	QueryLog *db.QueryLoggerConfig `json:"queryLog,omitempty"`
 }
 
 func (c ClientConfig) Validate() error {
 	if len(c.DSN) == 0 {
 		return ErrEmptyDSN
 	}
+	if c.QueryLog != nil {
+		return c.QueryLog.Validate()
+	}
 	return nil
 }
 
@@ -30,7 +35,11 @@ func NewClient(config *ClientConfig) (*db.SqlClient, error) {
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
-	return db.NewSqlClient(config.DSN, "clickhouse", nil), nil
+	client := db.NewSqlClient(config.DSN, "clickhouse", nil)
+	if config.QueryLog != nil {
+		client.AddQueryHook(db.NewQueryLogger(config.QueryLog))
+	}
+	return client, nil
 }
 
 func DialectOptions() *goqu.SQLDialectOptions {
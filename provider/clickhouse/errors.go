@@ -0,0 +1,47 @@
+package clickhouse
+
+import (
+	"database/sql"
+	"errors"
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/oddbit-project/blueprint/utils"
+)
+
+// chErrorKinds maps ClickHouse exception codes to generic error categories
+var chErrorKinds = map[int32]utils.Error{
+	60:  utils.ErrNotFound,     // UNKNOWN_TABLE
+	81:  utils.ErrNotFound,     // UNKNOWN_DATABASE
+	57:  utils.ErrConflict,     // TABLE_ALREADY_EXISTS
+	82:  utils.ErrConflict,     // DATABASE_ALREADY_EXISTS
+	192: utils.ErrUnauthorized, // UNKNOWN_USER
+	193: utils.ErrUnauthorized, // WRONG_PASSWORD
+	497: utils.ErrUnauthorized, // ACCESS_DENIED
+	516: utils.ErrUnauthorized, // AUTHENTICATION_FAILED
+	159: utils.ErrTimeout,      // TIMEOUT_EXCEEDED
+	209: utils.ErrTimeout,      // SOCKET_TIMEOUT
+	202: utils.ErrUnavailable,  // TOO_MANY_SIMULTANEOUS_QUERIES
+	210: utils.ErrUnavailable,  // NETWORK_ERROR
+}
+
+// MapError maps clickhouse and database/sql errors to the generic provider error categories (utils.ErrNotFound,
+// utils.ErrConflict, utils.ErrUnauthorized, utils.ErrUnavailable, utils.ErrTimeout)
+// the original error is preserved, and unknown errors are returned unchanged
+func MapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return utils.NewProviderError(utils.ErrNotFound, err)
+	}
+	var chErr *ch.Exception
+	if errors.As(err, &chErr) {
+		if kind, ok := chErrorKinds[chErr.Code]; ok {
+			return utils.NewProviderError(kind, err)
+		}
+		return err
+	}
+	if mapped := utils.MapCommonError(err); mapped != nil {
+		return mapped
+	}
+	return err
+}
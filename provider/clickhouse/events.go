@@ -0,0 +1,175 @@
+package clickhouse
+
+import (
+	"context"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/provider/metrics"
+	"github.com/oddbit-project/blueprint/utils"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	DefaultEventBufferSize = 100000
+
+	DropNewest = "newest" // DropNewest discard new events when the buffer is full
+	DropOldest = "oldest" // DropOldest discard the oldest buffered event to make room for new events
+
+	ErrInvalidBufferSize = utils.Error("bufferSize must be >= 1")
+	ErrInvalidDropPolicy = utils.Error("invalid dropPolicy")
+)
+
+// EventWriterConfig event writer configuration
+type EventWriterConfig struct {
+	BatchWriterConfig
+	BufferSize int    `json:"bufferSize"` // BufferSize max events waiting to be batched
+	DropPolicy string `json:"dropPolicy"` // DropPolicy DropNewest or DropOldest
+}
+
+// EventWriter is a non-blocking writer for high-volume events, such as audit and access logs
+// events are queued in a bounded buffer and inserted in batches by a background goroutine; when the buffer is
+// full, events are dropped according to DropPolicy, so callers are never blocked by analytics writes
+type EventWriter struct {
+	table   string
+	config  *EventWriterConfig
+	queue   chan any
+	writer  *BatchWriter
+	done    chan struct{}
+	closed  bool
+	written atomic.Uint64
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+	mx      sync.RWMutex
+}
+
+func NewEventWriterConfig() *EventWriterConfig {
+	return &EventWriterConfig{
+		BatchWriterConfig: *NewBatchWriterConfig(),
+		BufferSize:        DefaultEventBufferSize,
+		DropPolicy:        DropNewest,
+	}
+}
+
+func (c *EventWriterConfig) Validate() error {
+	if err := c.BatchWriterConfig.Validate(); err != nil {
+		return err
+	}
+	if c.BufferSize < 1 {
+		return ErrInvalidBufferSize
+	}
+	if c.DropPolicy != DropNewest && c.DropPolicy != DropOldest {
+		return ErrInvalidDropPolicy
+	}
+	return nil
+}
+
+// NewEventWriter creates a new EventWriter for table
+//
+// Example usage:
+//
+//	audit, err := clickhouse.NewEventWriter(client, "audit_log", clickhouse.NewEventWriterConfig())
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	prometheus.MustRegister(metrics.NewWriterCollector("audit", audit))
+//	blueprint.RegisterDrain(audit.Close)
+//
+//	// in request handlers
+//	audit.Write(&AuditEntry{Timestamp: time.Now(), User: principal.Subject, Action: "login"})
+func NewEventWriter(client *db.SqlClient, table string, cfg *EventWriterConfig) (*EventWriter, error) {
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if len(table) == 0 {
+		return nil, ErrMissingTable
+	}
+	if _, err := quoteTable(table); err != nil {
+		return nil, err
+	}
+	w := &EventWriter{
+		table:  table,
+		config: cfg,
+		queue:  make(chan any, cfg.BufferSize),
+		done:   make(chan struct{}),
+	}
+	var err error
+	w.writer, err = NewBatchWriter(client, &cfg.BatchWriterConfig, func(table string, rows []any, err error) {
+		w.failed.Add(uint64(len(rows)))
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.writer.onFlush = func(table string, rows int) {
+		w.written.Add(uint64(rows))
+	}
+	go w.run()
+	return w, nil
+}
+
+// Write queues an event without blocking; returns false if the event was dropped
+// with DropOldest, the new event is always queued and the oldest queued event is dropped instead
+func (w *EventWriter) Write(event any) bool {
+	w.mx.RLock()
+	defer w.mx.RUnlock()
+	if w.closed {
+		w.dropped.Add(1)
+		return false
+	}
+	for {
+		select {
+		case w.queue <- event:
+			return true
+		default:
+		}
+		if w.config.DropPolicy == DropNewest {
+			w.dropped.Add(1)
+			return false
+		}
+		select {
+		case <-w.queue:
+			w.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// Close stops accepting events, and writes all queued events; returns ctx.Err() if ctx expires first
+func (w *EventWriter) Close(ctx context.Context) error {
+	w.mx.Lock()
+	if w.closed {
+		w.mx.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.queue)
+	w.mx.Unlock()
+
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return w.writer.Close(ctx)
+}
+
+// Stats returns the writer counters; implements metrics.StatsProvider
+func (w *EventWriter) Stats() metrics.WriterStats {
+	return metrics.WriterStats{
+		Buffered: len(w.queue) + w.writer.Buffered(w.table),
+		Capacity: w.config.BufferSize,
+		Written:  w.written.Load(),
+		Dropped:  w.dropped.Load(),
+		Failed:   w.failed.Load(),
+	}
+}
+
+// run moves queued events to the batch writer; batch write errors are counted as failed events
+func (w *EventWriter) run() {
+	defer close(w.done)
+	for event := range w.queue {
+		_ = w.writer.Write(w.table, event)
+	}
+}
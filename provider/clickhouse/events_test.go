@@ -0,0 +1,89 @@
+package clickhouse
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEventWriterConfigValidate(t *testing.T) {
+	cfg := NewEventWriterConfig()
+	assert.Nil(t, cfg.Validate())
+	cfg.BufferSize = 0
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidBufferSize)
+	cfg.BufferSize = 10
+	cfg.DropPolicy = "random"
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidDropPolicy)
+
+	_, err := NewEventWriter(nil, "events", nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+	_, err = NewEventWriter(nil, "", NewEventWriterConfig())
+	assert.ErrorIs(t, err, ErrMissingTable)
+}
+
+func TestEventWriter(t *testing.T) {
+	// client is never connected, so all batches fail
+	client, err := NewClient(&ClientConfig{DSN: "clickhouse://localhost:9000/default"})
+	assert.Nil(t, err)
+
+	type event struct {
+		Name string `db:"name"`
+	}
+
+	cfg := NewEventWriterConfig()
+	cfg.BufferSize = 2
+	cfg.FlushInterval = 60000
+	// each event is flushed and retried after 50ms; once the batch writer queue is full, the background goroutine
+	// blocks, and events are buffered and then dropped
+	cfg.BatchSize = 1
+	cfg.MaxRetries = 1
+	cfg.RetryDelay = 50
+	w, err := NewEventWriter(client, "events", cfg)
+	assert.Nil(t, err)
+
+	accepted := 0
+	for i := 0; i < 100; i++ {
+		if w.Write(&event{Name: "a"}) {
+			accepted++
+		}
+	}
+	assert.Less(t, accepted, 100)
+	assert.GreaterOrEqual(t, accepted, cfg.BufferSize)
+	assert.Equal(t, uint64(100-accepted), w.Stats().Dropped)
+	assert.Equal(t, 2, w.Stats().Capacity)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// failed batches are reported in stats; batches still queued on close return their error
+	if err = w.Close(ctx); err != nil {
+		assert.ErrorIs(t, err, ErrClientNotConnected)
+	}
+	stats := w.Stats()
+	assert.Equal(t, 0, stats.Buffered)
+	assert.Equal(t, uint64(0), stats.Written)
+	assert.Equal(t, uint64(accepted), stats.Failed)
+
+	// closed writer drops events
+	dropped := stats.Dropped
+	assert.False(t, w.Write(&event{Name: "d"}))
+	assert.Equal(t, dropped+1, w.Stats().Dropped)
+}
+
+func TestEventWriterDropOldest(t *testing.T) {
+	client, err := NewClient(&ClientConfig{DSN: "clickhouse://localhost:9000/default"})
+	assert.Nil(t, err)
+
+	cfg := NewEventWriterConfig()
+	cfg.BufferSize = 1
+	cfg.DropPolicy = DropOldest
+	cfg.MaxRetries = 0
+	w, err := NewEventWriter(client, "events", cfg)
+	assert.Nil(t, err)
+	defer w.Close(context.Background())
+
+	// new events are always accepted
+	for i := 0; i < 100; i++ {
+		assert.True(t, w.Write(map[string]any{"id": i}))
+	}
+}
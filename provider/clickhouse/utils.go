@@ -0,0 +1,31 @@
+package clickhouse
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/utils/version"
+)
+
+const (
+	MinServerVersion = "22.8" // MinServerVersion minimum supported ClickHouse version
+)
+
+// GetServerVersion fetch clickhouse version
+func GetServerVersion(ctx context.Context, db *sqlx.DB) (string, error) {
+	var result string
+	err := db.QueryRowContext(ctx, "SELECT version()").Scan(&result)
+	return result, err
+}
+
+// CheckServerVersion checks if the server version is at least minimum; if minimum is empty, MinServerVersion is used
+// if warnOnly is true, an older version is only logged as a warning
+func CheckServerVersion(ctx context.Context, db *sqlx.DB, minimum string, warnOnly bool) error {
+	current, err := GetServerVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if minimum == "" {
+		minimum = MinServerVersion
+	}
+	return version.Require("clickhouse", current, minimum, warnOnly)
+}
@@ -0,0 +1,251 @@
+package discovery
+
+import (
+	"context"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/rs/zerolog/log"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	LookupSRV = "srv"
+	LookupA   = "a"
+
+	DefaultTTL = 30 // DefaultTTL default cache duration, in seconds
+
+	ErrNilConfig        = utils.Error("Config is nil")
+	ErrMissingName      = utils.Error("missing DNS name")
+	ErrInvalidType      = utils.Error("invalid lookup type")
+	ErrInvalidPort      = utils.Error("A lookups require a port between 1 and 65535")
+	ErrInvalidTTL       = utils.Error("ttl must be >= 1")
+	ErrNoEndpoints      = utils.Error("no endpoints found")
+	ErrNilChangeHandler = utils.Error("change handler is nil")
+)
+
+// Config resolver configuration
+// for SRV lookups, Name is the full record name (e.g. "_kafka._tcp.kafka.default.svc.cluster.local");
+// for A lookups, Name is the host name and Port is used for all resolved addresses
+type Config struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Port int    `json:"port"`
+	TTL  int    `json:"ttl"` // TTL cache duration, in seconds
+}
+
+// Endpoint is a resolved service address
+type Endpoint struct {
+	Host     string
+	Port     uint16
+	Priority uint16
+	Weight   uint16
+}
+
+// ChangeHandler is called when the resolved endpoint list changes
+type ChangeHandler func(endpoints []Endpoint)
+
+// dnsResolver is the subset of net.Resolver used by Resolver
+type dnsResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Resolver resolves a DNS name into a list of endpoints, caching results for TTL seconds
+type Resolver struct {
+	cfg       *Config
+	dns       dnsResolver
+	endpoints []Endpoint
+	expires   time.Time
+	handlers  []ChangeHandler
+	mx        sync.Mutex
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Name: "",
+		Type: LookupSRV,
+		Port: 0,
+		TTL:  DefaultTTL,
+	}
+}
+
+func (c *Config) Validate() error {
+	if len(c.Name) == 0 {
+		return ErrMissingName
+	}
+	switch c.Type {
+	case LookupSRV:
+	case LookupA:
+		if c.Port < 1 || c.Port > 65535 {
+			return ErrInvalidPort
+		}
+	default:
+		return ErrInvalidType
+	}
+	if c.TTL < 1 {
+		return ErrInvalidTTL
+	}
+	return nil
+}
+
+// String returns the endpoint in host:port format
+func (e Endpoint) String() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(int(e.Port)))
+}
+
+// NewResolver creates a new DNS resolver
+//
+// Example usage:
+//
+//	cfg := discovery.NewConfig()
+//	cfg.Name = "_kafka._tcp.kafka.default.svc.cluster.local"
+//	resolver, err := discovery.NewResolver(cfg)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	addrs, err := resolver.Addresses(ctx)
+//	kafkaCfg.Brokers = strings.Join(addrs, ",")
+func NewResolver(cfg *Config) (*Resolver, error) {
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Resolver{
+		cfg:      cfg,
+		dns:      net.DefaultResolver,
+		handlers: make([]ChangeHandler, 0),
+	}, nil
+}
+
+// OnChange registers a handler called whenever a refresh changes the endpoint list
+func (r *Resolver) OnChange(fn ChangeHandler) error {
+	if fn == nil {
+		return ErrNilChangeHandler
+	}
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.handlers = append(r.handlers, fn)
+	return nil
+}
+
+// Endpoints returns the resolved endpoints, performing a lookup if the cache is expired
+// if the lookup fails and a previous result exists, the stale result is returned
+func (r *Resolver) Endpoints(ctx context.Context) ([]Endpoint, error) {
+	r.mx.Lock()
+	if r.endpoints != nil && time.Now().Before(r.expires) {
+		result := slices.Clone(r.endpoints)
+		r.mx.Unlock()
+		return result, nil
+	}
+	r.mx.Unlock()
+	return r.Refresh(ctx)
+}
+
+// Addresses returns the resolved endpoints in host:port format
+func (r *Resolver) Addresses(ctx context.Context) ([]string, error) {
+	endpoints, err := r.Endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, len(endpoints))
+	for i, e := range endpoints {
+		result[i] = e.String()
+	}
+	return result, nil
+}
+
+// Refresh performs a lookup, updates the cache and notifies change handlers if the endpoint list changed
+func (r *Resolver) Refresh(ctx context.Context) ([]Endpoint, error) {
+	endpoints, err := r.lookup(ctx)
+	if err == nil && len(endpoints) == 0 {
+		err = ErrNoEndpoints
+	}
+
+	r.mx.Lock()
+	if err != nil {
+		stale := slices.Clone(r.endpoints)
+		r.mx.Unlock()
+		if stale != nil {
+			log.Warn().Err(err).Str("name", r.cfg.Name).Msg("DNS lookup failed, using cached endpoints")
+			return stale, nil
+		}
+		return nil, err
+	}
+
+	changed := !slices.Equal(r.endpoints, endpoints)
+	r.endpoints = endpoints
+	r.expires = time.Now().Add(time.Duration(r.cfg.TTL) * time.Second)
+	handlers := slices.Clone(r.handlers)
+	r.mx.Unlock()
+
+	if changed {
+		for _, fn := range handlers {
+			fn(slices.Clone(endpoints))
+		}
+	}
+	return slices.Clone(endpoints), nil
+}
+
+// Watch refreshes the endpoint list every TTL seconds in a separate goroutine, until ctx is cancelled
+func (r *Resolver) Watch(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Duration(r.cfg.TTL) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := r.Refresh(ctx); err != nil {
+					log.Error().Err(err).Str("name", r.cfg.Name).Msg("DNS lookup failed")
+				}
+			}
+		}
+	}()
+}
+
+// lookup queries DNS; results are sorted to allow change detection
+func (r *Resolver) lookup(ctx context.Context) ([]Endpoint, error) {
+	result := make([]Endpoint, 0)
+	if r.cfg.Type == LookupSRV {
+		_, records, err := r.dns.LookupSRV(ctx, "", "", r.cfg.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, srv := range records {
+			result = append(result, Endpoint{
+				Host:     strings.TrimSuffix(srv.Target, "."),
+				Port:     srv.Port,
+				Priority: srv.Priority,
+				Weight:   srv.Weight,
+			})
+		}
+	} else {
+		hosts, err := r.dns.LookupHost(ctx, r.cfg.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, host := range hosts {
+			result = append(result, Endpoint{
+				Host: host,
+				Port: uint16(r.cfg.Port),
+			})
+		}
+	}
+	slices.SortFunc(result, func(a, b Endpoint) int {
+		if a.Priority != b.Priority {
+			return int(a.Priority) - int(b.Priority)
+		}
+		if c := strings.Compare(a.Host, b.Host); c != 0 {
+			return c
+		}
+		return int(a.Port) - int(b.Port)
+	})
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+type fakeDNS struct {
+	srv   []*net.SRV
+	hosts []string
+	err   error
+}
+
+func (f *fakeDNS) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	return "", f.srv, f.err
+}
+
+func (f *fakeDNS) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return f.hosts, f.err
+}
+
+func TestConfigValidate(t *testing.T) {
+	_, err := NewResolver(nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+
+	cfg := NewConfig()
+	assert.ErrorIs(t, cfg.Validate(), ErrMissingName)
+	cfg.Name = "_kafka._tcp.example.com"
+	assert.Nil(t, cfg.Validate())
+	cfg.Type = "mx"
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidType)
+	cfg.Type = LookupA
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidPort)
+	cfg.Port = 9092
+	cfg.TTL = 0
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidTTL)
+}
+
+func TestResolverSRV(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Name = "_kafka._tcp.example.com"
+	r, err := NewResolver(cfg)
+	assert.Nil(t, err)
+	dns := &fakeDNS{srv: []*net.SRV{
+		{Target: "b.example.com.", Port: 9092, Priority: 10},
+		{Target: "a.example.com.", Port: 9092, Priority: 10},
+	}}
+	r.dns = dns
+
+	changes := 0
+	assert.ErrorIs(t, r.OnChange(nil), ErrNilChangeHandler)
+	assert.Nil(t, r.OnChange(func(endpoints []Endpoint) { changes++ }))
+
+	addrs, err := r.Addresses(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a.example.com:9092", "b.example.com:9092"}, addrs)
+	assert.Equal(t, 1, changes)
+
+	// cached result
+	dns.srv = dns.srv[:1]
+	addrs, err = r.Addresses(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, addrs, 2)
+
+	// forced refresh notifies changes
+	endpoints, err := r.Refresh(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, endpoints, 1)
+	assert.Equal(t, 2, changes)
+
+	// same result does not notify
+	_, err = r.Refresh(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, changes)
+
+	// failed lookups return stale endpoints
+	dns.err = errors.New("lookup failed")
+	endpoints, err = r.Refresh(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, endpoints, 1)
+}
+
+func TestResolverA(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Name = "db.example.com"
+	cfg.Type = LookupA
+	cfg.Port = 5432
+	r, err := NewResolver(cfg)
+	assert.Nil(t, err)
+	r.dns = &fakeDNS{hosts: []string{"10.0.0.2", "10.0.0.1", "::1"}}
+
+	addrs, err := r.Addresses(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.1:5432", "10.0.0.2:5432", "[::1]:5432"}, addrs)
+
+	r, _ = NewResolver(cfg)
+	r.dns = &fakeDNS{}
+	_, err = r.Endpoints(context.Background())
+	assert.ErrorIs(t, err, ErrNoEndpoints)
+}
@@ -0,0 +1,69 @@
+package httpserver
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"reflect"
+)
+
+// BindHeaders binds request headers to target, a struct pointer with header tags, and validates the result using
+// the binding tags; if binding or validation fails, a 400 response is sent and false is returned
+// for JSON requests, the error detail contains the failed validation rule for each header, e.g.
+// {"X-Client-Version":"required"}
+//
+// Example usage:
+//
+//	type ClientHeaders struct {
+//	  Version  string `header:"X-Client-Version" binding:"required,semver"`
+//	  TenantId int    `header:"X-Tenant-Id" binding:"required,min=1"`
+//	}
+//
+//	router.GET("/orders", func(ctx *gin.Context) {
+//	  headers := &ClientHeaders{}
+//	  if !httpserver.BindHeaders(ctx, headers) {
+//	    return
+//	  }
+//	  ...
+//	})
+func BindHeaders(ctx *gin.Context, target any) bool {
+	err := ctx.ShouldBindHeader(target)
+	if err == nil {
+		return true
+	}
+	if !IsJSONRequest(ctx) {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return false
+	}
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		JSONError(ctx, http.StatusBadRequest, JSONErrorDetail{Message: err.Error()})
+		return false
+	}
+	formError := make(map[string]string, len(validationErrors))
+	for _, fe := range validationErrors {
+		formError[headerName(target, fe.StructField())] = fe.Tag()
+	}
+	JSONError(ctx, http.StatusBadRequest, JSONErrorDetail{
+		Message:   http.StatusText(http.StatusBadRequest),
+		FormError: formError,
+	})
+	return false
+}
+
+// headerName returns the header tag of field in target, or the field name if not found
+func headerName(target any, field string) string {
+	t := reflect.TypeOf(target)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		if f, ok := t.FieldByName(field); ok {
+			if name := f.Tag.Get("header"); len(name) > 0 {
+				return name
+			}
+		}
+	}
+	return field
+}
@@ -0,0 +1,54 @@
+package httpserver
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBindHeaders(t *testing.T) {
+	type clientHeaders struct {
+		Version  string `header:"X-Client-Version" binding:"required,semver"`
+		TenantId int    `header:"X-Tenant-Id" binding:"required,min=1"`
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", func(ctx *gin.Context) {
+		headers := &clientHeaders{}
+		if !BindHeaders(ctx, headers) {
+			return
+		}
+		JSONSuccess(ctx, http.StatusOK, headers)
+	})
+
+	request := func(headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderAccept, ContentTypeJson)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(map[string]string{"X-Client-Version": "1.2.0", "X-Tenant-Id": "12"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"success":true,"data":{"Version":"1.2.0","TenantId":12}}`, w.Body.String())
+
+	w = request(map[string]string{"X-Client-Version": "latest"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"success":false,"error":{"message":"Bad Request","formError":{"X-Client-Version":"semver","X-Tenant-Id":"required"}}}`, w.Body.String())
+
+	// conversion errors
+	w = request(map[string]string{"X-Client-Version": "1.2.0", "X-Tenant-Id": "abc"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// non-JSON requests
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, w.Body.String())
+}
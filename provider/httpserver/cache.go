@@ -0,0 +1,262 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/log/zerolog/logctx"
+	"github.com/oddbit-project/blueprint/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	HeaderCacheControl = "Cache-Control"
+	HeaderVary         = "Vary"
+	HeaderSurrogateKey = "Surrogate-Key" // HeaderSurrogateKey surrogate keys header, space-separated (Fastly, Varnish)
+	HeaderCacheTag     = "Cache-Tag"     // HeaderCacheTag surrogate keys header, comma-separated (Cloudflare, Akamai)
+
+	ContextCachePolicy   = "cachePolicy"   // gin context key for the route CachePolicy
+	ContextSurrogateKeys = "surrogateKeys" // gin context key for the response surrogate keys
+
+	ErrInvalidCacheConfig = utils.Error("invalid cache configuration")
+)
+
+// CacheConfig route caching policy; ages are in seconds
+type CacheConfig struct {
+	Public               bool     `json:"public"`               // Public response may be stored by shared caches
+	Private              bool     `json:"private"`              // Private response may only be stored by the client
+	NoStore              bool     `json:"noStore"`              // NoStore response must not be stored
+	NoCache              bool     `json:"noCache"`              // NoCache response must be revalidated before use
+	MaxAge               int      `json:"maxAge"`               // MaxAge client cache time, in seconds
+	SMaxAge              int      `json:"sMaxAge"`              // SMaxAge shared cache (CDN) time, in seconds; 0 to use MaxAge
+	StaleWhileRevalidate int      `json:"staleWhileRevalidate"` // StaleWhileRevalidate seconds
+	StaleIfError         int      `json:"staleIfError"`         // StaleIfError seconds
+	Immutable            bool     `json:"immutable"`            // Immutable response never changes while fresh
+	Vary                 []string `json:"vary"`                 // Vary request headers that select the response
+	SurrogateKeys        []string `json:"surrogateKeys"`        // SurrogateKeys static surrogate keys of the route
+	SurrogateKeyHeader   string   `json:"surrogateKeyHeader"`   // SurrogateKeyHeader surrogate keys header name
+}
+
+// CachePolicy sets the caching headers of route responses from a CacheConfig; error responses (status >= 400)
+// are sent with "Cache-Control: no-store" and without surrogate keys, so CDNs do not cache failures
+type CachePolicy struct {
+	config       *CacheConfig
+	cacheControl string
+}
+
+// CachePurger purges cached content from a CDN; implementations wrap the CDN API
+type CachePurger interface {
+	// PurgeKeys invalidates all cached responses tagged with any of the surrogate keys
+	PurgeKeys(ctx context.Context, keys ...string) error
+	// PurgeURLs invalidates the cached responses of the URLs
+	PurgeURLs(ctx context.Context, urls ...string) error
+}
+
+// cacheWriter replaces the caching headers of error responses
+type cacheWriter struct {
+	gin.ResponseWriter
+	policy *CachePolicy
+}
+
+func NewCacheConfig() *CacheConfig {
+	return &CacheConfig{
+		Public:               false,
+		Private:              false,
+		NoStore:              false,
+		NoCache:              false,
+		MaxAge:               0,
+		SMaxAge:              0,
+		StaleWhileRevalidate: 0,
+		StaleIfError:         0,
+		Immutable:            false,
+		Vary:                 []string{},
+		SurrogateKeys:        []string{},
+		SurrogateKeyHeader:   HeaderSurrogateKey,
+	}
+}
+
+func (c *CacheConfig) Validate() error {
+	if c.Public && c.Private {
+		return fmt.Errorf("%w: public and private are mutually exclusive", ErrInvalidCacheConfig)
+	}
+	if c.MaxAge < 0 || c.SMaxAge < 0 || c.StaleWhileRevalidate < 0 || c.StaleIfError < 0 {
+		return fmt.Errorf("%w: cache times must not be negative", ErrInvalidCacheConfig)
+	}
+	if c.NoStore && (c.MaxAge > 0 || c.SMaxAge > 0 || c.Public || c.Immutable) {
+		return fmt.Errorf("%w: noStore cannot be combined with cache times", ErrInvalidCacheConfig)
+	}
+	if c.Private && c.SMaxAge > 0 {
+		return fmt.Errorf("%w: sMaxAge cannot be used with private responses", ErrInvalidCacheConfig)
+	}
+	if len(c.SurrogateKeyHeader) == 0 {
+		return fmt.Errorf("%w: surrogateKeyHeader is empty", ErrInvalidCacheConfig)
+	}
+	return nil
+}
+
+// CacheControl returns the Cache-Control header value of the configuration
+func (c *CacheConfig) CacheControl() string {
+	directives := make([]string, 0, 8)
+	if c.NoStore {
+		return "no-store"
+	}
+	if c.Public {
+		directives = append(directives, "public")
+	}
+	if c.Private {
+		directives = append(directives, "private")
+	}
+	if c.NoCache {
+		directives = append(directives, "no-cache")
+	}
+	directives = append(directives, "max-age="+strconv.Itoa(c.MaxAge))
+	if c.SMaxAge > 0 {
+		directives = append(directives, "s-maxage="+strconv.Itoa(c.SMaxAge))
+	}
+	if c.StaleWhileRevalidate > 0 {
+		directives = append(directives, "stale-while-revalidate="+strconv.Itoa(c.StaleWhileRevalidate))
+	}
+	if c.StaleIfError > 0 {
+		directives = append(directives, "stale-if-error="+strconv.Itoa(c.StaleIfError))
+	}
+	if c.Immutable {
+		directives = append(directives, "immutable")
+	}
+	return strings.Join(directives, ", ")
+}
+
+// NewCachePolicy creates a new CachePolicy
+//
+// Example usage:
+//
+//	cfg := httpserver.NewCacheConfig()
+//	cfg.Public = true
+//	cfg.MaxAge = 60
+//	cfg.SMaxAge = 3600
+//	cfg.StaleWhileRevalidate = 30
+//	cfg.SurrogateKeys = []string{"products"}
+//	productCache, err := httpserver.NewCachePolicy(cfg)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	router.GET("/products/:id", productCache.Middleware(), func(c *gin.Context) {
+//	  httpserver.AddSurrogateKeys(c, "product:"+c.Param("id"))
+//	  ...
+//	})
+func NewCachePolicy(cfg *CacheConfig) (*CachePolicy, error) {
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &CachePolicy{
+		config:       cfg,
+		cacheControl: cfg.CacheControl(),
+	}, nil
+}
+
+// Middleware returns the caching middleware; handlers may replace the Cache-Control header of individual responses
+func (p *CachePolicy) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		header := ctx.Writer.Header()
+		header.Set(HeaderCacheControl, p.cacheControl)
+		for _, v := range p.config.Vary {
+			header.Add(HeaderVary, v)
+		}
+		ctx.Set(ContextCachePolicy, p)
+		if len(p.config.SurrogateKeys) > 0 {
+			AddSurrogateKeys(ctx, p.config.SurrogateKeys...)
+		}
+		writer := &cacheWriter{ResponseWriter: ctx.Writer, policy: p}
+		ctx.Writer = writer
+		ctx.Next()
+		// status-only responses are flushed by the engine without calling the wrapper
+		writer.apply()
+	}
+}
+
+// AddSurrogateKeys adds surrogate keys to the response, so the CDN can purge it by key (see CachePurger); keys are
+// sent in the surrogate key header of the route CachePolicy, and are available to PurgeSurrogateKeys()
+func AddSurrogateKeys(ctx *gin.Context, keys ...string) {
+	current := ctx.GetStringSlice(ContextSurrogateKeys)
+	current = append(current, keys...)
+	ctx.Set(ContextSurrogateKeys, current)
+
+	if v, ok := ctx.Get(ContextCachePolicy); ok {
+		p := v.(*CachePolicy)
+		sep := ","
+		if p.config.SurrogateKeyHeader == HeaderSurrogateKey {
+			sep = " "
+		}
+		ctx.Writer.Header().Set(p.config.SurrogateKeyHeader, strings.Join(current, sep))
+	}
+}
+
+// PurgeSurrogateKeys middleware that purges the surrogate keys added by handlers of successful unsafe requests
+// (POST, PUT, PATCH, DELETE), after the handler executes; purge errors are logged, and do not affect the response
+//
+// Example usage:
+//
+//	router.PUT("/products/:id", httpserver.PurgeSurrogateKeys(cdn), func(c *gin.Context) {
+//	  ...
+//	  httpserver.AddSurrogateKeys(c, "product:"+c.Param("id"), "products")
+//	  httpserver.JSONSuccess(c, http.StatusOK, product)
+//	})
+func PurgeSurrogateKeys(purger CachePurger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+		switch ctx.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return
+		}
+		if ctx.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		keys := ctx.GetStringSlice(ContextSurrogateKeys)
+		if len(keys) == 0 {
+			return
+		}
+		if err := purger.PurgeKeys(ctx.Request.Context(), keys...); err != nil {
+			logctx.FromContext(ctx.Request.Context()).Error().Err(err).Strs("keys", keys).Msg("cache purge failed")
+		}
+	}
+}
+
+// apply replaces the caching headers of error responses, before the headers are sent; it may be called several
+// times, as the status may change until the headers are sent
+func (w *cacheWriter) apply() {
+	if w.ResponseWriter.Written() {
+		return
+	}
+	if w.ResponseWriter.Status() < http.StatusBadRequest {
+		return
+	}
+	header := w.ResponseWriter.Header()
+	if header.Get(HeaderCacheControl) == w.policy.cacheControl {
+		header.Set(HeaderCacheControl, "no-store")
+	}
+	header.Del(w.policy.config.SurrogateKeyHeader)
+}
+
+func (w *cacheWriter) WriteHeader(code int) {
+	w.ResponseWriter.WriteHeader(code)
+	w.apply()
+}
+
+func (w *cacheWriter) WriteHeaderNow() {
+	w.apply()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *cacheWriter) Write(data []byte) (int, error) {
+	w.apply()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *cacheWriter) WriteString(s string) (int, error) {
+	w.apply()
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,162 @@
+package httpserver
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testPurger struct {
+	keys []string
+}
+
+func (p *testPurger) PurgeKeys(ctx context.Context, keys ...string) error {
+	p.keys = append(p.keys, keys...)
+	return nil
+}
+
+func (p *testPurger) PurgeURLs(ctx context.Context, urls ...string) error {
+	return nil
+}
+
+func TestCacheConfigValidate(t *testing.T) {
+	cfg := NewCacheConfig()
+	assert.Nil(t, cfg.Validate())
+	assert.Equal(t, "max-age=0", cfg.CacheControl())
+
+	cfg.Public = true
+	cfg.Private = true
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidCacheConfig)
+
+	cfg = NewCacheConfig()
+	cfg.NoStore = true
+	cfg.MaxAge = 10
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidCacheConfig)
+	cfg.MaxAge = 0
+	assert.Nil(t, cfg.Validate())
+	assert.Equal(t, "no-store", cfg.CacheControl())
+
+	cfg = NewCacheConfig()
+	cfg.Private = true
+	cfg.SMaxAge = 10
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidCacheConfig)
+
+	cfg = NewCacheConfig()
+	cfg.StaleIfError = -1
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidCacheConfig)
+
+	cfg = NewCacheConfig()
+	cfg.Public = true
+	cfg.MaxAge = 60
+	cfg.SMaxAge = 3600
+	cfg.StaleWhileRevalidate = 30
+	cfg.StaleIfError = 600
+	cfg.Immutable = true
+	assert.Equal(t, "public, max-age=60, s-maxage=3600, stale-while-revalidate=30, stale-if-error=600, immutable", cfg.CacheControl())
+
+	_, err := NewCachePolicy(nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+}
+
+func TestCachePolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := NewCacheConfig()
+	cfg.Public = true
+	cfg.MaxAge = 60
+	cfg.SMaxAge = 3600
+	cfg.Vary = []string{HeaderAccept}
+	cfg.SurrogateKeys = []string{"products"}
+	policy, err := NewCachePolicy(cfg)
+	assert.Nil(t, err)
+
+	cfg = NewCacheConfig()
+	cfg.MaxAge = 10
+	cfg.SurrogateKeyHeader = HeaderCacheTag
+	tagged, err := NewCachePolicy(cfg)
+	assert.Nil(t, err)
+
+	router := gin.New()
+	router.GET("/products/:id", policy.Middleware(), func(ctx *gin.Context) {
+		if ctx.Param("id") == "0" {
+			JSONError(ctx, http.StatusNotFound, JSONErrorDetail{Message: "not found"})
+			return
+		}
+		AddSurrogateKeys(ctx, "product:"+ctx.Param("id"))
+		ctx.String(http.StatusOK, "ok")
+	})
+	router.GET("/private", policy.Middleware(), func(ctx *gin.Context) {
+		ctx.Header(HeaderCacheControl, "private, max-age=5")
+		ctx.String(http.StatusOK, "ok")
+	})
+	router.GET("/abort", policy.Middleware(), func(ctx *gin.Context) {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+	})
+	router.GET("/status", policy.Middleware(), func(ctx *gin.Context) {
+		ctx.Status(http.StatusServiceUnavailable)
+	})
+	router.GET("/tags", tagged.Middleware(), func(ctx *gin.Context) {
+		AddSurrogateKeys(ctx, "a", "b")
+		ctx.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products/12", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=60, s-maxage=3600", w.Header().Get(HeaderCacheControl))
+	assert.Equal(t, HeaderAccept, w.Header().Get(HeaderVary))
+	assert.Equal(t, "products product:12", w.Header().Get(HeaderSurrogateKey))
+
+	// error responses are not cached
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products/0", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get(HeaderCacheControl))
+	assert.Empty(t, w.Header().Get(HeaderSurrogateKey))
+
+	// status-only error responses are not cached
+	for _, path := range []string{"/abort", "/status"} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.GreaterOrEqual(t, w.Code, http.StatusInternalServerError, path)
+		assert.Equal(t, "no-store", w.Header().Get(HeaderCacheControl), path)
+		assert.Empty(t, w.Header().Get(HeaderSurrogateKey), path)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/private", nil))
+	assert.Equal(t, "private, max-age=5", w.Header().Get(HeaderCacheControl))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tags", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "max-age=10", w.Header().Get(HeaderCacheControl))
+	assert.Equal(t, "a,b", w.Header().Get(HeaderCacheTag))
+}
+
+func TestPurgeSurrogateKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	purger := &testPurger{}
+	router := gin.New()
+	handler := func(ctx *gin.Context) {
+		AddSurrogateKeys(ctx, "product:"+ctx.Param("id"))
+		if ctx.Param("id") == "0" {
+			JSONError(ctx, http.StatusNotFound, JSONErrorDetail{Message: "not found"})
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	}
+	router.PUT("/products/:id", PurgeSurrogateKeys(purger), handler)
+	router.GET("/products/:id", PurgeSurrogateKeys(purger), handler)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPut, "/products/12", nil),
+		httptest.NewRequest(http.MethodPut, "/products/0", nil),
+		httptest.NewRequest(http.MethodGet, "/products/13", nil),
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Equal(t, []string{"product:12"}, purger.keys)
+}
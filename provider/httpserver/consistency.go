@@ -0,0 +1,46 @@
+package httpserver
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/db"
+	"net/http"
+)
+
+const (
+	ConsistencyCookie          = "consistency_token" // ConsistencyCookie cookie name of the consistency token
+	DefaultConsistencyTokenTTL = 60                  // seconds
+)
+
+// ReadYourWrites middleware that adds the consistency token of a request to the request context, so reads after
+// writes are not routed to stale replicas (see db.WithConsistencyToken()); the token is read from the
+// X-Consistency-Token header, or from the consistency cookie set by SetConsistencyToken()
+// tokens are client-supplied and passed as-is; they must be verified by the consumer (see pgsql.Cluster.ReplicaFor())
+//
+// Example usage:
+//
+//	router.Use(httpserver.ReadYourWrites())
+//	router.GET("/orders/:id", func(c *gin.Context) {
+//	  repo := cluster.NewRepository(c.Request.Context(), "orders")
+//	  ...
+//	})
+func ReadYourWrites() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token := ctx.GetHeader(HeaderConsistencyToken)
+		if len(token) == 0 {
+			token, _ = ctx.Cookie(ConsistencyCookie)
+		}
+		if len(token) > 0 {
+			ctx.Request = ctx.Request.WithContext(db.WithConsistencyToken(ctx.Request.Context(), token))
+		}
+		ctx.Next()
+	}
+}
+
+// SetConsistencyToken sends a consistency token to the client after a write, in the X-Consistency-Token header
+// and in a cookie valid for DefaultConsistencyTokenTTL seconds, so subsequent requests, such as the redirect
+// after a form submission, read the write
+func SetConsistencyToken(ctx *gin.Context, token string) {
+	ctx.Header(HeaderConsistencyToken, token)
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(ConsistencyCookie, token, DefaultConsistencyTokenTTL, "/", "", ctx.Request.TLS != nil, true)
+}
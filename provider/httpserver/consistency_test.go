@@ -0,0 +1,51 @@
+package httpserver
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ReadYourWrites())
+	router.POST("/", func(ctx *gin.Context) {
+		SetConsistencyToken(ctx, "0/16B3748")
+		ctx.Status(http.StatusNoContent)
+	})
+	router.GET("/", func(ctx *gin.Context) {
+		token, _ := db.ConsistencyToken(ctx.Request.Context())
+		ctx.String(http.StatusOK, token)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, "0/16B3748", w.Header().Get(HeaderConsistencyToken))
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, ConsistencyCookie, cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+
+	// cookie
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "0/16B3748", w.Body.String())
+
+	// header
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderConsistencyToken, "0/16B3750")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "0/16B3750", w.Body.String())
+
+	// no token
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "", w.Body.String())
+}
@@ -10,10 +10,23 @@ const (
 
 	HeaderAccept      = "Accept"
 	HeaderContentType = "Content-Type"
+	HeaderRequestId   = "X-Request-Id"
+	HeaderIncidentId  = "X-Incident-Id"
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+	HeaderLink        = "Link"
+
+	HeaderConsistencyToken = "X-Consistency-Token"
+
+	ContextRequestId = "requestId" // gin context key for the request id
 
 	ContentTypeHtml   = "text/html"
 	ContentTypeJson   = "application/json"
 	ContentTypeBinary = "application/octet-stream"
 
-	ErrNilConfig = utils.Error("Config is nil")
+	ErrNilConfig      = utils.Error("Config is nil")
+	ErrInvalidPort    = utils.Error("invalid port")
+	ErrInvalidTimeout = utils.Error("invalid timeout")
+
+	ErrInvalidTrustedProxy = utils.Error("invalid trusted proxy address or network")
 )
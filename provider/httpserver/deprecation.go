@@ -0,0 +1,215 @@
+package httpserver
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/log/zerolog/logctx"
+	"github.com/oddbit-project/blueprint/provider/metrics"
+	"github.com/oddbit-project/blueprint/utils"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultDeprecationLogInterval = 3600 // seconds
+
+	// maxDeprecationCallers max number of callers tracked for log rate limiting
+	maxDeprecationCallers = 10000
+
+	ErrInvalidDeprecationDate = utils.Error("invalid deprecation date")
+	ErrMissingSunset          = utils.Error("disableAfterSunset requires a sunset date")
+	ErrInvalidLogInterval     = utils.Error("logInterval must be >= 0")
+)
+
+// DeprecationConfig route deprecation configuration; dates are RFC3339 timestamps or YYYY-MM-DD dates (UTC)
+type DeprecationConfig struct {
+	Since              string `json:"since"`              // Since date the route was deprecated; optional
+	Sunset             string `json:"sunset"`             // Sunset date the route will be removed; optional
+	Link               string `json:"link"`               // Link URL of the migration documentation; optional
+	DisableAfterSunset bool   `json:"disableAfterSunset"` // DisableAfterSunset respond with 410 Gone after Sunset
+	LogInterval        int    `json:"logInterval"`        // LogInterval min seconds between log entries of a caller; 0 logs every request
+}
+
+// Deprecation marks routes as deprecated: responses include the Deprecation, Sunset and Link headers, and usage
+// is logged with the caller identity, so remaining clients can be identified before the route is removed
+type Deprecation struct {
+	since   time.Time
+	sunset  time.Time
+	config  *DeprecationConfig
+	metrics *metrics.DeprecationMetrics
+	callers map[string]*deprecationCaller
+	mx      sync.Mutex
+}
+
+// deprecationCaller log state of a caller
+type deprecationCaller struct {
+	logged time.Time // logged time of the last log entry
+	calls  int       // calls requests since the last log entry
+}
+
+func NewDeprecationConfig() *DeprecationConfig {
+	return &DeprecationConfig{
+		Since:              "",
+		Sunset:             "",
+		Link:               "",
+		DisableAfterSunset: false,
+		LogInterval:        DefaultDeprecationLogInterval,
+	}
+}
+
+func (c *DeprecationConfig) Validate() error {
+	if _, err := parseDeprecationDate(c.Since); err != nil {
+		return err
+	}
+	if _, err := parseDeprecationDate(c.Sunset); err != nil {
+		return err
+	}
+	if c.DisableAfterSunset && len(c.Sunset) == 0 {
+		return ErrMissingSunset
+	}
+	if c.LogInterval < 0 {
+		return ErrInvalidLogInterval
+	}
+	return nil
+}
+
+// NewDeprecation creates a new Deprecation
+//
+// Example usage:
+//
+//	cfg := httpserver.NewDeprecationConfig()
+//	cfg.Sunset = "2026-06-30"
+//	cfg.Link = "https://docs.example.com/migration/v2"
+//	cfg.DisableAfterSunset = true
+//	deprecation, err := httpserver.NewDeprecation(cfg)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	router.GET("/v1/users", deprecation.Middleware(), listUsersV1)
+func NewDeprecation(cfg *DeprecationConfig) (*Deprecation, error) {
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	// dates were validated
+	since, _ := parseDeprecationDate(cfg.Since)
+	sunset, _ := parseDeprecationDate(cfg.Sunset)
+	return &Deprecation{
+		since:   since,
+		sunset:  sunset,
+		config:  cfg,
+		callers: make(map[string]*deprecationCaller),
+	}, nil
+}
+
+// UseMetrics enables usage metrics for deprecated routes
+func (d *Deprecation) UseMetrics(m *metrics.DeprecationMetrics) {
+	d.metrics = m
+}
+
+// Middleware returns the deprecation middleware; requests after the sunset date are rejected with 410 Gone if
+// DisableAfterSunset is set
+func (d *Deprecation) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		disabled := d.config.DisableAfterSunset && time.Now().After(d.sunset)
+
+		subject := ""
+		if p, ok := GetPrincipal(ctx.Request.Context()); ok {
+			subject = p.Subject
+		}
+		caller := ctx.Request.Method + " " + route + "\x00" + subject + "\x00" + ctx.ClientIP() + "\x00" + ctx.Request.UserAgent()
+		if calls := d.shouldLog(caller, time.Now()); calls > 0 {
+			event := logctx.FromContext(ctx.Request.Context()).Warn().
+				Str("method", ctx.Request.Method).
+				Str("route", route).
+				Str("ip", ctx.ClientIP()).
+				Str("userAgent", ctx.Request.UserAgent()).
+				Bool("disabled", disabled).
+				Int("calls", calls)
+			if len(subject) > 0 {
+				event = event.Str(logctx.FieldSubject, subject)
+			}
+			event.Msg("deprecated route called")
+		}
+		if d.metrics != nil {
+			d.metrics.Inc(ctx.Request.Method, route)
+		}
+
+		if d.since.IsZero() {
+			ctx.Header(HeaderDeprecation, "true")
+		} else {
+			ctx.Header(HeaderDeprecation, "@"+strconv.FormatInt(d.since.Unix(), 10))
+		}
+		if !d.sunset.IsZero() {
+			ctx.Header(HeaderSunset, d.sunset.UTC().Format(http.TimeFormat))
+		}
+		if len(d.config.Link) > 0 {
+			ctx.Header(HeaderLink, fmt.Sprintf("<%s>; rel=\"deprecation\"", d.config.Link))
+		}
+		if disabled {
+			HttpError410(ctx)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// shouldLog registers a request of caller, and returns the number of requests of caller since its last log entry
+// if a new entry should be logged, or 0 otherwise; callers are logged at most once per LogInterval
+func (d *Deprecation) shouldLog(caller string, now time.Time) int {
+	interval := time.Duration(d.config.LogInterval) * time.Second
+	if interval == 0 {
+		return 1
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	c, ok := d.callers[caller]
+	if !ok {
+		if len(d.callers) >= maxDeprecationCallers {
+			d.prune(now.Add(-interval))
+		}
+		c = &deprecationCaller{}
+		d.callers[caller] = c
+	}
+	c.calls++
+	if ok && now.Sub(c.logged) < interval {
+		return 0
+	}
+	calls := c.calls
+	c.logged = now
+	c.calls = 0
+	return calls
+}
+
+// prune removes callers last logged before expired; if no caller expired, all callers are removed, so memory is
+// bounded when the route is called by many distinct clients
+func (d *Deprecation) prune(expired time.Time) {
+	for k, c := range d.callers {
+		if c.logged.Before(expired) {
+			delete(d.callers, k)
+		}
+	}
+	if len(d.callers) >= maxDeprecationCallers {
+		clear(d.callers)
+	}
+}
+
+// parseDeprecationDate parses a RFC3339 timestamp or a YYYY-MM-DD date; an empty value returns the zero time
+func parseDeprecationDate(value string) (time.Time, error) {
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidDeprecationDate, value)
+	}
+	return t, nil
+}
@@ -0,0 +1,109 @@
+package httpserver
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/provider/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeprecationConfigValidate(t *testing.T) {
+	cfg := NewDeprecationConfig()
+	assert.Nil(t, cfg.Validate())
+	cfg.Since = "yesterday"
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidDeprecationDate)
+	cfg.Since = "2025-01-01"
+	cfg.DisableAfterSunset = true
+	assert.ErrorIs(t, cfg.Validate(), ErrMissingSunset)
+	cfg.Sunset = "2025-06-30T00:00:00Z"
+	assert.Nil(t, cfg.Validate())
+	cfg.LogInterval = -1
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidLogInterval)
+
+	_, err := NewDeprecation(nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+}
+
+func TestDeprecation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reg := prometheus.NewRegistry()
+	dm, err := metrics.NewDeprecationMetrics(reg)
+	assert.Nil(t, err)
+
+	cfg := NewDeprecationConfig()
+	cfg.Since = "2025-01-01"
+	cfg.Sunset = "2999-12-31"
+	cfg.Link = "https://example.com/migration"
+	deprecation, err := NewDeprecation(cfg)
+	assert.Nil(t, err)
+	deprecation.UseMetrics(dm)
+
+	cfg = NewDeprecationConfig()
+	cfg.Sunset = "2020-01-01"
+	cfg.DisableAfterSunset = true
+	removed, err := NewDeprecation(cfg)
+	assert.Nil(t, err)
+
+	router := gin.New()
+	router.GET("/v1/users/:id", deprecation.Middleware(), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+	router.GET("/v0/users", removed.Middleware(), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/users/12", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "@1735689600", w.Header().Get(HeaderDeprecation))
+	assert.Equal(t, "Tue, 31 Dec 2999 00:00:00 GMT", w.Header().Get(HeaderSunset))
+	assert.Equal(t, `<https://example.com/migration>; rel="deprecation"`, w.Header().Get(HeaderLink))
+	count, err := testutil.GatherAndCount(reg, "http_deprecated_requests_total")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	// disabled after sunset
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v0/users", nil)
+	req.Header.Set(HeaderAccept, ContentTypeJson)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Equal(t, "true", w.Header().Get(HeaderDeprecation))
+	assert.Contains(t, w.Body.String(), http.StatusText(http.StatusGone))
+}
+
+func TestDeprecationLogInterval(t *testing.T) {
+	cfg := NewDeprecationConfig()
+	cfg.LogInterval = 60
+	d, err := NewDeprecation(cfg)
+	assert.Nil(t, err)
+
+	now := time.Now()
+	assert.Equal(t, 1, d.shouldLog("a", now))
+	assert.Equal(t, 0, d.shouldLog("a", now.Add(time.Second)))
+	assert.Equal(t, 0, d.shouldLog("a", now.Add(59*time.Second)))
+	// other callers are logged independently
+	assert.Equal(t, 1, d.shouldLog("b", now.Add(time.Second)))
+	// the next entry reports the calls since the last entry
+	assert.Equal(t, 3, d.shouldLog("a", now.Add(61*time.Second)))
+	assert.Equal(t, 0, d.shouldLog("a", now.Add(62*time.Second)))
+
+	// tracked callers are bounded
+	for i := 0; i < maxDeprecationCallers+10; i++ {
+		d.shouldLog(string(rune(i)), now)
+	}
+	assert.LessOrEqual(t, len(d.callers), maxDeprecationCallers)
+
+	// every request is logged with a zero interval
+	cfg.LogInterval = 0
+	d, err = NewDeprecation(cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, d.shouldLog("a", now))
+	assert.Equal(t, 1, d.shouldLog("a", now))
+	assert.Len(t, d.callers, 0)
+}
@@ -0,0 +1,234 @@
+package ipfilter
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/config"
+	"github.com/oddbit-project/blueprint/log/zerolog/logctx"
+	"github.com/oddbit-project/blueprint/provider/httpserver"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/rs/zerolog/log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ErrNilConfig       = utils.Error("Config is nil")
+	ErrInvalidNetwork  = utils.Error("invalid IP address or CIDR network")
+	ErrNilLoader       = utils.Error("config loader is nil")
+	ErrInvalidInterval = utils.Error("reload interval must be greater than zero")
+
+	ReasonDenied     = "denied"      // ReasonDenied address matches the deny list
+	ReasonNotAllowed = "not allowed" // ReasonNotAllowed address does not match the allow list
+	ReasonInvalidIP  = "invalid ip"  // ReasonInvalidIP client address could not be parsed
+)
+
+// Config network policy configuration; entries are IP addresses or CIDR networks
+// the deny list has precedence; if the allow list is empty, all addresses not denied are allowed
+type Config struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// Loader returns the current policy configuration, e.g. read from a config provider or a kv store
+type Loader func(ctx context.Context) (*Config, error)
+
+// rules compiled policy
+type rules struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// Filter is a CIDR-based allow/deny policy for incoming requests; the policy can be replaced at runtime
+type Filter struct {
+	name  string
+	rules *rules
+	mx    sync.RWMutex
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Allow: make([]string, 0),
+		Deny:  make([]string, 0),
+	}
+}
+
+func (c *Config) Validate() error {
+	_, err := c.compile()
+	return err
+}
+
+func (c *Config) compile() (*rules, error) {
+	allow, err := parseNetworks(c.Allow)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parseNetworks(c.Deny)
+	if err != nil {
+		return nil, err
+	}
+	return &rules{allow: allow, deny: deny}, nil
+}
+
+// NewFilter creates a new filter; name identifies the policy in audit logs
+//
+// Example usage:
+//
+//	cfg := ipfilter.NewConfig()
+//	cfg.Allow = []string{"10.8.0.0/16", "192.168.1.10"}
+//	filter, err := ipfilter.NewFilter("admin", cfg)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	admin := router.Group("/admin", filter.Middleware())
+func NewFilter(name string, cfg *Config) (*Filter, error) {
+	f := &Filter{name: name}
+	if err := f.Update(cfg); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Update replaces the policy; the current policy is kept if cfg is invalid
+func (f *Filter) Update(cfg *Config) error {
+	if cfg == nil {
+		return ErrNilConfig
+	}
+	r, err := cfg.compile()
+	if err != nil {
+		return err
+	}
+	f.mx.Lock()
+	f.rules = r
+	f.mx.Unlock()
+	return nil
+}
+
+// Reload reads the policy from key of a config provider, and replaces the current policy
+func (f *Filter) Reload(cfg config.ConfigInterface, key string) error {
+	c := NewConfig()
+	if err := cfg.GetKey(key, c); err != nil {
+		return err
+	}
+	return f.Update(c)
+}
+
+// Watch calls loader periodically in a separate goroutine and updates the policy, until ctx is cancelled
+// loader errors and invalid configurations are logged, and the current policy is kept
+func (f *Filter) Watch(ctx context.Context, interval time.Duration, loader Loader) error {
+	if loader == nil {
+		return ErrNilLoader
+	}
+	if interval <= 0 {
+		return ErrInvalidInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				cfg, err := loader(ctx)
+				if err == nil {
+					err = f.Update(cfg)
+				}
+				if err != nil {
+					log.Error().Err(err).Str("policy", f.name).Msg("failed to reload network policy")
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Allowed returns true if ip is allowed by the policy
+func (f *Filter) Allowed(ip net.IP) bool {
+	return f.check(ip) == ""
+}
+
+// check returns the rejection reason for ip, or an empty string if ip is allowed
+func (f *Filter) check(ip net.IP) string {
+	if ip == nil {
+		return ReasonInvalidIP
+	}
+	f.mx.RLock()
+	r := f.rules
+	f.mx.RUnlock()
+	if contains(r.deny, ip) {
+		return ReasonDenied
+	}
+	if len(r.allow) > 0 && !contains(r.allow, ip) {
+		return ReasonNotAllowed
+	}
+	return ""
+}
+
+// Middleware returns a gin middleware that rejects requests from addresses not allowed by the policy with 403
+// the client address is obtained with gin's ClientIP(), so the router trusted proxies configuration applies;
+// routers created with httpserver.NewRouter() trust no proxies; when using gin.New() directly, call
+// SetTrustedProxies() on the engine, otherwise clients can spoof their address with X-Forwarded-For;
+// rejections are logged with the request logger
+func (f *Filter) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		clientIP := ctx.ClientIP()
+		reason := f.check(net.ParseIP(clientIP))
+		if reason == "" {
+			ctx.Next()
+			return
+		}
+		logctx.FromContext(ctx.Request.Context()).Warn().
+			Str("policy", f.name).
+			Str("ip", clientIP).
+			Str("method", ctx.Request.Method).
+			Str("path", ctx.Request.URL.Path).
+			Str("reason", reason).
+			Msg("request rejected by network policy")
+
+		if httpserver.IsJSONRequest(ctx) {
+			httpserver.JSONError(ctx, http.StatusForbidden, httpserver.JSONErrorDetail{Message: http.StatusText(http.StatusForbidden)})
+			return
+		}
+		ctx.AbortWithStatus(http.StatusForbidden)
+	}
+}
+
+// parseNetworks parses IP addresses and CIDR networks; addresses are converted to single-host networks
+func parseNetworks(entries []string) ([]*net.IPNet, error) {
+	result := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, ErrInvalidNetwork
+			}
+			result = append(result, network)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, ErrInvalidNetwork
+		}
+		bits := 128
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+			bits = 32
+		}
+		result = append(result, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return result, nil
+}
+
+func contains(networks []*net.IPNet, ip net.IP) bool {
+	for _, n := range networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
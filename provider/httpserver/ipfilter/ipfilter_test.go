@@ -0,0 +1,138 @@
+package ipfilter
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/config/provider"
+	"github.com/oddbit-project/blueprint/provider/httpserver"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConfig(t *testing.T) {
+	_, err := NewFilter("test", nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+
+	cfg := NewConfig()
+	assert.Nil(t, cfg.Validate())
+	cfg.Allow = []string{"10.0.0.0/8", "192.168.1.10", "::1"}
+	assert.Nil(t, cfg.Validate())
+	cfg.Deny = []string{"10.0.0.0/33"}
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidNetwork)
+	cfg.Deny = []string{"host.example.com"}
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidNetwork)
+}
+
+func TestAllowed(t *testing.T) {
+	// empty policy allows everything
+	f, err := NewFilter("test", NewConfig())
+	assert.Nil(t, err)
+	assert.True(t, f.Allowed(net.ParseIP("8.8.8.8")))
+	assert.False(t, f.Allowed(nil))
+
+	cfg := NewConfig()
+	cfg.Allow = []string{"10.0.0.0/8", "192.168.1.10", "::1"}
+	cfg.Deny = []string{"10.0.0.13"}
+	assert.Nil(t, f.Update(cfg))
+	assert.True(t, f.Allowed(net.ParseIP("10.1.2.3")))
+	assert.True(t, f.Allowed(net.ParseIP("192.168.1.10")))
+	assert.True(t, f.Allowed(net.ParseIP("::1")))
+	assert.False(t, f.Allowed(net.ParseIP("10.0.0.13")))
+	assert.False(t, f.Allowed(net.ParseIP("192.168.1.11")))
+
+	// invalid update keeps current policy
+	assert.ErrorIs(t, f.Update(&Config{Allow: []string{"invalid"}}), ErrInvalidNetwork)
+	assert.False(t, f.Allowed(net.ParseIP("192.168.1.11")))
+
+	// deny-only policy
+	assert.Nil(t, f.Update(&Config{Deny: []string{"203.0.113.0/24"}}))
+	assert.True(t, f.Allowed(net.ParseIP("192.168.1.11")))
+	assert.False(t, f.Allowed(net.ParseIP("203.0.113.7")))
+}
+
+func TestReload(t *testing.T) {
+	f, err := NewFilter("test", NewConfig())
+	assert.Nil(t, err)
+
+	cfg, err := provider.NewJsonProvider([]byte(`{"admin": {"allow": ["10.8.0.0/16"]}}`))
+	assert.Nil(t, err)
+	assert.Nil(t, f.Reload(cfg, "admin"))
+	assert.False(t, f.Allowed(net.ParseIP("10.9.0.1")))
+	assert.True(t, f.Allowed(net.ParseIP("10.8.0.1")))
+
+	assert.ErrorIs(t, f.Watch(context.Background(), time.Second, nil), ErrNilLoader)
+	assert.ErrorIs(t, f.Watch(context.Background(), 0, func(ctx context.Context) (*Config, error) { return nil, nil }), ErrInvalidInterval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.Nil(t, f.Watch(ctx, 10*time.Millisecond, func(ctx context.Context) (*Config, error) {
+		return &Config{Allow: []string{"10.9.0.0/16"}}, nil
+	}))
+	assert.Eventually(t, func() bool {
+		return f.Allowed(net.ParseIP("10.9.0.1"))
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f, err := NewFilter("admin", &Config{Allow: []string{"10.8.0.0/16"}})
+	assert.Nil(t, err)
+
+	router := gin.New()
+	admin := router.Group("/admin", f.Middleware())
+	admin.GET("/status", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+	router.GET("/public", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+
+	request := func(path string, remote string, json bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remote
+		if json {
+			req.Header.Set("Accept", "application/json")
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request("/admin/status", "10.8.1.1:5000", false).Code)
+	assert.Equal(t, http.StatusForbidden, request("/admin/status", "172.16.0.1:5000", false).Code)
+	w := request("/admin/status", "172.16.0.1:5000", true)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `"success":false`)
+	assert.Equal(t, http.StatusOK, request("/public", "172.16.0.1:5000", false).Code)
+}
+
+func TestMiddlewareSpoofedHeader(t *testing.T) {
+	f, err := NewFilter("admin", &Config{Allow: []string{"10.8.0.0/16"}})
+	assert.Nil(t, err)
+
+	router := httpserver.NewRouter("test", true)
+	router.GET("/admin/status", f.Middleware(), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+
+	request := func(remote string, forwarded string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/admin/status", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("X-Forwarded-For", forwarded)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// forwarding headers are ignored by default
+	assert.Equal(t, http.StatusForbidden, request("203.0.113.9:5000", "10.8.0.1"))
+
+	// forwarding headers are honored only from trusted proxies
+	assert.Nil(t, router.SetTrustedProxies([]string{"192.168.0.1"}))
+	assert.Equal(t, http.StatusForbidden, request("203.0.113.9:5000", "10.8.0.1"))
+	assert.Equal(t, http.StatusOK, request("192.168.0.1:5000", "10.8.0.1"))
+}
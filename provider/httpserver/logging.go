@@ -0,0 +1,59 @@
+package httpserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/log/zerolog/logctx"
+)
+
+const maxRequestIdLength = 64
+
+// LogContext middleware that assigns a request id to each request and stores a request-scoped logger
+// in the request context; the request id is read from the X-Request-Id header if present and valid,
+// or generated otherwise, and is returned in the response headers.
+//
+// Logs emitted with logctx.FromContext(ctx.Request.Context()) will include the request id, method and path
+func LogContext() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestId := ctx.GetHeader(HeaderRequestId)
+		if !validRequestId(requestId) {
+			requestId = newRequestId()
+		}
+		ctx.Set(ContextRequestId, requestId)
+		ctx.Header(HeaderRequestId, requestId)
+		ctx.Request = ctx.Request.WithContext(logctx.WithFields(ctx.Request.Context(), map[string]any{
+			logctx.FieldRequestId: requestId,
+			"method":              ctx.Request.Method,
+			"path":                ctx.Request.URL.Path,
+		}))
+		ctx.Next()
+	}
+}
+
+// GetRequestId returns the request id assigned by LogContext, or empty string if none
+func GetRequestId(ctx *gin.Context) string {
+	return ctx.GetString(ContextRequestId)
+}
+
+// newRequestId generates a random request id
+func newRequestId() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
+// validRequestId returns true if id is a non-empty string with safe characters
+func validRequestId(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIdLength {
+		return false
+	}
+	for _, c := range id {
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
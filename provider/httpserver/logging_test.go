@@ -0,0 +1,38 @@
+package httpserver
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLogContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(LogContext())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, GetRequestId(c))
+	})
+
+	// generated id
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Len(t, w.Body.String(), 32)
+	assert.Equal(t, w.Body.String(), w.Header().Get(HeaderRequestId))
+
+	// propagated id
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderRequestId, "abc-123")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "abc-123", w.Body.String())
+
+	// invalid id is replaced
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderRequestId, "abc\n123")
+	router.ServeHTTP(w, req)
+	assert.Len(t, w.Body.String(), 32)
+}
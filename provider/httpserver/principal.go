@@ -0,0 +1,94 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/x509"
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/log/zerolog/logctx"
+	"slices"
+)
+
+const (
+	AuthMethodJWT     = "jwt"
+	AuthMethodSession = "session"
+	AuthMethodHMAC    = "hmac"
+	AuthMethodMTLS    = "mtls"
+	AuthMethodAPIKey  = "apikey"
+
+	ContextPrincipal = "principal" // gin context key for the authenticated principal
+)
+
+// principalKey context.Context key for the authenticated principal
+type principalKey struct{}
+
+// Principal is the authenticated identity of a request, set by auth middlewares
+type Principal struct {
+	Subject     string            // Subject user, client or service identifier
+	Roles       []string          // Roles granted roles, if any
+	Tenant      string            // Tenant tenant identifier, if any
+	AuthMethod  string            // AuthMethod one of the AuthMethod* constants, or a custom method
+	Claims      map[string]any    // Claims raw token claims or session attributes, if any
+	Certificate *x509.Certificate // Certificate client certificate, for AuthMethodMTLS
+}
+
+// HasRole returns true if the principal has the specified role
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// SetPrincipal stores the authenticated principal in the gin context and in the request context, and adds
+// the subject to the request logger; auth middlewares call SetPrincipal after successful authentication
+func SetPrincipal(ctx *gin.Context, p *Principal) {
+	ctx.Set(ContextPrincipal, p)
+	reqCtx := context.WithValue(ctx.Request.Context(), principalKey{}, p)
+	ctx.Request = ctx.Request.WithContext(logctx.WithField(reqCtx, logctx.FieldSubject, p.Subject))
+}
+
+// GetPrincipal returns the authenticated principal of a request; ctx can be a *gin.Context or a request
+// context, e.g. propagated to services and repositories
+//
+// Example usage:
+//
+//	router.GET("/profile", func(ctx *gin.Context) {
+//	  principal, ok := httpserver.GetPrincipal(ctx)
+//	  if !ok {
+//	    httpserver.HttpError401(ctx)
+//	    return
+//	  }
+//	  ctx.JSON(http.StatusOK, httpserver.JSONResponse{Success: true, Data: principal.Subject})
+//	})
+func GetPrincipal(ctx context.Context) (*Principal, bool) {
+	if gc, ok := ctx.(*gin.Context); ok {
+		if v, exists := gc.Get(ContextPrincipal); exists {
+			p, ok := v.(*Principal)
+			return p, ok && p != nil
+		}
+		if gc.Request == nil {
+			return nil, false
+		}
+		ctx = gc.Request.Context()
+	}
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// ClientCertAuth middleware that sets the principal from the verified TLS client certificate, and rejects
+// requests without one with 401; the subject is the certificate common name, and the roles are the certificate
+// organizational units
+// requires a server configured with tlsAllowedCACerts, so client certificates are verified during the handshake
+func ClientCertAuth() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.TLS == nil || len(ctx.Request.TLS.VerifiedChains) == 0 || len(ctx.Request.TLS.VerifiedChains[0]) == 0 {
+			HttpError401(ctx)
+			return
+		}
+		cert := ctx.Request.TLS.VerifiedChains[0][0]
+		SetPrincipal(ctx, &Principal{
+			Subject:     cert.Subject.CommonName,
+			Roles:       cert.Subject.OrganizationalUnit,
+			AuthMethod:  AuthMethodMTLS,
+			Certificate: cert,
+		})
+		ctx.Next()
+	}
+}
@@ -0,0 +1,79 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPrincipal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		if ctx.GetHeader("X-Api-Key") == "secret" {
+			SetPrincipal(ctx, &Principal{Subject: "client1", Roles: []string{"admin"}, AuthMethod: AuthMethodAPIKey})
+		}
+		ctx.Next()
+	})
+	router.GET("/", func(ctx *gin.Context) {
+		p, ok := GetPrincipal(ctx)
+		if !ok {
+			HttpError401(ctx)
+			return
+		}
+		// request context is propagated to services
+		p2, ok := GetPrincipal(ctx.Request.Context())
+		assert.True(t, ok)
+		assert.Equal(t, p, p2)
+		assert.True(t, p.HasRole("admin"))
+		assert.False(t, p.HasRole("user"))
+		ctx.String(http.StatusOK, p.Subject+":"+p.AuthMethod)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Api-Key", "secret")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "client1:apikey", w.Body.String())
+
+	_, ok := GetPrincipal(context.Background())
+	assert.False(t, ok)
+}
+
+func TestClientCertAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ClientCertAuth())
+	router.GET("/", func(ctx *gin.Context) {
+		p, _ := GetPrincipal(ctx)
+		assert.Equal(t, AuthMethodMTLS, p.AuthMethod)
+		assert.True(t, p.HasRole("ops"))
+		assert.NotNil(t, p.Certificate)
+		ctx.String(http.StatusOK, p.Subject)
+	})
+
+	// plain http
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// verified client certificate
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "service-a", OrganizationalUnit: []string{"ops"}}}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "service-a", w.Body.String())
+}
@@ -0,0 +1,92 @@
+package httpserver
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/log/zerolog/logctx"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"syscall"
+)
+
+// Recovery middleware that recovers from panics in handlers and returns a 500 response with an incident id
+// the panic value, stack trace and request details are logged under the incident id with the request logger;
+// the response only contains the incident id, returned in the error envelope for JSON requests and in the
+// X-Incident-Id header, so user reports can be correlated with logs without leaking internal details
+//
+// Example usage:
+//
+//	router := gin.New()
+//	router.Use(httpserver.LogContext())
+//	router.Use(httpserver.Recovery())
+//
+//	// a panic in a handler results in:
+//	// {"success":false,"error":{"message":"Internal Server Error","incidentId":"6f1c..."}}
+func Recovery() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			logger := logctx.FromContext(ctx.Request.Context())
+
+			// client disconnected; the response cannot be written
+			if isBrokenPipe(r) {
+				logger.Warn().Interface("error", r).Msg("connection closed by client")
+				ctx.Abort()
+				return
+			}
+
+			incidentId := newRequestId()
+			logger.Error().
+				Str("incidentId", incidentId).
+				Interface("panic", r).
+				Str("method", ctx.Request.Method).
+				Str("path", ctx.Request.URL.Path).
+				Str("query", ctx.Request.URL.RawQuery).
+				Str("ip", ctx.ClientIP()).
+				Str("userAgent", ctx.Request.UserAgent()).
+				Str("stack", string(debug.Stack())).
+				Msg("unhandled panic")
+
+			if ctx.Writer.Written() {
+				ctx.Abort()
+				return
+			}
+			ctx.Header(HeaderIncidentId, incidentId)
+			if IsJSONRequest(ctx) {
+				JSONError(ctx, http.StatusInternalServerError, JSONErrorDetail{
+					Message:    http.StatusText(http.StatusInternalServerError),
+					IncidentId: incidentId,
+				})
+				return
+			}
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}()
+		ctx.Next()
+	}
+}
+
+// isBrokenPipe returns true if the panic value is a network error caused by the client closing the connection
+func isBrokenPipe(r any) bool {
+	err, ok := r.(error)
+	if !ok {
+		return false
+	}
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var ne *net.OpError
+	if errors.As(err, &ne) {
+		var se *os.SyscallError
+		if errors.As(ne, &se) {
+			msg := strings.ToLower(se.Error())
+			return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+)
+
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(LogContext())
+	router.Use(Recovery())
+	router.GET("/panic", func(c *gin.Context) {
+		panic("something failed")
+	})
+	router.GET("/ok", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	// JSON request
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(HeaderAccept, ContentTypeJson)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	incidentId := w.Header().Get(HeaderIncidentId)
+	assert.Len(t, incidentId, 32)
+
+	response := JSONResponseError{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.False(t, response.Success)
+	assert.Equal(t, incidentId, response.Error.IncidentId)
+	assert.Equal(t, http.StatusText(http.StatusInternalServerError), response.Error.Message)
+	assert.NotContains(t, w.Body.String(), "something failed")
+
+	// non-JSON request
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Len(t, w.Header().Get(HeaderIncidentId), 32)
+	assert.NotEqual(t, incidentId, w.Header().Get(HeaderIncidentId))
+	assert.Empty(t, w.Body.String())
+
+	// no panic
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(HeaderIncidentId))
+
+	assert.True(t, isBrokenPipe(syscall.EPIPE))
+	assert.False(t, isBrokenPipe("broken pipe"))
+}
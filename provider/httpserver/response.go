@@ -1,16 +1,208 @@
 package httpserver
 
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/utils"
+	"sync"
+)
+
+const (
+	ContextRawResponse = "rawResponse" // gin context key to disable the response envelope
+
+	ErrMissingEnvelopeField = utils.Error("dataField and errorField are required")
+)
+
 type JSONResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 }
 
 type JSONErrorDetail struct {
-	Message   string      `json:"message,omitempty"`
-	FormError interface{} `json:"formError,omitempty"`
+	Message    string      `json:"message,omitempty"`
+	FormError  interface{} `json:"formError,omitempty"`
+	IncidentId string      `json:"incidentId,omitempty"`
 }
 
 type JSONResponseError struct {
 	Success bool            `json:"success"`
 	Error   JSONErrorDetail `json:"error"`
 }
+
+// ResponseFormatter builds the body of JSON responses written with JSONSuccess() and JSONError(); the result is
+// serialized with encoding/json
+type ResponseFormatter interface {
+	Success(ctx *gin.Context, status int, data any) any
+	Error(ctx *gin.Context, status int, detail JSONErrorDetail) any
+}
+
+// DefaultResponseFormatter returns JSONResponse and JSONResponseError envelopes
+type DefaultResponseFormatter struct{}
+
+var (
+	formatter   ResponseFormatter = DefaultResponseFormatter{}
+	formatterMx sync.RWMutex
+)
+
+func (DefaultResponseFormatter) Success(_ *gin.Context, _ int, data any) any {
+	return JSONResponse{
+		Success: true,
+		Data:    data,
+	}
+}
+
+func (DefaultResponseFormatter) Error(_ *gin.Context, _ int, detail JSONErrorDetail) any {
+	return JSONResponseError{
+		Success: false,
+		Error:   detail,
+	}
+}
+
+// SetResponseFormatter replaces the response formatter used by all JSON responses; if f is nil, the default
+// formatter is restored
+// Note: should be called before the server is started
+func SetResponseFormatter(f ResponseFormatter) {
+	if f == nil {
+		f = DefaultResponseFormatter{}
+	}
+	formatterMx.Lock()
+	defer formatterMx.Unlock()
+	formatter = f
+}
+
+// GetResponseFormatter returns the current response formatter
+func GetResponseFormatter() ResponseFormatter {
+	formatterMx.RLock()
+	defer formatterMx.RUnlock()
+	return formatter
+}
+
+// JSONSuccess writes a success response with the current response formatter
+//
+// Example usage:
+//
+//	router.GET("/users/:id", func(ctx *gin.Context) {
+//	  user, err := svc.Get(ctx, ctx.Param("id"))
+//	  if err != nil {
+//	    httpserver.JSONError(ctx, http.StatusNotFound, httpserver.JSONErrorDetail{Message: "user not found"})
+//	    return
+//	  }
+//	  httpserver.JSONSuccess(ctx, http.StatusOK, user)
+//	})
+func JSONSuccess(ctx *gin.Context, status int, data any) {
+	if ctx.GetBool(ContextRawResponse) {
+		ctx.JSON(status, data)
+		return
+	}
+	ctx.JSON(status, GetResponseFormatter().Success(ctx, status, data))
+}
+
+// JSONError aborts the request with an error response, using the current response formatter
+func JSONError(ctx *gin.Context, status int, detail JSONErrorDetail) {
+	if ctx.GetBool(ContextRawResponse) {
+		ctx.AbortWithStatusJSON(status, detail)
+		return
+	}
+	ctx.AbortWithStatusJSON(status, GetResponseFormatter().Error(ctx, status, detail))
+}
+
+// RawResponse middleware disables the response envelope: JSONSuccess() writes the data, and JSONError() writes the
+// error detail, without the envelope; e.g. for routes consumed by third-party clients expecting a fixed format
+//
+// Example usage:
+//
+//	router.GET("/.well-known/openid-configuration", httpserver.RawResponse(), discoveryHandler)
+func RawResponse() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set(ContextRawResponse, true)
+		ctx.Next()
+	}
+}
+
+// EnvelopeConfig envelope formatter configuration
+type EnvelopeConfig struct {
+	SuccessField   string         `json:"successField"`   // SuccessField name of the success flag; omitted if empty
+	DataField      string         `json:"dataField"`      // DataField name of the data field
+	ErrorField     string         `json:"errorField"`     // ErrorField name of the error detail field
+	RequestIdField string         `json:"requestIdField"` // RequestIdField name of the request id field; omitted if empty
+	Meta           map[string]any `json:"meta"`           // Meta static fields added to every response, e.g. the api version
+}
+
+// EnvelopeFormatter is a ResponseFormatter with configurable field names and metadata
+type EnvelopeFormatter struct {
+	config *EnvelopeConfig
+}
+
+func NewEnvelopeConfig() *EnvelopeConfig {
+	return &EnvelopeConfig{
+		SuccessField:   "success",
+		DataField:      "data",
+		ErrorField:     "error",
+		RequestIdField: "",
+		Meta:           nil,
+	}
+}
+
+func (c *EnvelopeConfig) Validate() error {
+	if len(c.DataField) == 0 || len(c.ErrorField) == 0 {
+		return ErrMissingEnvelopeField
+	}
+	return nil
+}
+
+// NewEnvelopeFormatter creates a new EnvelopeFormatter
+//
+// Example usage:
+//
+//	cfg := httpserver.NewEnvelopeConfig()
+//	cfg.SuccessField = ""
+//	cfg.DataField = "result"
+//	cfg.RequestIdField = "requestId"
+//	cfg.Meta = map[string]any{"apiVersion": "2.1"}
+//	f, err := httpserver.NewEnvelopeFormatter(cfg)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	httpserver.SetResponseFormatter(f)
+//
+//	// JSONSuccess(ctx, http.StatusOK, user) writes:
+//	// {"apiVersion":"2.1","result":{...},"requestId":"6f1c..."}
+func NewEnvelopeFormatter(cfg *EnvelopeConfig) (*EnvelopeFormatter, error) {
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &EnvelopeFormatter{config: cfg}, nil
+}
+
+func (f *EnvelopeFormatter) Success(ctx *gin.Context, _ int, data any) any {
+	result := f.envelope(ctx, true)
+	if data != nil {
+		result[f.config.DataField] = data
+	}
+	return result
+}
+
+func (f *EnvelopeFormatter) Error(ctx *gin.Context, _ int, detail JSONErrorDetail) any {
+	result := f.envelope(ctx, false)
+	result[f.config.ErrorField] = detail
+	return result
+}
+
+// envelope returns the common envelope fields
+func (f *EnvelopeFormatter) envelope(ctx *gin.Context, success bool) map[string]any {
+	result := make(map[string]any, len(f.config.Meta)+3)
+	for k, v := range f.config.Meta {
+		result[k] = v
+	}
+	if len(f.config.SuccessField) > 0 {
+		result[f.config.SuccessField] = success
+	}
+	if len(f.config.RequestIdField) > 0 {
+		if id := GetRequestId(ctx); len(id) > 0 {
+			result[f.config.RequestIdField] = id
+		}
+	}
+	return result
+}
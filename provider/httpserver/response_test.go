@@ -0,0 +1,59 @@
+package httpserver
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseFormatter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(LogContext())
+	router.GET("/ok", func(ctx *gin.Context) {
+		JSONSuccess(ctx, http.StatusOK, map[string]string{"name": "john"})
+	})
+	router.GET("/fail", func(ctx *gin.Context) {
+		JSONError(ctx, http.StatusBadRequest, JSONErrorDetail{Message: "invalid name"})
+	})
+	router.GET("/raw", RawResponse(), func(ctx *gin.Context) {
+		JSONSuccess(ctx, http.StatusOK, map[string]string{"name": "john"})
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(HeaderRequestId, "req-1")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// default envelope
+	assert.JSONEq(t, `{"success":true,"data":{"name":"john"}}`, get("/ok").Body.String())
+	w := get("/fail")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"success":false,"error":{"message":"invalid name"}}`, w.Body.String())
+	assert.JSONEq(t, `{"name":"john"}`, get("/raw").Body.String())
+
+	// custom envelope
+	_, err := NewEnvelopeFormatter(&EnvelopeConfig{DataField: "result"})
+	assert.ErrorIs(t, err, ErrMissingEnvelopeField)
+	cfg := NewEnvelopeConfig()
+	cfg.SuccessField = ""
+	cfg.DataField = "result"
+	cfg.RequestIdField = "requestId"
+	cfg.Meta = map[string]any{"apiVersion": "2.1"}
+	f, err := NewEnvelopeFormatter(cfg)
+	assert.Nil(t, err)
+	SetResponseFormatter(f)
+	defer SetResponseFormatter(nil)
+
+	assert.JSONEq(t, `{"apiVersion":"2.1","requestId":"req-1","result":{"name":"john"}}`, get("/ok").Body.String())
+	assert.JSONEq(t, `{"apiVersion":"2.1","requestId":"req-1","error":{"message":"invalid name"}}`, get("/fail").Body.String())
+	assert.JSONEq(t, `{"name":"john"}`, get("/raw").Body.String())
+
+	SetResponseFormatter(nil)
+	assert.IsType(t, DefaultResponseFormatter{}, GetResponseFormatter())
+}
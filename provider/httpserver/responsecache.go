@@ -0,0 +1,264 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/cache"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	HeaderXCache        = "X-Cache" // HeaderXCache response cache result, HIT or MISS
+	HeaderSetCookie     = "Set-Cookie"
+	HeaderCookie        = "Cookie"
+	HeaderAuthorization = "Authorization"
+
+	DefaultResponseCacheTTL         = 60      // seconds
+	DefaultResponseCacheJitter      = 10      // percent
+	DefaultResponseCacheMaxBodySize = 1 << 20 // bytes
+
+	ErrInvalidMaxBodySize = utils.Error("maxBodySize must be >= 1")
+
+	// errNotCacheable loader error of responses that are not stored
+	errNotCacheable = utils.Error("response is not cacheable")
+)
+
+// ResponseCacheConfig response cache configuration
+type ResponseCacheConfig struct {
+	TTL         int `json:"ttl"`         // TTL time in seconds responses are cached
+	Jitter      int `json:"jitter"`      // Jitter max random TTL increase, in percent
+	MaxBodySize int `json:"maxBodySize"` // MaxBodySize max cached response body size, in bytes
+}
+
+// ResponseCache caches the responses of idempotent GET routes in a cache.Backend; responses are cached by route,
+// path, query string and authenticated subject (see GetPrincipal()), so authenticated responses are never shared
+// between principals; concurrent misses of the same key execute the handler once, and share its response
+// the auth middleware must run before the response cache, so the principal is known; requests with credentials
+// (an Authorization or Cookie header) but no principal are not cached
+type ResponseCache struct {
+	cache       *cache.Cache[*cachedResponse]
+	maxBodySize int
+}
+
+// cachedResponse stored response
+type cachedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// captureWriter copies the response body while it is written
+type captureWriter struct {
+	gin.ResponseWriter
+	body     []byte
+	max      int
+	overflow bool
+}
+
+func NewResponseCacheConfig() *ResponseCacheConfig {
+	return &ResponseCacheConfig{
+		TTL:         DefaultResponseCacheTTL,
+		Jitter:      DefaultResponseCacheJitter,
+		MaxBodySize: DefaultResponseCacheMaxBodySize,
+	}
+}
+
+func (c *ResponseCacheConfig) Validate() error {
+	if c.MaxBodySize < 1 {
+		return ErrInvalidMaxBodySize
+	}
+	return c.cacheConfig().Validate()
+}
+
+// cacheConfig returns the cache.Config of the response cache; stale responses are not served, as reloads require
+// the request, and negative caching is not used
+func (c *ResponseCacheConfig) cacheConfig() *cache.Config {
+	cfg := cache.NewConfig()
+	cfg.TTL = c.TTL
+	cfg.Jitter = c.Jitter
+	cfg.StaleTTL = 0
+	cfg.NegativeTTL = 0
+	return cfg
+}
+
+// NewResponseCache creates a new ResponseCache; name prefixes the backend keys, and is the "cache" label of the
+// cache metrics (see cache.New())
+//
+// Only responses with status 200, without Set-Cookie, without a "no-store" or "private" Cache-Control header, and
+// without a Vary header set by the handler chain are stored; other responses are sent as usual. Responses varying
+// by request headers (e.g. compressed by a middleware after the cache) are not stored, as the cache key does not
+// include request headers
+//
+// Example usage:
+//
+//	responseCache, err := httpserver.NewResponseCache("catalog", cache.NewMemoryBackend(), httpserver.NewResponseCacheConfig())
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	prometheus.MustRegister(responseCache)
+//	router.GET("/products", responseCache.Middleware(), listProducts)
+func NewResponseCache(name string, backend cache.Backend, cfg *ResponseCacheConfig) (*ResponseCache, error) {
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	c, err := cache.New[*cachedResponse](name, backend, cfg.cacheConfig())
+	if err != nil {
+		return nil, err
+	}
+	return &ResponseCache{
+		cache:       c,
+		maxBodySize: cfg.MaxBodySize,
+	}, nil
+}
+
+// Middleware returns the response cache middleware; cached responses are sent with "X-Cache: HIT", and responses
+// generated by the handler with "X-Cache: MISS"; requests other than GET are not cached
+func (r *ResponseCache) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method != http.MethodGet {
+			ctx.Next()
+			return
+		}
+		if _, ok := GetPrincipal(ctx); !ok && hasCredentials(ctx.Request) {
+			// the response may depend on credentials not resolved to a principal, e.g. if the auth middleware runs
+			// after the response cache; it must not be shared with anonymous requests
+			ctx.Header(HeaderXCache, "MISS")
+			ctx.Next()
+			return
+		}
+		executed := false
+		response, err := r.cache.GetOrLoad(ctx.Request.Context(), r.key(ctx), func(_ context.Context) (*cachedResponse, error) {
+			executed = true
+			return r.execute(ctx)
+		})
+		switch {
+		case executed:
+			// the handler already sent the response
+		case err != nil:
+			// shared execution did not produce a cacheable response
+			ctx.Header(HeaderXCache, "MISS")
+			ctx.Next()
+		default:
+			header := ctx.Writer.Header()
+			for k, v := range response.Header {
+				header[k] = v
+			}
+			header.Set(HeaderXCache, "HIT")
+			ctx.Writer.WriteHeader(response.Status)
+			_, _ = ctx.Writer.Write(response.Body)
+			ctx.Abort()
+		}
+	}
+}
+
+// Delete removes the cached response of a GET request; route is the registered route (e.g. "/products/:id"), and
+// subject the principal subject, or empty for anonymous requests
+//
+// Example usage:
+//
+//	err := responseCache.Delete(ctx, "/products/:id", "/products/12", url.Values{}, "")
+func (r *ResponseCache) Delete(ctx context.Context, route string, path string, query url.Values, subject string) error {
+	return r.cache.Delete(ctx, responseKey(route, path, query.Encode(), subject))
+}
+
+// Describe implements prometheus.Collector
+func (r *ResponseCache) Describe(ch chan<- *prometheus.Desc) {
+	r.cache.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (r *ResponseCache) Collect(ch chan<- prometheus.Metric) {
+	r.cache.Collect(ch)
+}
+
+// execute runs the handler chain, and returns the response if it can be cached
+func (r *ResponseCache) execute(ctx *gin.Context) (*cachedResponse, error) {
+	before := ctx.Writer.Header().Clone()
+	ctx.Header(HeaderXCache, "MISS")
+	w := &captureWriter{ResponseWriter: ctx.Writer, max: r.maxBodySize}
+	ctx.Writer = w
+	ctx.Next()
+	ctx.Writer = w.ResponseWriter
+
+	header := w.Header()
+	cacheControl := strings.ToLower(header.Get(HeaderCacheControl))
+	if w.overflow ||
+		w.Status() != http.StatusOK ||
+		len(header.Values(HeaderSetCookie)) > 0 ||
+		!slices.Equal(before.Values(HeaderVary), header.Values(HeaderVary)) ||
+		strings.Contains(cacheControl, "no-store") ||
+		strings.Contains(cacheControl, "private") {
+		return nil, errNotCacheable
+	}
+	// only headers set by the handler chain are stored; headers set by previous middleware, such as the request id,
+	// are specific to each request
+	stored := make(http.Header)
+	for k, v := range header {
+		if k != HeaderXCache && !slices.Equal(before[k], v) {
+			stored[k] = v
+		}
+	}
+	return &cachedResponse{
+		Status: w.Status(),
+		Header: stored,
+		Body:   w.body,
+	}, nil
+}
+
+// key returns the cache key of a request
+func (r *ResponseCache) key(ctx *gin.Context) string {
+	subject := ""
+	if p, ok := GetPrincipal(ctx); ok {
+		subject = p.Subject
+	}
+	return responseKey(ctx.FullPath(), ctx.Request.URL.Path, ctx.Request.URL.Query().Encode(), subject)
+}
+
+// hasCredentials returns true if the request carries credentials
+func hasCredentials(req *http.Request) bool {
+	return len(req.Header.Get(HeaderAuthorization)) > 0 || len(req.Header.Get(HeaderCookie)) > 0
+}
+
+// responseKey hashes the request attributes, so keys have a fixed size; query parameters are sorted, so their order
+// does not matter
+func responseKey(route string, path string, query string, subject string) string {
+	h := sha256.New()
+	for _, s := range []string{route, path, query, subject} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (w *captureWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// capture copies data, up to max bytes
+func (w *captureWriter) capture(data []byte) {
+	if w.overflow {
+		return
+	}
+	if len(w.body)+len(data) > w.max {
+		w.overflow = true
+		w.body = nil
+		return
+	}
+	w.body = append(w.body, data...)
+}
@@ -0,0 +1,230 @@
+package httpserver
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/cache"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResponseCacheConfig(t *testing.T) {
+	cfg := NewResponseCacheConfig()
+	assert.Nil(t, cfg.Validate())
+	cfg.MaxBodySize = 0
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidMaxBodySize)
+	cfg = NewResponseCacheConfig()
+	cfg.TTL = 0
+	assert.ErrorIs(t, cfg.Validate(), cache.ErrInvalidTTL)
+
+	_, err := NewResponseCache("test", cache.NewMemoryBackend(), nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+	_, err = NewResponseCache("test", nil, NewResponseCacheConfig())
+	assert.ErrorIs(t, err, cache.ErrNilBackend)
+}
+
+func TestResponseCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rc, err := NewResponseCache("test", cache.NewMemoryBackend(), NewResponseCacheConfig())
+	assert.Nil(t, err)
+
+	var calls atomic.Int32
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Header(HeaderRequestId, ctx.GetHeader(HeaderRequestId))
+		if subject := ctx.GetHeader("X-User"); subject != "" {
+			SetPrincipal(ctx, &Principal{Subject: subject})
+		}
+		ctx.Next()
+	})
+	router.Use(rc.Middleware())
+	router.GET("/products/:id", func(ctx *gin.Context) {
+		calls.Add(1)
+		ctx.Header("X-Product", ctx.Param("id"))
+		ctx.String(http.StatusOK, "product %s %s", ctx.Param("id"), ctx.Query("lang"))
+	})
+	router.GET("/private", func(ctx *gin.Context) {
+		calls.Add(1)
+		ctx.Header(HeaderCacheControl, "private, max-age=60")
+		ctx.String(http.StatusOK, "private")
+	})
+	router.GET("/missing", func(ctx *gin.Context) {
+		calls.Add(1)
+		ctx.String(http.StatusNotFound, "missing")
+	})
+	router.POST("/products/:id", func(ctx *gin.Context) {
+		calls.Add(1)
+		ctx.String(http.StatusOK, "updated")
+	})
+
+	request := func(method string, target string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, target, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// miss, then hit
+	w := request(http.MethodGet, "/products/1?lang=en&a=1", map[string]string{HeaderRequestId: "r1"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "MISS", w.Header().Get(HeaderXCache))
+	assert.Equal(t, "product 1 en", w.Body.String())
+	w = request(http.MethodGet, "/products/1?a=1&lang=en", map[string]string{HeaderRequestId: "r2"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "HIT", w.Header().Get(HeaderXCache))
+	assert.Equal(t, "product 1 en", w.Body.String())
+	assert.Equal(t, "1", w.Header().Get("X-Product"))
+	assert.Equal(t, "r2", w.Header().Get(HeaderRequestId))
+	assert.Equal(t, int32(1), calls.Load())
+
+	// different query and subject are cached separately
+	w = request(http.MethodGet, "/products/1?lang=pt", nil)
+	assert.Equal(t, "MISS", w.Header().Get(HeaderXCache))
+	w = request(http.MethodGet, "/products/1?a=1&lang=en", map[string]string{"X-User": "john"})
+	assert.Equal(t, "MISS", w.Header().Get(HeaderXCache))
+	w = request(http.MethodGet, "/products/1?a=1&lang=en", map[string]string{"X-User": "john"})
+	assert.Equal(t, "HIT", w.Header().Get(HeaderXCache))
+	assert.Equal(t, int32(3), calls.Load())
+
+	// delete
+	assert.Nil(t, rc.Delete(context.Background(), "/products/:id", "/products/1", url.Values{"lang": {"en"}, "a": {"1"}}, ""))
+	w = request(http.MethodGet, "/products/1?lang=en&a=1", nil)
+	assert.Equal(t, "MISS", w.Header().Get(HeaderXCache))
+	assert.Equal(t, int32(4), calls.Load())
+
+	// private, error and non-GET responses are not cached
+	for i := 0; i < 2; i++ {
+		w = request(http.MethodGet, "/private", nil)
+		assert.Equal(t, "private", w.Body.String())
+		w = request(http.MethodGet, "/missing", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = request(http.MethodPost, "/products/1", nil)
+		assert.Equal(t, "updated", w.Body.String())
+		assert.Empty(t, w.Header().Get(HeaderXCache))
+	}
+	assert.Equal(t, int32(10), calls.Load())
+
+	// requests with credentials but no principal are not cached, nor served from the cache
+	for i := 0; i < 2; i++ {
+		for _, h := range []string{HeaderAuthorization, HeaderCookie} {
+			w = request(http.MethodGet, "/products/1?lang=en&a=1", map[string]string{h: "secret"})
+			assert.Equal(t, "MISS", w.Header().Get(HeaderXCache))
+		}
+	}
+	assert.Equal(t, int32(14), calls.Load())
+	w = request(http.MethodGet, "/products/1?lang=en&a=1", map[string]string{"X-User": "john", HeaderAuthorization: "secret"})
+	assert.Equal(t, "HIT", w.Header().Get(HeaderXCache))
+	assert.Equal(t, int32(14), calls.Load())
+}
+
+func TestResponseCacheVary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rc, err := NewResponseCache("test", cache.NewMemoryBackend(), NewResponseCacheConfig())
+	assert.Nil(t, err)
+
+	var calls atomic.Int32
+	router := gin.New()
+	// Vary set before the cache is not stored, and does not prevent caching
+	router.Use(func(ctx *gin.Context) {
+		ctx.Header(HeaderVary, "Origin")
+		ctx.Next()
+	})
+	router.Use(rc.Middleware())
+	compress := func(ctx *gin.Context) {
+		ctx.Writer.Header().Add(HeaderVary, "Accept-Encoding")
+		if strings.Contains(ctx.GetHeader("Accept-Encoding"), "gzip") {
+			ctx.Header("Content-Encoding", "gzip")
+		}
+		ctx.Next()
+	}
+	router.GET("/compressed", compress, func(ctx *gin.Context) {
+		calls.Add(1)
+		ctx.String(http.StatusOK, "body")
+	})
+	router.GET("/plain", func(ctx *gin.Context) {
+		calls.Add(1)
+		ctx.String(http.StatusOK, "body")
+	})
+
+	request := func(target string, encoding string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if encoding != "" {
+			req.Header.Set("Accept-Encoding", encoding)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// responses varying by request headers are not stored
+	w := request("/compressed", "gzip")
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	w = request("/compressed", "")
+	assert.Equal(t, "MISS", w.Header().Get(HeaderXCache))
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, int32(2), calls.Load())
+
+	request("/plain", "")
+	w = request("/plain", "gzip")
+	assert.Equal(t, "HIT", w.Header().Get(HeaderXCache))
+	assert.Equal(t, []string{"Origin"}, w.Header().Values(HeaderVary))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestResponseCacheSingleFlight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := NewResponseCacheConfig()
+	cfg.MaxBodySize = 4
+	rc, err := NewResponseCache("test", cache.NewMemoryBackend(), cfg)
+	assert.Nil(t, err)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	router := gin.New()
+	router.GET("/slow", rc.Middleware(), func(ctx *gin.Context) {
+		calls.Add(1)
+		<-release
+		ctx.String(http.StatusOK, "slow")
+	})
+	router.GET("/large", rc.Middleware(), func(ctx *gin.Context) {
+		calls.Add(1)
+		ctx.String(http.StatusOK, "too large")
+	})
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/slow", nil)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "slow", w.Body.String())
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+
+	// responses larger than MaxBodySize are not cached
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/large", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, "too large", w.Body.String())
+		assert.Equal(t, "MISS", w.Header().Get(HeaderXCache))
+	}
+	assert.Equal(t, int32(3), calls.Load())
+}
@@ -0,0 +1,166 @@
+package httpserver
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RoutePolicy security declaration of a route, for route table exports; it is documentation only, and is not
+// enforced: auth, role and rate limit checks are performed by the route middlewares
+type RoutePolicy struct {
+	Public      bool     `json:"public"`                // Public route does not require authentication
+	Auth        []string `json:"auth,omitempty"`        // Auth accepted auth methods, see the AuthMethod* constants
+	Roles       []string `json:"roles,omitempty"`       // Roles required roles
+	Permissions []string `json:"permissions,omitempty"` // Permissions required permissions
+	RateLimit   string   `json:"rateLimit,omitempty"`   // RateLimit rate limit policy, e.g. "100/min per ip"
+	Description string   `json:"description,omitempty"`
+}
+
+// RouteEntry exported route
+type RouteEntry struct {
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Handler   string `json:"handler"`
+	Described bool   `json:"described"` // Described false if no policy was declared for the route or its group
+	RoutePolicy
+}
+
+// RouteTable collects the security declarations of routes, and exports them with the route table of a router,
+// so security reviews and gateway configurations can be generated from the application
+type RouteTable struct {
+	routes map[string]RoutePolicy
+	groups map[string]RoutePolicy
+	mx     sync.RWMutex
+}
+
+func NewRouteTable() *RouteTable {
+	return &RouteTable{
+		routes: make(map[string]RoutePolicy),
+		groups: make(map[string]RoutePolicy),
+	}
+}
+
+// Describe declares the policy of a route; path is the full route path, as registered in the router
+func (t *RouteTable) Describe(method string, path string, p RoutePolicy) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.routes[strings.ToUpper(method)+" "+path] = p
+}
+
+// DescribeGroup declares the policy of all routes under the path prefix; prefixes match whole path segments, the
+// policy of the longest matching prefix is used, and fields set in route policies take precedence
+func (t *RouteTable) DescribeGroup(prefix string, p RoutePolicy) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.groups[strings.TrimSuffix(prefix, "/")] = p
+}
+
+// Use attaches middleware to group and records p as the group policy, so the exported policy follows the
+// middleware actually registered; routes must be added to the group after calling Use
+//
+// Example usage:
+//
+//	admin := server.Group("/admin")
+//	routes.Use(admin, httpserver.RoutePolicy{Auth: []string{httpserver.AuthMethodSession}, Roles: []string{"admin"}}, requireAdmin)
+//	admin.GET("/users", listUsers)
+func (t *RouteTable) Use(group *gin.RouterGroup, p RoutePolicy, middleware ...gin.HandlerFunc) {
+	group.Use(middleware...)
+	t.DescribeGroup(group.BasePath(), p)
+}
+
+// UseAuth attaches auth to group, as Server.UseAuth, and records p as the group policy
+func (t *RouteTable) UseAuth(group *gin.RouterGroup, auth AuthMiddlewareInterface, p RoutePolicy) {
+	t.Use(group, p, AuthMiddleware(auth))
+}
+
+// Export returns the routes of engine with their declared policies, sorted by path and method
+//
+// Example usage:
+//
+//	routes := httpserver.NewRouteTable()
+//	routes.DescribeGroup("/admin", httpserver.RoutePolicy{Auth: []string{httpserver.AuthMethodSession}, Roles: []string{"admin"}})
+//	routes.Describe(http.MethodGet, "/health", httpserver.RoutePolicy{Public: true})
+//
+//	data, err := json.MarshalIndent(routes.Export(server.Router), "", "  ")
+func (t *RouteTable) Export(engine *gin.Engine) []RouteEntry {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+	routes := engine.Routes()
+	result := make([]RouteEntry, 0, len(routes))
+	for _, r := range routes {
+		entry := RouteEntry{
+			Method:  r.Method,
+			Path:    r.Path,
+			Handler: r.Handler,
+		}
+		group, hasGroup := t.group(r.Path)
+		route, hasRoute := t.routes[r.Method+" "+r.Path]
+		entry.Described = hasGroup || hasRoute
+		entry.RoutePolicy = mergePolicy(group, route)
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Path == result[j].Path {
+			return result[i].Method < result[j].Method
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// Handler returns a handler that responds with the exported route table of engine; the route should be restricted
+// to administrators, as it discloses the application attack surface
+func (t *RouteTable) Handler(engine *gin.Engine) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		JSONSuccess(ctx, http.StatusOK, t.Export(engine))
+	}
+}
+
+// group returns the policy of the longest group prefix matching path
+func (t *RouteTable) group(path string) (RoutePolicy, bool) {
+	match := ""
+	found := false
+	for prefix := range t.groups {
+		if matchPrefix(path, prefix) && (!found || len(prefix) > len(match)) {
+			match = prefix
+			found = true
+		}
+	}
+	return t.groups[match], found
+}
+
+// matchPrefix returns true if prefix matches whole path segments of path; "/admin" matches "/admin" and
+// "/admin/users", but not "/administrator"
+func matchPrefix(path string, prefix string) bool {
+	if len(prefix) == 0 || path == prefix {
+		return true
+	}
+	return strings.HasPrefix(path, prefix+"/")
+}
+
+// mergePolicy returns base with the fields set in override
+func mergePolicy(base RoutePolicy, override RoutePolicy) RoutePolicy {
+	result := base
+	if override.Public {
+		result.Public = true
+	}
+	if override.Auth != nil {
+		result.Auth = override.Auth
+	}
+	if override.Roles != nil {
+		result.Roles = override.Roles
+	}
+	if override.Permissions != nil {
+		result.Permissions = override.Permissions
+	}
+	if len(override.RateLimit) > 0 {
+		result.RateLimit = override.RateLimit
+	}
+	if len(override.Description) > 0 {
+		result.Description = override.Description
+	}
+	return result
+}
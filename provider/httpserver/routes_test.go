@@ -0,0 +1,107 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteTable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := func(ctx *gin.Context) {}
+	router.GET("/health", handler)
+	router.GET("/admin/users", handler)
+	router.DELETE("/admin/users/:id", handler)
+	router.GET("/admin/audit/export", handler)
+	router.GET("/orders", handler)
+
+	routes := NewRouteTable()
+	routes.Describe(http.MethodGet, "/health", RoutePolicy{Public: true, Description: "liveness probe"})
+	routes.DescribeGroup("/admin", RoutePolicy{Auth: []string{AuthMethodSession}, Roles: []string{"admin"}})
+	routes.DescribeGroup("/admin/audit", RoutePolicy{Auth: []string{AuthMethodMTLS}, Roles: []string{"auditor"}})
+	routes.Describe("delete", "/admin/users/:id", RoutePolicy{Permissions: []string{"users.delete"}, RateLimit: "10/min"})
+	router.GET("/routes", routes.Handler(router))
+
+	entries := routes.Export(router)
+	assert.Len(t, entries, 6)
+	byRoute := make(map[string]RouteEntry)
+	for _, e := range entries {
+		byRoute[e.Method+" "+e.Path] = e
+	}
+
+	assert.Equal(t, "/admin/audit/export", entries[0].Path)
+	assert.True(t, byRoute["GET /health"].Public)
+	assert.Equal(t, "liveness probe", byRoute["GET /health"].Description)
+	assert.Equal(t, []string{"admin"}, byRoute["GET /admin/users"].Roles)
+	assert.Equal(t, []string{"auditor"}, byRoute["GET /admin/audit/export"].Roles)
+	assert.Equal(t, []string{AuthMethodMTLS}, byRoute["GET /admin/audit/export"].Auth)
+
+	deleteUser := byRoute["DELETE /admin/users/:id"]
+	assert.Equal(t, []string{"admin"}, deleteUser.Roles)
+	assert.Equal(t, []string{"users.delete"}, deleteUser.Permissions)
+	assert.Equal(t, "10/min", deleteUser.RateLimit)
+	assert.Contains(t, deleteUser.Handler, "TestRouteTable")
+
+	assert.True(t, byRoute["GET /admin/users"].Described)
+	assert.False(t, byRoute["GET /orders"].Described)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/routes", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := struct {
+		Success bool         `json:"success"`
+		Data    []RouteEntry `json:"data"`
+	}{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Data, 6)
+}
+
+type denyAuth struct{}
+
+func (denyAuth) CanAccess(ctx *gin.Context) bool {
+	return false
+}
+
+func TestRouteTableMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := func(ctx *gin.Context) {}
+	routes := NewRouteTable()
+
+	admin := router.Group("/admin")
+	routes.UseAuth(admin, denyAuth{}, RoutePolicy{Auth: []string{AuthMethodSession}, Roles: []string{"admin"}})
+	admin.GET("/users", handler)
+	admin.GET("", handler)
+
+	reports := router.Group("/reports/")
+	routes.Use(reports, RoutePolicy{Auth: []string{AuthMethodAPIKey}}, func(ctx *gin.Context) { ctx.Next() })
+	reports.GET("/daily", handler)
+
+	router.GET("/administrator", handler)
+	router.GET("/reportsx", handler)
+
+	byRoute := make(map[string]RouteEntry)
+	for _, e := range routes.Export(router) {
+		byRoute[e.Method+" "+e.Path] = e
+	}
+	assert.True(t, byRoute["GET /admin/users"].Described)
+	assert.Equal(t, []string{"admin"}, byRoute["GET /admin/users"].Roles)
+	assert.True(t, byRoute["GET /admin"].Described)
+	assert.Equal(t, []string{AuthMethodAPIKey}, byRoute["GET /reports/daily"].Auth)
+	assert.False(t, byRoute["GET /administrator"].Described)
+	assert.Nil(t, byRoute["GET /administrator"].Roles)
+	assert.False(t, byRoute["GET /reportsx"].Described)
+
+	// the recorded middleware is enforced
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/administrator", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -18,6 +18,9 @@ type ServerConfig struct {
 	WriteTimeout int               `json:"writeTimeout"`
 	Debug        bool              `json:"debug"`
 	Options      map[string]string `json:"options"`
+	// TrustedProxies networks or addresses allowed to set the client IP via X-Forwarded-For/X-Real-IP;
+	// if empty, forwarding headers are ignored and the client IP is the connection remote address
+	TrustedProxies []string `json:"trustedProxies"`
 	tlsProvider.ServerConfig
 }
 
@@ -33,12 +36,13 @@ type Server struct {
 
 func NewServerConfig() *ServerConfig {
 	return &ServerConfig{
-		Host:         "",
-		Port:         ServerDefaultPort,
-		ReadTimeout:  ServerDefaultReadTimeout,
-		WriteTimeout: ServerDefaultWriteTimeout,
-		Debug:        false,
-		Options:      make(map[string]string),
+		Host:           "",
+		Port:           ServerDefaultPort,
+		ReadTimeout:    ServerDefaultReadTimeout,
+		WriteTimeout:   ServerDefaultWriteTimeout,
+		Debug:          false,
+		Options:        make(map[string]string),
+		TrustedProxies: make([]string, 0),
 		ServerConfig: tlsProvider.ServerConfig{
 			TLSCert:            "",
 			TLSKey:             "",
@@ -77,17 +81,27 @@ func (c *ServerConfig) GetOption(key string, defaultValue string) string {
 }
 
 func (c *ServerConfig) Validate() error {
-	return nil
+	if c.Port < 0 || c.Port > 65535 {
+		return ErrInvalidPort
+	}
+	if c.ReadTimeout < 0 || c.WriteTimeout < 0 {
+		return ErrInvalidTimeout
+	}
+	return c.ServerConfig.Validate()
 }
 
 // NewRouter creates a new gin router
+// forwarding headers are not trusted; use SetTrustedProxies() on the returned router to enable them
 func NewRouter(serverName string, debug bool) *gin.Engine {
 	if !debug {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	router := gin.New()
+	// gin trusts all proxies by default, allowing clients to spoof ClientIP()
+	_ = router.SetTrustedProxies(nil)
+	router.Use(LogContext())
 	router.Use(ginzerolog.Logger(serverName))
-	router.Use(gin.Recovery())
+	router.Use(Recovery())
 	return router
 }
 
@@ -121,6 +135,11 @@ func NewServer(cfg *ServerConfig) (*Server, error) {
 		return nil, err
 	}
 	router := NewRouter(cfg.GetOption("serverName", ServerDefaultName), cfg.Debug)
+	if len(cfg.TrustedProxies) > 0 {
+		if err = router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+			return nil, ErrInvalidTrustedProxy
+		}
+	}
 	result := &Server{
 		Config: cfg,
 		Router: router,
@@ -156,15 +175,18 @@ func (c *Server) AddMiddleware(middlewareFunc gin.HandlerFunc) {
 
 // UseAuth registers an auth middleware
 func (c *Server) UseAuth(authMiddleware AuthMiddlewareInterface) {
-	mw := func(ctx *gin.Context) {
+	c.Router.Use(AuthMiddleware(authMiddleware))
+}
+
+// AuthMiddleware returns a middleware that aborts with 401 if authMiddleware denies access
+func AuthMiddleware(authMiddleware AuthMiddlewareInterface) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
 		if authMiddleware.CanAccess(ctx) {
 			ctx.Next()
 		} else {
 			HttpError401(ctx)
 		}
-		return
 	}
-	c.Router.Use(mw)
 }
 
 // Group creates a new RouterGroup with the specified relativePath.
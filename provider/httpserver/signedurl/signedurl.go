@@ -0,0 +1,189 @@
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/provider/httpserver"
+	"github.com/oddbit-project/blueprint/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	ParamExpires   = "expires"
+	ParamKeyId     = "kid"
+	ParamSignature = "signature"
+
+	DefaultTTL = 3600 // DefaultTTL default link validity, in seconds
+
+	ErrNilConfig        = utils.Error("Config is nil")
+	ErrMissingKeys      = utils.Error("at least one signing key is required")
+	ErrInvalidKey       = utils.Error("signing keys require an id and a secret with at least 32 characters")
+	ErrDuplicateKey     = utils.Error("duplicate signing key id")
+	ErrInvalidTTL       = utils.Error("defaultTtl must be >= 1")
+	ErrMissingSignature = utils.Error("missing URL signature")
+	ErrInvalidSignature = utils.Error("invalid URL signature")
+	ErrUnknownKey       = utils.Error("unknown URL signing key")
+	ErrExpired          = utils.Error("signed URL has expired")
+)
+
+// Key is a named signing key; key ids are embedded in signed URLs to allow rotation
+type Key struct {
+	Id     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+// Config signer configuration
+// the first key is used to sign new URLs; all keys are accepted when verifying, so a new key can be prepended
+// and old keys removed once the links signed with them expire
+type Config struct {
+	Keys       []Key `json:"keys"`
+	DefaultTTL int   `json:"defaultTtl"` // DefaultTTL validity of signed URLs, in seconds
+}
+
+// Signer mints and verifies expiring signed URLs
+type Signer struct {
+	keys       map[string][]byte
+	signingKey string
+	defaultTTL time.Duration
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Keys:       make([]Key, 0),
+		DefaultTTL: DefaultTTL,
+	}
+}
+
+func (c *Config) Validate() error {
+	if len(c.Keys) == 0 {
+		return ErrMissingKeys
+	}
+	ids := make(map[string]struct{})
+	for _, k := range c.Keys {
+		if len(k.Id) == 0 || len(k.Secret) < 32 {
+			return ErrInvalidKey
+		}
+		if _, ok := ids[k.Id]; ok {
+			return ErrDuplicateKey
+		}
+		ids[k.Id] = struct{}{}
+	}
+	if c.DefaultTTL < 1 {
+		return ErrInvalidTTL
+	}
+	return nil
+}
+
+// NewSigner creates a new URL signer
+//
+// Example usage:
+//
+//	cfg := signedurl.NewConfig()
+//	cfg.Keys = []signedurl.Key{{Id: "2024-01", Secret: secret}}
+//	signer, err := signedurl.NewSigner(cfg)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	link, err := signer.Sign("https://example.com/download/report.pdf?format=a4", 0)
+//
+//	router.GET("/download/:file", signer.Middleware(), downloadHandler)
+func NewSigner(cfg *Config) (*Signer, error) {
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	keys := make(map[string][]byte)
+	for _, k := range cfg.Keys {
+		keys[k.Id] = []byte(k.Secret)
+	}
+	return &Signer{
+		keys:       keys,
+		signingKey: cfg.Keys[0].Id,
+		defaultTTL: time.Duration(cfg.DefaultTTL) * time.Second,
+	}, nil
+}
+
+// Sign returns rawUrl with expiration, key id and signature query parameters
+// if ttl is 0, the configured default TTL is used; only the path and query are signed, so the same link is valid
+// for any host serving the route
+func (s *Signer) Sign(rawUrl string, ttl time.Duration) (string, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", err
+	}
+	if ttl <= 0 {
+		ttl = s.defaultTTL
+	}
+	query := u.Query()
+	query.Del(ParamSignature)
+	query.Set(ParamExpires, strconv.FormatInt(time.Now().Add(ttl).Unix(), 10))
+	query.Set(ParamKeyId, s.signingKey)
+	query.Set(ParamSignature, s.signature(s.keys[s.signingKey], u.EscapedPath(), query))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Verify checks the signature and expiration of a signed URL
+func (s *Signer) Verify(u *url.URL) error {
+	query := u.Query()
+	signature := query.Get(ParamSignature)
+	if len(signature) == 0 {
+		return ErrMissingSignature
+	}
+	key, ok := s.keys[query.Get(ParamKeyId)]
+	if !ok {
+		return ErrUnknownKey
+	}
+	query.Del(ParamSignature)
+	if !hmac.Equal([]byte(signature), []byte(s.signature(key, u.EscapedPath(), query))) {
+		return ErrInvalidSignature
+	}
+	expires, err := strconv.ParseInt(query.Get(ParamExpires), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if time.Now().Unix() > expires {
+		return ErrExpired
+	}
+	return nil
+}
+
+// VerifyString parses and verifies a signed URL
+func (s *Signer) VerifyString(rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return err
+	}
+	return s.Verify(u)
+}
+
+// Middleware returns a gin middleware that rejects requests without a valid signed URL with 403
+func (s *Signer) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := s.Verify(ctx.Request.URL); err != nil {
+			if httpserver.IsJSONRequest(ctx) {
+				httpserver.JSONError(ctx, http.StatusForbidden, httpserver.JSONErrorDetail{Message: err.Error()})
+				return
+			}
+			ctx.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// signature computes the HMAC-SHA256 of the path and the encoded (sorted) query
+func (s *Signer) signature(key []byte, path string, query url.Values) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(path))
+	mac.Write([]byte{'?'})
+	mac.Write([]byte(query.Encode()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,100 @@
+package signedurl
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	secret1 = "0123456789abcdef0123456789abcdef"
+	secret2 = "fedcba9876543210fedcba9876543210"
+)
+
+func newSigner(t *testing.T, keys ...Key) *Signer {
+	cfg := NewConfig()
+	cfg.Keys = keys
+	s, err := NewSigner(cfg)
+	assert.Nil(t, err)
+	return s
+}
+
+func TestConfig(t *testing.T) {
+	_, err := NewSigner(nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+
+	cfg := NewConfig()
+	assert.ErrorIs(t, cfg.Validate(), ErrMissingKeys)
+	cfg.Keys = []Key{{Id: "a", Secret: "short"}}
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidKey)
+	cfg.Keys = []Key{{Id: "a", Secret: secret1}, {Id: "a", Secret: secret2}}
+	assert.ErrorIs(t, cfg.Validate(), ErrDuplicateKey)
+	cfg.Keys = cfg.Keys[:1]
+	cfg.DefaultTTL = 0
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidTTL)
+}
+
+func TestSignVerify(t *testing.T) {
+	s := newSigner(t, Key{Id: "k1", Secret: secret1})
+	link, err := s.Sign("https://example.com/download/file.pdf?format=a4", time.Minute)
+	assert.Nil(t, err)
+	assert.Nil(t, s.VerifyString(link))
+
+	// host is not signed
+	assert.Nil(t, s.VerifyString(strings.Replace(link, "example.com", "internal:8080", 1)))
+
+	// tampering
+	assert.ErrorIs(t, s.VerifyString(strings.Replace(link, "format=a4", "format=a3", 1)), ErrInvalidSignature)
+	assert.ErrorIs(t, s.VerifyString(strings.Replace(link, "file.pdf", "other.pdf", 1)), ErrInvalidSignature)
+	assert.ErrorIs(t, s.VerifyString("https://example.com/download/file.pdf"), ErrMissingSignature)
+
+	// changing the expiration invalidates the signature
+	u, _ := url.Parse(link)
+	q := u.Query()
+	q.Set(ParamExpires, "1")
+	u.RawQuery = q.Encode()
+	assert.ErrorIs(t, s.Verify(u), ErrInvalidSignature)
+
+	// expired
+	q.Del(ParamSignature)
+	q.Set(ParamSignature, s.signature(s.keys["k1"], u.EscapedPath(), q))
+	u.RawQuery = q.Encode()
+	assert.ErrorIs(t, s.Verify(u), ErrExpired)
+}
+
+func TestKeyRotation(t *testing.T) {
+	old := newSigner(t, Key{Id: "k1", Secret: secret1})
+	link, err := old.Sign("/invite?code=abc", 0)
+	assert.Nil(t, err)
+
+	rotated := newSigner(t, Key{Id: "k2", Secret: secret2}, Key{Id: "k1", Secret: secret1})
+	assert.Nil(t, rotated.VerifyString(link))
+	newLink, err := rotated.Sign("/invite?code=abc", 0)
+	assert.Nil(t, err)
+	assert.Contains(t, newLink, "kid=k2")
+	assert.ErrorIs(t, old.VerifyString(newLink), ErrUnknownKey)
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newSigner(t, Key{Id: "k1", Secret: secret1})
+	router := gin.New()
+	router.GET("/download", s.Middleware(), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+
+	link, err := s.Sign("/download?file=a", 0)
+	assert.Nil(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, link, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/download?file=a", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
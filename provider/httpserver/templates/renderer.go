@@ -0,0 +1,269 @@
+package templates
+
+import (
+	"github.com/gin-gonic/gin/render"
+	"github.com/oddbit-project/blueprint/utils"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	DefaultExtension  = ".html"
+	DefaultLayoutDir  = "layouts"
+	DefaultPartialDir = "partials"
+
+	ErrNilConfig        = utils.Error("Config is nil")
+	ErrNilFS            = utils.Error("template filesystem is nil")
+	ErrMissingDirectory = utils.Error("dev mode requires a template directory")
+	ErrTemplateNotFound = utils.Error("template not found")
+)
+
+var htmlContentType = []string{"text/html; charset=utf-8"}
+
+type Config struct {
+	Directory  string `json:"directory"`  // Directory template root on disk; used when DevMode is enabled
+	Extension  string `json:"extension"`  // Extension template file extension
+	LayoutDir  string `json:"layoutDir"`  // LayoutDir folder with layout templates, relative to root
+	PartialDir string `json:"partialDir"` // PartialDir folder with partial templates, relative to root
+	DevMode    bool   `json:"devMode"`    // DevMode reload templates from Directory on every render
+}
+
+// Renderer html template renderer with layout and partial support
+//
+// Every page template is parsed into its own template set, together with all layouts and partials; this
+// allows pages to redefine the same blocks (e.g. "content") without collisions.
+// Templates are named after their path relative to the root folder, e.g. "users/list.html"
+type Renderer struct {
+	fs     fs.FS
+	config *Config
+	funcs  template.FuncMap
+	pages  map[string]*template.Template
+	mx     sync.RWMutex
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Directory:  "",
+		Extension:  DefaultExtension,
+		LayoutDir:  DefaultLayoutDir,
+		PartialDir: DefaultPartialDir,
+		DevMode:    false,
+	}
+}
+
+func (c *Config) Validate() error {
+	if c.DevMode && len(c.Directory) == 0 {
+		return ErrMissingDirectory
+	}
+	return nil
+}
+
+// NewRenderer creates a new Renderer from a filesystem (usually an embed.FS)
+// if cfg.DevMode is true, templates are read from cfg.Directory instead, and reloaded on every render
+//
+// Example usage:
+//
+//	//go:embed templates
+//	var templateFS embed.FS
+//
+//	root, _ := fs.Sub(templateFS, "templates")
+//	renderer, err := templates.NewRenderer(root, templates.NewConfig())
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	server.Router.HTMLRender = renderer
+func NewRenderer(fsys fs.FS, cfg *Config) (*Renderer, error) {
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.DevMode {
+		fsys = os.DirFS(cfg.Directory)
+	}
+	if fsys == nil {
+		return nil, ErrNilFS
+	}
+	return &Renderer{
+		fs:     fsys,
+		config: cfg,
+		funcs:  make(template.FuncMap),
+		pages:  nil,
+	}, nil
+}
+
+// AddFunc registers a template function
+// Functions must be registered before the first render; registering a function discards previously loaded templates
+func (r *Renderer) AddFunc(name string, fn any) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.funcs[name] = fn
+	r.pages = nil
+}
+
+// AddFuncs registers multiple template functions
+func (r *Renderer) AddFuncs(funcs template.FuncMap) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	for name, fn := range funcs {
+		r.funcs[name] = fn
+	}
+	r.pages = nil
+}
+
+// Load parses all templates from the filesystem
+func (r *Renderer) Load() error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.load()
+}
+
+// Names returns the list of available page templates
+func (r *Renderer) Names() ([]string, error) {
+	if err := r.ensureLoaded(); err != nil {
+		return nil, err
+	}
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	result := make([]string, 0, len(r.pages))
+	for name := range r.pages {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// Render executes the page template name with the specified data
+func (r *Renderer) Render(w io.Writer, name string, data any) error {
+	if err := r.ensureLoaded(); err != nil {
+		return err
+	}
+	r.mx.RLock()
+	tpl, ok := r.pages[name]
+	r.mx.RUnlock()
+	if !ok {
+		return ErrTemplateNotFound
+	}
+	return tpl.ExecuteTemplate(w, name, data)
+}
+
+// Instance implements gin's render.HTMLRender interface
+func (r *Renderer) Instance(name string, data any) render.Render {
+	return &htmlRender{
+		renderer: r,
+		name:     name,
+		data:     data,
+	}
+}
+
+// ensureLoaded loads templates if not loaded yet, or always if in dev mode
+func (r *Renderer) ensureLoaded() error {
+	if !r.config.DevMode {
+		r.mx.RLock()
+		loaded := r.pages != nil
+		r.mx.RUnlock()
+		if loaded {
+			return nil
+		}
+	}
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if r.pages != nil && !r.config.DevMode {
+		return nil
+	}
+	return r.load()
+}
+
+// load parses layouts and partials into a base set, and clones it for every page
+// caller must hold the write lock
+func (r *Renderer) load() error {
+	var shared, pages []string
+	err := fs.WalkDir(r.fs, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != r.config.Extension {
+			return nil
+		}
+		if r.isShared(p) {
+			shared = append(shared, p)
+		} else {
+			pages = append(pages, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(shared)
+	sort.Strings(pages)
+
+	base := template.New("").Funcs(r.funcs)
+	for _, name := range shared {
+		if err = r.parse(base, name); err != nil {
+			return err
+		}
+	}
+
+	result := make(map[string]*template.Template, len(pages))
+	for _, name := range pages {
+		tpl, err := base.Clone()
+		if err != nil {
+			return err
+		}
+		if err = r.parse(tpl, name); err != nil {
+			return err
+		}
+		result[name] = tpl
+	}
+	r.pages = result
+	return nil
+}
+
+// parse reads a file and adds it to the template set with its relative path as name
+func (r *Renderer) parse(tpl *template.Template, name string) error {
+	content, err := fs.ReadFile(r.fs, name)
+	if err != nil {
+		return err
+	}
+	_, err = tpl.New(name).Parse(string(content))
+	return err
+}
+
+// isShared returns true if the path is a layout or partial
+func (r *Renderer) isShared(p string) bool {
+	for _, dir := range []string{r.config.LayoutDir, r.config.PartialDir} {
+		if len(dir) > 0 && strings.HasPrefix(p, strings.TrimSuffix(dir, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+type htmlRender struct {
+	renderer *Renderer
+	name     string
+	data     any
+}
+
+// Render executes the template and writes the result to the response
+func (h *htmlRender) Render(w http.ResponseWriter) error {
+	h.WriteContentType(w)
+	return h.renderer.Render(w, h.name, h.data)
+}
+
+// WriteContentType writes the html content type header
+func (h *htmlRender) WriteContentType(w http.ResponseWriter) {
+	header := w.Header()
+	if val := header["Content-Type"]; len(val) == 0 {
+		header["Content-Type"] = htmlContentType
+	}
+}
@@ -0,0 +1,77 @@
+package templates
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/base.html":  {Data: []byte(`<html><title>{{ block "title" . }}default{{ end }}</title><body>{{ block "content" . }}{{ end }}</body></html>`)},
+		"partials/user.html": {Data: []byte(`{{ define "user" }}<b>{{ . }}</b>{{ end }}`)},
+		"index.html":         {Data: []byte(`{{ template "layouts/base.html" . }}{{ define "title" }}index{{ end }}{{ define "content" }}{{ template "user" .Name }}{{ end }}`)},
+		"users/list.html":    {Data: []byte(`{{ template "layouts/base.html" . }}{{ define "content" }}{{ upper .Name }}{{ end }}`)},
+		"readme.txt":         {Data: []byte(`not a template`)},
+	}
+}
+
+func TestRenderer(t *testing.T) {
+	r, err := NewRenderer(testFS(), NewConfig())
+	assert.Nil(t, err)
+	r.AddFunc("upper", strings.ToUpper)
+
+	names, err := r.Names()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"index.html", "users/list.html"}, names)
+
+	// layout, partial and escaping
+	buf := &bytes.Buffer{}
+	assert.Nil(t, r.Render(buf, "index.html", map[string]string{"Name": "<john>"}))
+	assert.Equal(t, "<html><title>index</title><body><b>&lt;john&gt;</b></body></html>", buf.String())
+
+	// block redefinition does not leak between pages
+	buf.Reset()
+	assert.Nil(t, r.Render(buf, "users/list.html", map[string]string{"Name": "sarah"}))
+	assert.Equal(t, "<html><title>default</title><body>SARAH</body></html>", buf.String())
+
+	// missing template
+	assert.ErrorIs(t, r.Render(buf, "missing.html", nil), ErrTemplateNotFound)
+}
+
+func TestRendererConfig(t *testing.T) {
+	_, err := NewRenderer(testFS(), nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+
+	cfg := NewConfig()
+	cfg.DevMode = true
+	_, err = NewRenderer(testFS(), cfg)
+	assert.ErrorIs(t, err, ErrMissingDirectory)
+
+	_, err = NewRenderer(nil, NewConfig())
+	assert.ErrorIs(t, err, ErrNilFS)
+}
+
+func TestRendererGin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, err := NewRenderer(testFS(), NewConfig())
+	assert.Nil(t, err)
+	r.AddFunc("upper", strings.ToUpper)
+
+	router := gin.New()
+	router.HTMLRender = r
+	router.GET("/", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "users/list.html", gin.H{"Name": "kyle"})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "KYLE")
+}
@@ -14,13 +14,26 @@ func IsJSONRequest(ctx *gin.Context) bool {
 // HttpError401 generates a error 401 response
 func HttpError401(ctx *gin.Context) {
 	if IsJSONRequest(ctx) {
-		ctx.AbortWithStatusJSON(http.StatusUnauthorized, JSONResponseError{
-			Success: false,
-			Error: JSONErrorDetail{
-				Message: http.StatusText(http.StatusUnauthorized),
-			},
-		})
+		JSONError(ctx, http.StatusUnauthorized, JSONErrorDetail{Message: http.StatusText(http.StatusUnauthorized)})
 		return
 	}
 	ctx.AbortWithStatus(http.StatusUnauthorized)
 }
+
+// HttpError409 generates a error 409 response, e.g. when an update fails with db.ErrStaleRecord
+func HttpError409(ctx *gin.Context) {
+	if IsJSONRequest(ctx) {
+		JSONError(ctx, http.StatusConflict, JSONErrorDetail{Message: http.StatusText(http.StatusConflict)})
+		return
+	}
+	ctx.AbortWithStatus(http.StatusConflict)
+}
+
+// HttpError410 generates a error 410 response, e.g. for routes disabled after their sunset date
+func HttpError410(ctx *gin.Context) {
+	if IsJSONRequest(ctx) {
+		JSONError(ctx, http.StatusGone, JSONErrorDetail{Message: http.StatusText(http.StatusGone)})
+		return
+	}
+	ctx.AbortWithStatus(http.StatusGone)
+}
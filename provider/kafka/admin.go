@@ -2,13 +2,29 @@ package kafka
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	tlsProvider "github.com/oddbit-project/blueprint/provider/tls"
 	"github.com/oddbit-project/blueprint/utils/str"
+	"github.com/rs/zerolog/log"
 	"github.com/segmentio/kafka-go"
 	"github.com/segmentio/kafka-go/sasl/plain"
 	"github.com/segmentio/kafka-go/sasl/scram"
+	"strings"
 )
 
+const (
+	FirstOffset = kafka.FirstOffset // FirstOffset oldest available offset
+	LastOffset  = kafka.LastOffset  // LastOffset offset of the next produced message
+)
+
+// ACLEntry alias of the kafka-go ACL entry; its resource, pattern, operation and permission type fields are kafka-go
+// types, so building entries requires importing github.com/segmentio/kafka-go
+type ACLEntry = kafka.ACLEntry
+
+// ACLFilter alias of the kafka-go ACL delete filter; as with ACLEntry, its type fields are kafka-go types
+type ACLFilter = kafka.DeleteACLsFilter
+
 type AdminConfig struct {
 	Brokers  string `json:"brokers"`
 	AuthType string `json:"authType"`
@@ -21,6 +37,7 @@ type KafkaAdmin struct {
 	broker string
 	ctx    context.Context
 	dialer *kafka.Dialer
+	client *kafka.Client
 	Conn   *kafka.Conn
 }
 
@@ -32,7 +49,7 @@ func (c AdminConfig) Validate() error {
 	if str.Contains(c.AuthType, validAuthTypes) == -1 {
 		return ErrInvalidAuthType
 	}
-	return nil
+	return c.ClientConfig.Validate()
 }
 func NewAdmin(ctx context.Context, cfg *AdminConfig) (*KafkaAdmin, error) {
 	if cfg == nil {
@@ -72,10 +89,22 @@ func NewAdmin(ctx context.Context, cfg *AdminConfig) (*KafkaAdmin, error) {
 		dialer.TLS = tls
 	}
 
+	// client used for admin requests not available on kafka.Conn, with the same auth and TLS settings
+	client := &kafka.Client{
+		Addr:    kafka.TCP(strings.Split(cfg.Brokers, ",")...),
+		Timeout: DefaultTimeout,
+		Transport: &kafka.Transport{
+			DialTimeout: DefaultTimeout,
+			SASL:        dialer.SASLMechanism,
+			TLS:         dialer.TLS,
+		},
+	}
+
 	return &KafkaAdmin{
 		broker: cfg.Brokers,
 		ctx:    ctx,
 		dialer: dialer,
+		client: client,
 		Conn:   nil,
 	}, nil
 }
@@ -168,3 +197,211 @@ func (c *KafkaAdmin) DeleteTopic(topic string) error {
 	}
 	return c.Conn.DeleteTopics(topic)
 }
+
+// ApiVersions returns the API versions supported by the broker
+func (c *KafkaAdmin) ApiVersions() ([]kafka.ApiVersion, error) {
+	if c.Conn == nil {
+		if err := c.Connect(); err != nil {
+			return nil, err
+		}
+		defer c.Disconnect()
+	}
+	return c.Conn.ApiVersions()
+}
+
+// CheckApiVersions checks if the broker supports the API versions listed in RequiredApiVersions
+// if warnOnly is true, unsupported versions are only logged as warnings
+func (c *KafkaAdmin) CheckApiVersions(warnOnly bool) error {
+	versions, err := c.ApiVersions()
+	if err != nil {
+		return err
+	}
+	supported := make(map[int16]int16, len(versions))
+	for _, v := range versions {
+		supported[v.ApiKey] = v.MaxVersion
+	}
+
+	var result error
+	for apiKey, minVersion := range RequiredApiVersions {
+		maxVersion, ok := supported[apiKey]
+		if ok && maxVersion >= minVersion {
+			continue
+		}
+		evt := log.Error()
+		if warnOnly {
+			evt = log.Warn()
+		}
+		evt.Str("component", "kafka").
+			Str("broker", c.broker).
+			Int16("apiKey", apiKey).
+			Int16("minVersion", minVersion).
+			Bool("supported", ok).
+			Int16("maxVersion", maxVersion).
+			Msg("broker does not support required API version")
+		if !warnOnly && result == nil {
+			result = fmt.Errorf("%w: api key %d requires version %d", ErrUnsupportedApiVersion, apiKey, minVersion)
+		}
+	}
+	return result
+}
+
+// CreatePartitions increases the number of partitions of topic to count
+// existing keys may be mapped to different partitions after the change
+func (c *KafkaAdmin) CreatePartitions(topic string, count int) error {
+	resp, err := c.client.CreatePartitions(c.ctx, &kafka.CreatePartitionsRequest{
+		Topics: []kafka.TopicPartitionsConfig{{Name: topic, Count: int32(count)}},
+	})
+	if err != nil {
+		return err
+	}
+	return resp.Errors[topic]
+}
+
+// AlterTopicConfig sets configuration entries of topic, e.g. "retention.ms"; entries not in configs are kept
+//
+// Example usage:
+//
+//	err := admin.AlterTopicConfig("orders", map[string]string{
+//	  "retention.ms":   "604800000",
+//	  "cleanup.policy": "compact",
+//	})
+func (c *KafkaAdmin) AlterTopicConfig(topic string, configs map[string]string) error {
+	entries := make([]kafka.IncrementalAlterConfigsRequestConfig, 0, len(configs))
+	for name, value := range configs {
+		entries = append(entries, kafka.IncrementalAlterConfigsRequestConfig{
+			Name:            name,
+			Value:           value,
+			ConfigOperation: kafka.ConfigOperationSet,
+		})
+	}
+	resp, err := c.client.IncrementalAlterConfigs(c.ctx, &kafka.IncrementalAlterConfigsRequest{
+		Resources: []kafka.IncrementalAlterConfigsRequestResource{{
+			ResourceType: kafka.ResourceTypeTopic,
+			ResourceName: topic,
+			Configs:      entries,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	errs := make([]error, 0)
+	for _, r := range resp.Resources {
+		if r.Error != nil {
+			errs = append(errs, r.Error)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ListConsumerGroups list existing consumer groups
+func (c *KafkaAdmin) ListConsumerGroups() ([]string, error) {
+	resp, err := c.client.ListGroups(c.ctx, &kafka.ListGroupsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	groups := make([]string, len(resp.Groups))
+	for i, g := range resp.Groups {
+		groups[i] = g.GroupID
+	}
+	return groups, nil
+}
+
+// ResetOffsets sets the committed offset of group for all partitions of topic; offset is an absolute offset,
+// FirstOffset or LastOffset
+// the group must have no active members, otherwise the broker rejects the commit
+//
+// Example usage:
+//
+//	// replay all messages
+//	err := admin.ResetOffsets("billing", "orders", kafka.FirstOffset)
+func (c *KafkaAdmin) ResetOffsets(group string, topic string, offset int64) error {
+	partitions, err := c.GetTopics(topic)
+	if err != nil {
+		return err
+	}
+	commits := make([]kafka.OffsetCommit, len(partitions))
+	requests := make([]kafka.OffsetRequest, len(partitions))
+	for i, p := range partitions {
+		commits[i] = kafka.OffsetCommit{Partition: p.ID, Offset: offset}
+		requests[i] = kafka.OffsetRequest{Partition: p.ID, Timestamp: offset}
+	}
+
+	// resolve logical offsets
+	if offset == FirstOffset || offset == LastOffset {
+		resp, err := c.client.ListOffsets(c.ctx, &kafka.ListOffsetsRequest{
+			Topics: map[string][]kafka.OffsetRequest{topic: requests},
+		})
+		if err != nil {
+			return err
+		}
+		resolved := make(map[int]int64)
+		for _, p := range resp.Topics[topic] {
+			if p.Error != nil {
+				return p.Error
+			}
+			resolved[p.Partition] = p.LastOffset
+			if offset == FirstOffset {
+				resolved[p.Partition] = p.FirstOffset
+			}
+		}
+		for i := range commits {
+			commits[i].Offset = resolved[commits[i].Partition]
+		}
+	}
+
+	resp, err := c.client.OffsetCommit(c.ctx, &kafka.OffsetCommitRequest{
+		GroupID:      group,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{topic: commits},
+	})
+	if err != nil {
+		return err
+	}
+	errs := make([]error, 0)
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			errs = append(errs, fmt.Errorf("partition %d: %w", p.Partition, p.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CreateACLs creates ACL entries; requires an authorizer to be configured on the broker
+//
+// Example usage:
+//
+//	// kafkago is github.com/segmentio/kafka-go
+//	err := admin.CreateACLs(kafka.ACLEntry{
+//	  ResourceType:        kafkago.ResourceTypeTopic,
+//	  ResourceName:        "orders",
+//	  ResourcePatternType: kafkago.PatternTypeLiteral,
+//	  Principal:           "User:billing",
+//	  Host:                "*",
+//	  Operation:           kafkago.ACLOperationTypeRead,
+//	  PermissionType:      kafkago.ACLPermissionTypeAllow,
+//	})
+func (c *KafkaAdmin) CreateACLs(acls ...ACLEntry) error {
+	resp, err := c.client.CreateACLs(c.ctx, &kafka.CreateACLsRequest{ACLs: acls})
+	if err != nil {
+		return err
+	}
+	return errors.Join(resp.Errors...)
+}
+
+// DeleteACLs removes the ACL entries matching any of filters
+func (c *KafkaAdmin) DeleteACLs(filters ...ACLFilter) error {
+	resp, err := c.client.DeleteACLs(c.ctx, &kafka.DeleteACLsRequest{Filters: filters})
+	if err != nil {
+		return err
+	}
+	errs := make([]error, 0)
+	for _, r := range resp.Results {
+		if r.Error != nil {
+			errs = append(errs, r.Error)
+		}
+	}
+	return errors.Join(errs...)
+}
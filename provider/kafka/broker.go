@@ -0,0 +1,101 @@
+package kafka
+
+import (
+	"context"
+	"github.com/oddbit-project/blueprint/provider/broker"
+	"github.com/segmentio/kafka-go"
+)
+
+// BrokerPublisher adapts KafkaProducer to broker.Publisher; messages must have an empty topic or the producer topic
+type BrokerPublisher struct {
+	producer *KafkaProducer
+}
+
+// BrokerSubscriber adapts KafkaConsumer to broker.Subscriber; the topic must be the consumer topic
+// messages are processed in order, and offsets are committed after the handler returns nil; if the handler returns
+// an error, Subscribe() returns the error, and the message is redelivered when consumption restarts
+type BrokerSubscriber struct {
+	consumer *KafkaConsumer
+}
+
+// NewBrokerPublisher creates a broker.Publisher using producer
+//
+// Example usage:
+//
+//	producer, err := kafka.NewProducer(ctx, producerCfg)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	var pub broker.Publisher = kafka.NewBrokerPublisher(producer)
+//	err = pub.Publish(ctx, &broker.Message{Key: []byte(order.Id), Payload: payload})
+func NewBrokerPublisher(producer *KafkaProducer) *BrokerPublisher {
+	return &BrokerPublisher{producer: producer}
+}
+
+// Publish writes messages to the producer topic
+func (p *BrokerPublisher) Publish(ctx context.Context, msgs ...*broker.Message) error {
+	if p.producer.Writer == nil {
+		return ErrProducerClosed
+	}
+	out := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		if len(msg.Topic) > 0 && msg.Topic != p.producer.Topic {
+			return broker.ErrTopicMismatch
+		}
+		out[i] = kafka.Message{
+			Key:   msg.Key,
+			Value: msg.Payload,
+		}
+		for k, v := range msg.Headers {
+			out[i].Headers = append(out[i].Headers, kafka.Header{Key: k, Value: []byte(v)})
+		}
+	}
+	return p.producer.Writer.WriteMessages(ctx, out...)
+}
+
+// Close disconnects the producer
+func (p *BrokerPublisher) Close() error {
+	p.producer.Disconnect()
+	return nil
+}
+
+// NewBrokerSubscriber creates a broker.Subscriber using consumer
+func NewBrokerSubscriber(consumer *KafkaConsumer) *BrokerSubscriber {
+	return &BrokerSubscriber{consumer: consumer}
+}
+
+// Subscribe consumes topic using ProcessLoop() with a single worker
+func (s *BrokerSubscriber) Subscribe(ctx context.Context, topic string, handler broker.Handler) error {
+	if topic != s.consumer.Topic {
+		return broker.ErrTopicMismatch
+	}
+	if handler == nil {
+		return broker.ErrNilHandler
+	}
+	opts := NewProcessOptions()
+	opts.Workers = 1
+	return s.consumer.ProcessLoop(ctx, func(ctx context.Context, msg Message) error {
+		return handler(ctx, brokerMessage(msg))
+	}, opts)
+}
+
+// Close disconnects the consumer
+func (s *BrokerSubscriber) Close() error {
+	s.consumer.Disconnect()
+	return nil
+}
+
+func brokerMessage(msg Message) *broker.Message {
+	result := &broker.Message{
+		Topic:   msg.Topic,
+		Key:     msg.Key,
+		Payload: msg.Value,
+	}
+	if len(msg.Headers) > 0 {
+		result.Headers = make(map[string]string, len(msg.Headers))
+		for _, h := range msg.Headers {
+			result.Headers[h.Key] = string(h.Value)
+		}
+	}
+	return result
+}
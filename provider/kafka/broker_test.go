@@ -0,0 +1,34 @@
+package kafka
+
+import (
+	"context"
+	"github.com/oddbit-project/blueprint/provider/broker"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBrokerAdapters(t *testing.T) {
+	pub := NewBrokerPublisher(&KafkaProducer{Topic: "orders"})
+	assert.ErrorIs(t, pub.Publish(context.Background(), &broker.Message{Payload: []byte("x")}), ErrProducerClosed)
+
+	pub = NewBrokerPublisher(&KafkaProducer{Topic: "orders", Writer: &kafka.Writer{}})
+	assert.ErrorIs(t, pub.Publish(context.Background(), &broker.Message{Topic: "users"}), broker.ErrTopicMismatch)
+
+	sub := NewBrokerSubscriber(&KafkaConsumer{Topic: "orders"})
+	assert.ErrorIs(t, sub.Subscribe(context.Background(), "users", nil), broker.ErrTopicMismatch)
+	assert.ErrorIs(t, sub.Subscribe(context.Background(), "orders", nil), broker.ErrNilHandler)
+
+	msg := brokerMessage(Message{
+		Topic:   "orders",
+		Key:     []byte("1"),
+		Value:   []byte("created"),
+		Headers: []kafka.Header{{Key: "type", Value: []byte("order.created")}},
+	})
+	assert.Equal(t, &broker.Message{
+		Topic:   "orders",
+		Key:     []byte("1"),
+		Payload: []byte("created"),
+		Headers: map[string]string{"type": "order.created"},
+	}, msg)
+}
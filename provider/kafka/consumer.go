@@ -3,12 +3,17 @@ package kafka
 import (
 	"context"
 	"errors"
+	"github.com/oddbit-project/blueprint/log/zerolog/logctx"
+	"github.com/oddbit-project/blueprint/provider/metrics"
 	tlsProvider "github.com/oddbit-project/blueprint/provider/tls"
+	"github.com/oddbit-project/blueprint/types/connhooks"
 	"github.com/oddbit-project/blueprint/utils/str"
 	"github.com/segmentio/kafka-go"
 	"github.com/segmentio/kafka-go/sasl/plain"
 	"github.com/segmentio/kafka-go/sasl/scram"
 	"strings"
+	"sync"
+	"time"
 )
 
 type ConsumerConfig struct {
@@ -27,13 +32,20 @@ type Message = kafka.Message
 // ConsumerFunc Reader handler type
 type ConsumerFunc func(ctx context.Context, message Message) error
 
+// KafkaConsumer kafka consumer; connection hooks are called on Connect() and Disconnect()
+// kafka-go manages broker connections internally, so no reconnect events are emitted
 type KafkaConsumer struct {
 	ctx     context.Context
+	fetch   context.Context
+	stopFn  context.CancelFunc
+	running sync.WaitGroup
 	Brokers string
 	Group   string
 	Topic   string
 	config  *kafka.ReaderConfig
+	metrics *metrics.ConsumerMetrics
 	Reader  *kafka.Reader
+	connhooks.Hooks
 }
 
 func (c ConsumerConfig) Validate() error {
@@ -49,7 +61,7 @@ func (c ConsumerConfig) Validate() error {
 	if str.Contains(c.AuthType, validAuthTypes) == -1 {
 		return ErrInvalidAuthType
 	}
-	return nil
+	return c.ClientConfig.Validate()
 }
 
 func NewConsumer(ctx context.Context, cfg *ConsumerConfig) (*KafkaConsumer, error) {
@@ -99,8 +111,11 @@ func NewConsumer(ctx context.Context, cfg *ConsumerConfig) (*KafkaConsumer, erro
 		Dialer:  dialer,
 	}
 
+	fetch, stopFn := context.WithCancel(ctx)
 	return &KafkaConsumer{
 		ctx:     ctx,
+		fetch:   fetch,
+		stopFn:  stopFn,
 		config:  cfgReader,
 		Brokers: cfg.Brokers,
 		Topic:   cfg.Topic,
@@ -127,6 +142,7 @@ func (c *KafkaConsumer) Rewind() error {
 // Connect to Kafka broker
 func (c *KafkaConsumer) Connect() {
 	c.Reader = kafka.NewReader(*c.config)
+	c.Emit(MetricsSystem, connhooks.Connect, nil)
 }
 
 // Disconnect Diconnect from kafka
@@ -134,6 +150,7 @@ func (c *KafkaConsumer) Disconnect() {
 	if c.Reader != nil {
 		c.Reader.Close()
 		c.Reader = nil
+		c.Emit(MetricsSystem, connhooks.Disconnect, nil)
 	}
 }
 
@@ -148,21 +165,39 @@ func (c *KafkaConsumer) Subscribe(handler ConsumerFunc) error {
 	if !c.IsConnected() {
 		c.Connect()
 	}
+	c.running.Add(1)
+	defer c.running.Done()
 	defer c.Reader.Close()
 	for {
-		msg, err := c.Reader.ReadMessage(c.ctx)
+		msg, err := c.Reader.ReadMessage(c.fetch)
 		if err != nil {
 			if !errors.Is(err, context.Canceled) {
 				return err
 			}
 			return nil
 		}
-		if err := handler(c.ctx, msg); err != nil {
+		if err := c.handle(handler, msg); err != nil {
 			return err
 		}
 	}
 }
 
+// UseMetrics enables processing metrics for messages handled by Subscribe() and SubscribeWithOffsets()
+func (c *KafkaConsumer) UseMetrics(m *metrics.ConsumerMetrics) {
+	c.metrics = m
+}
+
+// handle calls handler with the message context, recording metrics if enabled
+func (c *KafkaConsumer) handle(handler ConsumerFunc, msg Message) error {
+	if c.metrics == nil {
+		return handler(MessageContext(c.ctx, msg), msg)
+	}
+	start := time.Now()
+	err := handler(MessageContext(c.ctx, msg), msg)
+	c.metrics.Observe(MetricsSystem, msg.Topic, c.Group, start, msg.Time, err)
+	return err
+}
+
 // ReadMessage reads a single message from Kafka
 // It returns the Kafka message and an error
 // If there is no message available, it will block until a message is available
@@ -180,10 +215,12 @@ func (c *KafkaConsumer) ChannelSubscribe(ch chan Message) error {
 	if !c.IsConnected() {
 		c.Connect()
 	}
+	c.running.Add(1)
+	defer c.running.Done()
 	defer c.Reader.Close()
 
 	for {
-		msg, err := c.Reader.ReadMessage(c.ctx)
+		msg, err := c.Reader.ReadMessage(c.fetch)
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
 				// clean exit
@@ -201,9 +238,11 @@ func (c *KafkaConsumer) SubscribeWithOffsets(handler ConsumerFunc) error {
 	if !c.IsConnected() {
 		c.Connect()
 	}
+	c.running.Add(1)
+	defer c.running.Done()
 	defer c.Reader.Close()
 	for {
-		msg, err := c.Reader.FetchMessage(c.ctx)
+		msg, err := c.Reader.FetchMessage(c.fetch)
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
 				// clean exit
@@ -211,8 +250,48 @@ func (c *KafkaConsumer) SubscribeWithOffsets(handler ConsumerFunc) error {
 			}
 			return err
 		}
-		if err := handler(c.ctx, msg); err != nil {
+		if err := c.handle(handler, msg); err != nil {
 			return err
 		}
 	}
 }
+
+// Stop stops fetching messages; Subscribe(), ChannelSubscribe() and SubscribeWithOffsets() return after the
+// in-flight message is handled, and the reader is closed, committing pending offsets
+// handlers keep receiving the consumer context, so in-flight work is not cancelled
+func (c *KafkaConsumer) Stop() {
+	c.stopFn()
+}
+
+// Drain waits for subscription loops to finish after Stop(); it returns ctx.Err() if ctx expires first
+//
+// Example usage:
+//
+//	blueprint.RegisterStageDestructor(blueprint.StageStopIntake, func() error {
+//	  consumer.Stop()
+//	  return nil
+//	})
+//	blueprint.RegisterDrain(consumer.Drain)
+func (c *KafkaConsumer) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		c.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// MessageContext returns a context with a logger containing the message topic, partition and offset
+// Subscribe() and SubscribeWithOffsets() call handlers with this context
+func MessageContext(ctx context.Context, msg Message) context.Context {
+	return logctx.WithFields(ctx, map[string]any{
+		logctx.FieldTopic:     msg.Topic,
+		logctx.FieldPartition: msg.Partition,
+		logctx.FieldOffset:    msg.Offset,
+	})
+}
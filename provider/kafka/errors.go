@@ -0,0 +1,43 @@
+package kafka
+
+import (
+	"errors"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaErrorKinds maps kafka protocol errors to generic error categories
+var kafkaErrorKinds = map[kafka.Error]utils.Error{
+	kafka.UnknownTopicOrPartition:      utils.ErrNotFound,
+	kafka.TopicAlreadyExists:           utils.ErrConflict,
+	kafka.SASLAuthenticationFailed:     utils.ErrUnauthorized,
+	kafka.TopicAuthorizationFailed:     utils.ErrUnauthorized,
+	kafka.GroupAuthorizationFailed:     utils.ErrUnauthorized,
+	kafka.ClusterAuthorizationFailed:   utils.ErrUnauthorized,
+	kafka.RequestTimedOut:              utils.ErrTimeout,
+	kafka.LeaderNotAvailable:           utils.ErrUnavailable,
+	kafka.NotLeaderForPartition:        utils.ErrUnavailable,
+	kafka.BrokerNotAvailable:           utils.ErrUnavailable,
+	kafka.NetworkException:             utils.ErrUnavailable,
+	kafka.GroupCoordinatorNotAvailable: utils.ErrUnavailable,
+}
+
+// MapError maps kafka errors to the generic provider error categories (utils.ErrNotFound,
+// utils.ErrConflict, utils.ErrUnauthorized, utils.ErrUnavailable, utils.ErrTimeout)
+// the original error is preserved, and unknown errors are returned unchanged
+func MapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var kErr kafka.Error
+	if errors.As(err, &kErr) {
+		if kind, ok := kafkaErrorKinds[kErr]; ok {
+			return utils.NewProviderError(kind, err)
+		}
+		return err
+	}
+	if mapped := utils.MapCommonError(err); mapped != nil {
+		return mapped
+	}
+	return err
+}
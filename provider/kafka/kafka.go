@@ -17,13 +17,31 @@ const (
 	ErrProducerClosed        = utils.Error("Producer is already closed")
 	ErrInvalidAuthType       = utils.Error("Invalid authentication type")
 
-	ErrMissingAdminBroker = utils.Error("Missing Admin broker address")
-	ErrNilConfig          = utils.Error("Config is nil")
+	ErrMissingAdminBroker    = utils.Error("Missing Admin broker address")
+	ErrNilConfig             = utils.Error("Config is nil")
+	ErrUnsupportedApiVersion = utils.Error("Broker does not support a required API version")
 
 	AuthTypeNone     = "none"
 	AuthTypePlain    = "plain"
 	AuthTypeScram256 = "scram256"
 	AuthTypeScram512 = "scram512"
+
+	MetricsSystem = "kafka" // MetricsSystem value of the system label in consumer metrics
 )
 
 var validAuthTypes = []string{AuthTypeNone, AuthTypePlain, AuthTypeScram256, AuthTypeScram512}
+
+// RequiredApiVersions minimum broker API versions used by the provider, indexed by API key
+var RequiredApiVersions = map[int16]int16{
+	0:  2, // Produce
+	1:  2, // Fetch
+	2:  1, // ListOffsets
+	3:  1, // Metadata
+	8:  2, // OffsetCommit
+	9:  1, // OffsetFetch
+	10: 0, // FindCoordinator
+	11: 1, // JoinGroup
+	12: 0, // Heartbeat
+	13: 0, // LeaveGroup
+	14: 0, // SyncGroup
+}
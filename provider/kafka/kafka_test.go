@@ -267,3 +267,33 @@ func TestProducer(t *testing.T) {
 	}
 
 }
+
+func TestAdmin(t *testing.T) {
+	producerCfg, consumerCfg := getConfig()
+	producerCfg.Topic = "test_admin_topic"
+	purgeTopic(t, producerCfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	admin, err := NewAdmin(ctx, &AdminConfig{
+		Brokers:      producerCfg.Brokers,
+		AuthType:     producerCfg.AuthType,
+		Username:     producerCfg.Username,
+		Password:     producerCfg.Password,
+		ClientConfig: producerCfg.ClientConfig,
+	})
+	assert.Nil(t, err)
+
+	assert.Nil(t, admin.CreatePartitions(producerCfg.Topic, 3))
+	partitions, err := admin.GetTopics(producerCfg.Topic)
+	assert.Nil(t, err)
+	assert.Len(t, partitions, 3)
+
+	assert.Nil(t, admin.AlterTopicConfig(producerCfg.Topic, map[string]string{"retention.ms": "3600000"}))
+
+	// commit offsets for a group without members
+	assert.Nil(t, admin.ResetOffsets(consumerCfg.Group+"_admin", producerCfg.Topic, FirstOffset))
+	groups, err := admin.ListConsumerGroups()
+	assert.Nil(t, err)
+	assert.Contains(t, groups, consumerCfg.Group+"_admin")
+}
@@ -0,0 +1,234 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"github.com/oddbit-project/blueprint/utils"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const (
+	OrderByPartition = "partition" // OrderByPartition messages of the same partition are processed in order
+	OrderByKey       = "key"       // OrderByKey messages with the same key are processed in order
+
+	DefaultProcessWorkers     = 8
+	DefaultProcessMaxInFlight = 1000
+
+	ErrInvalidWorkers     = utils.Error("workers must be >= 1")
+	ErrInvalidMaxInFlight = utils.Error("maxInFlight must be >= 1")
+	ErrInvalidOrderBy     = utils.Error("invalid orderBy")
+)
+
+// ProcessOptions concurrent processing options
+type ProcessOptions struct {
+	Workers     int    `json:"workers"`     // Workers number of concurrent handlers
+	MaxInFlight int    `json:"maxInFlight"` // MaxInFlight max fetched messages not yet processed
+	OrderBy     string `json:"orderBy"`     // OrderBy ordering guarantee, OrderByPartition or OrderByKey
+}
+
+func NewProcessOptions() *ProcessOptions {
+	return &ProcessOptions{
+		Workers:     DefaultProcessWorkers,
+		MaxInFlight: DefaultProcessMaxInFlight,
+		OrderBy:     OrderByPartition,
+	}
+}
+
+func (o *ProcessOptions) Validate() error {
+	if o.Workers < 1 {
+		return ErrInvalidWorkers
+	}
+	if o.MaxInFlight < 1 {
+		return ErrInvalidMaxInFlight
+	}
+	if o.OrderBy != OrderByPartition && o.OrderBy != OrderByKey {
+		return ErrInvalidOrderBy
+	}
+	return nil
+}
+
+// ProcessLoop consumes messages with a pool of workers; messages of the same partition (or key, see OrderBy) are
+// always handled by the same worker, in order. Offsets are committed only after the message and all previous
+// messages of the partition were handled successfully
+// ProcessLoop returns nil when ctx is cancelled or Stop() is called, after in-flight messages are handled and their
+// offsets committed; if a handler returns an error, fetching stops and the error is returned after in-flight
+// messages are handled; the failed message offset is not committed
+// Note: this function is blocking
+//
+// Example usage:
+//
+//	opts := kafka.NewProcessOptions()
+//	opts.OrderBy = kafka.OrderByKey
+//	err := consumer.ProcessLoop(ctx, func(ctx context.Context, msg kafka.Message) error {
+//	  return processOrder(ctx, msg.Value)
+//	}, opts)
+func (c *KafkaConsumer) ProcessLoop(ctx context.Context, handler ConsumerFunc, opts *ProcessOptions) error {
+	if opts == nil {
+		opts = NewProcessOptions()
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if !c.IsConnected() {
+		c.Connect()
+	}
+	c.running.Add(1)
+	defer c.running.Done()
+	defer c.Reader.Close()
+
+	fetchCtx, cancel := context.WithCancel(c.fetch)
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-fetchCtx.Done():
+		}
+	}()
+
+	tracker := newOffsetTracker()
+	slots := make(chan struct{}, opts.MaxInFlight)
+	completed := make(chan Message, opts.MaxInFlight)
+	var handlerErr error
+	var errOnce sync.Once
+
+	// committer; completions available without blocking are committed together
+	committerDone := make(chan error, 1)
+	go func() {
+		var commitErr error
+		for msg := range completed {
+			commit := tracker.done(msg)
+			for pending := len(completed); pending > 0; pending-- {
+				commit = append(commit, tracker.done(<-completed)...)
+			}
+			if len(commit) > 0 && commitErr == nil {
+				if commitErr = c.Reader.CommitMessages(c.ctx, commit...); commitErr != nil {
+					cancel()
+				}
+			}
+		}
+		committerDone <- commitErr
+	}()
+
+	// workers
+	workers := make([]chan Message, opts.Workers)
+	wg := sync.WaitGroup{}
+	for i := range workers {
+		workers[i] = make(chan Message, opts.MaxInFlight)
+		wg.Add(1)
+		go func(queue chan Message) {
+			defer wg.Done()
+			for msg := range queue {
+				if err := c.handle(handler, msg); err != nil {
+					errOnce.Do(func() {
+						handlerErr = err
+						cancel()
+					})
+				} else {
+					completed <- msg
+				}
+				<-slots
+			}
+		}(workers[i])
+	}
+
+	var fetchErr error
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-fetchCtx.Done():
+		}
+		if fetchCtx.Err() != nil {
+			break
+		}
+		msg, err := c.Reader.FetchMessage(fetchCtx)
+		if err != nil {
+			<-slots
+			if !errors.Is(err, context.Canceled) {
+				fetchErr = err
+			}
+			break
+		}
+		tracker.add(msg)
+		workers[workerIndex(msg, opts, len(workers))] <- msg
+	}
+
+	// drain
+	for _, w := range workers {
+		close(w)
+	}
+	wg.Wait()
+	close(completed)
+	commitErr := <-committerDone
+	return errors.Join(handlerErr, fetchErr, commitErr)
+}
+
+// workerIndex returns the worker for a message
+func workerIndex(msg Message, opts *ProcessOptions, workers int) int {
+	h := fnv.New32a()
+	if opts.OrderBy == OrderByKey && len(msg.Key) > 0 {
+		_, _ = h.Write(msg.Key)
+	} else {
+		_, _ = h.Write([]byte(msg.Topic))
+		_, _ = h.Write([]byte(strconv.Itoa(msg.Partition)))
+	}
+	return int(h.Sum32() % uint32(workers))
+}
+
+// partitionKey identifies a topic partition
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+// offsetTracker tracks in-flight offsets per partition, to commit only contiguous completed offsets
+type offsetTracker struct {
+	pending  map[partitionKey][]int64
+	complete map[partitionKey]map[int64]bool
+	mx       sync.Mutex
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		pending:  make(map[partitionKey][]int64),
+		complete: make(map[partitionKey]map[int64]bool),
+	}
+}
+
+// add registers a fetched message; offsets of a partition are fetched in increasing order
+func (t *offsetTracker) add(msg Message) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	key := partitionKey{msg.Topic, msg.Partition}
+	t.pending[key] = append(t.pending[key], msg.Offset)
+	if t.complete[key] == nil {
+		t.complete[key] = make(map[int64]bool)
+	}
+}
+
+// done marks a message as processed, and returns the message to commit, if the lowest pending offsets of the
+// partition are now complete
+func (t *offsetTracker) done(msg Message) []Message {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	key := partitionKey{msg.Topic, msg.Partition}
+	pending := t.pending[key]
+	if i := sort.Search(len(pending), func(i int) bool { return pending[i] >= msg.Offset }); i == len(pending) || pending[i] != msg.Offset {
+		return nil
+	}
+	t.complete[key][msg.Offset] = true
+	commit := int64(-1)
+	for len(pending) > 0 && t.complete[key][pending[0]] {
+		commit = pending[0]
+		delete(t.complete[key], pending[0])
+		pending = pending[1:]
+	}
+	t.pending[key] = pending
+	if commit < 0 {
+		return nil
+	}
+	return []Message{{Topic: msg.Topic, Partition: msg.Partition, Offset: commit}}
+}
@@ -0,0 +1,59 @@
+package kafka
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestProcessOptionsValidate(t *testing.T) {
+	opts := NewProcessOptions()
+	assert.Nil(t, opts.Validate())
+	opts.Workers = 0
+	assert.ErrorIs(t, opts.Validate(), ErrInvalidWorkers)
+	opts.Workers = 1
+	opts.MaxInFlight = 0
+	assert.ErrorIs(t, opts.Validate(), ErrInvalidMaxInFlight)
+	opts.MaxInFlight = 1
+	opts.OrderBy = "topic"
+	assert.ErrorIs(t, opts.Validate(), ErrInvalidOrderBy)
+}
+
+func TestProcessWorkerIndex(t *testing.T) {
+	opts := NewProcessOptions()
+	a := Message{Topic: "orders", Partition: 1, Key: []byte("a")}
+	b := Message{Topic: "orders", Partition: 1, Key: []byte("b")}
+	// same partition, same worker
+	assert.Equal(t, workerIndex(a, opts, 16), workerIndex(b, opts, 16))
+
+	opts.OrderBy = OrderByKey
+	a2 := Message{Topic: "orders", Partition: 2, Key: []byte("a")}
+	// same key, same worker
+	assert.Equal(t, workerIndex(a, opts, 16), workerIndex(a2, opts, 16))
+	for i := 0; i < 100; i++ {
+		idx := workerIndex(Message{Key: []byte{byte(i)}}, opts, 3)
+		assert.True(t, idx >= 0 && idx < 3)
+	}
+}
+
+func TestOffsetTracker(t *testing.T) {
+	tracker := newOffsetTracker()
+	msg := func(partition int, offset int64) Message {
+		return Message{Topic: "orders", Partition: partition, Offset: offset}
+	}
+	for i := int64(10); i < 14; i++ {
+		tracker.add(msg(0, i))
+	}
+	tracker.add(msg(1, 5))
+
+	// out of order completion is not committed until previous offsets complete
+	assert.Empty(t, tracker.done(msg(0, 12)))
+	assert.Empty(t, tracker.done(msg(0, 11)))
+	assert.Equal(t, []Message{msg(0, 12)}, tracker.done(msg(0, 10)))
+
+	// other partitions are independent
+	assert.Equal(t, []Message{msg(1, 5)}, tracker.done(msg(1, 5)))
+
+	// unknown offsets are ignored
+	assert.Empty(t, tracker.done(msg(0, 99)))
+	assert.Equal(t, []Message{msg(0, 13)}, tracker.done(msg(0, 13)))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	tlsProvider "github.com/oddbit-project/blueprint/provider/tls"
+	"github.com/oddbit-project/blueprint/types/connhooks"
 	"github.com/oddbit-project/blueprint/utils/str"
 	"github.com/segmentio/kafka-go"
 	"github.com/segmentio/kafka-go/sasl/plain"
@@ -20,11 +21,13 @@ type ProducerConfig struct {
 	tlsProvider.ClientConfig
 }
 
+// KafkaProducer kafka producer; connection hooks are called on Disconnect()
 type KafkaProducer struct {
 	ctx     context.Context
 	Brokers string
 	Topic   string
 	Writer  *kafka.Writer
+	connhooks.Hooks
 }
 
 func (c ProducerConfig) Validate() error {
@@ -37,7 +40,7 @@ func (c ProducerConfig) Validate() error {
 	if str.Contains(c.AuthType, validAuthTypes) == -1 {
 		return ErrInvalidAuthType
 	}
-	return nil
+	return c.ClientConfig.Validate()
 }
 
 func NewProducer(ctx context.Context, cfg *ProducerConfig) (*KafkaProducer, error) {
@@ -99,6 +102,7 @@ func (p *KafkaProducer) Disconnect() {
 	if p.Writer != nil {
 		p.Writer.Close()
 		p.Writer = nil
+		p.Emit(MetricsSystem, connhooks.Disconnect, nil)
 	}
 }
 
@@ -122,6 +126,14 @@ func (p *KafkaProducer) Write(value []byte, key ...[]byte) error {
 	})
 }
 
+// WriteMessages writes messages with keys and headers to Topic; the message Topic field must be empty
+func (p *KafkaProducer) WriteMessages(msgs ...Message) error {
+	if p.Writer == nil {
+		return ErrProducerClosed
+	}
+	return p.Writer.WriteMessages(p.ctx, msgs...)
+}
+
 // WriteMulti Write multiple messages to Topic
 func (p *KafkaProducer) WriteMulti(values ...[]byte) error {
 	if p.Writer == nil {
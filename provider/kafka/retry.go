@@ -0,0 +1,158 @@
+package kafka
+
+import (
+	"context"
+	"github.com/oddbit-project/blueprint/log/zerolog/logctx"
+	"github.com/oddbit-project/blueprint/provider/metrics"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/segmentio/kafka-go"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultMaxRetries     = 3
+	DefaultInitialBackoff = 100   // milliseconds
+	DefaultMaxBackoff     = 10000 // milliseconds
+
+	HeaderDLQError     = "x-dlq-error"
+	HeaderDLQTopic     = "x-dlq-topic"
+	HeaderDLQPartition = "x-dlq-partition"
+	HeaderDLQOffset    = "x-dlq-offset"
+	HeaderDLQRetries   = "x-dlq-retries"
+	HeaderDLQTimestamp = "x-dlq-timestamp"
+
+	ErrInvalidMaxRetries     = utils.Error("maxRetries must be >= 0")
+	ErrInvalidInitialBackoff = utils.Error("initialBackoff must be >= 0")
+	ErrInvalidMaxBackoff     = utils.Error("maxBackoff must be >= initialBackoff")
+)
+
+// RetryConfig handler retry configuration
+type RetryConfig struct {
+	MaxRetries     int `json:"maxRetries"`     // MaxRetries retries after the first failed attempt
+	InitialBackoff int `json:"initialBackoff"` // InitialBackoff delay in milliseconds before the first retry
+	MaxBackoff     int `json:"maxBackoff"`     // MaxBackoff max delay in milliseconds between retries
+}
+
+// MessageWriter writes messages to a topic, such as KafkaProducer
+type MessageWriter interface {
+	WriteMessages(msgs ...Message) error
+}
+
+// RetryHandler retries failed messages with exponential backoff, and publishes messages that still fail to a
+// dead-letter topic
+type RetryHandler struct {
+	config  *RetryConfig
+	dlq     MessageWriter
+	metrics *metrics.ConsumerMetrics
+	group   string
+}
+
+func NewRetryConfig() *RetryConfig {
+	return &RetryConfig{
+		MaxRetries:     DefaultMaxRetries,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+	}
+}
+
+func (c *RetryConfig) Validate() error {
+	if c.MaxRetries < 0 {
+		return ErrInvalidMaxRetries
+	}
+	if c.InitialBackoff < 0 {
+		return ErrInvalidInitialBackoff
+	}
+	if c.MaxBackoff < c.InitialBackoff {
+		return ErrInvalidMaxBackoff
+	}
+	return nil
+}
+
+// NewRetryHandler creates a new RetryHandler; if dlq is nil, the handler error is returned after all retries
+//
+// Example usage:
+//
+//	dlq, err := kafka.NewProducer(ctx, &kafka.ProducerConfig{Brokers: "kafka:9092", Topic: "orders.dlq"})
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	retry, err := kafka.NewRetryHandler(kafka.NewRetryConfig(), dlq)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	err = consumer.SubscribeWithOffsets(retry.Wrap(handler))
+func NewRetryHandler(cfg *RetryConfig, dlq MessageWriter) (*RetryHandler, error) {
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &RetryHandler{
+		config: cfg,
+		dlq:    dlq,
+	}, nil
+}
+
+// UseMetrics enables retry and dead-letter metrics; group is the consumer group label
+func (r *RetryHandler) UseMetrics(m *metrics.ConsumerMetrics, group string) {
+	r.metrics = m
+	r.group = group
+}
+
+// Wrap returns a ConsumerFunc that calls handler, retrying on error; if all retries fail, the message is published
+// to the dead-letter topic with failure metadata headers, and nil is returned so consumption continues
+// errors publishing to the dead-letter topic, and context cancellation during backoff, are returned
+func (r *RetryHandler) Wrap(handler ConsumerFunc) ConsumerFunc {
+	return func(ctx context.Context, msg Message) error {
+		backoff := time.Duration(r.config.InitialBackoff) * time.Millisecond
+		maxBackoff := time.Duration(r.config.MaxBackoff) * time.Millisecond
+		err := handler(ctx, msg)
+		retries := 0
+		for err != nil && retries < r.config.MaxRetries {
+			logctx.FromContext(ctx).Warn().Err(err).Int("retry", retries+1).Dur("backoff", backoff).Msg("message handler failed")
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			retries++
+			if r.metrics != nil {
+				r.metrics.Retry(MetricsSystem, msg.Topic, r.group)
+			}
+			err = handler(ctx, msg)
+			backoff = min(backoff*2, maxBackoff)
+		}
+		if err == nil || r.dlq == nil {
+			return err
+		}
+		if dlqErr := r.dlq.WriteMessages(DeadLetterMessage(msg, err, retries)); dlqErr != nil {
+			return dlqErr
+		}
+		logctx.FromContext(ctx).Error().Err(err).Int("retries", retries).Msg("message sent to dead-letter topic")
+		if r.metrics != nil {
+			r.metrics.DeadLetter(MetricsSystem, msg.Topic, r.group)
+		}
+		return nil
+	}
+}
+
+// DeadLetterMessage returns a copy of msg for a dead-letter topic, with the original headers and failure metadata
+func DeadLetterMessage(msg Message, err error, retries int) Message {
+	headers := make([]kafka.Header, 0, len(msg.Headers)+6)
+	headers = append(headers, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderDLQError, Value: []byte(err.Error())},
+		kafka.Header{Key: HeaderDLQTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderDLQPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderDLQOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: HeaderDLQRetries, Value: []byte(strconv.Itoa(retries))},
+		kafka.Header{Key: HeaderDLQTimestamp, Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))},
+	)
+	return Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+}
@@ -0,0 +1,93 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type mockWriter struct {
+	msgs []Message
+	err  error
+}
+
+func (w *mockWriter) WriteMessages(msgs ...Message) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func TestRetryConfigValidate(t *testing.T) {
+	cfg := NewRetryConfig()
+	assert.Nil(t, cfg.Validate())
+	cfg.MaxRetries = -1
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidMaxRetries)
+	cfg.MaxRetries = 1
+	cfg.InitialBackoff = -1
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidInitialBackoff)
+	cfg.InitialBackoff = 10
+	cfg.MaxBackoff = 5
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidMaxBackoff)
+
+	_, err := NewRetryHandler(nil, nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+}
+
+func TestRetryHandler(t *testing.T) {
+	cfg := &RetryConfig{MaxRetries: 2, InitialBackoff: 1, MaxBackoff: 2}
+	dlq := &mockWriter{}
+	retry, err := NewRetryHandler(cfg, dlq)
+	assert.Nil(t, err)
+
+	msg := Message{Topic: "orders", Partition: 2, Offset: 42, Key: []byte("k1"), Value: []byte("v1")}
+
+	// succeeds on retry
+	calls := 0
+	handler := retry.Wrap(func(ctx context.Context, message Message) error {
+		calls++
+		if calls < 2 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	assert.Nil(t, handler(context.Background(), msg))
+	assert.Equal(t, 2, calls)
+	assert.Len(t, dlq.msgs, 0)
+
+	// always fails; sent to dead-letter topic
+	calls = 0
+	handler = retry.Wrap(func(ctx context.Context, message Message) error {
+		calls++
+		return errors.New("invalid order")
+	})
+	assert.Nil(t, handler(context.Background(), msg))
+	assert.Equal(t, 3, calls)
+	assert.Len(t, dlq.msgs, 1)
+	dead := dlq.msgs[0]
+	assert.Equal(t, msg.Key, dead.Key)
+	assert.Equal(t, msg.Value, dead.Value)
+	assert.Empty(t, dead.Topic)
+	headers := make(map[string]string)
+	for _, h := range dead.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	assert.Equal(t, "invalid order", headers[HeaderDLQError])
+	assert.Equal(t, "orders", headers[HeaderDLQTopic])
+	assert.Equal(t, "2", headers[HeaderDLQPartition])
+	assert.Equal(t, "42", headers[HeaderDLQOffset])
+	assert.Equal(t, "2", headers[HeaderDLQRetries])
+
+	// dead-letter errors are returned
+	dlq.err = errors.New("broker unavailable")
+	assert.Equal(t, dlq.err, handler(context.Background(), msg))
+
+	// without dead-letter topic, the handler error is returned
+	retry, err = NewRetryHandler(cfg, nil)
+	assert.Nil(t, err)
+	assert.EqualError(t, retry.Wrap(func(ctx context.Context, message Message) error {
+		return errors.New("failed")
+	})(context.Background(), msg), "failed")
+}
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+const (
+	ConsumerNamespace = "messaging_consumer"
+
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// ConsumerMetrics standard message consumer metrics, shared by all messaging providers
+// all metrics have the labels system (e.g. "kafka"), topic and group (consumer group, if any)
+type ConsumerMetrics struct {
+	duration *prometheus.HistogramVec
+	latency  *prometheus.HistogramVec
+	messages *prometheus.CounterVec
+	retries  *prometheus.CounterVec
+	dlq      *prometheus.CounterVec
+}
+
+// NewConsumerMetrics creates and registers consumer metrics with reg; if reg is nil,
+// prometheus.DefaultRegisterer is used. Metrics should be created once and shared by all consumers
+//
+// Example usage:
+//
+//	m, err := metrics.NewConsumerMetrics(nil)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	consumer.UseMetrics(m)
+//	err = consumer.Subscribe(handler) // handler calls are measured automatically
+func NewConsumerMetrics(reg prometheus.Registerer) (*ConsumerMetrics, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	labels := []string{"system", "topic", "group"}
+	m := &ConsumerMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ConsumerNamespace,
+			Name:      "processing_duration_seconds",
+			Help:      "Message handler processing duration.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ConsumerNamespace,
+			Name:      "end_to_end_latency_seconds",
+			Help:      "Time from message creation to processing completion.",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 900, 3600},
+		}, labels),
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ConsumerNamespace,
+			Name:      "messages_total",
+			Help:      "Total number of processed messages, by status.",
+		}, append(labels, "status")),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ConsumerNamespace,
+			Name:      "retries_total",
+			Help:      "Total number of message processing retries.",
+		}, labels),
+		dlq: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ConsumerNamespace,
+			Name:      "dead_lettered_total",
+			Help:      "Total number of messages sent to a dead-letter topic.",
+		}, labels),
+	}
+	var err error
+	if m.duration, err = register(reg, m.duration); err != nil {
+		return nil, err
+	}
+	if m.latency, err = register(reg, m.latency); err != nil {
+		return nil, err
+	}
+	if m.messages, err = register(reg, m.messages); err != nil {
+		return nil, err
+	}
+	if m.retries, err = register(reg, m.retries); err != nil {
+		return nil, err
+	}
+	if m.dlq, err = register(reg, m.dlq); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Observe records the processing of a message; start is the time the handler was called, and timestamp the
+// message creation time, if available (zero otherwise); err is the handler result
+func (m *ConsumerMetrics) Observe(system, topic, group string, start time.Time, timestamp time.Time, err error) {
+	now := time.Now()
+	m.duration.WithLabelValues(system, topic, group).Observe(now.Sub(start).Seconds())
+	if !timestamp.IsZero() {
+		m.latency.WithLabelValues(system, topic, group).Observe(now.Sub(timestamp).Seconds())
+	}
+	status := StatusSuccess
+	if err != nil {
+		status = StatusFailure
+	}
+	m.messages.WithLabelValues(system, topic, group, status).Inc()
+}
+
+// Retry records a message processing retry
+func (m *ConsumerMetrics) Retry(system, topic, group string) {
+	m.retries.WithLabelValues(system, topic, group).Inc()
+}
+
+// DeadLetter records a message sent to a dead-letter topic
+func (m *ConsumerMetrics) DeadLetter(system, topic, group string) {
+	m.dlq.WithLabelValues(system, topic, group).Inc()
+}
+
+// register registers c with reg; if an identical collector is already registered, the existing one is returned
+func register[T prometheus.Collector](reg prometheus.Registerer, c T) (T, error) {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing, nil
+			}
+		}
+		return c, err
+	}
+	return c, nil
+}
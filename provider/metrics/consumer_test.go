@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestConsumerMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewConsumerMetrics(reg)
+	assert.Nil(t, err)
+
+	start := time.Now().Add(-time.Second)
+	m.Observe("kafka", "orders", "billing", start, start.Add(-time.Minute), nil)
+	m.Observe("kafka", "orders", "billing", start, start.Add(-time.Minute), nil)
+	m.Observe("kafka", "orders", "billing", start, time.Time{}, errors.New("failed"))
+	m.Retry("kafka", "orders", "billing")
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.messages.WithLabelValues("kafka", "orders", "billing", StatusSuccess)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.messages.WithLabelValues("kafka", "orders", "billing", StatusFailure)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.retries.WithLabelValues("kafka", "orders", "billing")))
+
+	// latency is only recorded for messages with timestamp
+	count, err := testutil.GatherAndCount(reg, "messaging_consumer_end_to_end_latency_seconds")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	// metrics can be created again with the same registry
+	m2, err := NewConsumerMetrics(reg)
+	assert.Nil(t, err)
+	m2.Retry("kafka", "orders", "billing")
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.retries.WithLabelValues("kafka", "orders", "billing")))
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	DBPoolNamespace = "db_pool"
+)
+
+// dbPoolCollector collects connection pool statistics of a db.SqlClient
+type dbPoolCollector struct {
+	client *db.SqlClient
+
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxIdleTimeClosed *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+}
+
+// NewDBPoolCollector creates a collector for the connection pool of a database client, such as the clients
+// returned by pgsql.NewClient() and clickhouse.NewClient(); name is exported as the "db" label
+// the pool is read on each scrape, so the collector can be registered before the client is connected; no metrics
+// are reported while the client is disconnected
+//
+// Example usage:
+//
+//	client, err := pgsql.NewClient(cfg)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	prometheus.MustRegister(metrics.NewDBPoolCollector("main", client))
+func NewDBPoolCollector(name string, client *db.SqlClient) prometheus.Collector {
+	labels := prometheus.Labels{"db": name, "driver": client.DriverName}
+	desc := func(metric string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(DBPoolNamespace, "", metric), help, nil, labels)
+	}
+	return &dbPoolCollector{
+		client:            client,
+		maxOpen:           desc("max_open_connections", "Maximum number of open connections."),
+		open:              desc("open_connections", "Number of established connections, in use and idle."),
+		inUse:             desc("in_use_connections", "Number of connections in use."),
+		idle:              desc("idle_connections", "Number of idle connections."),
+		waitCount:         desc("wait_count_total", "Total number of connections waited for."),
+		waitDuration:      desc("wait_duration_seconds_total", "Total time blocked waiting for a connection."),
+		maxIdleClosed:     desc("max_idle_closed_total", "Total number of connections closed due to max idle connections."),
+		maxIdleTimeClosed: desc("max_idle_time_closed_total", "Total number of connections closed due to max idle time."),
+		maxLifetimeClosed: desc("max_lifetime_closed_total", "Total number of connections closed due to max connection lifetime."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxIdleClosed
+	ch <- c.maxIdleTimeClosed
+	ch <- c.maxLifetimeClosed
+}
+
+// Collect implements prometheus.Collector
+func (c *dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	conn := c.client.Conn
+	if conn == nil {
+		return
+	}
+	stats := conn.Stats()
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(stats.MaxIdleClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxIdleTimeClosed, prometheus.CounterValue, float64(stats.MaxIdleTimeClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(stats.MaxLifetimeClosed))
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	DeprecationNamespace = "http_deprecated"
+)
+
+// DeprecationMetrics counts requests to deprecated routes, with the labels method and route
+type DeprecationMetrics struct {
+	requests *prometheus.CounterVec
+}
+
+// NewDeprecationMetrics creates the deprecated route usage counter and registers it with reg; if reg is nil,
+// prometheus.DefaultRegisterer is used
+//
+// Example usage:
+//
+//	dm, err := metrics.NewDeprecationMetrics(nil)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	deprecation.UseMetrics(dm)
+func NewDeprecationMetrics(reg prometheus.Registerer) (*DeprecationMetrics, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	requests, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: DeprecationNamespace,
+		Name:      "requests_total",
+		Help:      "Requests to deprecated routes.",
+	}, []string{"method", "route"}))
+	if err != nil {
+		return nil, err
+	}
+	return &DeprecationMetrics{requests: requests}, nil
+}
+
+// Inc records a request to a deprecated route
+func (m *DeprecationMetrics) Inc(method string, route string) {
+	m.requests.WithLabelValues(method, route).Inc()
+}
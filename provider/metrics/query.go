@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	QueryNamespace = "db_query"
+)
+
+// QueryMetrics is a db.QueryHook that records statement durations, with the labels db and status
+type QueryMetrics struct {
+	name     string
+	duration *prometheus.HistogramVec
+}
+
+// NewQueryMetrics creates a query duration histogram for a database client and registers it with reg; if reg is
+// nil, prometheus.DefaultRegisterer is used; name is exported as the "db" label
+//
+// Example usage:
+//
+//	qm, err := metrics.NewQueryMetrics("main", nil)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	client.AddQueryHook(qm)
+func NewQueryMetrics(name string, reg prometheus.Registerer) (*QueryMetrics, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	duration, err := register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: QueryNamespace,
+		Name:      "duration_seconds",
+		Help:      "Database statement duration.",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"db", "status"}))
+	if err != nil {
+		return nil, err
+	}
+	return &QueryMetrics{name: name, duration: duration}, nil
+}
+
+// AfterQuery implements db.QueryHook
+func (m *QueryMetrics) AfterQuery(_ context.Context, event *db.QueryEvent) {
+	status := StatusSuccess
+	if event.Err != nil {
+		status = StatusFailure
+	}
+	m.duration.WithLabelValues(m.name, status).Observe(event.Duration.Seconds())
+}
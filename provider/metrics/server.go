@@ -19,7 +19,9 @@ const (
 	DefaultPort         = 2201
 	DefaultEndpoint     = "/metrics"
 
-	ErrNilConfig = utils.Error("Config is nil")
+	ErrNilConfig       = utils.Error("Config is nil")
+	ErrInvalidPort     = utils.Error("invalid port")
+	ErrMissingEndpoint = utils.Error("missing metrics endpoint")
 )
 
 type Config struct {
@@ -57,7 +59,13 @@ func NewConfig() *Config {
 }
 
 func (c *Config) Validate() error {
-	return nil
+	if c.Port < 0 || c.Port > 65535 {
+		return ErrInvalidPort
+	}
+	if len(c.Endpoint) == 0 {
+		return ErrMissingEndpoint
+	}
+	return c.ServerConfig.Validate()
 }
 
 func (c *Config) NewServer() (*Server, error) {
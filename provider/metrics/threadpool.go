@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"github.com/oddbit-project/blueprint/threadpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	ThreadPoolNamespace = "threadpool"
+)
+
+// ThreadPoolStats counters of a worker pool
+type ThreadPoolStats = threadpool.Stats
+
+// ThreadPoolStatsProvider is implemented by worker pools, such as threadpool.ThreadPool
+type ThreadPoolStatsProvider interface {
+	Stats() ThreadPoolStats
+}
+
+// threadPoolCollector collects ThreadPoolStats
+type threadPoolCollector struct {
+	pool       ThreadPoolStatsProvider
+	workers    *prometheus.Desc
+	busy       *prometheus.Desc
+	queued     *prometheus.Desc
+	capacity   *prometheus.Desc
+	completed  *prometheus.Desc
+	panics     *prometheus.Desc
+	rejected   *prometheus.Desc
+	saturation *prometheus.Desc
+	duration   *prometheus.Desc
+}
+
+// NewThreadPoolCollector creates a collector for a worker pool; name is exported as the "pool" label
+//
+// Example usage:
+//
+//	pool, err := threadpool.NewThreadPool(8, 100)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	prometheus.MustRegister(metrics.NewThreadPoolCollector("thumbnails", pool))
+func NewThreadPoolCollector(name string, pool ThreadPoolStatsProvider) prometheus.Collector {
+	labels := prometheus.Labels{"pool": name}
+	desc := func(metric string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(ThreadPoolNamespace, "", metric), help, nil, labels)
+	}
+	return &threadPoolCollector{
+		pool:       pool,
+		workers:    desc("workers", "Number of workers."),
+		busy:       desc("busy_workers", "Number of workers running a job."),
+		queued:     desc("queue_depth", "Number of jobs waiting for a worker."),
+		capacity:   desc("queue_capacity", "Job queue capacity."),
+		completed:  desc("jobs_total", "Total number of executed jobs."),
+		panics:     desc("panics_total", "Total number of jobs that panicked."),
+		rejected:   desc("rejected_total", "Total number of jobs rejected because the queue was full."),
+		saturation: desc("saturation", "Ratio of used workers and queue slots, from 0 to 1."),
+		duration:   desc("job_duration_seconds", "Job execution duration in seconds."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *threadPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.workers
+	ch <- c.busy
+	ch <- c.queued
+	ch <- c.capacity
+	ch <- c.completed
+	ch <- c.panics
+	ch <- c.rejected
+	ch <- c.saturation
+	ch <- c.duration
+}
+
+// Collect implements prometheus.Collector
+func (c *threadPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.pool.Stats()
+	ch <- prometheus.MustNewConstMetric(c.workers, prometheus.GaugeValue, float64(stats.Workers))
+	ch <- prometheus.MustNewConstMetric(c.busy, prometheus.GaugeValue, float64(stats.Busy))
+	ch <- prometheus.MustNewConstMetric(c.queued, prometheus.GaugeValue, float64(stats.Queued))
+	ch <- prometheus.MustNewConstMetric(c.capacity, prometheus.GaugeValue, float64(stats.Capacity))
+	ch <- prometheus.MustNewConstMetric(c.completed, prometheus.CounterValue, float64(stats.Completed))
+	ch <- prometheus.MustNewConstMetric(c.panics, prometheus.CounterValue, float64(stats.Panics))
+	ch <- prometheus.MustNewConstMetric(c.rejected, prometheus.CounterValue, float64(stats.Rejected))
+	ch <- prometheus.MustNewConstMetric(c.saturation, prometheus.GaugeValue, stats.Saturation())
+	ch <- prometheus.MustNewConstHistogram(c.duration, stats.DurationCount, stats.DurationSum, stats.DurationBuckets)
+}
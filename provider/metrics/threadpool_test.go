@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type poolStats ThreadPoolStats
+
+func (s poolStats) Stats() ThreadPoolStats {
+	return ThreadPoolStats(s)
+}
+
+func TestThreadPoolCollector(t *testing.T) {
+	collector := NewThreadPoolCollector("images", poolStats{
+		Workers:         4,
+		Busy:            2,
+		Queued:          3,
+		Capacity:        6,
+		Completed:       7,
+		Panics:          1,
+		Rejected:        2,
+		DurationCount:   7,
+		DurationSum:     1.5,
+		DurationBuckets: map[float64]uint64{0.1: 5, 1: 7},
+	})
+	assert.Equal(t, 9, testutil.CollectAndCount(collector))
+	expected := `
+# HELP threadpool_busy_workers Number of workers running a job.
+# TYPE threadpool_busy_workers gauge
+threadpool_busy_workers{pool="images"} 2
+# HELP threadpool_job_duration_seconds Job execution duration in seconds.
+# TYPE threadpool_job_duration_seconds histogram
+threadpool_job_duration_seconds_bucket{pool="images",le="0.1"} 5
+threadpool_job_duration_seconds_bucket{pool="images",le="1"} 7
+threadpool_job_duration_seconds_bucket{pool="images",le="+Inf"} 7
+threadpool_job_duration_seconds_sum{pool="images"} 1.5
+threadpool_job_duration_seconds_count{pool="images"} 7
+# HELP threadpool_rejected_total Total number of jobs rejected because the queue was full.
+# TYPE threadpool_rejected_total counter
+threadpool_rejected_total{pool="images"} 2
+# HELP threadpool_saturation Ratio of used workers and queue slots, from 0 to 1.
+# TYPE threadpool_saturation gauge
+threadpool_saturation{pool="images"} 0.5
+`
+	assert.Nil(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "threadpool_busy_workers", "threadpool_job_duration_seconds",
+		"threadpool_rejected_total", "threadpool_saturation"))
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	WriterNamespace = "async_writer"
+)
+
+// WriterStats counters of an asynchronous, buffered writer
+type WriterStats struct {
+	Buffered int    // Buffered items waiting to be written
+	Capacity int    // Capacity buffer capacity
+	Written  uint64 // Written items written successfully
+	Dropped  uint64 // Dropped items dropped because the buffer was full or the writer was closed
+	Failed   uint64 // Failed items that could not be written
+}
+
+// StatsProvider is implemented by asynchronous writers, such as clickhouse.EventWriter
+type StatsProvider interface {
+	Stats() WriterStats
+}
+
+// writerCollector collects WriterStats
+type writerCollector struct {
+	writer   StatsProvider
+	buffered *prometheus.Desc
+	capacity *prometheus.Desc
+	written  *prometheus.Desc
+	dropped  *prometheus.Desc
+	failed   *prometheus.Desc
+}
+
+// NewWriterCollector creates a collector for an asynchronous writer; name is exported as the "writer" label
+//
+// Example usage:
+//
+//	writer, err := clickhouse.NewEventWriter(client, "audit_log", clickhouse.NewEventWriterConfig())
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	prometheus.MustRegister(metrics.NewWriterCollector("audit", writer))
+func NewWriterCollector(name string, writer StatsProvider) prometheus.Collector {
+	labels := prometheus.Labels{"writer": name}
+	desc := func(metric string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(WriterNamespace, "", metric), help, nil, labels)
+	}
+	return &writerCollector{
+		writer:   writer,
+		buffered: desc("buffered", "Number of items waiting to be written."),
+		capacity: desc("capacity", "Buffer capacity."),
+		written:  desc("written_total", "Total number of items written."),
+		dropped:  desc("dropped_total", "Total number of items dropped due to overload."),
+		failed:   desc("failed_total", "Total number of items that could not be written."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *writerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.buffered
+	ch <- c.capacity
+	ch <- c.written
+	ch <- c.dropped
+	ch <- c.failed
+}
+
+// Collect implements prometheus.Collector
+func (c *writerCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.writer.Stats()
+	ch <- prometheus.MustNewConstMetric(c.buffered, prometheus.GaugeValue, float64(stats.Buffered))
+	ch <- prometheus.MustNewConstMetric(c.capacity, prometheus.GaugeValue, float64(stats.Capacity))
+	ch <- prometheus.MustNewConstMetric(c.written, prometheus.CounterValue, float64(stats.Written))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(stats.Dropped))
+	ch <- prometheus.MustNewConstMetric(c.failed, prometheus.CounterValue, float64(stats.Failed))
+}
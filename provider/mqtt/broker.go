@@ -0,0 +1,81 @@
+package mqtt
+
+import (
+	"context"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/oddbit-project/blueprint/log/zerolog/logctx"
+	"github.com/oddbit-project/blueprint/provider/broker"
+)
+
+// BrokerAdapter adapts Client to broker.Publisher and broker.Subscriber
+// MQTT 3.1.1 messages have no headers, so publishing messages with headers fails with broker.ErrHeadersNotSupported;
+// message keys are ignored. Messages are acknowledged by the client on receipt: handler errors are logged, and the
+// message is not redelivered
+type BrokerAdapter struct {
+	client *Client
+	qos    byte
+}
+
+// NewBrokerAdapter creates a broker.Publisher and broker.Subscriber using client; qos is used for subscriptions
+//
+// Example usage:
+//
+//	client, err := mqtt.NewClient(cfg)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	if _, err = client.Connect(); err != nil {
+//	  log.Fatal(err)
+//	}
+//	var sub broker.Subscriber = mqtt.NewBrokerAdapter(client, 1)
+//	go sub.Subscribe(ctx, "sensors/+/temperature", handler)
+func NewBrokerAdapter(client *Client, qos byte) *BrokerAdapter {
+	return &BrokerAdapter{
+		client: client,
+		qos:    qos,
+	}
+}
+
+// Publish publishes messages with the client QoS and retain settings
+func (a *BrokerAdapter) Publish(_ context.Context, msgs ...*broker.Message) error {
+	for _, msg := range msgs {
+		if len(msg.Topic) == 0 {
+			return broker.ErrMissingTopic
+		}
+		if len(msg.Headers) > 0 {
+			return broker.ErrHeadersNotSupported
+		}
+		if err := a.client.Write(msg.Topic, msg.Payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe subscribes topic, a topic filter, and unsubscribes when ctx is cancelled
+func (a *BrokerAdapter) Subscribe(ctx context.Context, topic string, handler broker.Handler) error {
+	if len(topic) == 0 {
+		return broker.ErrMissingTopic
+	}
+	if handler == nil {
+		return broker.ErrNilHandler
+	}
+	err := a.client.Subscribe(topic, a.qos, func(_ paho.Client, msg paho.Message) {
+		msgCtx := MessageContext(ctx, msg)
+		if err := handler(msgCtx, &broker.Message{Topic: msg.Topic(), Payload: msg.Payload()}); err != nil {
+			logctx.FromContext(msgCtx).Error().Err(err).Msg("error processing message")
+		}
+	})
+	if err != nil {
+		return err
+	}
+	<-ctx.Done()
+	token := a.client.Client.Unsubscribe(topic)
+	token.WaitTimeout(a.client.Timeout)
+	return nil
+}
+
+// Close disconnects the client
+func (a *BrokerAdapter) Close() error {
+	return a.client.Close()
+}
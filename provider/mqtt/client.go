@@ -1,12 +1,17 @@
 package mqtt
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	paho "github.com/eclipse/paho.mqtt.golang"
 	"github.com/oddbit-project/blueprint/generator"
+	"github.com/oddbit-project/blueprint/log/zerolog/logctx"
+	"github.com/oddbit-project/blueprint/provider/metrics"
 	tlsProvider "github.com/oddbit-project/blueprint/provider/tls"
+	"github.com/oddbit-project/blueprint/types/connhooks"
 	"github.com/oddbit-project/blueprint/utils"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,6 +26,8 @@ const (
 	ErrPublishTimeout  = utils.Error("timeout when publishing")
 
 	ErrNilConfig = utils.Error("Config is nil")
+
+	MetricsSystem = "mqtt" // MetricsSystem value of the system label in consumer metrics
 )
 
 type MqttHandlers struct {
@@ -49,12 +56,17 @@ type Config struct {
 	MqttHandlers `json:"-"`
 }
 
+// Client mqtt client; connection hooks are called when the client connects, loses the connection, reconnects
+// (with AutoReconnect) and on Close()
 type Client struct {
 	ClientOptions *paho.ClientOptions
 	Client        paho.Client
 	QoS           byte
 	Timeout       time.Duration
 	Retain        bool
+	metrics       *metrics.ConsumerMetrics
+	connected     atomic.Bool
+	connhooks.Hooks
 }
 
 type connectToken interface {
@@ -115,7 +127,7 @@ func (c *Config) Validate() error {
 	if c.KeepAlive < 0 {
 		return fmt.Errorf("keep alive must be greater than zero")
 	}
-	return nil
+	return c.ClientConfig.Validate()
 }
 
 func NewClient(cfg *Config) (*Client, error) {
@@ -135,6 +147,25 @@ func NewClient(cfg *Config) (*Client, error) {
 		Retain:        true,
 	}
 
+	// connection hooks, called after the configured handlers
+	onConnect, onConnectionLost := opts.OnConnect, opts.OnConnectionLost
+	opts.SetOnConnectHandler(func(client paho.Client) {
+		if onConnect != nil {
+			onConnect(client)
+		}
+		if result.connected.Swap(true) {
+			result.Emit(MetricsSystem, connhooks.Reconnect, nil)
+		} else {
+			result.Emit(MetricsSystem, connhooks.Connect, nil)
+		}
+	})
+	opts.SetConnectionLostHandler(func(client paho.Client, err error) {
+		if onConnectionLost != nil {
+			onConnectionLost(client, err)
+		}
+		result.Emit(MetricsSystem, connhooks.Disconnect, err)
+	})
+
 	// run extra configurations
 	if err = result.CustomSettings(); err != nil {
 		return nil, err
@@ -220,6 +251,8 @@ func (c *Client) Close() error {
 	if c.Client != nil {
 		if c.Client.IsConnected() {
 			c.Client.Disconnect(250)
+			c.connected.Store(false)
+			c.Emit(MetricsSystem, connhooks.Disconnect, nil)
 		}
 	}
 	return nil
@@ -244,13 +277,41 @@ func (c *Client) WriteJson(topic string, value interface{}) error {
 	return c.Write(topic, data)
 }
 
+// UseMetrics enables processing metrics for handlers registered after this call, with Subscribe(),
+// SubscribeMultiple() and AddRoute(); the topic label is the subscription filter
+// MQTT messages have no creation timestamp and handlers do not return errors, so only processing duration and
+// message counts are recorded
+func (c *Client) UseMetrics(m *metrics.ConsumerMetrics) {
+	c.metrics = m
+}
+
+// measure wraps handler to record metrics, if enabled
+func (c *Client) measure(filter string, handler paho.MessageHandler) paho.MessageHandler {
+	if c.metrics == nil || handler == nil {
+		return handler
+	}
+	m := c.metrics
+	return func(client paho.Client, msg paho.Message) {
+		start := time.Now()
+		handler(client, msg)
+		m.Observe(MetricsSystem, filter, "", start, time.Time{}, nil)
+	}
+}
+
 func (c *Client) Subscribe(topic string, qos byte, handler paho.MessageHandler) error {
-	token := c.Client.Subscribe(topic, qos, handler)
+	token := c.Client.Subscribe(topic, qos, c.measure(topic, handler))
 	token.Wait()
 	return token.Error()
 }
 
 func (c *Client) SubscribeMultiple(filters map[string]byte, handler paho.MessageHandler) error {
+	if c.metrics != nil && handler != nil {
+		// each filter is measured separately
+		for filter := range filters {
+			c.Client.AddRoute(filter, c.measure(filter, handler))
+		}
+		handler = nil
+	}
 	token := c.Client.SubscribeMultiple(filters, handler)
 	token.Wait()
 	return token.Error()
@@ -266,5 +327,14 @@ func (c *Client) ChannelSubscribe(topic string, qos byte, ch chan paho.Message)
 }
 
 func (c *Client) AddRoute(topic string, handler paho.MessageHandler) {
-	c.Client.AddRoute(topic, handler)
+	c.Client.AddRoute(topic, c.measure(topic, handler))
+}
+
+// MessageContext returns a context with a logger containing the message topic and id
+// useful to propagate log correlation fields from within message handlers
+func MessageContext(ctx context.Context, msg paho.Message) context.Context {
+	return logctx.WithFields(ctx, map[string]any{
+		logctx.FieldTopic: msg.Topic(),
+		"messageId":       msg.MessageID(),
+	})
 }
@@ -0,0 +1,25 @@
+package mqtt
+
+import (
+	"errors"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/oddbit-project/blueprint/utils"
+)
+
+// MapError maps mqtt errors to the generic provider error categories (utils.ErrUnavailable, utils.ErrTimeout)
+// the original error is preserved, and unknown errors are returned unchanged
+func MapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, paho.ErrNotConnected) {
+		return utils.NewProviderError(utils.ErrUnavailable, err)
+	}
+	if errors.Is(err, ErrPublishTimeout) {
+		return utils.NewProviderError(utils.ErrTimeout, err)
+	}
+	if mapped := utils.MapCommonError(err); mapped != nil {
+		return mapped
+	}
+	return err
+}
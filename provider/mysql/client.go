@@ -0,0 +1,148 @@
+package mysql
+
+import (
+	"errors"
+	"github.com/doug-martin/goqu/v9"
+	goquMysql "github.com/doug-martin/goqu/v9/dialect/mysql"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/utils"
+	"time"
+)
+
+const (
+	DriverName = "mysql"
+
+	DefaultIdleConns          = 2
+	DefaultMaxConns           = 4
+	DefaultConnLifeTimeSecond = 3600
+	DefaultConnIdleTimeSecond = 1800
+
+	ErrEmptyDSN            = utils.Error("Empty DSN")
+	ErrInvalidDSN          = utils.Error("Invalid DSN")
+	ErrNilConfig           = utils.Error("Config is nil")
+	ErrInvalidIdleConns    = utils.Error("Invalid idleConns")
+	ErrInvalidMaxConns     = utils.Error("Invalid maxConns")
+	ErrInvalidConnLifeTime = utils.Error("connLifeTime must be >= 1")
+	ErrInvalidConnIdleTime = utils.Error("connIdleTime must be >= 1")
+
+	errLockWaitTimeout = 1205 // ER_LOCK_WAIT_TIMEOUT
+	errLockDeadlock    = 1213 // ER_LOCK_DEADLOCK
+)
+
+// ClientConfig MySQL/MariaDB client configuration
+// DSN uses the go-sql-driver format, e.g. "user:password@tcp(localhost:3306)/database"
+type ClientConfig struct {
+	DSN          string `json:"dsn"`
+	MaxOpenConns int    `json:"maxOpenConns"` // MaxOpenConns max number of pool connections
+	MaxIdleConns int    `json:"maxIdleConns"` // MaxIdleConns max number of idle pool connections
+
+	// ConnLifeTime is the duration in seconds since creation after which a connection will be automatically closed
+	ConnLifetime int `json:"connLifetime"`
+	// ConnIdleTime is the duration in seconds after which an idle connection will be automatically closed by the health check
+	ConnIdleTime int `json:"connIdleTime"`
+}
+
+func NewClientConfig() *ClientConfig {
+	return &ClientConfig{
+		DSN:          "",
+		MaxIdleConns: DefaultIdleConns,
+		MaxOpenConns: DefaultMaxConns,
+		ConnLifetime: DefaultConnLifeTimeSecond,
+		ConnIdleTime: DefaultConnIdleTimeSecond,
+	}
+}
+
+func (c ClientConfig) Validate() error {
+	if len(c.DSN) == 0 {
+		return ErrEmptyDSN
+	}
+	if _, err := mysql.ParseDSN(c.DSN); err != nil {
+		return ErrInvalidDSN
+	}
+	if c.MaxIdleConns < 0 {
+		return ErrInvalidIdleConns
+	}
+	if c.MaxOpenConns < 1 {
+		return ErrInvalidMaxConns
+	}
+	if c.ConnLifetime < 1 {
+		return ErrInvalidConnLifeTime
+	}
+	if c.ConnIdleTime < 1 {
+		return ErrInvalidConnIdleTime
+	}
+	return nil
+}
+
+func (c ClientConfig) Apply(db *sqlx.DB) error {
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxIdleTime(time.Duration(c.ConnIdleTime) * time.Second)
+	db.SetConnMaxLifetime(time.Duration(c.ConnLifetime) * time.Second)
+	return nil
+}
+
+// NewClient creates a new MySQL client
+// DATE and DATETIME values are always parsed to time.Time; multiple statements per query are disabled, and only
+// enabled on the dedicated connections used by the migration manager
+//
+// Example usage:
+//
+//	cfg := mysql.NewClientConfig()
+//	cfg.DSN = "blueprint:password@tcp(localhost:3306)/blueprint"
+//	client, err := mysql.NewClient(cfg)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	repo := db.NewRepository(ctx, client, "users")
+func NewClient(config *ClientConfig) (*db.SqlClient, error) {
+	if config == nil {
+		return nil, ErrNilConfig
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	dsn, err := normalizeDSN(config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	client := db.NewSqlClient(dsn, DriverName, config)
+	client.SetTxRetryFn(IsRetryable)
+	return client, nil
+}
+
+// IsRetryable returns true if err is a deadlock or lock wait timeout, and the transaction can be retried;
+// it is the db.TxRetryFn of clients created with NewClient()
+func IsRetryable(err error) bool {
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		return myErr.Number == errLockDeadlock || myErr.Number == errLockWaitTimeout
+	}
+	return false
+}
+
+// normalizeDSN enables the driver options required by blueprint
+func normalizeDSN(dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", ErrInvalidDSN
+	}
+	cfg.ParseTime = true
+	cfg.MultiStatements = false
+	return cfg.FormatDSN(), nil
+}
+
+// DialectOptions goqu dialect options for MySQL/MariaDB
+// INSERT IGNORE is disabled, as it silently discards errors other than duplicate keys; conflict handling is
+// rendered as ON DUPLICATE KEY UPDATE instead
+func DialectOptions() *goqu.SQLDialectOptions {
+	opts := goquMysql.DialectOptions()
+	opts.SupportsInsertIgnoreSyntax = false
+	return opts
+}
+
+func init() {
+	goqu.RegisterDialect(DriverName, DialectOptions())
+}
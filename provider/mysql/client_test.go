@@ -0,0 +1,113 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/go-sql-driver/mysql"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestClientConfigValidate(t *testing.T) {
+	defaultCfg := NewClientConfig()
+	defaultCfg.DSN = "blueprint:password@tcp(localhost:3306)/blueprint"
+
+	testCases := []struct {
+		name     string
+		cfg      *ClientConfig
+		expected error
+	}{
+		{
+			name:     "Empty Config",
+			cfg:      &ClientConfig{},
+			expected: ErrEmptyDSN,
+		},
+		{
+			name:     "Invalid DSN",
+			cfg:      &ClientConfig{DSN: "postgres://localhost/blueprint"},
+			expected: ErrInvalidDSN,
+		},
+		{
+			name:     "Default Config",
+			cfg:      defaultCfg,
+			expected: nil,
+		},
+		{
+			name: "Invalid MaxOpenConns",
+			cfg: &ClientConfig{
+				DSN:          defaultCfg.DSN,
+				MaxOpenConns: 0,
+				ConnLifetime: DefaultConnLifeTimeSecond,
+				ConnIdleTime: DefaultConnIdleTimeSecond,
+			},
+			expected: ErrInvalidMaxConns,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, tc.cfg.Validate(), tc.expected)
+		})
+	}
+
+	_, err := NewClient(nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+}
+
+func TestNormalizeDSN(t *testing.T) {
+	dsn, err := normalizeDSN("blueprint:password@tcp(localhost:3306)/blueprint")
+	assert.Nil(t, err)
+	assert.Contains(t, dsn, "parseTime=true")
+	assert.NotContains(t, dsn, "multiStatements=true")
+
+	// multiStatements cannot be enabled on application connections
+	dsn, err = normalizeDSN("blueprint:password@tcp(localhost:3306)/blueprint?multiStatements=true")
+	assert.Nil(t, err)
+	assert.NotContains(t, dsn, "multiStatements=true")
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&mysql.MySQLError{Number: 1213}))
+	assert.True(t, IsRetryable(fmt.Errorf("insert: %w", &mysql.MySQLError{Number: 1205})))
+	assert.False(t, IsRetryable(&mysql.MySQLError{Number: 1062}))
+	assert.False(t, IsRetryable(errors.New("other")))
+}
+
+func TestDialect(t *testing.T) {
+	qry, args, err := goqu.Dialect(DriverName).From("users").Where(goqu.C("id").Eq(1)).Prepared(true).ToSQL()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `users` WHERE (`id` = ?)", qry)
+	assert.Equal(t, []any{int64(1)}, args)
+}
+
+// captureExecer records executed statements
+type captureExecer struct {
+	queries []string
+}
+
+func (c *captureExecer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	c.queries = append(c.queries, query)
+	return nil, nil
+}
+
+func TestUpsertDialect(t *testing.T) {
+	conn := &captureExecer{}
+	qry := goqu.Dialect(DriverName).Insert("users")
+	row := goqu.Record{"email": "john@example.com", "name": "John"}
+
+	assert.Nil(t, db.Upsert(context.Background(), conn, qry, []string{"email"}, []string{"name"}, row))
+	assert.Nil(t, db.Upsert(context.Background(), conn, qry, []string{"email", "name"}, nil, row))
+	assert.Len(t, conn.queries, 2)
+	assert.True(t, strings.HasPrefix(conn.queries[0], "INSERT INTO `users`"))
+	assert.True(t, strings.HasSuffix(conn.queries[0], "ON DUPLICATE KEY UPDATE `name`=VALUES(`name`)"))
+	assert.True(t, strings.HasSuffix(conn.queries[1], "ON DUPLICATE KEY UPDATE `email`=`email`"))
+	for _, q := range conn.queries {
+		assert.NotContains(t, q, "IGNORE")
+		assert.NotContains(t, q, "excluded")
+	}
+}
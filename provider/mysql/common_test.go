@@ -0,0 +1,27 @@
+package mysql
+
+import (
+	"fmt"
+	"github.com/oddbit-project/blueprint/db"
+	"os"
+	"testing"
+)
+
+func getDSN() string {
+	user := os.Getenv("MYSQL_USER")
+	pwd := os.Getenv("MYSQL_PASSWORD")
+	database := os.Getenv("MYSQL_DATABASE")
+	port := os.Getenv("MYSQL_PORT")
+	host := os.Getenv("MYSQL_HOST")
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", user, pwd, host, port, database)
+}
+
+func dbClient(t *testing.T) *db.SqlClient {
+	cfg := NewClientConfig()
+	cfg.DSN = getDSN()
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
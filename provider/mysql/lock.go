@@ -0,0 +1,78 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/utils"
+)
+
+const (
+	ErrLockNotAcquired = utils.Error("lock not acquired")
+	ErrLockNotHeld     = utils.Error("lock not held by this session")
+)
+
+// AdvisoryLock implements named locks using MySQL's GET_LOCK() and RELEASE_LOCK()
+//
+// Locks are bound to a session, so a connection is kept from the pool until Close() is called. As with PostgreSQL
+// advisory locks, locks are stackable: acquiring the same lock multiple times in a session requires the same amount of
+// calls to Unlock()
+//
+// See https://dev.mysql.com/doc/refman/8.0/en/locking-functions.html for more details
+type AdvisoryLock struct {
+	conn *sql.Conn
+	name string
+}
+
+func NewAdvisoryLock(ctx context.Context, db *sqlx.DB, name string) (*AdvisoryLock, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &AdvisoryLock{
+		conn: conn,
+		name: name,
+	}, nil
+}
+
+func (l *AdvisoryLock) Close() {
+	if l.conn != nil {
+		l.conn.Close()
+		l.conn = nil
+	}
+}
+
+// Lock attempts to perform a lock, and waits until it is available or ctx is cancelled
+func (l *AdvisoryLock) Lock(ctx context.Context) error {
+	var result sql.NullInt64
+	// -1 waits indefinitely; the wait is interrupted when ctx is cancelled
+	if err := l.conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", l.name).Scan(&result); err != nil {
+		return err
+	}
+	if !result.Valid || result.Int64 != 1 {
+		return ErrLockNotAcquired
+	}
+	return nil
+}
+
+// TryLock attempts to perform a lock, and returns true if operation was successful
+func (l *AdvisoryLock) TryLock(ctx context.Context) (bool, error) {
+	var result sql.NullInt64
+	if err := l.conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", l.name).Scan(&result); err != nil {
+		return false, err
+	}
+	return result.Valid && result.Int64 == 1, nil
+}
+
+// Unlock unlocks a given lock
+// Unlock of a given lock needs to be done with the same connection
+func (l *AdvisoryLock) Unlock(ctx context.Context) error {
+	var result sql.NullInt64
+	if err := l.conn.QueryRowContext(ctx, "SELECT RELEASE_LOCK(?)", l.name).Scan(&result); err != nil {
+		return err
+	}
+	if !result.Valid || result.Int64 != 1 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
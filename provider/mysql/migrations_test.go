@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"github.com/oddbit-project/blueprint/db/migrations"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	client := dbClient(t)
+	ctx := context.Background()
+	_, err := client.Db().Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", MigrationTable))
+	assert.Nil(t, err)
+
+	src := migrations.NewMemorySource()
+	src.Add("001.sql", "drop table if exists sample;")
+	src.Add("002.sql", "-- migrate:up\ncreate table sample(id int);\n-- migrate:down\ndrop table sample;")
+	src.Add("003.sql", "insert into sample(id) values(1); insert into sample(id) values(2);")
+
+	mgr, err := NewMigrationManager(ctx, client)
+	assert.Nil(t, err)
+	list, err := mgr.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, list, 0)
+
+	assert.Nil(t, mgr.Run(ctx, src, migrations.DefaultProgressFn))
+	list, err = mgr.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, list, 3)
+
+	var count int
+	assert.Nil(t, client.Db().Get(&count, "select count(*) from sample"))
+	assert.Equal(t, 2, count)
+
+	exists, err := mgr.MigrationExists(ctx, "002.sql", list[1].SHA2)
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	// running again skips applied migrations
+	assert.Nil(t, mgr.Run(ctx, src, migrations.DefaultProgressFn))
+
+	// 003.sql has no down section
+	assert.ErrorIs(t, mgr.Rollback(ctx, 1, nil), migrations.ErrNoDownMigration)
+
+	result, err := migrations.Verify(ctx, mgr, src)
+	assert.Nil(t, err)
+	assert.True(t, result.OK())
+}
+
+func TestAdvisoryLock(t *testing.T) {
+	client := dbClient(t)
+	ctx := context.Background()
+
+	l1, err := NewAdvisoryLock(ctx, client.Db(), "blueprint_test")
+	assert.Nil(t, err)
+	defer l1.Close()
+	l2, err := NewAdvisoryLock(ctx, client.Db(), "blueprint_test")
+	assert.Nil(t, err)
+	defer l2.Close()
+
+	assert.Nil(t, l1.Lock(ctx))
+	locked, err := l2.TryLock(ctx)
+	assert.Nil(t, err)
+	assert.False(t, locked)
+	assert.ErrorIs(t, l2.Unlock(ctx), ErrLockNotHeld)
+
+	assert.Nil(t, l1.Unlock(ctx))
+	locked, err = l2.TryLock(ctx)
+	assert.Nil(t, err)
+	assert.True(t, locked)
+	assert.Nil(t, l2.Unlock(ctx))
+}
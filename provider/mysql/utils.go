@@ -0,0 +1,78 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/utils/version"
+	"strings"
+)
+
+const (
+	TblTypeTable = "BASE TABLE"
+	TblTypeView  = "VIEW"
+
+	MinServerVersion  = "8.0"  // MinServerVersion minimum supported MySQL version
+	MinMariaDBVersion = "10.6" // MinMariaDBVersion minimum supported MariaDB version
+)
+
+// GetServerVersion fetch server version
+func GetServerVersion(db *sqlx.DB, ctx context.Context) (string, error) {
+	var result string
+	err := db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&result)
+	return result, err
+}
+
+// IsMariaDB returns true if the server is MariaDB
+func IsMariaDB(ctx context.Context, db *sqlx.DB) (bool, error) {
+	v, err := GetServerVersion(db, ctx)
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(strings.ToLower(v), "mariadb"), nil
+}
+
+// CheckServerVersion checks if the server version is at least minimum; if minimum is empty, MinServerVersion or
+// MinMariaDBVersion is used, according to the server type
+// if warnOnly is true, an older version is only logged as a warning
+func CheckServerVersion(ctx context.Context, db *sqlx.DB, minimum string, warnOnly bool) error {
+	current, err := GetServerVersion(db, ctx)
+	if err != nil {
+		return err
+	}
+	name := "mysql"
+	if strings.Contains(strings.ToLower(current), "mariadb") {
+		name = "mariadb"
+		if minimum == "" {
+			minimum = MinMariaDBVersion
+		}
+	}
+	if minimum == "" {
+		minimum = MinServerVersion
+	}
+	return version.Require(name, current, minimum, warnOnly)
+}
+
+// TableExists returns true if specified table exists; if schema is empty, the current database is used
+func TableExists(ctx context.Context, db *sqlx.DB, tableName string, schema string) (bool, error) {
+	return dbObjectExists(ctx, db, TblTypeTable, tableName, schema)
+}
+
+// ViewExists returns true if specified view exists; if schema is empty, the current database is used
+func ViewExists(ctx context.Context, db *sqlx.DB, tableName string, schema string) (bool, error) {
+	return dbObjectExists(ctx, db, TblTypeView, tableName, schema)
+}
+
+// dbObjectExists checks if given table-like object exists
+func dbObjectExists(ctx context.Context, db *sqlx.DB, tableType string, tableName string, schema string) (bool, error) {
+	var record string
+	qry := "SELECT table_name FROM information_schema.tables WHERE table_schema=COALESCE(NULLIF(?, ''), DATABASE()) AND table_name=? AND table_type=?"
+	if err := db.QueryRowContext(ctx, qry, schema, tableName, tableType).Scan(&record); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
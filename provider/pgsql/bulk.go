@@ -0,0 +1,49 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/utils"
+	"strings"
+)
+
+const (
+	ErrInvalidDriverConn = utils.Error("connection is not a pgx connection")
+)
+
+// BulkInsert inserts rows using COPY FROM, and returns the number of inserted rows
+// rows may be structs (mapped with db tags, as in db.Repository.Insert) or maps, and must share the same fields;
+// table may be schema-qualified (e.g. "public.users")
+//
+// Example usage:
+//
+//	rows := make([]any, 0, len(users))
+//	for _, u := range users {
+//	  rows = append(rows, u)
+//	}
+//	count, err := pgsql.BulkInsert(ctx, client.Db(), "users", rows...)
+func BulkInsert(ctx context.Context, conn *sqlx.DB, table string, rows ...any) (int64, error) {
+	columns, values, err := db.RowValues(rows)
+	if err != nil {
+		return 0, err
+	}
+	c, err := conn.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+
+	var count int64
+	err = c.Raw(func(driverConn any) error {
+		pgConn, ok := db.UnwrapConn(driverConn).(*stdlib.Conn)
+		if !ok {
+			return ErrInvalidDriverConn
+		}
+		count, err = pgConn.Conn().CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), columns, pgx.CopyFromRows(values))
+		return err
+	})
+	return count, err
+}
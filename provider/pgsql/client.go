@@ -32,6 +32,8 @@ type ClientConfig struct {
 	ConnLifetime int `json:"connLifetime"`
 	// ConnIdleTime is the duration in seconds after which an idle connection will be automatically closed by the health check
 	ConnIdleTime int `json:"connIdleTime"`
+	// QueryLog enables statement logging, see db.QueryLogger; if nil, statements are not logged
+	QueryLog *db.QueryLoggerConfig `json:"queryLog,omitempty"`
 }
 
 func NewClientConfig() *ClientConfig {
@@ -60,6 +62,9 @@ func (c ClientConfig) Validate() error {
 	if c.ConnIdleTime < 1 {
 		return ErrInvalidConnIdleTime
 	}
+	if c.QueryLog != nil {
+		return c.QueryLog.Validate()
+	}
 	return nil
 }
 
@@ -78,7 +83,12 @@ func NewClient(config *ClientConfig) (*db.SqlClient, error) {
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
-	return db.NewSqlClient(config.DSN, "pgx", config), nil
+	client := db.NewSqlClient(config.DSN, "pgx", config)
+	client.SetTxRetryFn(IsRetryable)
+	if config.QueryLog != nil {
+		client.AddQueryHook(db.NewQueryLogger(config.QueryLog))
+	}
+	return client, nil
 }
 
 func DialectOptions() *goqu.SQLDialectOptions {
@@ -2,6 +2,7 @@ package pgsql
 
 import (
 	"errors"
+	"github.com/oddbit-project/blueprint/db"
 	"testing"
 )
 
@@ -36,6 +37,18 @@ func TestClientConfigValidate(t *testing.T) {
 			},
 			expected: nil,
 		},
+		{
+			name: "Invalid Query Log",
+			cfg: &ClientConfig{
+				DSN:          defaultCfg.DSN,
+				MaxIdleConns: DefaultIdleConns,
+				MaxOpenConns: DefaultMaxConns,
+				ConnLifetime: DefaultConnLifeTimeSecond,
+				ConnIdleTime: DefaultConnIdleTimeSecond,
+				QueryLog:     &db.QueryLoggerConfig{SlowQueryThreshold: -1},
+			},
+			expected: db.ErrInvalidSlowQueryThreshold,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,364 @@
+package pgsql
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultHealthCheckInterval = 10 // seconds
+	DefaultMaxReplicationLag   = 30 // seconds
+	DefaultHealthCheckTimeout  = 5  // seconds
+
+	ErrNilClusterConfig           = utils.Error("ClusterConfig is nil")
+	ErrInvalidHealthCheckInterval = utils.Error("healthCheckInterval must be >= 1")
+	ErrInvalidMaxReplicationLag   = utils.Error("maxReplicationLag must be >= 0")
+	ErrInvalidHealthCheckTimeout  = utils.Error("healthCheckTimeout must be >= 1")
+	ErrEmptyReplicaDSN            = utils.Error("Empty replica DSN")
+	ErrInvalidConsistencyKey      = utils.Error("consistencyKey must have at least 32 characters")
+)
+
+// ClusterConfig primary and replica configuration; replicas use the pool settings of the primary
+type ClusterConfig struct {
+	ClientConfig
+	Replicas []string `json:"replicas"` // Replicas replica DSNs
+	// MaxReplicationLag max replication lag in seconds for a replica to receive reads; 0 disables the lag check
+	MaxReplicationLag int `json:"maxReplicationLag"`
+	// HealthCheckInterval interval in seconds between replica health checks
+	HealthCheckInterval int `json:"healthCheckInterval"`
+	// HealthCheckTimeout timeout in seconds of each replica health check
+	HealthCheckTimeout int `json:"healthCheckTimeout"`
+	// ConsistencyKey secret used to sign consistency tokens; if empty, a random key is generated, and tokens are
+	// only accepted by the instance that issued them
+	ConsistencyKey string `json:"consistencyKey"`
+}
+
+// replica replica client and health status
+type replica struct {
+	client   *db.SqlClient
+	healthy  atomic.Bool
+	replayed atomic.Uint64 // replayed last known replayed LSN
+}
+
+// Cluster is a client for a primary server and zero or more read replicas
+// read-only repository operations are routed to healthy replicas in round-robin; writes, transactions and
+// reads are routed to the primary if no replica is available
+type Cluster struct {
+	config     *ClusterConfig
+	primary    *db.SqlClient
+	replicas   []*replica
+	next       atomic.Uint32
+	tokenKey   []byte
+	primaryLSN atomic.Uint64 // primaryLSN last known WAL position of the primary
+	stopFn     context.CancelFunc
+	mx         sync.Mutex
+}
+
+func NewClusterConfig() *ClusterConfig {
+	return &ClusterConfig{
+		ClientConfig:        *NewClientConfig(),
+		Replicas:            make([]string, 0),
+		MaxReplicationLag:   DefaultMaxReplicationLag,
+		HealthCheckInterval: DefaultHealthCheckInterval,
+		HealthCheckTimeout:  DefaultHealthCheckTimeout,
+	}
+}
+
+func (c *ClusterConfig) Validate() error {
+	if err := c.ClientConfig.Validate(); err != nil {
+		return err
+	}
+	for _, dsn := range c.Replicas {
+		if len(dsn) == 0 {
+			return ErrEmptyReplicaDSN
+		}
+	}
+	if c.MaxReplicationLag < 0 {
+		return ErrInvalidMaxReplicationLag
+	}
+	if c.HealthCheckInterval < 1 {
+		return ErrInvalidHealthCheckInterval
+	}
+	if c.HealthCheckTimeout < 1 {
+		return ErrInvalidHealthCheckTimeout
+	}
+	if len(c.ConsistencyKey) > 0 && len(c.ConsistencyKey) < 32 {
+		return ErrInvalidConsistencyKey
+	}
+	return nil
+}
+
+// NewCluster creates a new Cluster client
+//
+// Example usage:
+//
+//	cfg := pgsql.NewClusterConfig()
+//	if err := config.GetKey("db", cfg); err != nil {
+//	  log.Fatal(err)
+//	}
+//	cluster, err := pgsql.NewCluster(cfg)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	if err = cluster.Connect(ctx); err != nil {
+//	  log.Fatal(err)
+//	}
+//	defer cluster.Disconnect()
+//
+//	repo := cluster.NewRepository(ctx, "users")
+//	err = repo.FetchByKey("id_user", 1, user) // executed on a replica
+//	err = repo.Insert(user)                   // executed on the primary
+func NewCluster(config *ClusterConfig) (*Cluster, error) {
+	if config == nil {
+		return nil, ErrNilClusterConfig
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	primary, err := NewClient(&config.ClientConfig)
+	if err != nil {
+		return nil, err
+	}
+	tokenKey := []byte(config.ConsistencyKey)
+	if len(tokenKey) == 0 {
+		tokenKey = make([]byte, 32)
+		if _, err = rand.Read(tokenKey); err != nil {
+			return nil, err
+		}
+	}
+	result := &Cluster{
+		config:   config,
+		primary:  primary,
+		replicas: make([]*replica, 0, len(config.Replicas)),
+		tokenKey: tokenKey,
+	}
+	for _, dsn := range config.Replicas {
+		replicaCfg := config.ClientConfig
+		replicaCfg.DSN = dsn
+		client, err := NewClient(&replicaCfg)
+		if err != nil {
+			return nil, err
+		}
+		result.replicas = append(result.replicas, &replica{client: client})
+	}
+	return result, nil
+}
+
+// Connect connects to the primary server, and starts the replica health check until ctx is cancelled or
+// Disconnect() is called; replicas that fail to connect are retried by the health check
+func (c *Cluster) Connect(ctx context.Context) error {
+	if err := c.primary.Connect(); err != nil {
+		return err
+	}
+	c.CheckReplicas(ctx)
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.stopFn != nil {
+		c.stopFn()
+	}
+	var checkCtx context.Context
+	checkCtx, c.stopFn = context.WithCancel(ctx)
+	go c.healthCheck(checkCtx)
+	return nil
+}
+
+// Disconnect stops the health check and closes all connections
+func (c *Cluster) Disconnect() {
+	c.mx.Lock()
+	if c.stopFn != nil {
+		c.stopFn()
+		c.stopFn = nil
+	}
+	c.mx.Unlock()
+	c.primary.Disconnect()
+	for _, r := range c.replicas {
+		r.healthy.Store(false)
+		r.client.Disconnect()
+	}
+}
+
+// Primary returns the primary client
+func (c *Cluster) Primary() *db.SqlClient {
+	return c.primary
+}
+
+// Replica returns a healthy replica client in round-robin, or the primary client if no replica is healthy
+func (c *Cluster) Replica() *db.SqlClient {
+	count := len(c.replicas)
+	if count == 0 {
+		return c.primary
+	}
+	start := int(c.next.Add(1))
+	for i := 0; i < count; i++ {
+		r := c.replicas[(start+i)%count]
+		if r.healthy.Load() {
+			return r.client
+		}
+	}
+	return c.primary
+}
+
+// HealthyReplicas returns the number of replicas currently receiving reads
+func (c *Cluster) HealthyReplicas() int {
+	result := 0
+	for _, r := range c.replicas {
+		if r.healthy.Load() {
+			result++
+		}
+	}
+	return result
+}
+
+// CheckReplicas checks the connectivity and replication lag of all replicas, and updates their health status and
+// replayed positions; the current position of the primary is also refreshed
+// replicas are checked concurrently, each with a HealthCheckTimeout timeout
+func (c *Cluster) CheckReplicas(ctx context.Context) {
+	timeout := time.Duration(c.config.HealthCheckTimeout) * time.Second
+	if c.primary.IsConnected() {
+		primaryCtx, cancel := context.WithTimeout(ctx, timeout)
+		if _, err := c.currentLSN(primaryCtx); err != nil {
+			log.Warn().Err(err).Msg("failed to read primary WAL position")
+		}
+		cancel()
+	}
+	maxLag := time.Duration(c.config.MaxReplicationLag) * time.Second
+	wg := sync.WaitGroup{}
+	for i, r := range c.replicas {
+		wg.Add(1)
+		go func(i int, r *replica) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			lag, err := c.checkReplica(checkCtx, r)
+			healthy := err == nil && (maxLag == 0 || lag <= maxLag)
+			if r.healthy.Swap(healthy) != healthy {
+				if healthy {
+					log.Info().Int("replica", i).Dur("lag", lag).Msg("replica is healthy")
+				} else {
+					log.Warn().Err(err).Int("replica", i).Dur("lag", lag).Msg("replica is unhealthy, reads routed to other servers")
+				}
+			}
+		}(i, r)
+	}
+	wg.Wait()
+}
+
+// checkReplica connects the replica if necessary, updates its replayed LSN, and returns its replication lag
+// the lag is the time since the last replayed transaction, or 0 if the replica replayed all the WAL it received,
+// so replicas of idle primaries are not considered lagging
+func (c *Cluster) checkReplica(ctx context.Context, r *replica) (time.Duration, error) {
+	if !r.client.IsConnected() {
+		if err := r.client.Connect(); err != nil {
+			return 0, err
+		}
+	}
+	var lag sql.NullFloat64
+	qry := `SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()) END`
+	if err := r.client.Conn.QueryRowContext(ctx, qry).Scan(&lag); err != nil {
+		return 0, err
+	}
+	if _, err := c.replayedLSN(ctx, r); err != nil {
+		return 0, err
+	}
+	// lag is NULL if the server is not replicating
+	return time.Duration(lag.Float64 * float64(time.Second)), nil
+}
+
+func (c *Cluster) healthCheck(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(c.config.HealthCheckInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.CheckReplicas(ctx)
+		}
+	}
+}
+
+// NewRepository creates a repository that routes read-only operations to replicas, and all other operations
+// and transactions to the primary
+// Note: writes may not be immediately visible on replicas; for read-after-write queries, use Primary(), or pass a
+// context with a consistency token (see WriteToken())
+func (c *Cluster) NewRepository(ctx context.Context, tableName string) db.Repository {
+	return &clusterRepository{
+		Repository: db.NewRepository(ctx, c.primary, tableName),
+		cluster:    c,
+		ctx:        ctx,
+	}
+}
+
+// clusterRepository db.Repository that executes read operations on replicas
+type clusterRepository struct {
+	db.Repository
+	cluster     *Cluster
+	ctx         context.Context
+	withDeleted bool
+}
+
+// reader returns a repository for a replica
+func (r *clusterRepository) reader() db.Repository {
+	result := db.NewRepository(r.ctx, r.cluster.ReplicaFor(r.ctx), r.Name())
+	if r.withDeleted {
+		return result.WithDeleted()
+	}
+	return result
+}
+
+func (r *clusterRepository) WithDeleted() db.Repository {
+	result := *r
+	result.Repository = r.Repository.WithDeleted()
+	result.withDeleted = true
+	return &result
+}
+
+func (r *clusterRepository) FetchOne(qry *goqu.SelectDataset, target any) error {
+	return r.reader().FetchOne(qry, target)
+}
+
+func (r *clusterRepository) Fetch(qry *goqu.SelectDataset, target any) error {
+	return r.reader().Fetch(qry, target)
+}
+
+func (r *clusterRepository) FetchRecord(fieldValues map[string]any, target any) error {
+	return r.reader().FetchRecord(fieldValues, target)
+}
+
+func (r *clusterRepository) FetchWhere(fieldValues map[string]any, target any) error {
+	return r.reader().FetchWhere(fieldValues, target)
+}
+
+func (r *clusterRepository) FetchByKey(keyField string, value any, target any) error {
+	return r.reader().FetchByKey(keyField, value, target)
+}
+
+func (r *clusterRepository) Exists(fieldName string, fieldValue any, skip ...any) (bool, error) {
+	return r.reader().Exists(fieldName, fieldValue, skip...)
+}
+
+func (r *clusterRepository) ExistsScoped(model any, fieldName string, fieldValue any, skip ...any) (bool, error) {
+	return r.reader().ExistsScoped(model, fieldName, fieldValue, skip...)
+}
+
+func (r *clusterRepository) Count() (int64, error) {
+	return r.reader().Count()
+}
+
+func (r *clusterRepository) CountWhere(fieldValues map[string]any) (int64, error) {
+	return r.reader().CountWhere(fieldValues)
+}
+
+func (r *clusterRepository) CountScoped(model any, fieldValues map[string]any) (int64, error) {
+	return r.reader().CountScoped(model, fieldValues)
+}
@@ -0,0 +1,150 @@
+package pgsql
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/rs/zerolog/log"
+	"strconv"
+	"strings"
+)
+
+const (
+	ErrInvalidLSN   = utils.Error("invalid LSN")
+	ErrInvalidToken = utils.Error("invalid consistency token")
+)
+
+// WriteToken returns a signed consistency token with the current WAL position (LSN) of the primary server, to be
+// used after writes; reads with the token in the context (see db.WithConsistencyToken()) are routed to replicas that
+// already replayed the writes, or to the primary
+//
+// Example usage:
+//
+//	if err = repo.Insert(order); err != nil {
+//	  return err
+//	}
+//	token, err := cluster.WriteToken(ctx)
+//	if err != nil {
+//	  return err
+//	}
+//	httpserver.SetConsistencyToken(c, token)
+func (c *Cluster) WriteToken(ctx context.Context) (string, error) {
+	lsn, err := c.currentLSN(ctx)
+	if err != nil {
+		return "", err
+	}
+	return c.signToken(lsn), nil
+}
+
+// ReplicaFor returns a client for reads of ctx; if ctx has a consistency token, a healthy replica that replayed the
+// token position is returned, or the primary if no replica is caught up; invalid tokens and tokens ahead of the
+// primary position route reads to the primary
+// replica positions are refreshed by the health check, so reads shortly after a write usually go to the primary
+func (c *Cluster) ReplicaFor(ctx context.Context) *db.SqlClient {
+	token, ok := db.ConsistencyToken(ctx)
+	if !ok {
+		return c.Replica()
+	}
+	lsn, err := c.verifyToken(token)
+	if err != nil {
+		log.Debug().Err(err).Msg("invalid consistency token, reading from primary")
+		return c.primary
+	}
+	if lsn > c.primaryLSN.Load() {
+		log.Debug().Str("token", token).Msg("consistency token ahead of primary position, reading from primary")
+		return c.primary
+	}
+	count := len(c.replicas)
+	start := int(c.next.Add(1))
+	for i := 0; i < count; i++ {
+		r := c.replicas[(start+i)%count]
+		if r.healthy.Load() && r.replayed.Load() >= lsn {
+			return r.client
+		}
+	}
+	return c.primary
+}
+
+// currentLSN queries the current WAL position of the primary, and updates the known primary position
+func (c *Cluster) currentLSN(ctx context.Context) (uint64, error) {
+	var value string
+	if err := c.primary.Conn.QueryRowContext(ctx, "SELECT pg_current_wal_lsn()::text").Scan(&value); err != nil {
+		return 0, err
+	}
+	lsn, err := ParseLSN(value)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		current := c.primaryLSN.Load()
+		if lsn <= current || c.primaryLSN.CompareAndSwap(current, lsn) {
+			return lsn, nil
+		}
+	}
+}
+
+// replayedLSN queries and caches the WAL position replayed by a replica
+func (c *Cluster) replayedLSN(ctx context.Context, r *replica) (uint64, error) {
+	var value sql.NullString
+	if err := r.client.Conn.QueryRowContext(ctx, "SELECT pg_last_wal_replay_lsn()::text").Scan(&value); err != nil {
+		return 0, err
+	}
+	// NULL if the server is not replicating
+	if !value.Valid {
+		return 0, nil
+	}
+	lsn, err := ParseLSN(value.String)
+	if err != nil {
+		return 0, err
+	}
+	r.replayed.Store(lsn)
+	return lsn, nil
+}
+
+// signToken returns a token in the format <LSN>.<signature>
+func (c *Cluster) signToken(lsn uint64) string {
+	value := FormatLSN(lsn)
+	return value + "." + c.tokenSignature(value)
+}
+
+// verifyToken checks the token signature, and returns its LSN
+func (c *Cluster) verifyToken(token string) (uint64, error) {
+	value, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(c.tokenSignature(value))) {
+		return 0, ErrInvalidToken
+	}
+	return ParseLSN(value)
+}
+
+func (c *Cluster) tokenSignature(value string) string {
+	mac := hmac.New(sha256.New, c.tokenKey)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ParseLSN parses a PostgreSQL LSN in the X/Y hexadecimal format
+func ParseLSN(value string) (uint64, error) {
+	hi, lo, ok := strings.Cut(value, "/")
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidLSN, value)
+	}
+	h, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidLSN, value)
+	}
+	l, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidLSN, value)
+	}
+	return h<<32 | l, nil
+}
+
+// FormatLSN formats an LSN in the X/Y hexadecimal format
+func FormatLSN(lsn uint64) string {
+	return strings.ToUpper(strconv.FormatUint(lsn>>32, 16) + "/" + strconv.FormatUint(lsn&0xFFFFFFFF, 16))
+}
@@ -0,0 +1,78 @@
+package pgsql
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/oddbit-project/blueprint/utils"
+	"strings"
+)
+
+// pgErrorKinds maps PostgreSQL SQLSTATE codes to generic error categories
+var pgErrorKinds = map[string]utils.Error{
+	"23505": utils.ErrConflict,     // unique_violation
+	"23P01": utils.ErrConflict,     // exclusion_violation
+	"40001": utils.ErrConflict,     // serialization_failure
+	"40P01": utils.ErrConflict,     // deadlock_detected
+	"28000": utils.ErrUnauthorized, // invalid_authorization_specification
+	"28P01": utils.ErrUnauthorized, // invalid_password
+	"42501": utils.ErrUnauthorized, // insufficient_privilege
+	"42P01": utils.ErrNotFound,     // undefined_table
+	"3D000": utils.ErrNotFound,     // invalid_catalog_name
+	"53300": utils.ErrUnavailable,  // too_many_connections
+	"57P01": utils.ErrUnavailable,  // admin_shutdown
+	"57P02": utils.ErrUnavailable,  // crash_shutdown
+	"57P03": utils.ErrUnavailable,  // cannot_connect_now
+	"57014": utils.ErrTimeout,      // query_canceled
+	"55P03": utils.ErrTimeout,      // lock_not_available
+}
+
+// MapError maps pgx and database/sql errors to the generic provider error categories (utils.ErrNotFound,
+// utils.ErrConflict, utils.ErrUnauthorized, utils.ErrUnavailable, utils.ErrTimeout)
+// the original error is preserved, and unknown errors are returned unchanged
+//
+// Example usage:
+//
+//	err := pgsql.MapError(repo.FetchByKey("id", 1, record))
+//	if errors.Is(err, utils.ErrNotFound) {
+//	  // ...
+//	}
+func MapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+		return utils.NewProviderError(utils.ErrNotFound, err)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if kind, ok := pgErrorKinds[pgErr.Code]; ok {
+			return utils.NewProviderError(kind, err)
+		}
+		// class 08 - connection exception
+		if strings.HasPrefix(pgErr.Code, "08") {
+			return utils.NewProviderError(utils.ErrUnavailable, err)
+		}
+		return err
+	}
+	if mapped := utils.MapCommonError(err); mapped != nil {
+		return mapped
+	}
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) {
+		return utils.NewProviderError(utils.ErrUnavailable, err)
+	}
+	return err
+}
+
+// IsRetryable returns true if err is a serialization failure or deadlock, and the transaction can be retried;
+// it is the db.TxRetryFn of clients created with NewClient()
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// serialization_failure, deadlock_detected
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package pgsql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMapError(t *testing.T) {
+	assert.Nil(t, MapError(nil))
+	assert.ErrorIs(t, MapError(sql.ErrNoRows), utils.ErrNotFound)
+	assert.ErrorIs(t, MapError(sql.ErrNoRows), sql.ErrNoRows)
+
+	cases := map[string]utils.Error{
+		"23505": utils.ErrConflict,
+		"28P01": utils.ErrUnauthorized,
+		"08006": utils.ErrUnavailable,
+		"57014": utils.ErrTimeout,
+	}
+	for code, kind := range cases {
+		err := MapError(&pgconn.PgError{Code: code})
+		assert.ErrorIs(t, err, kind, code)
+	}
+
+	// unknown errors are returned unchanged
+	other := errors.New("other")
+	assert.Equal(t, other, MapError(other))
+	pgErr := &pgconn.PgError{Code: "22001"}
+	assert.Equal(t, pgErr, MapError(pgErr))
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, IsRetryable(fmt.Errorf("commit: %w", &pgconn.PgError{Code: "40P01"})))
+	assert.False(t, IsRetryable(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, IsRetryable(errors.New("other")))
+}
@@ -0,0 +1,72 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueryHooks(t *testing.T) {
+	cfg := NewClientConfig()
+	cfg.DSN = getDSN()
+	cfg.QueryLog = db.NewQueryLoggerConfig()
+	cfg.QueryLog.LogQueries = true
+	client, err := NewClient(cfg)
+	assert.Nil(t, err)
+
+	events := make([]*db.QueryEvent, 0)
+	mx := sync.Mutex{}
+	client.AddQueryHook(db.QueryHookFunc(func(ctx context.Context, event *db.QueryEvent) {
+		mx.Lock()
+		defer mx.Unlock()
+		events = append(events, event)
+	}))
+	assert.Nil(t, client.Connect())
+	defer client.Disconnect()
+
+	var result int
+	assert.Nil(t, client.Db().GetContext(context.Background(), &result, "SELECT $1::int + 1", 41))
+	assert.Equal(t, 42, result)
+	_, err = client.Db().ExecContext(context.Background(), "SELECT * FROM non_existing_table")
+	assert.NotNil(t, err)
+
+	// statements in transactions
+	tx, err := client.Db().Beginx()
+	assert.Nil(t, err)
+	_, err = tx.Exec("SELECT 1")
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Rollback())
+
+	mx.Lock()
+	defer mx.Unlock()
+	assert.Len(t, events, 3)
+	assert.Equal(t, "SELECT $1::int + 1", events[0].Query)
+	assert.Len(t, events[0].Args, 1)
+	assert.EqualValues(t, 41, events[0].Args[0])
+	assert.Nil(t, events[0].Err)
+	assert.NotNil(t, events[1].Err)
+	assert.Equal(t, "SELECT 1", events[2].Query)
+}
+
+func TestBulkInsertWithHooks(t *testing.T) {
+	cfg := NewClientConfig()
+	cfg.DSN = getDSN()
+	cfg.QueryLog = db.NewQueryLoggerConfig()
+	cfg.QueryLog.LogQueries = true
+	client, err := NewClient(cfg)
+	assert.Nil(t, err)
+	assert.Nil(t, client.Connect())
+	defer client.Disconnect()
+	dbCleanup(t, client)
+
+	rows := make([]any, 0)
+	for i := 0; i < 10; i++ {
+		rows = append(rows, &sampleRecord{CreatedAt: time.Now(), Label: "hooked"})
+	}
+	count, err := BulkInsert(context.Background(), client.Db(), sampleTable, rows...)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), count)
+}
@@ -101,13 +101,18 @@ func (b *pgMigrationManager) MigrationExists(ctx context.Context, name string, s
 
 // runMigration internal function to execute migrations, called by RunMigration() and Run()
 func (b *pgMigrationManager) runMigration(ctx context.Context, m *migrations.MigrationRecord) error {
-	tx, err := b.db.Begin()
+	tx, err := b.db.Beginx()
 	if err != nil {
 		return err
 	}
 
 	// execute migration
-	if _, err := tx.ExecContext(ctx, m.Contents); err != nil {
+	if m.Func != nil {
+		err = m.Func(ctx, tx)
+	} else {
+		_, err = tx.ExecContext(ctx, m.Up())
+	}
+	if err != nil {
 		_ = tx.Rollback()
 		return err
 	}
@@ -223,3 +228,121 @@ func (b *pgMigrationManager) Run(ctx context.Context, src migrations.Source, con
 	}
 	return nil
 }
+
+// Plan returns the migrations from src that were not applied yet, in execution order
+func (b *pgMigrationManager) Plan(ctx context.Context, src migrations.Source) ([]*migrations.MigrationRecord, error) {
+	files, err := src.List()
+	if err != nil {
+		return nil, err
+	}
+	migList, err := b.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prevNames := make([]string, len(migList))
+	for i, r := range migList {
+		prevNames[i] = r.Name
+	}
+
+	result := make([]*migrations.MigrationRecord, 0)
+	for _, f := range files {
+		if slices.Contains(prevNames, f) {
+			continue
+		}
+		record, err := src.Read(f)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, record)
+	}
+	return result, nil
+}
+
+// Rollback reverts the last steps migrations, most recent first, using the down section of the registered contents
+// Example:
+//
+//	// revert the last migration
+//	if err := mm.Rollback(context.Background(), 1, DefaultProgressFn); err != nil {
+//	   panic(err)
+//	}
+func (b *pgMigrationManager) Rollback(ctx context.Context, steps int, consoleFn migrations.ProgressFn) error {
+	if consoleFn == nil {
+		consoleFn = migrations.DefaultProgressFn
+	}
+
+	lock, err := NewAdvisoryLock(ctx, b.db, MigrationLockId)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := lock.Lock(ctx); err != nil {
+		return err
+	}
+	defer lock.Unlock(ctx)
+
+	records, err := migrations.RollbackPlan(ctx, b, steps)
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		consoleFn(migrations.MsgRollbackMigration, r.Name, nil)
+		if err = b.rollbackMigration(ctx, r); err != nil {
+			consoleFn(migrations.MsgError, r.Name, err)
+			return err
+		}
+		consoleFn(migrations.MsgFinishedRollback, r.Name, nil)
+	}
+	return nil
+}
+
+// rollbackMigration executes the down section of a migration and removes its registration, in a single transaction
+func (b *pgMigrationManager) rollbackMigration(ctx context.Context, m *migrations.MigrationRecord) error {
+	down := m.Down()
+	if len(down) == 0 {
+		return migrations.ErrNoDownMigration
+	}
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, down); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	qry := fmt.Sprintf("DELETE FROM %s WHERE name=$1", EngineMigrationTable)
+	if _, err = tx.ExecContext(ctx, qry, m.Name); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Repair re-baselines drifted migrations, updating the registered checksum and contents with the ones from src
+// it returns the verification result prior to the repair; missing and out-of-order migrations are not modified
+func (b *pgMigrationManager) Repair(ctx context.Context, src migrations.Source) (*migrations.VerifyResult, error) {
+	lock, err := NewAdvisoryLock(ctx, b.db, MigrationLockId)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Close()
+	if err := lock.Lock(ctx); err != nil {
+		return nil, err
+	}
+	defer lock.Unlock(ctx)
+
+	result, err := migrations.Verify(ctx, b, src)
+	if err != nil {
+		return nil, err
+	}
+	qry := fmt.Sprintf("UPDATE %s SET sha2=$1, contents=$2 WHERE name=$3", EngineMigrationTable)
+	for _, name := range result.Drifted {
+		record, err := src.Read(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = b.db.ExecContext(ctx, qry, record.SHA2, record.Contents, record.Name); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
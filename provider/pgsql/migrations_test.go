@@ -1,8 +1,10 @@
 package pgsql
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"github.com/jmoiron/sqlx"
 	"github.com/oddbit-project/blueprint/db/migrations"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -34,3 +36,161 @@ func TestMigrations(t *testing.T) {
 
 	assert.Equal(t, "sample1.sql", list[0].Name)
 }
+
+func TestSplitMigration(t *testing.T) {
+	up, down := migrations.SplitMigration("-- migrate:up\ncreate table a(id int);\n\n-- migrate:down\ndrop table a;\n")
+	assert.Equal(t, "create table a(id int);", up)
+	assert.Equal(t, "drop table a;", down)
+
+	up, down = migrations.SplitMigration("create table a(id int);")
+	assert.Equal(t, "create table a(id int);", up)
+	assert.Equal(t, "", down)
+}
+
+func TestMigrationRollback(t *testing.T) {
+	client := dbClient(t)
+	ctx := context.Background()
+	_, err := client.Db().Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", EngineMigrationTable))
+	assert.Nil(t, err)
+	_, err = client.Db().Exec("DROP TABLE IF EXISTS rollback_sample")
+	assert.Nil(t, err)
+
+	src := migrations.NewMemorySource()
+	src.Add("001.sql", "-- migrate:up\ncreate table rollback_sample(id int);\n-- migrate:down\ndrop table rollback_sample;")
+	src.Add("002.sql", "-- migrate:up\ninsert into rollback_sample(id) values(1);\n-- migrate:down\ndelete from rollback_sample;")
+
+	mgr, err := NewMigrationManager(ctx, client)
+	assert.Nil(t, err)
+
+	// dry run does not execute
+	buf := &bytes.Buffer{}
+	assert.Nil(t, migrations.DryRun(ctx, mgr, src, buf))
+	assert.Contains(t, buf.String(), "create table rollback_sample")
+	assert.NotContains(t, buf.String(), "drop table")
+	exists, err := TableExists(ctx, client.Db(), "rollback_sample", SchemaDefault)
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	assert.Nil(t, mgr.Run(ctx, src, migrations.DefaultProgressFn))
+	pending, err := mgr.Plan(ctx, src)
+	assert.Nil(t, err)
+	assert.Len(t, pending, 0)
+
+	buf.Reset()
+	assert.Nil(t, migrations.DryRunRollback(ctx, mgr, 2, buf))
+	assert.Contains(t, buf.String(), "-- rollback: 002.sql\ndelete from rollback_sample;")
+
+	// rollback last migration
+	assert.Nil(t, mgr.Rollback(ctx, 1, migrations.DefaultProgressFn))
+	list, err := mgr.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, list, 1)
+
+	// rollback remaining
+	assert.Nil(t, mgr.Rollback(ctx, 5, migrations.DefaultProgressFn))
+	exists, err = TableExists(ctx, client.Db(), "rollback_sample", SchemaDefault)
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	assert.ErrorIs(t, mgr.Rollback(ctx, 0, nil), migrations.ErrInvalidSteps)
+}
+
+func TestMigrationVerify(t *testing.T) {
+	client := dbClient(t)
+	ctx := context.Background()
+	_, err := client.Db().Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", EngineMigrationTable))
+	assert.Nil(t, err)
+
+	src := migrations.NewMemorySource()
+	src.Add("001.sql", "select 1;")
+	src.Add("003.sql", "select 3;")
+	src.Add("004.sql", "select 4;")
+
+	mgr, err := NewMigrationManager(ctx, client)
+	assert.Nil(t, err)
+	assert.Nil(t, mgr.Run(ctx, src, migrations.DefaultProgressFn))
+
+	result, err := migrations.Verify(ctx, mgr, src)
+	assert.Nil(t, err)
+	assert.True(t, result.OK())
+
+	// edit, remove and add out of order
+	changed := migrations.NewMemorySource()
+	changed.Add("001.sql", "select 10;")
+	changed.Add("002.sql", "select 2;")
+	changed.Add("003.sql", "select 3;")
+	changed.Add("005.sql", "select 5;")
+
+	result, err = migrations.Verify(ctx, mgr, changed)
+	assert.Nil(t, err)
+	assert.False(t, result.OK())
+	assert.Equal(t, []string{"001.sql"}, result.Drifted)
+	assert.Equal(t, []string{"004.sql"}, result.Missing)
+	assert.Equal(t, []string{"002.sql"}, result.OutOfOrder)
+	assert.Equal(t, []string{"002.sql", "005.sql"}, result.Pending)
+
+	// repair re-baselines checksums
+	result, err = mgr.Repair(ctx, changed)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"001.sql"}, result.Drifted)
+	result, err = migrations.Verify(ctx, mgr, changed)
+	assert.Nil(t, err)
+	assert.Len(t, result.Drifted, 0)
+}
+
+func TestFuncMigration(t *testing.T) {
+	client := dbClient(t)
+	ctx := context.Background()
+	_, err := client.Db().Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", EngineMigrationTable))
+	assert.Nil(t, err)
+
+	sqlSrc := migrations.NewMemorySource()
+	sqlSrc.Add("001_users.sql", "drop table if exists fn_users; create table fn_users(id int, slug text);")
+	sqlSrc.Add("003_index.sql", "create index on fn_users(slug);")
+
+	src := migrations.NewFuncSource(sqlSrc)
+	backfill := migrations.FuncMigration{
+		Name:    "002_backfill",
+		Version: "1",
+		Up: func(ctx context.Context, tx *sqlx.Tx) error {
+			for i := 1; i <= 3; i++ {
+				if _, err := tx.ExecContext(ctx, "insert into fn_users(id, slug) values($1, $2)", i, fmt.Sprintf("user-%d", i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	assert.ErrorIs(t, src.Add(migrations.FuncMigration{Version: "1", Up: backfill.Up}), migrations.ErrMissingMigrationName)
+	assert.ErrorIs(t, src.Add(migrations.FuncMigration{Name: "x", Up: backfill.Up}), migrations.ErrMissingFuncVersion)
+	assert.ErrorIs(t, src.Add(migrations.FuncMigration{Name: "x", Version: "1"}), migrations.ErrNilMigrationFunc)
+	assert.ErrorIs(t, src.Add(migrations.FuncMigration{Name: "001_users.sql", Version: "1", Up: backfill.Up}), migrations.ErrDuplicateMigration)
+	assert.Nil(t, src.Add(backfill))
+
+	files, err := src.List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"001_users.sql", "002_backfill", "003_index.sql"}, files)
+
+	mgr, err := NewMigrationManager(ctx, client)
+	assert.Nil(t, err)
+	assert.Nil(t, mgr.Run(ctx, src, migrations.DefaultProgressFn))
+
+	var count int
+	assert.Nil(t, client.Db().Get(&count, "select count(*) from fn_users"))
+	assert.Equal(t, 3, count)
+
+	list, err := mgr.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, list, 3)
+	assert.Equal(t, "002_backfill", list[1].Name)
+	assert.Equal(t, migrations.FuncContents("002_backfill", "1"), list[1].Contents)
+	assert.True(t, list[1].IsFunc())
+
+	// version change is reported as drift
+	changed := migrations.NewFuncSource(sqlSrc)
+	backfill.Version = "2"
+	assert.Nil(t, changed.Add(backfill))
+	result, err := migrations.Verify(ctx, mgr, changed)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"002_backfill"}, result.Drifted)
+}
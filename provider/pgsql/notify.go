@@ -0,0 +1,198 @@
+package pgsql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/types/connhooks"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const (
+	DefaultListenMinBackoff = time.Second
+	DefaultListenMaxBackoff = 30 * time.Second
+	DefaultListenMaxRetries = 0 // DefaultListenMaxRetries reconnect forever
+
+	ListenerProvider = "pgsql.listener" // ListenerProvider provider name of Listener connection events
+
+	ErrMissingChannel = utils.Error("missing notification channel")
+	ErrNilHandler     = utils.Error("notification handler is nil")
+)
+
+// Notification is a message received from a LISTEN channel
+type Notification struct {
+	Channel string
+	Payload string
+	PID     uint32 // PID process id of the notifying backend
+}
+
+// NotificationHandler processes notifications; errors are logged and do not stop the listener
+type NotificationHandler func(ctx context.Context, n *Notification) error
+
+// Listener receives notifications from a channel on a dedicated connection, reconnecting with exponential backoff
+// notifications sent while the listener is reconnecting are lost
+// connection hooks are called when the channel is subscribed, when the connection is lost, and when the channel is
+// subscribed again after reconnecting
+type Listener struct {
+	dsn        string
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	MaxRetries int // MaxRetries consecutive failed reconnect attempts before Listen returns; 0 retries forever
+	connhooks.Hooks
+}
+
+// Decode unmarshals a JSON payload into v
+func (n *Notification) Decode(v any) error {
+	return json.Unmarshal([]byte(n.Payload), v)
+}
+
+// NewListener creates a new Listener using the DSN of client
+func NewListener(client *db.SqlClient) *Listener {
+	return &Listener{
+		dsn:        client.Dsn,
+		MinBackoff: DefaultListenMinBackoff,
+		MaxBackoff: DefaultListenMaxBackoff,
+		MaxRetries: DefaultListenMaxRetries,
+	}
+}
+
+// Listen subscribes a channel using a new Listener; see Listener.Listen()
+//
+// Example usage:
+//
+//	go func() {
+//	  err := pgsql.Listen(ctx, client, "cache_invalidation", func(ctx context.Context, n *pgsql.Notification) error {
+//	    key := &CacheKey{}
+//	    if err := n.Decode(key); err != nil {
+//	      return err
+//	    }
+//	    cache.Delete(key.Name)
+//	    return nil
+//	  })
+//	  if err != nil {
+//	    log.Error().Err(err).Msg("listener failed")
+//	  }
+//	}()
+//
+//	// elsewhere
+//	err := pgsql.NotifyJSON(ctx, client.Db(), "cache_invalidation", &CacheKey{Name: "users"})
+func Listen(ctx context.Context, client *db.SqlClient, channel string, handler NotificationHandler) error {
+	return NewListener(client).Listen(ctx, channel, handler)
+}
+
+// Listen subscribes channel and calls handler for each notification, until ctx is cancelled
+// if the first connection or subscription fails, the error is returned immediately; if the connection is lost, the
+// listener reconnects, and returns the last error after MaxRetries consecutive failed attempts
+// Note: this function is blocking; it returns nil when ctx is cancelled
+func (l *Listener) Listen(ctx context.Context, channel string, handler NotificationHandler) error {
+	if len(channel) == 0 {
+		return ErrMissingChannel
+	}
+	if handler == nil {
+		return ErrNilHandler
+	}
+	backoff := l.MinBackoff
+	retries := 0
+	subscribed := false
+	onListen := func() {
+		if subscribed {
+			l.Emit(ListenerProvider, connhooks.Reconnect, nil)
+		} else {
+			subscribed = true
+			l.Emit(ListenerProvider, connhooks.Connect, nil)
+		}
+	}
+	for {
+		connected, err := l.listen(ctx, channel, handler, onListen)
+		if ctx.Err() != nil {
+			if connected {
+				l.Emit(ListenerProvider, connhooks.Disconnect, nil)
+			}
+			return nil
+		}
+		if !subscribed {
+			return err
+		}
+		if connected {
+			backoff = l.MinBackoff
+			retries = 0
+			l.Emit(ListenerProvider, connhooks.Disconnect, err)
+		} else {
+			retries++
+			if l.MaxRetries > 0 && retries > l.MaxRetries {
+				return err
+			}
+		}
+		log.Warn().Err(err).Str("channel", channel).Dur("retry", backoff).Msg("listener disconnected")
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, l.MaxBackoff)
+	}
+}
+
+// listen connects, subscribes channel and processes notifications until an error occurs
+// returns true if the subscription was successful
+func (l *Listener) listen(ctx context.Context, channel string, handler NotificationHandler, onListen func()) (bool, error) {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return false, err
+	}
+	onListen()
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+		notification := &Notification{
+			Channel: n.Channel,
+			Payload: n.Payload,
+			PID:     n.PID,
+		}
+		if err = l.handle(ctx, handler, notification); err != nil {
+			log.Error().Err(err).Str("channel", channel).Msg("error processing notification")
+		}
+	}
+}
+
+// handle calls handler, recovering panics
+func (l *Listener) handle(ctx context.Context, handler NotificationHandler, n *Notification) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("panic in notification handler")
+			log.Error().Interface("panic", r).Str("channel", n.Channel).Msg("panic in notification handler")
+		}
+	}()
+	return handler(ctx, n)
+}
+
+// Notify sends a notification with payload to channel
+func Notify(ctx context.Context, conn sqlx.ExecerContext, channel string, payload string) error {
+	if len(channel) == 0 {
+		return ErrMissingChannel
+	}
+	_, err := conn.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, payload)
+	return err
+}
+
+// NotifyJSON sends a notification with the JSON encoding of v to channel
+// PostgreSQL limits payloads to 8000 bytes
+func NotifyJSON(ctx context.Context, conn sqlx.ExecerContext, channel string, v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return Notify(ctx, conn, channel, string(payload))
+}
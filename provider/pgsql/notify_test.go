@@ -0,0 +1,132 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/oddbit-project/blueprint/types/connhooks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestListenNotify(t *testing.T) {
+	client := dbClient(t)
+	assert.Nil(t, client.Connect())
+	defer client.Disconnect()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.ErrorIs(t, Listen(ctx, client, "", nil), ErrMissingChannel)
+	assert.ErrorIs(t, Listen(ctx, client, "events", nil), ErrNilHandler)
+
+	type event struct {
+		Name string `json:"name"`
+	}
+	received := make(chan string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- Listen(ctx, client, "blueprint_events", func(ctx context.Context, n *Notification) error {
+			e := &event{}
+			if err := n.Decode(e); err != nil {
+				return err
+			}
+			if e.Name == "panic" {
+				panic("handler panic")
+			}
+			received <- e.Name
+			return nil
+		})
+	}()
+
+	// notifications are only received after LISTEN; retry until the listener is subscribed
+	assert.Eventually(t, func() bool {
+		assert.Nil(t, NotifyJSON(context.Background(), client.Db(), "blueprint_events", &event{Name: "ping"}))
+		select {
+		case name := <-received:
+			return name == "ping"
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// invalid payloads and panics do not stop the listener
+	assert.Nil(t, Notify(context.Background(), client.Db(), "blueprint_events", "not json"))
+	assert.Nil(t, NotifyJSON(context.Background(), client.Db(), "blueprint_events", &event{Name: "panic"}))
+	assert.Nil(t, NotifyJSON(context.Background(), client.Db(), "blueprint_events", &event{Name: "user.created"}))
+	select {
+	case name := <-received:
+		assert.Equal(t, "user.created", name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification not received")
+	}
+
+	// graceful shutdown
+	cancel()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not stop")
+	}
+}
+
+func TestListenerHooks(t *testing.T) {
+	client := dbClient(t)
+	events := make(chan connhooks.Event, 10)
+	client.OnConnect(func(e connhooks.Event) {
+		events <- e
+	})
+	assert.Nil(t, client.Connect())
+	defer client.Disconnect()
+	assert.Equal(t, connhooks.Connect, (<-events).Type)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	listener := NewListener(client)
+	listener.OnConnect(func(e connhooks.Event) {
+		events <- e
+	})
+	listener.OnDisconnect(func(e connhooks.Event) {
+		events <- e
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- listener.Listen(ctx, "blueprint_hooks", func(ctx context.Context, n *Notification) error {
+			return nil
+		})
+	}()
+
+	select {
+	case e := <-events:
+		assert.Equal(t, ListenerProvider, e.Provider)
+		assert.Equal(t, connhooks.Connect, e.Type)
+	case <-time.After(5 * time.Second):
+		t.Fatal("connect event not received")
+	}
+
+	cancel()
+	assert.Nil(t, <-done)
+	e := <-events
+	assert.Equal(t, connhooks.Disconnect, e.Type)
+	assert.Nil(t, e.Err)
+}
+
+func TestListenConnectError(t *testing.T) {
+	listener := &Listener{
+		dsn:        "postgres://blueprint@127.0.0.1:1/blueprint?connect_timeout=1",
+		MinBackoff: DefaultListenMinBackoff,
+		MaxBackoff: DefaultListenMaxBackoff,
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- listener.Listen(context.Background(), "blueprint_events", func(ctx context.Context, n *Notification) error {
+			return nil
+		})
+	}()
+	select {
+	case err := <-done:
+		assert.NotNil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not return the connection error")
+	}
+}
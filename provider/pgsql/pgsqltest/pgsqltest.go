@@ -0,0 +1,49 @@
+// Package pgsqltest provides PostgreSQL fixtures for integration tests
+//
+// The test database is configured with the POSTGRES_USER, POSTGRES_PASSWORD, POSTGRES_HOST, POSTGRES_PORT and
+// POSTGRES_DB environment variables
+package pgsqltest
+
+import (
+	"fmt"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/provider/pgsql"
+	"os"
+	"testing"
+)
+
+// DSN returns the DSN of the test database
+func DSN() string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
+		os.Getenv("POSTGRES_USER"), os.Getenv("POSTGRES_PASSWORD"), os.Getenv("POSTGRES_HOST"),
+		os.Getenv("POSTGRES_PORT"), os.Getenv("POSTGRES_DB"))
+}
+
+// Client returns a connected client for the test database; the test fails if the connection fails
+//
+// Example usage:
+//
+//	func TestRepository(t *testing.T) {
+//	  client := pgsqltest.Client(t)
+//	  defer client.Disconnect()
+//	  ...
+//	}
+func Client(t testing.TB) *db.SqlClient {
+	t.Helper()
+	return ClientDSN(t, DSN())
+}
+
+// ClientDSN returns a connected client for dsn, e.g. the test database DSN with additional parameters
+func ClientDSN(t testing.TB, dsn string) *db.SqlClient {
+	t.Helper()
+	cfg := pgsql.NewClientConfig()
+	cfg.DSN = dsn
+	client, err := pgsql.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/doug-martin/goqu/v9"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/oddbit-project/blueprint/db"
 	"github.com/stretchr/testify/assert"
 	"strconv"
@@ -27,6 +28,7 @@ type testRepository interface {
 	db.Reader
 	db.Executor
 	db.Writer
+	db.BatchWriter
 	db.Deleter
 	db.Updater
 	db.Counter
@@ -183,4 +185,231 @@ func testFunctions(t *testing.T, repo testRepository) {
 	count, err = repo.CountWhere(map[string]any{"label": "bar"})
 	assert.Nil(t, err)
 	assert.Equal(t, int64(1), count)
+	// insert in batches
+	records = make([]*sampleRecord, 0)
+	rows := make([]any, 0)
+	for i := 0; i < 5; i++ {
+		rows = append(rows, &sampleRecord{CreatedAt: time.Now(), Label: "batch"})
+	}
+	assert.Nil(t, repo.InsertMany(rows, 2))
+	assert.Nil(t, repo.FetchWhere(map[string]any{"label": "batch"}, &records))
+	assert.Len(t, records, 5)
+
+	// upsert existing and new records
+	assert.Nil(t, repo.Upsert(goqu.Record{"id_sample_table": records[0].Id, "label": "upsert", "created_at": time.Now()}, []string{"id_sample_table"}, "label"))
+	assert.Nil(t, repo.UpsertMany([]any{
+		goqu.Record{"id_sample_table": records[1].Id, "label": "upsert", "created_at": time.Now()},
+		goqu.Record{"id_sample_table": 1000, "label": "upsert", "created_at": time.Now()},
+	}, 1, []string{"id_sample_table"}))
+	count, err = repo.CountWhere(map[string]any{"label": "upsert"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestBulkInsert(t *testing.T) {
+	client := dbClient(t)
+	dbCleanup(t, client)
+
+	rows := make([]any, 0)
+	for i := 0; i < 100; i++ {
+		rows = append(rows, &sampleRecord{CreatedAt: time.Now(), Label: "record " + strconv.Itoa(i)})
+	}
+	count, err := BulkInsert(context.Background(), client.Db(), sampleTable, rows...)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), count)
+
+	repo := db.NewRepository(context.Background(), client, sampleTable)
+	total, err := repo.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), total)
+
+	_, err = BulkInsert(context.Background(), client.Db(), sampleTable)
+	assert.ErrorIs(t, err, db.ErrInvalidParameters)
+}
+
+func TestWithTransaction(t *testing.T) {
+	client := dbClient(t)
+	dbCleanup(t, client)
+	ctx := context.Background()
+
+	// savepoints
+	err := db.WithTransaction(ctx, client, nil, func(tx *db.Tx) error {
+		repo := tx.Repository(sampleTable)
+		if err := repo.Insert(&sampleRecord{CreatedAt: time.Now(), Label: "kept"}); err != nil {
+			return err
+		}
+		if err := tx.Savepoint("sp1"); err != nil {
+			return err
+		}
+		if err := repo.Insert(&sampleRecord{CreatedAt: time.Now(), Label: "discarded"}); err != nil {
+			return err
+		}
+		return tx.RollbackTo("sp1")
+	})
+	assert.Nil(t, err)
+
+	repo := db.NewRepository(ctx, client, sampleTable)
+	count, err := repo.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// rollback on error
+	err = db.WithTransaction(ctx, client, nil, func(tx *db.Tx) error {
+		if err := tx.Repository(sampleTable).Insert(&sampleRecord{CreatedAt: time.Now(), Label: "discarded"}); err != nil {
+			return err
+		}
+		return db.ErrInvalidParameters
+	})
+	assert.ErrorIs(t, err, db.ErrInvalidParameters)
+	count, err = repo.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// invalid savepoint names
+	tx, err := client.Begin(ctx, nil)
+	assert.Nil(t, err)
+	assert.ErrorIs(t, tx.Savepoint("sp; DROP TABLE x"), db.ErrInvalidSavepoint)
+	assert.Nil(t, tx.Rollback())
+
+	// retry on serialization failure
+	attempts := 0
+	err = db.WithTransaction(ctx, client, nil, func(tx *db.Tx) error {
+		attempts++
+		if attempts < 2 {
+			return &pgconn.PgError{Code: "40001"}
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+type lockedRecord struct {
+	Id      int    `db:"id_locked_table" goqu:"skipinsert"`
+	Label   string `db:"label"`
+	Version int    `db:"version" lock:"version"`
+}
+
+func TestOptimisticLocking(t *testing.T) {
+	client := dbClient(t)
+	_, err := client.Db().Exec("DROP TABLE IF EXISTS locked_table")
+	assert.Nil(t, err)
+	_, err = client.Db().Exec("create table locked_table(id_locked_table serial not null primary key, label text, version int not null)")
+	assert.Nil(t, err)
+
+	repo := db.NewRepository(context.Background(), client, "locked_table")
+	record := &lockedRecord{Label: "first", Version: 1}
+	assert.Nil(t, repo.InsertReturning(record, []any{"id_locked_table"}, &record.Id))
+
+	stale := &lockedRecord{}
+	assert.Nil(t, repo.FetchByKey("id_locked_table", record.Id, stale))
+
+	// update increments version
+	record.Label = "second"
+	assert.Nil(t, repo.UpdateByKey(record, "id_locked_table", record.Id))
+	assert.Equal(t, 2, record.Version)
+
+	// update with old version fails
+	stale.Label = "stale"
+	assert.ErrorIs(t, repo.UpdateByKey(stale, "id_locked_table", stale.Id), db.ErrStaleRecord)
+
+	// retry re-reading the record
+	attempts := 0
+	err = db.RetryStale(3, func() error {
+		attempts++
+		if attempts > 1 {
+			if err := repo.FetchByKey("id_locked_table", stale.Id, stale); err != nil {
+				return err
+			}
+		}
+		stale.Label = "third"
+		return repo.UpdateRecord(stale, map[string]any{"id_locked_table": stale.Id})
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, 3, stale.Version)
+}
+
+type softRecord struct {
+	Id        int        `db:"id_soft_table" goqu:"skipinsert"`
+	Label     string     `db:"label"`
+	CreatedAt time.Time  `db:"created_at" auto:"created"`
+	UpdatedAt time.Time  `db:"updated_at" auto:"updated"`
+	DeletedAt *time.Time `db:"deleted_at" auto:"deleted"`
+}
+
+func TestSoftDelete(t *testing.T) {
+	client := dbClient(t)
+	_, err := client.Db().Exec("DROP TABLE IF EXISTS soft_table")
+	assert.Nil(t, err)
+	_, err = client.Db().Exec("create table soft_table(id_soft_table serial not null primary key, label text, created_at timestamp with time zone, updated_at timestamp with time zone, deleted_at timestamp with time zone)")
+	assert.Nil(t, err)
+
+	repo := db.NewRepository(context.Background(), client, "soft_table")
+	record := &softRecord{Label: "first"}
+	assert.Nil(t, repo.InsertReturning(record, []any{"id_soft_table"}, &record.Id))
+	assert.False(t, record.CreatedAt.IsZero())
+	assert.Equal(t, record.CreatedAt, record.UpdatedAt)
+	assert.Nil(t, repo.Insert(&softRecord{Label: "second"}))
+
+	// updated_at is refreshed
+	created := record.CreatedAt
+	record.Label = "updated"
+	assert.Nil(t, repo.UpdateByKey(record, "id_soft_table", record.Id))
+	assert.Equal(t, created, record.CreatedAt)
+	assert.True(t, record.UpdatedAt.After(created))
+
+	// soft delete hides the record
+	assert.Nil(t, repo.SoftDelete(&softRecord{}, db.FV{"id_soft_table": record.Id}))
+	records := make([]*softRecord, 0)
+	assert.Nil(t, repo.FetchWhere(db.FV{"label": "updated"}, &records))
+	assert.Len(t, records, 0)
+	assert.True(t, db.EmptyResult(repo.FetchByKey("id_soft_table", record.Id, &softRecord{})))
+
+	// aliased queries are scoped by the alias
+	aliased := repo.Sql().From(goqu.T("soft_table").As("s")).Select(goqu.I("s.*")).Order(goqu.I("s.id_soft_table").Asc())
+	assert.Nil(t, repo.Fetch(aliased, &records))
+	assert.Len(t, records, 1)
+	assert.Equal(t, "second", records[0].Label)
+
+	// scoped counts exclude deleted records; unscoped counts include them
+	count, err := repo.CountScoped(&softRecord{}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+	count, err = repo.CountScoped(&softRecord{}, db.FV{"label": "updated"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+	count, err = repo.WithDeleted().CountScoped(&softRecord{}, db.FV{"label": "updated"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+	count, err = repo.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+	exists, err := repo.ExistsScoped(&softRecord{}, "label", "updated")
+	assert.Nil(t, err)
+	assert.False(t, exists)
+	exists, err = repo.Exists("label", "updated")
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	// deleted records are visible WithDeleted
+	assert.Nil(t, repo.WithDeleted().FetchWhere(db.FV{"label": "updated"}, &records))
+	assert.Len(t, records, 1)
+	assert.NotNil(t, records[0].DeletedAt)
+
+	// restore
+	assert.Nil(t, repo.Restore(&softRecord{}, db.FV{"id_soft_table": record.Id}))
+	assert.Nil(t, repo.FetchByKey("id_soft_table", record.Id, &softRecord{}))
+
+	assert.ErrorIs(t, repo.SoftDelete(&sampleRecord{}, db.FV{"id_soft_table": 1}), db.ErrNoSoftDelete)
+
+	// bulk inserts set automatic timestamps
+	bulk := &softRecord{Label: "bulk"}
+	_, err = BulkInsert(context.Background(), client.Db(), "soft_table", bulk)
+	assert.Nil(t, err)
+	assert.False(t, bulk.CreatedAt.IsZero())
+	assert.Nil(t, repo.FetchWhere(db.FV{"label": "bulk"}, &records))
+	assert.Len(t, records, 1)
+	assert.False(t, records[0].CreatedAt.IsZero())
+	assert.False(t, records[0].UpdatedAt.IsZero())
 }
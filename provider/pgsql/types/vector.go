@@ -0,0 +1,112 @@
+package types
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/oddbit-project/blueprint/utils"
+	"strconv"
+	"strings"
+)
+
+const (
+	ErrInvalidVector = utils.Error("invalid vector value")
+)
+
+// Vector is a pgvector "vector" column value
+//
+// Example usage:
+//
+//	type Document struct {
+//	  Id        int          `db:"id_document" goqu:"skipinsert"`
+//	  Content   string       `db:"content"`
+//	  Embedding types.Vector `db:"embedding"`
+//	}
+//
+//	// 5 nearest documents
+//	qry := repo.SqlSelect().
+//	  Order(types.CosineDistance("embedding", embedding).Asc()).
+//	  Limit(5)
+//	err := repo.Fetch(qry, &docs)
+type Vector []float32
+
+// String returns the pgvector text representation, e.g. "[1,2,3]"
+func (v Vector) String() string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(f), 'f', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// Value implements driver.Valuer
+func (v Vector) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return v.String(), nil
+}
+
+// Scan implements sql.Scanner
+func (v *Vector) Scan(src any) error {
+	switch value := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case string:
+		return v.parse(value)
+	case []byte:
+		return v.parse(string(value))
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidVector, src)
+	}
+}
+
+// parse reads the pgvector text representation
+func (v *Vector) parse(src string) error {
+	src = strings.TrimSpace(src)
+	if len(src) < 2 || src[0] != '[' || src[len(src)-1] != ']' {
+		return ErrInvalidVector
+	}
+	src = src[1 : len(src)-1]
+	if len(strings.TrimSpace(src)) == 0 {
+		*v = Vector{}
+		return nil
+	}
+	items := strings.Split(src, ",")
+	result := make(Vector, len(items))
+	for i, item := range items {
+		f, err := strconv.ParseFloat(strings.TrimSpace(item), 32)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidVector, err)
+		}
+		result[i] = float32(f)
+	}
+	*v = result
+	return nil
+}
+
+// L2Distance returns a "column <-> vector" expression, usable in Order() or Select()
+func L2Distance(column string, v Vector) exp.LiteralExpression {
+	return distance(column, "<->", v)
+}
+
+// CosineDistance returns a "column <=> vector" expression, usable in Order() or Select()
+func CosineDistance(column string, v Vector) exp.LiteralExpression {
+	return distance(column, "<=>", v)
+}
+
+// InnerProduct returns a "column <#> vector" expression (negative inner product), usable in Order() or Select()
+func InnerProduct(column string, v Vector) exp.LiteralExpression {
+	return distance(column, "<#>", v)
+}
+
+func distance(column string, operator string, v Vector) exp.LiteralExpression {
+	return goqu.L("? "+operator+" ?::vector", goqu.C(column), v.String())
+}
@@ -0,0 +1,51 @@
+package types
+
+import (
+	"github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVectorValue(t *testing.T) {
+	v := Vector{1, 2.5, -3}
+	value, err := v.Value()
+	assert.Nil(t, err)
+	assert.Equal(t, "[1,2.5,-3]", value)
+
+	value, err = Vector(nil).Value()
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+}
+
+func TestVectorScan(t *testing.T) {
+	var v Vector
+	assert.Nil(t, v.Scan("[1,2.5,-3]"))
+	assert.Equal(t, Vector{1, 2.5, -3}, v)
+	assert.Nil(t, v.Scan([]byte("[ 0.5 , 1 ]")))
+	assert.Equal(t, Vector{0.5, 1}, v)
+	assert.Nil(t, v.Scan("[]"))
+	assert.Equal(t, Vector{}, v)
+	assert.Nil(t, v.Scan(nil))
+	assert.Nil(t, v)
+
+	assert.ErrorIs(t, v.Scan("1,2"), ErrInvalidVector)
+	assert.ErrorIs(t, v.Scan("[1,a]"), ErrInvalidVector)
+	assert.ErrorIs(t, v.Scan(12), ErrInvalidVector)
+}
+
+func TestVectorDistance(t *testing.T) {
+	v := Vector{1, 2}
+	cases := map[string]exp.LiteralExpression{
+		`SELECT * FROM "docs" ORDER BY "embedding" <-> $1::vector ASC`: L2Distance("embedding", v),
+		`SELECT * FROM "docs" ORDER BY "embedding" <=> $1::vector ASC`: CosineDistance("embedding", v),
+		`SELECT * FROM "docs" ORDER BY "embedding" <#> $1::vector ASC`: InnerProduct("embedding", v),
+	}
+	for expected, expr := range cases {
+		qry, args, err := goqu.Dialect("postgres").From("docs").Order(expr.Asc()).Prepared(true).ToSQL()
+		assert.Nil(t, err)
+		assert.Equal(t, expected, qry)
+		assert.Equal(t, []any{"[1,2]"}, args)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/utils/version"
 )
 
 const (
@@ -14,6 +15,8 @@ const (
 	TblTypeView         = "VIEW"
 	TblTypeForeignTable = "FOREIGN TABLE"
 	TblTypeLocal        = "LOCAL TEMPORARY"
+
+	MinServerVersion = "12.0" // MinServerVersion minimum supported PostgreSQL version
 )
 
 // GetServerVersion fetch postgresql version
@@ -23,6 +26,19 @@ func GetServerVersion(db *sqlx.DB, ctx context.Context) (string, error) {
 	return result, err
 }
 
+// CheckServerVersion checks if the server version is at least minimum; if minimum is empty, MinServerVersion is used
+// if warnOnly is true, an older version is only logged as a warning
+func CheckServerVersion(ctx context.Context, db *sqlx.DB, minimum string, warnOnly bool) error {
+	var current string
+	if err := db.QueryRowContext(ctx, "SHOW server_version").Scan(&current); err != nil {
+		return err
+	}
+	if minimum == "" {
+		minimum = MinServerVersion
+	}
+	return version.Require("postgresql", current, minimum, warnOnly)
+}
+
 // TableExists returns true if specified table exists
 func TableExists(ctx context.Context, db *sqlx.DB, tableName string, schema string) (bool, error) {
 	return dbObjectExists(ctx, db, TblTypeTable, tableName, schema)
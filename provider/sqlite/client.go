@@ -0,0 +1,190 @@
+//go:build sqlite
+
+// Package sqlite provides a SQLite client for embedded usage and repository unit tests
+//
+// The provider uses the mattn/go-sqlite3 driver, which requires cgo, and is only built with the "sqlite" build tag:
+//
+//	go test -tags sqlite ./...
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"github.com/doug-martin/goqu/v9"
+	goquSqlite "github.com/doug-martin/goqu/v9/dialect/sqlite3"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/oddbit-project/blueprint/utils/str"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DriverName = "sqlite3"
+
+	JournalModeDelete = "DELETE"
+	JournalModeWAL    = "WAL"
+	JournalModeMemory = "MEMORY"
+
+	DefaultMaxConns           = 4
+	DefaultIdleConns          = 2
+	DefaultBusyTimeout        = 5000
+	DefaultConnLifeTimeSecond = 3600
+
+	ErrEmptyPath           = utils.Error("Empty database path")
+	ErrNilConfig           = utils.Error("Config is nil")
+	ErrInvalidJournalMode  = utils.Error("Invalid journal mode")
+	ErrInvalidIdleConns    = utils.Error("Invalid maxIdleConns")
+	ErrInvalidMaxConns     = utils.Error("Invalid maxOpenConns")
+	ErrInvalidBusyTimeout  = utils.Error("busyTimeout must be >= 0")
+	ErrInvalidConnLifeTime = utils.Error("connLifeTime must be >= 1")
+)
+
+var validJournalModes = []string{JournalModeDelete, JournalModeWAL, JournalModeMemory}
+
+// memoryDbCounter generates unique names for in-memory databases
+var memoryDbCounter uint64
+
+// ClientConfig SQLite client configuration
+type ClientConfig struct {
+	Path         string `json:"path"`         // Path database file path
+	JournalMode  string `json:"journalMode"`  // JournalMode one of DELETE, WAL or MEMORY
+	BusyTimeout  int    `json:"busyTimeout"`  // BusyTimeout time in milliseconds to wait for locks
+	ForeignKeys  bool   `json:"foreignKeys"`  // ForeignKeys enables foreign key enforcement
+	MaxOpenConns int    `json:"maxOpenConns"` // MaxOpenConns max number of pool connections
+	MaxIdleConns int    `json:"maxIdleConns"` // MaxIdleConns max number of idle pool connections
+
+	// ConnLifeTime is the duration in seconds since creation after which a connection will be automatically closed
+	ConnLifetime int `json:"connLifetime"`
+	memory       bool
+}
+
+func NewClientConfig() *ClientConfig {
+	return &ClientConfig{
+		Path:         "",
+		JournalMode:  JournalModeWAL,
+		BusyTimeout:  DefaultBusyTimeout,
+		ForeignKeys:  true,
+		MaxOpenConns: DefaultMaxConns,
+		MaxIdleConns: DefaultIdleConns,
+		ConnLifetime: DefaultConnLifeTimeSecond,
+	}
+}
+
+func (c ClientConfig) Validate() error {
+	if len(c.Path) == 0 {
+		return ErrEmptyPath
+	}
+	if str.Contains(strings.ToUpper(c.JournalMode), validJournalModes) == -1 {
+		return ErrInvalidJournalMode
+	}
+	if c.BusyTimeout < 0 {
+		return ErrInvalidBusyTimeout
+	}
+	if c.MaxIdleConns < 0 {
+		return ErrInvalidIdleConns
+	}
+	if c.MaxOpenConns < 1 {
+		return ErrInvalidMaxConns
+	}
+	if c.ConnLifetime < 1 {
+		return ErrInvalidConnLifeTime
+	}
+	return nil
+}
+
+func (c ClientConfig) Apply(db *sqlx.DB) error {
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	if c.memory {
+		// in-memory databases are destroyed when the last connection is closed
+		db.SetConnMaxLifetime(0)
+		db.SetConnMaxIdleTime(0)
+		return nil
+	}
+	db.SetConnMaxLifetime(time.Duration(c.ConnLifetime) * time.Second)
+	return nil
+}
+
+// DSN returns the driver DSN for the configuration
+func (c ClientConfig) DSN() string {
+	params := url.Values{}
+	params.Set("_busy_timeout", fmt.Sprintf("%d", c.BusyTimeout))
+	params.Set("_journal_mode", strings.ToUpper(c.JournalMode))
+	if c.ForeignKeys {
+		params.Set("_foreign_keys", "on")
+	}
+	if c.memory {
+		params.Set("mode", "memory")
+		params.Set("cache", "shared")
+	}
+	return "file:" + c.Path + "?" + params.Encode()
+}
+
+// NewClient creates a new SQLite client
+//
+// Example usage:
+//
+//	cfg := sqlite.NewClientConfig()
+//	cfg.Path = "/var/lib/myapp/data.db"
+//	client, err := sqlite.NewClient(cfg)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	repo := db.NewRepository(ctx, client, "users")
+func NewClient(config *ClientConfig) (*db.SqlClient, error) {
+	if config == nil {
+		return nil, ErrNilConfig
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	client := db.NewSqlClient(config.DSN(), DriverName, config)
+	client.SetTxRetryFn(IsRetryable)
+	return client, nil
+}
+
+// IsRetryable returns true if err is a SQLITE_BUSY or SQLITE_LOCKED error, e.g. if the database is locked by
+// another connection for longer than BusyTimeout, and the transaction can be retried; it is the db.TxRetryFn of
+// clients created with NewClient()
+func IsRetryable(err error) bool {
+	var liteErr sqlite3.Error
+	if errors.As(err, &liteErr) {
+		return liteErr.Code == sqlite3.ErrBusy || liteErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
+
+// NewMemoryClient creates a client for a new, empty in-memory database
+// the pool is limited to a single connection, and the database is destroyed when the client is disconnected;
+// useful for repository unit tests
+//
+// Example usage:
+//
+//	func TestUserRepository(t *testing.T) {
+//	  client, err := sqlite.NewMemoryClient()
+//	  require.NoError(t, err)
+//	  defer client.Disconnect()
+//	  ...
+//	}
+func NewMemoryClient() (*db.SqlClient, error) {
+	cfg := NewClientConfig()
+	cfg.Path = fmt.Sprintf("memdb%d", atomic.AddUint64(&memoryDbCounter, 1))
+	cfg.JournalMode = JournalModeMemory
+	cfg.MaxOpenConns = 1
+	cfg.MaxIdleConns = 1
+	cfg.memory = true
+	return NewClient(cfg)
+}
+
+func DialectOptions() *goqu.SQLDialectOptions {
+	return goquSqlite.DialectOptions()
+}
+
+func init() {
+	goqu.RegisterDialect(DriverName, DialectOptions())
+}
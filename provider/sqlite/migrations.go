@@ -0,0 +1,276 @@
+//go:build sqlite
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/db/migrations"
+	"slices"
+	"sync"
+)
+
+const (
+	MigrationTable = "db_migration"
+)
+
+// sqliteMigrationManager MigrationManager for SQLite
+// SQLite allows a single writer per database, so migration transactions are serialized by the database itself; the
+// manager additionally serializes operations within the process
+type sqliteMigrationManager struct {
+	db *sqlx.DB
+	mx sync.Mutex
+}
+
+// NewMigrationManager creates a migration manager for the database of client
+//
+// Example usage:
+//
+//	mm, err := sqlite.NewMigrationManager(ctx, client)
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	if err := mm.Run(ctx, src, migrations.DefaultProgressFn); err != nil {
+//	  log.Fatal(err)
+//	}
+func NewMigrationManager(ctx context.Context, client *db.SqlClient) (migrations.Manager, error) {
+	result := &sqliteMigrationManager{
+		db: client.Db(),
+	}
+	if err := result.init(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// init checks if migration table exists, and if not, creates
+func (b *sqliteMigrationManager) init(ctx context.Context) error {
+	qry := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			created TIMESTAMP,
+			name TEXT,
+			sha2 TEXT,
+			contents TEXT)`,
+		MigrationTable)
+	_, err := b.db.ExecContext(ctx, qry)
+	return err
+}
+
+// registerMigration internal function to register a migration
+func (b *sqliteMigrationManager) registerMigration(ctx context.Context, conn sqlx.ExecerContext, m *migrations.MigrationRecord) error {
+	qry := fmt.Sprintf("INSERT INTO %s (created, name, sha2, contents) VALUES (?, ?, ?, ?)", MigrationTable)
+	_, err := conn.ExecContext(ctx, qry, m.Created, m.Name, m.SHA2, m.Contents)
+	return err
+}
+
+func (b *sqliteMigrationManager) List(ctx context.Context) ([]*migrations.MigrationRecord, error) {
+	result := make([]*migrations.MigrationRecord, 0)
+	qry := fmt.Sprintf("SELECT created, name, sha2, contents FROM %s ORDER BY created", MigrationTable)
+	if err := b.db.SelectContext(ctx, &result, qry); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return result, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (b *sqliteMigrationManager) MigrationExists(ctx context.Context, name string, sha2 string) (bool, error) {
+	result := &migrations.MigrationRecord{}
+	qry := fmt.Sprintf("SELECT created, name, sha2, contents FROM %s WHERE name=? LIMIT 1", MigrationTable)
+	if err := b.db.GetContext(ctx, result, qry, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	if result.SHA2 != sha2 {
+		return true, migrations.ErrMigrationNameHashMismatch
+	}
+	return true, nil
+}
+
+// runMigration internal function to execute and register a migration in a single transaction
+func (b *sqliteMigrationManager) runMigration(ctx context.Context, m *migrations.MigrationRecord) error {
+	tx, err := b.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if m.Func != nil {
+		err = m.Func(ctx, tx)
+	} else {
+		_, err = tx.ExecContext(ctx, m.Up())
+	}
+	if err == nil {
+		err = b.registerMigration(ctx, tx, m)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// RunMigration applies and registers a single migration
+func (b *sqliteMigrationManager) RunMigration(ctx context.Context, m *migrations.MigrationRecord) error {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	exists, err := b.MigrationExists(ctx, m.Name, m.SHA2)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return migrations.ErrMigrationExists
+	}
+	return b.runMigration(ctx, m)
+}
+
+// RegisterMigration registers a single migration but does not apply the contents
+func (b *sqliteMigrationManager) RegisterMigration(ctx context.Context, m *migrations.MigrationRecord) error {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	exists, err := b.MigrationExists(ctx, m.Name, m.SHA2)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return migrations.ErrMigrationExists
+	}
+	return b.registerMigration(ctx, b.db, m)
+}
+
+// Run all migrations from a source, and skip the ones already applied
+func (b *sqliteMigrationManager) Run(ctx context.Context, src migrations.Source, consoleFn migrations.ProgressFn) error {
+	if consoleFn == nil {
+		consoleFn = migrations.DefaultProgressFn
+	}
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	pending, err := b.Plan(ctx, src)
+	if err != nil {
+		return err
+	}
+	pendingNames := make([]string, len(pending))
+	for i, r := range pending {
+		pendingNames[i] = r.Name
+	}
+	files, err := src.List()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if !slices.Contains(pendingNames, f) {
+			consoleFn(migrations.MsgSkipMigration, f, nil)
+		}
+	}
+	for _, record := range pending {
+		consoleFn(migrations.MsgRunMigration, record.Name, nil)
+		if err = b.runMigration(ctx, record); err != nil {
+			consoleFn(migrations.MsgError, record.Name, err)
+			return err
+		}
+		consoleFn(migrations.MsgFinishedMigration, record.Name, nil)
+	}
+	return nil
+}
+
+// Plan returns the migrations from src that were not applied yet, in execution order
+func (b *sqliteMigrationManager) Plan(ctx context.Context, src migrations.Source) ([]*migrations.MigrationRecord, error) {
+	files, err := src.List()
+	if err != nil {
+		return nil, err
+	}
+	migList, err := b.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prevNames := make([]string, len(migList))
+	for i, r := range migList {
+		prevNames[i] = r.Name
+	}
+
+	result := make([]*migrations.MigrationRecord, 0)
+	for _, f := range files {
+		if slices.Contains(prevNames, f) {
+			continue
+		}
+		record, err := src.Read(f)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, record)
+	}
+	return result, nil
+}
+
+// Rollback reverts the last steps migrations, most recent first, using the down section of the registered contents
+func (b *sqliteMigrationManager) Rollback(ctx context.Context, steps int, consoleFn migrations.ProgressFn) error {
+	if consoleFn == nil {
+		consoleFn = migrations.DefaultProgressFn
+	}
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	records, err := migrations.RollbackPlan(ctx, b, steps)
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		consoleFn(migrations.MsgRollbackMigration, r.Name, nil)
+		if err = b.rollbackMigration(ctx, r); err != nil {
+			consoleFn(migrations.MsgError, r.Name, err)
+			return err
+		}
+		consoleFn(migrations.MsgFinishedRollback, r.Name, nil)
+	}
+	return nil
+}
+
+// rollbackMigration executes the down section of a migration and removes its registration, in a single transaction
+func (b *sqliteMigrationManager) rollbackMigration(ctx context.Context, m *migrations.MigrationRecord) error {
+	down := m.Down()
+	if len(down) == 0 {
+		return migrations.ErrNoDownMigration
+	}
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, down); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	qry := fmt.Sprintf("DELETE FROM %s WHERE name=?", MigrationTable)
+	if _, err = tx.ExecContext(ctx, qry, m.Name); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Repair re-baselines drifted migrations, updating the registered checksum and contents with the ones from src
+// it returns the verification result prior to the repair; missing and out-of-order migrations are not modified
+func (b *sqliteMigrationManager) Repair(ctx context.Context, src migrations.Source) (*migrations.VerifyResult, error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	result, err := migrations.Verify(ctx, b, src)
+	if err != nil {
+		return nil, err
+	}
+	qry := fmt.Sprintf("UPDATE %s SET sha2=?, contents=? WHERE name=?", MigrationTable)
+	for _, name := range result.Drifted {
+		record, err := src.Read(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = b.db.ExecContext(ctx, qry, record.SHA2, record.Contents, record.Name); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,140 @@
+//go:build sqlite
+
+package sqlite
+
+import (
+	"context"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/oddbit-project/blueprint/db"
+	"github.com/oddbit-project/blueprint/db/migrations"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type sampleRecord struct {
+	Id      int       `db:"id" goqu:"skipinsert"`
+	Name    string    `db:"name"`
+	Created time.Time `db:"created"`
+}
+
+func TestClientConfigValidate(t *testing.T) {
+	cfg := NewClientConfig()
+	assert.ErrorIs(t, cfg.Validate(), ErrEmptyPath)
+	cfg.Path = "test.db"
+	assert.Nil(t, cfg.Validate())
+	cfg.JournalMode = "invalid"
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidJournalMode)
+	cfg.JournalMode = "wal"
+	assert.Nil(t, cfg.Validate())
+	cfg.MaxOpenConns = 0
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidMaxConns)
+
+	_, err := NewClient(nil)
+	assert.ErrorIs(t, err, ErrNilConfig)
+}
+
+func TestFileClient(t *testing.T) {
+	cfg := NewClientConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "test.db")
+	client, err := NewClient(cfg)
+	assert.Nil(t, err)
+	assert.Nil(t, client.Connect())
+	defer client.Disconnect()
+
+	var mode string
+	assert.Nil(t, client.Db().Get(&mode, "PRAGMA journal_mode"))
+	assert.Equal(t, "wal", mode)
+	var fk int
+	assert.Nil(t, client.Db().Get(&fk, "PRAGMA foreign_keys"))
+	assert.Equal(t, 1, fk)
+}
+
+func TestMemoryRepository(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewMemoryClient()
+	assert.Nil(t, err)
+	assert.Nil(t, client.Connect())
+	defer client.Disconnect()
+
+	mgr, err := NewMigrationManager(ctx, client)
+	assert.Nil(t, err)
+	src := migrations.NewMemorySource()
+	src.Add("001.sql", "-- migrate:up\nCREATE TABLE sample(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, created TIMESTAMP);\n-- migrate:down\nDROP TABLE sample;")
+	assert.Nil(t, mgr.Run(ctx, src, migrations.DefaultProgressFn))
+	list, err := mgr.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, list, 1)
+
+	repo := db.NewRepository(ctx, client, "sample")
+	assert.Nil(t, repo.Insert(&sampleRecord{Name: "a", Created: time.Now()}, &sampleRecord{Name: "b", Created: time.Now()}))
+
+	records := make([]*sampleRecord, 0)
+	assert.Nil(t, repo.Fetch(repo.SqlSelect().Order(goqu.C("id").Asc()), &records))
+	assert.Len(t, records, 2)
+	assert.Equal(t, "b", records[1].Name)
+	assert.False(t, records[0].Created.IsZero())
+
+	// a second memory client uses a different database
+	other, err := NewMemoryClient()
+	assert.Nil(t, err)
+	assert.Nil(t, other.Connect())
+	defer other.Disconnect()
+	exists := 0
+	assert.Nil(t, other.Db().Get(&exists, "SELECT COUNT(*) FROM sqlite_master WHERE name='sample'"))
+	assert.Equal(t, 0, exists)
+
+	assert.Nil(t, mgr.Rollback(ctx, 1, migrations.DefaultProgressFn))
+	assert.Nil(t, client.Db().Get(&exists, "SELECT COUNT(*) FROM sqlite_master WHERE name='sample'"))
+	assert.Equal(t, 0, exists)
+}
+
+func TestWithTransactionRetry(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewClientConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "test.db")
+	cfg.BusyTimeout = 0
+	client, err := NewClient(cfg)
+	assert.Nil(t, err)
+	assert.Nil(t, client.Connect())
+	defer client.Disconnect()
+	_, err = client.Db().Exec("CREATE TABLE sample(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, created TIMESTAMP)")
+	assert.Nil(t, err)
+
+	// a second client holds the write lock until the first attempt fails
+	locker, err := NewClient(cfg)
+	assert.Nil(t, err)
+	assert.Nil(t, locker.Connect())
+	defer locker.Disconnect()
+	lock, err := locker.Begin(ctx, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, lock.Repository("sample").Insert(&sampleRecord{Name: "locker", Created: time.Now()}))
+
+	attempts := 0
+	err = db.WithTransaction(ctx, client, nil, func(tx *db.Tx) error {
+		attempts++
+		err := tx.Repository("sample").Insert(&sampleRecord{Name: "retried", Created: time.Now()})
+		if attempts == 1 {
+			assert.True(t, IsRetryable(err))
+			assert.Nil(t, lock.Rollback())
+		}
+		return err
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, attempts)
+	names := make([]string, 0)
+	assert.Nil(t, client.Db().Select(&names, "SELECT name FROM sample"))
+	assert.Equal(t, []string{"retried"}, names)
+
+	// other errors are not retried
+	attempts = 0
+	err = db.WithTransaction(ctx, client, nil, func(tx *db.Tx) error {
+		attempts++
+		_, err := tx.Db().Exec("INSERT INTO non_existing_table(id) VALUES (1)")
+		return err
+	})
+	assert.NotNil(t, err)
+	assert.False(t, IsRetryable(err))
+	assert.Equal(t, 1, attempts)
+}
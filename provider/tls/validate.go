@@ -0,0 +1,69 @@
+package tls
+
+import (
+	"errors"
+	"fmt"
+	"github.com/oddbit-project/blueprint/utils"
+)
+
+const (
+	ErrCertKeyPair       = utils.Error("tlsCert and tlsKey must be set together")
+	ErrMissingCertKey    = utils.Error("tlsCert and tlsKey are required when tlsEnable is set")
+	ErrDNSNamesWithoutCA = utils.Error("tlsAllowedDNSNames requires tlsAllowedCACerts")
+)
+
+// Validate checks the client TLS options without loading certificate files; all problems found are returned
+// as a single error (see errors.Join())
+func (c *ClientConfig) Validate() error {
+	if !c.TLSEnable {
+		return nil
+	}
+	var errs []error
+	if (len(c.TLSCert) == 0) != (len(c.TLSKey) == 0) {
+		errs = append(errs, ErrCertKeyPair)
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the server TLS options without loading certificate files; all problems found are returned
+// as a single error (see errors.Join())
+func (c *ServerConfig) Validate() error {
+	if !c.TLSEnable {
+		return nil
+	}
+	var errs []error
+	switch {
+	case len(c.TLSCert) == 0 && len(c.TLSKey) == 0:
+		errs = append(errs, ErrMissingCertKey)
+	case len(c.TLSCert) == 0 || len(c.TLSKey) == 0:
+		errs = append(errs, ErrCertKeyPair)
+	}
+	for _, cipher := range c.TLSCipherSuites {
+		if _, ok := tlsCipherMap[cipher]; !ok {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidCipher, cipher))
+		}
+	}
+	var minVersion, maxVersion uint16
+	if len(c.TLSMinVersion) > 0 {
+		v, ok := tlsVersionMap[c.TLSMinVersion]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: tlsMinVersion %s", ErrInvalidTlsVersion, c.TLSMinVersion))
+		}
+		minVersion = v
+	}
+	if len(c.TLSMaxVersion) > 0 {
+		v, ok := tlsVersionMap[c.TLSMaxVersion]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: tlsMaxVersion %s", ErrInvalidTlsVersion, c.TLSMaxVersion))
+		}
+		maxVersion = v
+	}
+	if minVersion != 0 && maxVersion != 0 && minVersion > maxVersion {
+		errs = append(errs, fmt.Errorf("%w: tlsMinVersion %s is greater than tlsMaxVersion %s",
+			ErrInvalidTlsVersion, c.TLSMinVersion, c.TLSMaxVersion))
+	}
+	if len(c.TLSAllowedDNSNames) > 0 && len(c.TLSAllowedCACerts) == 0 {
+		errs = append(errs, ErrDNSNamesWithoutCA)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,53 @@
+package tls
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestClientConfigValidate(t *testing.T) {
+	cfg := &ClientConfig{TLSCert: "client.crt", TLSCA: "ca.crt", TLSInsecureSkipVerify: true}
+	// disabled
+	assert.Nil(t, cfg.Validate())
+
+	cfg.TLSEnable = true
+	err := cfg.Validate()
+	assert.ErrorIs(t, err, ErrCertKeyPair)
+
+	// client certificates are optional, and verification may be skipped with a CA configured
+	cfg.TLSKey = "client.key"
+	assert.Nil(t, cfg.Validate())
+	assert.Nil(t, (&ClientConfig{TLSEnable: true, TLSCA: "ca.crt", TLSInsecureSkipVerify: true}).Validate())
+}
+
+func TestServerConfigValidate(t *testing.T) {
+	cfg := &ServerConfig{
+		TLSEnable:          true,
+		TLSKey:             "server.key",
+		TLSCipherSuites:    []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_RC4"},
+		TLSMinVersion:      "TLS13",
+		TLSMaxVersion:      "TLS12",
+		TLSAllowedDNSNames: []string{"client.example.com"},
+	}
+	err := cfg.Validate()
+	assert.ErrorIs(t, err, ErrCertKeyPair)
+	assert.ErrorIs(t, err, ErrInvalidCipher)
+	assert.ErrorIs(t, err, ErrInvalidTlsVersion)
+	assert.ErrorIs(t, err, ErrDNSNamesWithoutCA)
+	assert.Contains(t, err.Error(), "TLS_RC4")
+
+	cfg.TLSMinVersion = "TLS1.3"
+	assert.Contains(t, cfg.Validate().Error(), "tlsMinVersion TLS1.3")
+
+	cfg.TLSCert = "server.crt"
+	cfg.TLSCipherSuites = nil
+	cfg.TLSMinVersion = "TLS12"
+	cfg.TLSMaxVersion = "TLS13"
+	cfg.TLSAllowedCACerts = []string{"ca.crt"}
+	assert.Nil(t, cfg.Validate())
+
+	// TLS requires a server certificate
+	err = (&ServerConfig{TLSEnable: true}).Validate()
+	assert.ErrorIs(t, err, ErrMissingCertKey)
+	assert.NotErrorIs(t, err, ErrCertKeyPair)
+}
@@ -58,9 +58,10 @@ func (a *Application) Build() {
 	// initialize http server config
 	httpConfig := httpserver.NewServerConfig()
 	// fill parameters from config provider
-	if err := a.container.Config.GetKey("server", httpConfig); err != nil {
-		a.container.AbortFatal(err)
-	}
+	a.container.LoadConfig("server", httpConfig)
+	// report all configuration errors at once
+	a.container.AbortFatal(a.container.ValidateConfig())
+
 	// Create http server from config
 	var err error
 	a.httpServer, err = httpConfig.NewServer()
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"embed"
+	"github.com/gin-gonic/gin"
+	"github.com/oddbit-project/blueprint/provider/httpserver"
+	"github.com/oddbit-project/blueprint/provider/httpserver/templates"
+	"io/fs"
+	"log"
+	"net/http"
+	"time"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+func main() {
+	srvConfig := httpserver.NewServerConfig()
+	srvConfig.Host = "localhost"
+	srvConfig.Port = 8089
+	srvConfig.Debug = true
+
+	server, err := httpserver.NewServer(srvConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// use embedded templates; set DevMode and Directory to reload from disk on every request
+	root, err := fs.Sub(templateFS, "templates")
+	if err != nil {
+		log.Fatal(err)
+	}
+	renderer, err := templates.NewRenderer(root, templates.NewConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
+	renderer.AddFunc("now", func() string {
+		return time.Now().Format(time.DateOnly)
+	})
+	server.Router.HTMLRender = renderer
+
+	server.Route().GET("/", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "index.html", gin.H{
+			"Title": "Templates sample",
+			"Name":  "<script>alert('escaped')</script>",
+		})
+	})
+
+	// start http server
+	server.Start()
+}
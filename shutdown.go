@@ -1,13 +1,36 @@
 package blueprint
 
 import (
+	"context"
 	"github.com/oddbit-project/blueprint/types/callstack"
+	"github.com/oddbit-project/blueprint/utils"
 	"github.com/rs/zerolog/log"
 	"sync"
+	"time"
 )
 
+// shutdown stages, executed in order
+const (
+	StageStopIntake = iota // StageStopIntake stop fetching messages and accepting jobs
+	StageDrain             // StageDrain wait for in-flight handlers and jobs, commit offsets/acks
+	StageClose             // StageClose close providers, such as database and http servers
+
+	DefaultGracePeriod = 30 * time.Second
+
+	ErrInvalidStage = utils.Error("invalid shutdown stage")
+)
+
+// DrainFn waits for in-flight work to finish; ctx expires when the shutdown grace period ends
+type DrainFn func(ctx context.Context) error
+
 var appDestructors *callstack.CallStack = nil
+var stageDestructors []*callstack.CallStack = nil
+var gracePeriod = DefaultGracePeriod
+
+// shutdownMx is held for the whole shutdown, so concurrent Shutdown() calls wait for destructors to finish;
+// registryMx protects the stage destructors and the grace period, and is never held while destructors run
 var shutdownMx = &sync.Mutex{}
+var registryMx = &sync.Mutex{}
 
 // GetDestructorManager Retrieve callback manager
 func GetDestructorManager() *callstack.CallStack {
@@ -15,27 +38,93 @@ func GetDestructorManager() *callstack.CallStack {
 }
 
 // RegisterDestructor Register a function to perform shutdown procedures
+// destructors are executed in the StageClose stage, in reverse order of registration
 func RegisterDestructor(fn callstack.CallableFn) {
 	appDestructors.Add(fn)
 }
 
+// RegisterStageDestructor Register a function to perform shutdown procedures in a given stage
+// stages are executed in order (StageStopIntake, StageDrain, StageClose); within each stage, functions are executed
+// in reverse order of registration; returns ErrInvalidStage if stage is not a valid stage
+//
+// Example usage:
+//
+//	blueprint.RegisterStageDestructor(blueprint.StageStopIntake, func() error {
+//	  consumer.Stop()
+//	  return nil
+//	})
+//	blueprint.RegisterDrain(consumer.Drain)
+//	blueprint.RegisterDestructor(func() error {
+//	  dbClient.Disconnect()
+//	  return nil
+//	})
+func RegisterStageDestructor(stage int, fn callstack.CallableFn) error {
+	if stage < StageStopIntake || stage > StageClose {
+		return ErrInvalidStage
+	}
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	// application is already shutting down
+	if stageDestructors == nil {
+		return nil
+	}
+	stageDestructors[stage].Add(fn)
+	return nil
+}
+
+// RegisterDrain Register a function that waits for in-flight work during the StageDrain stage
+// fn receives a context that expires at the end of the shutdown grace period
+func RegisterDrain(fn DrainFn) {
+	_ = RegisterStageDestructor(StageDrain, func() error {
+		registryMx.Lock()
+		period := gracePeriod
+		registryMx.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), period)
+		defer cancel()
+		// errors are logged, and do not prevent providers from being closed
+		if err := fn(ctx); err != nil {
+			log.Error().Err(err).Msg("error waiting for in-flight work during shutdown")
+		}
+		return nil
+	})
+}
+
+// SetGracePeriod Set the maximum time in-flight work is waited for during shutdown
+func SetGracePeriod(period time.Duration) {
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	gracePeriod = period
+}
+
 // Shutdown Shuts down the whole application
 func Shutdown(arg error) {
 	shutdownMx.Lock()
 	defer shutdownMx.Unlock()
 
-	if appDestructors == nil {
+	registryMx.Lock()
+	stages := stageDestructors
+	stageDestructors = nil
+	registryMx.Unlock()
+
+	if stages == nil {
 		return
 	}
 	if arg != nil {
 		log.Fatal().Err(arg).Msg("Fatal error")
 	}
-	if err := appDestructors.Run(false); err != nil {
-		log.Fatal().Err(arg).Msg("Fatal error while shutting down")
+	for _, destructors := range stages {
+		if err := destructors.Run(false); err != nil {
+			log.Fatal().Err(err).Msg("Fatal error while shutting down")
+		}
 	}
 	appDestructors = nil
 }
 
 func init() {
 	appDestructors = callstack.NewCallStack()
+	stageDestructors = []*callstack.CallStack{
+		callstack.NewCallStack(),
+		callstack.NewCallStack(),
+		appDestructors,
+	}
 }
@@ -0,0 +1,50 @@
+package threadpool
+
+import (
+	"sync"
+	"time"
+)
+
+// durationBuckets upper bounds in seconds of the job duration histogram
+var durationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+// durationHistogram job duration histogram
+type durationHistogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+	mx     sync.Mutex
+}
+
+func newDurationHistogram() *durationHistogram {
+	return &durationHistogram{
+		counts: make([]uint64, len(durationBuckets)),
+	}
+}
+
+func (h *durationHistogram) observe(d time.Duration) {
+	v := d.Seconds()
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.count++
+	h.sum += v
+	for i, bound := range durationBuckets {
+		if v <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+}
+
+// snapshot returns the observation count and sum, and the cumulative count of each bucket
+func (h *durationHistogram) snapshot() (uint64, float64, map[float64]uint64) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	buckets := make(map[float64]uint64, len(durationBuckets))
+	var cumulative uint64
+	for i, bound := range durationBuckets {
+		cumulative += h.counts[i]
+		buckets[bound] = cumulative
+	}
+	return h.count, h.sum, buckets
+}
@@ -3,6 +3,10 @@ package threadpool
 import (
 	"context"
 	"github.com/oddbit-project/blueprint/utils"
+	"github.com/rs/zerolog/log"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -10,6 +14,8 @@ const (
 	ErrInvalidQueueSize   = utils.Error("Invalid queueSize value")
 	ErrPoolNotStarted     = utils.Error("ThreadPool not started")
 	ErrPoolAlreadyStarted = utils.Error("ThreadPool already started")
+
+	drainPollInterval = 10 * time.Millisecond
 )
 
 type Pool interface {
@@ -22,6 +28,66 @@ type ThreadPool struct {
 	workers     *WorkerGroup
 	workerCount int
 	jobQueue    chan Job
+	pending     int64
+	busy        int64
+	panics      uint64
+	rejected    uint64
+	jobTimeout  int64 // nanoseconds
+	duration    *durationHistogram
+}
+
+// JobFunc adapts a function to Job
+type JobFunc func(ctx context.Context)
+
+func (f JobFunc) Run(ctx context.Context) {
+	f(ctx)
+}
+
+// timeoutJob cancels the job context after a timeout
+type timeoutJob struct {
+	job     Job
+	timeout time.Duration
+}
+
+func (j *timeoutJob) Run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, j.timeout)
+	defer cancel()
+	j.job.Run(ctx)
+}
+
+// WithTimeout returns a Job that runs j with a context that expires after timeout; jobs must observe ctx.Done()
+//
+// Example usage:
+//
+//	pool.Dispatch(threadpool.WithTimeout(job, 5*time.Second))
+func WithTimeout(j Job, timeout time.Duration) Job {
+	return &timeoutJob{job: j, timeout: timeout}
+}
+
+// trackedJob updates the pool counters, and isolates job panics, so a failing job does not stop its worker
+type trackedJob struct {
+	job  Job
+	pool *ThreadPool
+}
+
+func (j *trackedJob) Run(ctx context.Context) {
+	start := time.Now()
+	atomic.AddInt64(&j.pool.busy, 1)
+	if timeout := atomic.LoadInt64(&j.pool.jobTimeout); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout))
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddUint64(&j.pool.panics, 1)
+			log.Error().Str("stack", string(debug.Stack())).Msgf("threadpool job panic: %v", r)
+		}
+		j.pool.duration.observe(time.Since(start))
+		atomic.AddInt64(&j.pool.busy, -1)
+		atomic.AddInt64(&j.pool.pending, -1)
+	}()
+	j.job.Run(ctx)
 }
 
 // NewThreadPool is a constructor function that creates a new ThreadPool instance. It takes in two parameters:
@@ -49,6 +115,7 @@ func NewThreadPool(workerCount int, queueSize int) (*ThreadPool, error) {
 		workers:     nil,
 		workerCount: workerCount,
 		jobQueue:    make(chan Job, queueSize),
+		duration:    newDurationHistogram(),
 	}
 	return pool, nil
 }
@@ -114,6 +181,79 @@ func (t *ThreadPool) Stop() error {
 	return nil
 }
 
+// GetBusyCount returns the number of jobs currently running
+func (t *ThreadPool) GetBusyCount() int {
+	return int(atomic.LoadInt64(&t.busy))
+}
+
+// GetPanicCount returns the number of jobs that panicked; job panics are recovered and logged
+func (t *ThreadPool) GetPanicCount() uint64 {
+	return atomic.LoadUint64(&t.panics)
+}
+
+// Stats returns the pool counters; implements metrics.ThreadPoolStatsProvider
+func (t *ThreadPool) Stats() Stats {
+	count, sum, buckets := t.duration.snapshot()
+	return Stats{
+		Workers:         t.workerCount,
+		Busy:            t.GetBusyCount(),
+		Queued:          t.GetQueueLen(),
+		Capacity:        t.GetQueueCapacity(),
+		Completed:       t.GetRequestCount(),
+		Panics:          t.GetPanicCount(),
+		Rejected:        t.GetRejectedCount(),
+		DurationCount:   count,
+		DurationSum:     sum,
+		DurationBuckets: buckets,
+	}
+}
+
+// GetRejectedCount returns the number of jobs not queued by DispatchContext() and TryDispatch() because the queue
+// was full
+func (t *ThreadPool) GetRejectedCount() uint64 {
+	return atomic.LoadUint64(&t.rejected)
+}
+
+// SetJobTimeout sets the max execution time of jobs; the job context expires after timeout. A zero timeout disables
+// the limit (default). Use WithTimeout() for individual job timeouts
+func (t *ThreadPool) SetJobTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&t.jobTimeout, int64(timeout))
+}
+
+// GetPendingCount returns the number of dispatched jobs that are queued or running
+func (t *ThreadPool) GetPendingCount() int64 {
+	return atomic.LoadInt64(&t.pending)
+}
+
+// Drain waits for queued and in-flight jobs to finish, and then stops the ThreadPool. If ctx expires first, the
+// ThreadPool is stopped anyway, cancelling the context of running jobs, and ctx.Err() is returned.
+// Jobs must not be dispatched after Drain() is called.
+//
+// Example usage:
+//
+//	blueprint.RegisterDrain(pool.Drain)
+//
+// Note: this function is blocking
+func (t *ThreadPool) Drain(ctx context.Context) error {
+	if t.workers == nil {
+		return ErrPoolNotStarted
+	}
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	var err error
+	for atomic.LoadInt64(&t.pending) > 0 {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-ticker.C:
+			continue
+		}
+		break
+	}
+	t.Stop()
+	return err
+}
+
 // Dispatch adds a new job to the jobQueue of the ThreadPool.
 // The job will be executed by one of the worker goroutines in the ThreadPool.
 // The job must implement the Job interface with a Run method that takes a context.Context parameter.
@@ -125,5 +265,43 @@ func (t *ThreadPool) Stop() error {
 //
 // Note: This function is blocking if jobQueue is full
 func (t *ThreadPool) Dispatch(j Job) {
-	t.jobQueue <- j
+	atomic.AddInt64(&t.pending, 1)
+	t.jobQueue <- &trackedJob{job: j, pool: t}
+}
+
+// DispatchContext adds a new job to the jobQueue of the ThreadPool, waiting for queue space until ctx expires;
+// returns ctx.Err() if the job was not queued
+//
+// Example usage:
+//
+//	ctx, cancel := context.WithTimeout(ctx, time.Second)
+//	defer cancel()
+//	if err := pool.DispatchContext(ctx, threadpool.JobFunc(func(ctx context.Context) {
+//	  resize(ctx, image)
+//	})); err != nil {
+//	  httpserver.JSONError(c, http.StatusServiceUnavailable, httpserver.JSONErrorDetail{Message: "server busy"})
+//	}
+func (t *ThreadPool) DispatchContext(ctx context.Context, j Job) error {
+	atomic.AddInt64(&t.pending, 1)
+	select {
+	case t.jobQueue <- &trackedJob{job: j, pool: t}:
+		return nil
+	case <-ctx.Done():
+		atomic.AddInt64(&t.pending, -1)
+		atomic.AddUint64(&t.rejected, 1)
+		return ctx.Err()
+	}
+}
+
+// TryDispatch adds a new job to the jobQueue of the ThreadPool without blocking; returns false if the queue is full
+func (t *ThreadPool) TryDispatch(j Job) bool {
+	atomic.AddInt64(&t.pending, 1)
+	select {
+	case t.jobQueue <- &trackedJob{job: j, pool: t}:
+		return true
+	default:
+		atomic.AddInt64(&t.pending, -1)
+		atomic.AddUint64(&t.rejected, 1)
+		return false
+	}
 }
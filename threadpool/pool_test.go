@@ -3,6 +3,7 @@ package threadpool
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -76,3 +77,77 @@ func TestThreadPool_MultipleStartStop(t *testing.T) {
 	err = pool.Stop()
 	require.Equal(t, err, ErrPoolNotStarted)
 }
+
+func TestThreadPool_PanicIsolation(t *testing.T) {
+	pool, err := NewThreadPool(1, 4)
+	require.NoError(t, err)
+	require.NoError(t, pool.Start(context.Background()))
+	defer pool.Stop()
+
+	done := make(chan struct{})
+	pool.Dispatch(JobFunc(func(ctx context.Context) {
+		panic("boom")
+	}))
+	pool.Dispatch(JobFunc(func(ctx context.Context) {
+		close(done)
+	}))
+	<-done
+	require.Eventually(t, func() bool {
+		return pool.GetRequestCount() == 2
+	}, time.Second, time.Millisecond)
+	require.Equal(t, uint64(1), pool.GetPanicCount())
+
+	stats := pool.Stats()
+	require.Equal(t, 1, stats.Workers)
+	require.Equal(t, 0, stats.Busy)
+	require.Equal(t, uint64(2), stats.Completed)
+	require.Equal(t, uint64(2), stats.DurationCount)
+	require.Equal(t, uint64(2), stats.DurationBuckets[60])
+}
+
+func TestThreadPool_BoundedDispatch(t *testing.T) {
+	pool, err := NewThreadPool(1, 1)
+	require.NoError(t, err)
+
+	// the pool is not started, so the queue fills up
+	require.True(t, pool.TryDispatch(JobFunc(func(ctx context.Context) {})))
+	require.False(t, pool.TryDispatch(JobFunc(func(ctx context.Context) {})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, pool.DispatchContext(ctx, JobFunc(func(ctx context.Context) {})), context.DeadlineExceeded)
+	require.Equal(t, int64(1), pool.GetPendingCount())
+	require.Equal(t, uint64(2), pool.GetRejectedCount())
+	require.Equal(t, uint64(2), pool.Stats().Rejected)
+
+	require.NoError(t, pool.Start(context.Background()))
+	require.NoError(t, pool.Drain(context.Background()))
+	require.Equal(t, int64(0), pool.GetPendingCount())
+}
+
+func TestThreadPool_JobTimeout(t *testing.T) {
+	pool, err := NewThreadPool(2, 2)
+	require.NoError(t, err)
+	pool.SetJobTimeout(10 * time.Millisecond)
+	require.NoError(t, pool.Start(context.Background()))
+
+	results := make(chan error, 2)
+	wait := func(ctx context.Context) {
+		select {
+		case <-ctx.Done():
+			results <- ctx.Err()
+		case <-time.After(time.Second):
+			results <- nil
+		}
+	}
+	pool.Dispatch(JobFunc(wait))
+	require.ErrorIs(t, <-results, context.DeadlineExceeded)
+
+	// per-job timeout
+	pool.SetJobTimeout(0)
+	start := time.Now()
+	pool.Dispatch(WithTimeout(JobFunc(wait), 20*time.Millisecond))
+	require.ErrorIs(t, <-results, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+	require.NoError(t, pool.Drain(context.Background()))
+}
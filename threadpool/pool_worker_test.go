@@ -4,7 +4,9 @@ import (
 	"context"
 	"github.com/stretchr/testify/require"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 type testJob struct {
@@ -72,3 +74,33 @@ func TestThreadPool_work(t *testing.T) {
 		})
 	}
 }
+
+func TestThreadPool_Drain(t *testing.T) {
+	pool, err := NewThreadPool(2, 10)
+	require.NoError(t, err)
+	require.ErrorIs(t, pool.Drain(context.Background()), ErrPoolNotStarted)
+
+	// all jobs finish within the grace period
+	require.NoError(t, pool.Start(context.Background()))
+	var counter int32
+	for i := 0; i < 6; i++ {
+		pool.Dispatch(newTestJob(func() {
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&counter, 1)
+		}))
+	}
+	require.NoError(t, pool.Drain(context.Background()))
+	require.Equal(t, int32(6), atomic.LoadInt32(&counter))
+	require.Equal(t, int64(0), pool.GetPendingCount())
+	require.Equal(t, 0, pool.GetWorkerCount())
+
+	// grace period expires
+	require.NoError(t, pool.Start(context.Background()))
+	pool.Dispatch(newTestJob(func() {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, pool.Drain(ctx), context.DeadlineExceeded)
+	require.Equal(t, 0, pool.GetWorkerCount())
+}
@@ -0,0 +1,25 @@
+package threadpool
+
+// Stats counters of a worker pool
+type Stats struct {
+	Workers         int                // Workers number of workers
+	Busy            int                // Busy workers running a job
+	Queued          int                // Queued jobs waiting for a worker
+	Capacity        int                // Capacity queue capacity
+	Completed       uint64             // Completed jobs executed, including jobs that panicked
+	Panics          uint64             // Panics jobs that panicked
+	Rejected        uint64             // Rejected jobs not queued because the queue was full
+	DurationCount   uint64             // DurationCount number of job duration observations
+	DurationSum     float64            // DurationSum sum of job durations in seconds
+	DurationBuckets map[float64]uint64 // DurationBuckets cumulative observation count per upper bound in seconds
+}
+
+// Saturation returns the ratio of busy workers and queued jobs to workers and queue capacity, from 0 to 1; at 1,
+// new jobs wait for queue space or are rejected
+func (s Stats) Saturation() float64 {
+	total := s.Workers + s.Capacity
+	if total == 0 {
+		return 0
+	}
+	return min(float64(s.Busy+s.Queued)/float64(total), 1)
+}
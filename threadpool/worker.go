@@ -3,6 +3,7 @@ package threadpool
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 )
 
 type Worker struct {
@@ -34,7 +35,7 @@ func (w *Worker) Start(wg *sync.WaitGroup) {
 			select {
 			case job := <-w.jobQueue:
 				job.Run(w.ctx)
-				w.requestCounter++
+				atomic.AddUint64(&w.requestCounter, 1)
 
 			case <-w.ctx.Done():
 				return
@@ -45,7 +46,7 @@ func (w *Worker) Start(wg *sync.WaitGroup) {
 }
 
 func (w *Worker) RequestCounter() uint64 {
-	return w.requestCounter
+	return atomic.LoadUint64(&w.requestCounter)
 }
 
 func NewWorkerGroup(workerCount int, jobQueue chan Job, parentCtx context.Context) (*WorkerGroup, error) {
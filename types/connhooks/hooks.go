@@ -0,0 +1,109 @@
+package connhooks
+
+import (
+	"github.com/rs/zerolog/log"
+	"sync"
+)
+
+// EventType connection event type
+type EventType int
+
+const (
+	Connect    EventType = iota // Connect first successful connection
+	Disconnect                  // Disconnect connection closed or lost; Event.Err is nil if closed by the application
+	Reconnect                   // Reconnect connection re-established after being lost
+)
+
+func (t EventType) String() string {
+	switch t {
+	case Connect:
+		return "connect"
+	case Disconnect:
+		return "disconnect"
+	case Reconnect:
+		return "reconnect"
+	}
+	return "unknown"
+}
+
+// Event connection event
+type Event struct {
+	Provider string
+	Type     EventType
+	Err      error
+}
+
+// HookFn connection event hook
+type HookFn func(e Event)
+
+// Hooks connection event hooks, embedded in long-lived providers; the zero value is ready to use
+// hooks are called synchronously from the goroutine detecting the event, and should not block
+type Hooks struct {
+	hooks map[EventType][]HookFn
+	mx    sync.RWMutex
+}
+
+// OnConnect registers a hook called when the provider connects
+//
+// Example usage:
+//
+//	client.OnConnect(func(e connhooks.Event) {
+//	  log.Info().Str("provider", e.Provider).Msg("connected")
+//	})
+//	client.OnDisconnect(func(e connhooks.Event) {
+//	  readiness.Set(false)
+//	})
+//	client.OnReconnect(func(e connhooks.Event) {
+//	  cache.Purge()
+//	  readiness.Set(true)
+//	})
+func (h *Hooks) OnConnect(fn HookFn) {
+	h.add(Connect, fn)
+}
+
+// OnDisconnect registers a hook called when the provider connection is closed or lost
+func (h *Hooks) OnDisconnect(fn HookFn) {
+	h.add(Disconnect, fn)
+}
+
+// OnReconnect registers a hook called when the provider connection is re-established
+func (h *Hooks) OnReconnect(fn HookFn) {
+	h.add(Reconnect, fn)
+}
+
+// Emit calls the hooks registered for t; called by providers
+// hook panics are recovered and logged, so a faulty hook does not affect the provider
+func (h *Hooks) Emit(provider string, t EventType, err error) {
+	h.mx.RLock()
+	hooks := h.hooks[t]
+	h.mx.RUnlock()
+	e := Event{
+		Provider: provider,
+		Type:     t,
+		Err:      err,
+	}
+	for _, fn := range hooks {
+		call(fn, e)
+	}
+}
+
+func (h *Hooks) add(t EventType, fn HookFn) {
+	if fn == nil {
+		return
+	}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if h.hooks == nil {
+		h.hooks = make(map[EventType][]HookFn)
+	}
+	h.hooks[t] = append(h.hooks[t], fn)
+}
+
+func call(fn HookFn, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Interface("panic", r).Str("provider", e.Provider).Str("event", e.Type.String()).Msg("connection hook panic")
+		}
+	}()
+	fn(e)
+}
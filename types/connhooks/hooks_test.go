@@ -0,0 +1,37 @@
+package connhooks
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	h := &Hooks{}
+	events := make([]Event, 0)
+	record := func(e Event) {
+		events = append(events, e)
+	}
+	// no hooks registered
+	h.Emit("kafka", Connect, nil)
+
+	h.OnConnect(record)
+	h.OnDisconnect(record)
+	h.OnDisconnect(func(e Event) {
+		panic("faulty hook")
+	})
+	h.OnReconnect(record)
+	h.OnReconnect(nil)
+
+	errLost := errors.New("connection lost")
+	h.Emit("kafka", Connect, nil)
+	h.Emit("kafka", Disconnect, errLost)
+	h.Emit("kafka", Reconnect, nil)
+
+	assert.Equal(t, []Event{
+		{Provider: "kafka", Type: Connect},
+		{Provider: "kafka", Type: Disconnect, Err: errLost},
+		{Provider: "kafka", Type: Reconnect},
+	}, events)
+	assert.Equal(t, "disconnect", Disconnect.String())
+}
@@ -1,5 +1,22 @@
 package utils
 
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+)
+
+const (
+	// generic provider error categories; providers map driver-specific errors onto these, so
+	// callers can use errors.Is() without importing driver packages
+	ErrNotFound     = Error("provider: resource not found")
+	ErrConflict     = Error("provider: resource conflict")
+	ErrUnauthorized = Error("provider: unauthorized")
+	ErrUnavailable  = Error("provider: service unavailable")
+	ErrTimeout      = Error("provider: operation timed out")
+)
+
 type Error string
 
 func (e Error) Error() string {
@@ -11,3 +28,49 @@ func NotNil(v any, e Error) {
 		panic(e)
 	}
 }
+
+// ProviderError wraps a driver error with a generic error category
+// both the category and the original error can be matched with errors.Is() and errors.As()
+type ProviderError struct {
+	Kind Error
+	Err  error
+}
+
+// NewProviderError wraps err with the specified kind
+func NewProviderError(kind Error, err error) error {
+	return &ProviderError{
+		Kind: kind,
+		Err:  err,
+	}
+}
+
+func (e *ProviderError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ProviderError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// MapCommonError maps context, os and network errors to the generic provider error categories
+// it returns nil if err is nil or cannot be mapped
+func MapCommonError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var pErr *ProviderError
+	if errors.As(err, &pErr) {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return NewProviderError(ErrTimeout, err)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return NewProviderError(ErrTimeout, err)
+		}
+		return NewProviderError(ErrUnavailable, err)
+	}
+	return nil
+}
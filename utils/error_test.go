@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func TestProviderError(t *testing.T) {
+	src := errors.New("driver error")
+	err := NewProviderError(ErrConflict, src)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, err, src)
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, "driver error", err.Error())
+}
+
+func TestMapCommonError(t *testing.T) {
+	assert.Nil(t, MapCommonError(nil))
+	assert.Nil(t, MapCommonError(errors.New("other")))
+	assert.ErrorIs(t, MapCommonError(context.DeadlineExceeded), ErrTimeout)
+
+	opErr := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	assert.ErrorIs(t, MapCommonError(opErr), ErrUnavailable)
+
+	// already mapped errors are returned as-is
+	mapped := NewProviderError(ErrNotFound, errors.New("x"))
+	assert.Equal(t, mapped, MapCommonError(mapped))
+}
@@ -0,0 +1,74 @@
+package sanitize
+
+import (
+	"html"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DefaultURLSchemes schemes considered safe by IsSafeURL, if none are specified
+var DefaultURLSchemes = []string{"http", "https", "mailto"}
+
+// EscapeHTML encodes s for safe use as HTML text content
+func EscapeHTML(s string) string {
+	return html.EscapeString(s)
+}
+
+// EscapeAttribute encodes s for safe use as an HTML attribute value, including unquoted values
+// all characters except ASCII letters and digits are encoded as numeric character references
+func EscapeAttribute(s string) string {
+	b := &strings.Builder{}
+	for _, r := range s {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteString("&#x")
+		b.WriteString(strings.ToUpper(strconv.FormatInt(int64(r), 16)))
+		b.WriteString(";")
+	}
+	return b.String()
+}
+
+// EscapeURLComponent encodes s for safe use as a URL query or path component
+func EscapeURLComponent(s string) string {
+	return url.QueryEscape(s)
+}
+
+// IsSafeURL returns true if u is a relative URL, or an absolute URL using one of the given schemes
+// if no schemes are specified, DefaultURLSchemes is used
+func IsSafeURL(u string, schemes ...string) bool {
+	if len(schemes) == 0 {
+		schemes = DefaultURLSchemes
+	}
+	// browsers ignore control characters and whitespace in schemes, e.g. "java\tscript:"
+	u = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, html.UnescapeString(u))
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	if len(parsed.Scheme) == 0 {
+		// reject scheme-less values that still contain a colon before any path separator, e.g. "javascript&colon;"
+		if i := strings.IndexAny(u, ":/?#"); i >= 0 && u[i] == ':' {
+			return false
+		}
+		return true
+	}
+	return slices.Contains(schemes, strings.ToLower(parsed.Scheme))
+}
+
+// SafeURL returns u if it is considered safe by IsSafeURL, or "about:blank" otherwise
+func SafeURL(u string, schemes ...string) string {
+	if IsSafeURL(u, schemes...) {
+		return u
+	}
+	return "about:blank"
+}
@@ -0,0 +1,195 @@
+package sanitize
+
+import (
+	"golang.org/x/net/html"
+	"slices"
+	"strings"
+)
+
+// Policy is an allowlist of HTML elements and attributes
+// elements not in the allowlist are removed, but their text content is kept, except for the contents of
+// script-like elements, which are always discarded
+type Policy struct {
+	// Elements allowed elements and their allowed attributes
+	Elements map[string][]string
+	// GlobalAttributes attributes allowed in all allowed elements
+	GlobalAttributes []string
+	// URLSchemes allowed schemes for URL attributes; relative URLs are always allowed
+	URLSchemes []string
+	// NoFollow adds rel="nofollow noopener" to links
+	NoFollow bool
+}
+
+// urlAttributes attributes containing URLs
+var urlAttributes = []string{"href", "src", "cite", "action", "formaction", "poster", "background"}
+
+// discardElements elements whose contents are removed along with the element
+var discardElements = []string{"script", "style", "iframe", "object", "embed", "noscript", "template", "textarea", "title"}
+
+// voidElements elements without contents or end tag
+var voidElements = []string{"area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "source", "track", "wbr"}
+
+// NewPolicy creates an empty policy, that removes all elements and keeps only text
+func NewPolicy() *Policy {
+	return &Policy{
+		Elements:         make(map[string][]string),
+		GlobalAttributes: make([]string, 0),
+		URLSchemes:       []string{"http", "https", "mailto"},
+	}
+}
+
+// StrictPolicy returns a policy that removes all HTML, keeping only text
+func StrictPolicy() *Policy {
+	return NewPolicy()
+}
+
+// UGCPolicy returns a policy for user-generated content, allowing common formatting elements and links
+//
+// Example usage:
+//
+//	policy := sanitize.UGCPolicy()
+//	comment.Body = policy.Sanitize(req.Body)
+//	// "<p onclick=\"x()\">hi <script>alert(1)</script><a href=\"javascript:x()\">link</a></p>"
+//	// becomes "<p>hi <a rel=\"nofollow noopener\">link</a></p>"
+func UGCPolicy() *Policy {
+	p := NewPolicy()
+	p.NoFollow = true
+	p.AllowElements("p", "br", "b", "i", "u", "s", "em", "strong", "small", "sub", "sup", "mark",
+		"ul", "ol", "li", "dl", "dt", "dd", "blockquote", "code", "pre", "hr",
+		"h1", "h2", "h3", "h4", "h5", "h6", "table", "thead", "tbody", "tr", "th", "td")
+	p.AllowAttributes("a", "href", "title")
+	p.AllowAttributes("img", "src", "alt", "title", "width", "height")
+	p.AllowAttributes("abbr", "title")
+	p.AllowAttributes("th", "colspan", "rowspan")
+	p.AllowAttributes("td", "colspan", "rowspan")
+	return p
+}
+
+// AllowElements adds elements without attributes to the allowlist
+func (p *Policy) AllowElements(elements ...string) *Policy {
+	for _, e := range elements {
+		e = strings.ToLower(e)
+		if _, ok := p.Elements[e]; !ok {
+			p.Elements[e] = make([]string, 0)
+		}
+	}
+	return p
+}
+
+// AllowAttributes adds an element and the given attributes to the allowlist
+func (p *Policy) AllowAttributes(element string, attributes ...string) *Policy {
+	element = strings.ToLower(element)
+	p.AllowElements(element)
+	for _, a := range attributes {
+		a = strings.ToLower(a)
+		if !slices.Contains(p.Elements[element], a) {
+			p.Elements[element] = append(p.Elements[element], a)
+		}
+	}
+	return p
+}
+
+// Sanitize returns a copy of s with all elements and attributes not allowed by the policy removed
+// allowed elements are balanced: end tags without a matching start tag are removed, and elements left open are
+// closed, so the output does not affect the markup around it
+func (p *Policy) Sanitize(s string) string {
+	b := &strings.Builder{}
+	z := html.NewTokenizer(strings.NewReader(s))
+	discard := 0
+	// open allowed elements
+	open := make([]string, 0)
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// io.EOF or malformed input; elements left open are closed
+			for i := len(open) - 1; i >= 0; i-- {
+				writeEndTag(b, open[i])
+			}
+			return b.String()
+		}
+		token := z.Token()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if slices.Contains(discardElements, token.Data) {
+				if tt == html.StartTagToken {
+					discard++
+				}
+				continue
+			}
+			if discard > 0 {
+				continue
+			}
+			if attrs, ok := p.Elements[token.Data]; ok {
+				token.Attr = p.filterAttributes(token.Data, token.Attr, attrs)
+				if slices.Contains(voidElements, token.Data) {
+					b.WriteString(token.String())
+					continue
+				}
+				token.Type = html.StartTagToken
+				b.WriteString(token.String())
+				if tt == html.SelfClosingTagToken {
+					writeEndTag(b, token.Data)
+				} else {
+					open = append(open, token.Data)
+				}
+			}
+		case html.EndTagToken:
+			if slices.Contains(discardElements, token.Data) {
+				if discard > 0 {
+					discard--
+				}
+				continue
+			}
+			if discard > 0 {
+				continue
+			}
+			// elements opened after the matching start tag are closed first; unmatched end tags are removed
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == token.Data {
+					for j := len(open) - 1; j >= i; j-- {
+						writeEndTag(b, open[j])
+					}
+					open = open[:i]
+					break
+				}
+			}
+		case html.TextToken:
+			if discard == 0 {
+				b.WriteString(html.EscapeString(token.Data))
+			}
+		}
+		// comments and doctype are always removed
+	}
+}
+
+// writeEndTag writes the end tag of element
+func writeEndTag(b *strings.Builder, element string) {
+	b.WriteString("</")
+	b.WriteString(element)
+	b.WriteString(">")
+}
+
+func (p *Policy) filterAttributes(element string, attrs []html.Attribute, allowed []string) []html.Attribute {
+	result := make([]html.Attribute, 0, len(attrs))
+	for _, a := range attrs {
+		name := strings.ToLower(a.Key)
+		if len(a.Namespace) > 0 || (!slices.Contains(allowed, name) && !slices.Contains(p.GlobalAttributes, name)) {
+			continue
+		}
+		// event handlers and inline styles are never allowed
+		if strings.HasPrefix(name, "on") || name == "style" {
+			continue
+		}
+		if slices.Contains(urlAttributes, name) && !IsSafeURL(a.Val, p.URLSchemes...) {
+			continue
+		}
+		if element == "a" && name == "rel" && p.NoFollow {
+			continue
+		}
+		result = append(result, html.Attribute{Key: name, Val: a.Val})
+	}
+	if element == "a" && p.NoFollow {
+		result = append(result, html.Attribute{Key: "rel", Val: "nofollow noopener"})
+	}
+	return result
+}
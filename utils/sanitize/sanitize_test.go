@@ -0,0 +1,63 @@
+package sanitize
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUGCPolicy(t *testing.T) {
+	p := UGCPolicy()
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"plain text", "plain text"},
+		{"<p>hello <b>world</b></p>", "<p>hello <b>world</b></p>"},
+		{`<p onclick="x()" style="color:red">hi</p>`, "<p>hi</p>"},
+		{"a<script>alert(1)</script>b", "ab"},
+		{"<style>p{}</style><div>text</div>", "text"},
+		{`<a href="https://example.com" target="_blank">x</a>`, `<a href="https://example.com" rel="nofollow noopener">x</a>`},
+		{`<a href="javascript:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{`<a href="java	script:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{`<img src="/img/a.png" onerror="x()">`, `<img src="/img/a.png">`},
+		{`<img src="data:image/png;base64,xx">`, `<img>`},
+		{"<!-- comment -->text", "text"},
+		{"1 < 2 & 3", "1 &lt; 2 &amp; 3"},
+		{`<p title="a&quot;b">x</p>`, "<p>x</p>"},
+		// allowed elements are balanced
+		{"<b>unclosed", "<b>unclosed</b>"},
+		{"</p></div>text", "text"},
+		{"<b>bold <i>both</b> text</i>", "<b>bold <i>both</i></b> text"},
+		{"<ul><li>a<li>b</ul>", "<ul><li>a<li>b</li></li></ul>"},
+		{"<p/>x<br/><br></br>", "<p></p>x<br/><br>"},
+		{"<b>x<script>", "<b>x</b>"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, p.Sanitize(tc.input), tc.input)
+	}
+}
+
+func TestStrictPolicy(t *testing.T) {
+	p := StrictPolicy()
+	assert.Equal(t, "hello world", p.Sanitize("<p>hello <b>world</b></p><script>x</script>"))
+
+	p.AllowAttributes("span", "class", "onclick")
+	assert.Equal(t, `<span class="x">y</span>`, p.Sanitize(`<span class="x" onclick="z()" id="a">y</span>`))
+}
+
+func TestEncoders(t *testing.T) {
+	assert.Equal(t, "&lt;b&gt;&#34;x&#34;", EscapeHTML(`<b>"x"`))
+	assert.Equal(t, "a1&#x20;&#x22;&#x3E;", EscapeAttribute(`a1 ">`))
+	assert.Equal(t, "a+b%26c", EscapeURLComponent("a b&c"))
+
+	assert.True(t, IsSafeURL("/path?q=1"))
+	assert.True(t, IsSafeURL("https://example.com"))
+	assert.True(t, IsSafeURL("mailto:user@example.com"))
+	assert.False(t, IsSafeURL("javascript:alert(1)"))
+	assert.False(t, IsSafeURL("JavaScript:alert(1)"))
+	assert.False(t, IsSafeURL("javascript&colon;alert(1)"))
+	assert.False(t, IsSafeURL("ftp://example.com"))
+	assert.True(t, IsSafeURL("ftp://example.com", "ftp"))
+	assert.Equal(t, "about:blank", SafeURL("vbscript:x"))
+	assert.Equal(t, "/home", SafeURL("/home"))
+}
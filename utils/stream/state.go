@@ -0,0 +1,114 @@
+package stream
+
+import (
+	"sync"
+	"time"
+)
+
+// entry is a keyed state value with its expiration time
+type entry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+// KeyedState is an in-memory per-key state store with TTL, for use in message handlers
+// each write refreshes the key expiration; expired keys are not returned and are removed by Purge()
+type KeyedState[T any] struct {
+	ttl     time.Duration
+	entries map[string]*entry[T]
+	mx      sync.Mutex
+}
+
+// NewKeyedState creates a new keyed state store; if ttl is 0, entries never expire
+//
+// Example usage:
+//
+//	// last seen position per device, forgotten after 1h without updates
+//	positions := stream.NewKeyedState[Position](time.Hour)
+//	positions.Update(deviceId, func(prev Position, ok bool) Position {
+//	  if ok && prev.Time.After(p.Time) {
+//	    return prev
+//	  }
+//	  return p
+//	})
+func NewKeyedState[T any](ttl time.Duration) *KeyedState[T] {
+	return &KeyedState[T]{
+		ttl:     ttl,
+		entries: make(map[string]*entry[T]),
+	}
+}
+
+// Get returns the value of key, if it exists and is not expired
+func (s *KeyedState[T]) Get(key string) (T, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.get(key, time.Now())
+}
+
+// Set stores the value of key
+func (s *KeyedState[T]) Set(key string, value T) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.set(key, value, time.Now())
+}
+
+// Update atomically replaces the value of key with the result of fn; ok is false if the key does not exist
+func (s *KeyedState[T]) Update(key string, fn func(value T, ok bool) T) T {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	now := time.Now()
+	value, ok := s.get(key, now)
+	value = fn(value, ok)
+	s.set(key, value, now)
+	return value
+}
+
+// Delete removes key
+func (s *KeyedState[T]) Delete(key string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	delete(s.entries, key)
+}
+
+// Len returns the number of stored keys, including expired keys not yet purged
+func (s *KeyedState[T]) Len() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return len(s.entries)
+}
+
+// Purge removes expired keys, and returns the number of removed keys
+func (s *KeyedState[T]) Purge() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	now := time.Now()
+	count := 0
+	for key, e := range s.entries {
+		if s.expired(e, now) {
+			delete(s.entries, key)
+			count++
+		}
+	}
+	return count
+}
+
+func (s *KeyedState[T]) get(key string, now time.Time) (T, bool) {
+	e, ok := s.entries[key]
+	if !ok || s.expired(e, now) {
+		var zero T
+		return zero, false
+	}
+	return e.value, true
+}
+
+func (s *KeyedState[T]) set(key string, value T, now time.Time) {
+	e := &entry[T]{value: value}
+	if s.ttl > 0 {
+		e.expires = now.Add(s.ttl)
+	}
+	s.entries[key] = e
+}
+
+func (s *KeyedState[T]) expired(e *entry[T], now time.Time) bool {
+	return s.ttl > 0 && now.After(e.expires)
+}
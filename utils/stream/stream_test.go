@@ -0,0 +1,84 @@
+package stream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTumblingWindow(t *testing.T) {
+	_, err := NewTumblingWindow(0, 0, func(a *Aggregate) {})
+	assert.ErrorIs(t, err, ErrInvalidWindowSize)
+	_, err = NewTumblingWindow(time.Minute, -1, func(a *Aggregate) {})
+	assert.ErrorIs(t, err, ErrInvalidLateness)
+	_, err = NewTumblingWindow(time.Minute, 0, nil)
+	assert.ErrorIs(t, err, ErrNilEmitFn)
+
+	emitted := make([]*Aggregate, 0)
+	w, err := NewTumblingWindow(time.Minute, 10*time.Second, func(a *Aggregate) {
+		emitted = append(emitted, a)
+	})
+	assert.Nil(t, err)
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.Nil(t, w.Add("a", base.Add(5*time.Second), 1))
+	assert.Nil(t, w.Add("a", base.Add(30*time.Second), 3))
+	assert.Nil(t, w.Add("b", base.Add(50*time.Second), 10))
+	// within allowed lateness of the first window
+	assert.Nil(t, w.Add("b", base.Add(65*time.Second), 5))
+	assert.Nil(t, w.Add("b", base.Add(58*time.Second), 2))
+	assert.Len(t, emitted, 0)
+
+	// watermark passes the end of the first window
+	assert.Nil(t, w.Add("a", base.Add(71*time.Second), 1))
+	assert.Equal(t, base.Add(61*time.Second), w.Watermark())
+	assert.Len(t, emitted, 2)
+	assert.Equal(t, "a", emitted[0].Key)
+	assert.Equal(t, int64(2), emitted[0].Count)
+	assert.Equal(t, float64(4), emitted[0].Sum)
+	assert.Equal(t, float64(2), emitted[0].Mean())
+	assert.Equal(t, "b", emitted[1].Key)
+	assert.Equal(t, float64(2), emitted[1].Min)
+	assert.Equal(t, float64(10), emitted[1].Max)
+	assert.Equal(t, Window{Start: base, End: base.Add(time.Minute)}, emitted[1].Window)
+
+	// late event
+	assert.ErrorIs(t, w.Add("a", base.Add(40*time.Second), 1), ErrLateEvent)
+
+	// idle advance
+	w.Advance(base.Add(3 * time.Minute))
+	assert.Len(t, emitted, 4)
+
+	assert.Nil(t, w.Add("c", base.Add(5*time.Minute), 1))
+	w.Flush()
+	assert.Len(t, emitted, 5)
+}
+
+func TestKeyedState(t *testing.T) {
+	s := NewKeyedState[int](50 * time.Millisecond)
+	_, ok := s.Get("a")
+	assert.False(t, ok)
+
+	s.Set("a", 1)
+	v, ok := s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	assert.Equal(t, 3, s.Update("a", func(v int, ok bool) int { return v + 2 }))
+	assert.Equal(t, 1, s.Update("b", func(v int, ok bool) int {
+		assert.False(t, ok)
+		return 1
+	}))
+	s.Delete("b")
+	assert.Equal(t, 1, s.Len())
+
+	time.Sleep(60 * time.Millisecond)
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, s.Purge())
+	assert.Equal(t, 0, s.Len())
+
+	forever := NewKeyedState[string](0)
+	forever.Set("a", "x")
+	assert.Equal(t, 0, forever.Purge())
+}
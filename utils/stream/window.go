@@ -0,0 +1,190 @@
+package stream
+
+import (
+	"github.com/oddbit-project/blueprint/utils"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	ErrInvalidWindowSize = utils.Error("window size must be greater than zero")
+	ErrInvalidLateness   = utils.Error("allowed lateness must be >= 0")
+	ErrNilEmitFn         = utils.Error("emit function is nil")
+	ErrLateEvent         = utils.Error("event is older than the watermark")
+)
+
+// Window is a time interval [Start, End)
+type Window struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Aggregate holds the aggregated values of a key within a window
+type Aggregate struct {
+	Key    string
+	Window Window
+	Count  int64
+	Sum    float64
+	Min    float64
+	Max    float64
+}
+
+// EmitFn receives aggregates of closed windows
+type EmitFn func(a *Aggregate)
+
+// TumblingWindow aggregates keyed values in fixed-size, non-overlapping event-time windows
+//
+// The watermark is the latest event time seen minus the allowed lateness; windows ending before the watermark are
+// closed and emitted, and events older than the watermark are rejected with ErrLateEvent
+type TumblingWindow struct {
+	size      time.Duration
+	lateness  time.Duration
+	emit      EmitFn
+	watermark time.Time
+	windows   map[time.Time]map[string]*Aggregate
+	mx        sync.Mutex
+}
+
+// Mean returns the average value of the aggregate
+func (a *Aggregate) Mean() float64 {
+	if a.Count == 0 {
+		return 0
+	}
+	return a.Sum / float64(a.Count)
+}
+
+// NewTumblingWindow creates a new tumbling window aggregator
+//
+// Example usage:
+//
+//	// per-minute totals by customer, accepting events up to 10s late
+//	agg, err := stream.NewTumblingWindow(time.Minute, 10*time.Second, func(a *stream.Aggregate) {
+//	  log.Info().Str("customer", a.Key).Int64("orders", a.Count).Float64("total", a.Sum).Msg("orders per minute")
+//	})
+//
+//	consumer.Subscribe(func(ctx context.Context, msg kafka.Message) error {
+//	  order := &Order{}
+//	  if err := json.Unmarshal(msg.Value, order); err != nil {
+//	    return err
+//	  }
+//	  if err := agg.Add(order.CustomerId, msg.Time, order.Total); errors.Is(err, stream.ErrLateEvent) {
+//	    log.Warn().Msg("late event discarded")
+//	  }
+//	  return nil
+//	})
+func NewTumblingWindow(size time.Duration, allowedLateness time.Duration, emit EmitFn) (*TumblingWindow, error) {
+	if size <= 0 {
+		return nil, ErrInvalidWindowSize
+	}
+	if allowedLateness < 0 {
+		return nil, ErrInvalidLateness
+	}
+	if emit == nil {
+		return nil, ErrNilEmitFn
+	}
+	return &TumblingWindow{
+		size:     size,
+		lateness: allowedLateness,
+		emit:     emit,
+		windows:  make(map[time.Time]map[string]*Aggregate),
+	}, nil
+}
+
+// Add registers a value for key at event time ts, and emits any windows closed by the new watermark
+func (w *TumblingWindow) Add(key string, ts time.Time, value float64) error {
+	closed, err := w.add(key, ts, value)
+	w.emitAll(closed)
+	return err
+}
+
+// Advance moves the watermark to now minus the allowed lateness, if it is ahead of the current watermark,
+// emitting closed windows; it allows idle streams to flush windows without new events
+func (w *TumblingWindow) Advance(now time.Time) {
+	w.mx.Lock()
+	closed := w.advance(now.Add(-w.lateness))
+	w.mx.Unlock()
+	w.emitAll(closed)
+}
+
+// Flush emits all open windows, regardless of the watermark
+func (w *TumblingWindow) Flush() {
+	w.mx.Lock()
+	closed := w.collect(func(start time.Time) bool { return true })
+	w.mx.Unlock()
+	w.emitAll(closed)
+}
+
+// Watermark returns the current watermark
+func (w *TumblingWindow) Watermark() time.Time {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	return w.watermark
+}
+
+func (w *TumblingWindow) add(key string, ts time.Time, value float64) ([]*Aggregate, error) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	if ts.Before(w.watermark) {
+		return nil, ErrLateEvent
+	}
+	start := ts.Truncate(w.size)
+	keys, ok := w.windows[start]
+	if !ok {
+		keys = make(map[string]*Aggregate)
+		w.windows[start] = keys
+	}
+	a, ok := keys[key]
+	if !ok {
+		a = &Aggregate{
+			Key:    key,
+			Window: Window{Start: start, End: start.Add(w.size)},
+			Min:    value,
+			Max:    value,
+		}
+		keys[key] = a
+	}
+	a.Count++
+	a.Sum += value
+	a.Min = min(a.Min, value)
+	a.Max = max(a.Max, value)
+	return w.advance(ts.Add(-w.lateness)), nil
+}
+
+// advance moves the watermark forward and returns the closed windows; must be called with the lock held
+func (w *TumblingWindow) advance(watermark time.Time) []*Aggregate {
+	if !watermark.After(w.watermark) {
+		return nil
+	}
+	w.watermark = watermark
+	return w.collect(func(start time.Time) bool {
+		return !start.Add(w.size).After(watermark)
+	})
+}
+
+// collect removes and returns the windows matching fn, sorted by window start and key
+func (w *TumblingWindow) collect(fn func(start time.Time) bool) []*Aggregate {
+	result := make([]*Aggregate, 0)
+	for start, keys := range w.windows {
+		if !fn(start) {
+			continue
+		}
+		for _, a := range keys {
+			result = append(result, a)
+		}
+		delete(w.windows, start)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Window.Start.Equal(result[j].Window.Start) {
+			return result[i].Window.Start.Before(result[j].Window.Start)
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+func (w *TumblingWindow) emitAll(aggregates []*Aggregate) {
+	for _, a := range aggregates {
+		w.emit(a)
+	}
+}
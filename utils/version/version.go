@@ -0,0 +1,128 @@
+package version
+
+import (
+	"fmt"
+	"github.com/oddbit-project/blueprint/utils"
+	"github.com/rs/zerolog/log"
+	"strconv"
+	"strings"
+)
+
+const (
+	ErrInvalidVersion      = utils.Error("invalid version string")
+	ErrIncompatibleVersion = utils.Error("incompatible server version")
+)
+
+// Version numeric version representation; missing components are zero
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// Parse parses a version string
+// It is tolerant to prefixes and suffixes commonly returned by servers, such as
+// "v1.2.3", "16.1 (Debian 16.1-1.pgdg120+1)" or "24.1.2.5"; only the first three numeric components are considered
+func Parse(v string) (Version, error) {
+	result := Version{}
+	v = strings.TrimSpace(v)
+	v = strings.TrimPrefix(strings.TrimPrefix(v, "v"), "V")
+
+	// find end of the numeric dot-separated section
+	end := 0
+	for end < len(v) && (v[end] == '.' || (v[end] >= '0' && v[end] <= '9')) {
+		end++
+	}
+	tokens := strings.Split(strings.Trim(v[:end], "."), ".")
+	if len(tokens) == 0 || len(tokens[0]) == 0 {
+		return result, ErrInvalidVersion
+	}
+
+	dest := []*int{&result.Major, &result.Minor, &result.Patch}
+	for i, token := range tokens {
+		if i >= len(dest) {
+			break
+		}
+		n, err := strconv.Atoi(token)
+		if err != nil {
+			return result, ErrInvalidVersion
+		}
+		*dest[i] = n
+	}
+	return result, nil
+}
+
+// MustParse parses a version string, and panics on error
+func MustParse(v string) Version {
+	result, err := Parse(v)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// Compare returns -1 if v < other, 0 if v == other, 1 if v > other
+func (v Version) Compare(other Version) int {
+	a := []int{v.Major, v.Minor, v.Patch}
+	b := []int{other.Major, other.Minor, other.Patch}
+	for i := range a {
+		if a[i] < b[i] {
+			return -1
+		}
+		if a[i] > b[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// AtLeast returns true if v >= minimum
+func (v Version) AtLeast(minimum Version) bool {
+	return v.Compare(minimum) >= 0
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Require checks if the current version of a given component satisfies the minimum version
+// if warnOnly is true, an incompatible version is logged as a warning and nil is returned; otherwise,
+// an error is logged and ErrIncompatibleVersion is returned
+//
+// Example usage:
+//
+//	if err := version.Require("postgresql", "11.2", "12.0", false); err != nil {
+//	  // fail fast
+//	}
+func Require(component string, current string, minimum string, warnOnly bool) error {
+	cur, err := Parse(current)
+	if err != nil {
+		return err
+	}
+	min, err := Parse(minimum)
+	if err != nil {
+		return err
+	}
+	if cur.AtLeast(min) {
+		log.Debug().
+			Str("component", component).
+			Str("version", current).
+			Str("minimum", minimum).
+			Msg("server version check passed")
+		return nil
+	}
+	if warnOnly {
+		log.Warn().
+			Str("component", component).
+			Str("version", current).
+			Str("minimum", minimum).
+			Msg("server version is below the supported minimum")
+		return nil
+	}
+	log.Error().
+		Str("component", component).
+		Str("version", current).
+		Str("minimum", minimum).
+		Msg("server version is below the supported minimum")
+	return fmt.Errorf("%w: %s version %s is below minimum %s", ErrIncompatibleVersion, component, current, minimum)
+}
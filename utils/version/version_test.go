@@ -0,0 +1,41 @@
+package version
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]Version{
+		"16":                             {16, 0, 0},
+		"v1.2.3":                         {1, 2, 3},
+		"16.1 (Debian 16.1-1.pgdg120+1)": {16, 1, 0},
+		"24.1.2.5":                       {24, 1, 2},
+		"3.6.1-debian":                   {3, 6, 1},
+	}
+	for src, expected := range cases {
+		v, err := Parse(src)
+		assert.Nil(t, err, src)
+		assert.Equal(t, expected, v, src)
+	}
+
+	for _, src := range []string{"", "abc", "PostgreSQL"} {
+		_, err := Parse(src)
+		assert.ErrorIs(t, err, ErrInvalidVersion, src)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	assert.Equal(t, 0, MustParse("1.2.3").Compare(MustParse("1.2.3")))
+	assert.Equal(t, -1, MustParse("1.2").Compare(MustParse("1.10")))
+	assert.Equal(t, 1, MustParse("2").Compare(MustParse("1.99.99")))
+	assert.True(t, MustParse("16.1").AtLeast(MustParse("12")))
+	assert.False(t, MustParse("11.9").AtLeast(MustParse("12")))
+}
+
+func TestRequire(t *testing.T) {
+	assert.Nil(t, Require("test", "16.1", "12.0", false))
+	assert.Nil(t, Require("test", "11.0", "12.0", true))
+	assert.ErrorIs(t, Require("test", "11.0", "12.0", false), ErrIncompatibleVersion)
+	assert.ErrorIs(t, Require("test", "invalid", "12.0", false), ErrInvalidVersion)
+}